@@ -0,0 +1,387 @@
+package availability
+
+import (
+	"dbms/datetime"
+	"dbms/preference"
+	"dbms/recurrence"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Horizon is how far ahead a recurring schedule is checked for conflicts.
+const Horizon = 90 * 24 * time.Hour
+
+// notBusy lists the invitation statuses of participants who do not attend.
+var notBusy = []string{"removed", "declined", "rejected"}
+
+// Mode is what a write does when it double-books someone.
+type Mode string
+
+const (
+	// ModeIgnore writes without looking for conflicts.
+	ModeIgnore Mode = ""
+	// ModeWarn writes and returns the conflicts with the result.
+	ModeWarn Mode = "warn"
+	// ModeReject refuses the write with 409 when there are conflicts.
+	ModeReject Mode = "reject"
+)
+
+// ParseMode reads the conflicts query parameter of a write.
+func ParseMode(value string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(value)); mode {
+	case ModeIgnore, ModeWarn, ModeReject:
+		return mode, nil
+	default:
+		return ModeIgnore, fmt.Errorf("conflicts must be %s or %s", ModeWarn, ModeReject)
+	}
+}
+
+// Interval is a span of busy time.
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+func (i Interval) overlaps(other Interval) bool {
+	return i.Start.Before(other.End) && other.Start.Before(i.End)
+}
+
+// Occurrence is an occurrence of a schedule a workspace user attends.
+type Occurrence struct {
+	WorkspaceUserId int
+	Schedule        models.TwSchedule
+	Interval
+}
+
+// Conflict is an occurrence of another schedule that overlaps the schedule
+// being written, for one of its participants. WorkspaceUserId is the
+// participant's workspace user in the other schedule's workspace.
+type Conflict struct {
+	WorkspaceUserId int       `json:"workspace_user_id"`
+	ScheduleId      int       `json:"schedule_id"`
+	WorkspaceId     int       `json:"workspace_id"`
+	Title           string    `json:"title"`
+	StartTime       time.Time `json:"start_time"`
+	EndTime         time.Time `json:"end_time"`
+	AllDay          bool      `json:"all_day"`
+}
+
+// ConflictResponse is returned with 409 when a write is rejected for
+// conflicts.
+type ConflictResponse struct {
+	Error     string     `json:"error"`
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+// ConflictError carries the conflicts of a rejected write out of a
+// transaction.
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("schedule conflicts with %d other schedule occurrences", len(e.Conflicts))
+}
+
+// Respond writes the 409 response for a rejected write.
+func Respond(c *fiber.Ctx, conflicts []Conflict) error {
+	return c.Status(fiber.StatusConflict).JSON(ConflictResponse{
+		Error:     (&ConflictError{Conflicts: conflicts}).Error(),
+		Conflicts: conflicts,
+	})
+}
+
+// Span returns the time an occurrence of schedule starting at start and
+// ending at end takes up. All-day schedules take up the whole of every date,
+// in UTC as they are stored, from the start to the end date.
+func Span(schedule models.TwSchedule, start, end time.Time) Interval {
+	if !schedule.AllDay {
+		return Interval{Start: start, End: end}
+	}
+	day := datetime.Floating(start.UTC())
+	last := day.AddDate(0, 0, 1)
+	for last.Before(end) {
+		last = last.AddDate(0, 0, 1)
+	}
+	return Interval{Start: day, End: last}
+}
+
+// Occurrences returns the occurrences overlapping [from, to) of the schedules
+// workspaceUserIds attend, other than excludeScheduleId, with recurrences
+// expanded and exceptions applied. Occurrences without a duration take up no
+// time and are left out.
+func Occurrences(db *gorm.DB, workspaceUserIds []int, from, to time.Time, excludeScheduleId int) ([]Occurrence, error) {
+	if len(workspaceUserIds) == 0 {
+		return nil, nil
+	}
+	var participants []models.TwScheduleParticipant
+	if err := db.
+		Joins("JOIN tw_schedules ON tw_schedules.id = tw_schedule_participants.schedule_id").
+		Where("tw_schedule_participants.workspace_user_id IN (?)", workspaceUserIds).
+		Where("tw_schedule_participants.deleted_at IS NULL").
+		Where("tw_schedule_participants.invitation_status NOT IN (?)", notBusy).
+		Where("tw_schedules.id <> ?", excludeScheduleId).
+		Where("tw_schedules.is_deleted = false AND tw_schedules.deleted_at IS NULL").
+		// All-day schedules take up the whole day, so look a day around the window.
+		Where("tw_schedules.start_time IS NOT NULL AND tw_schedules.start_time < ?", to.AddDate(0, 0, 1)).
+		Where("(tw_schedules.recurrence_pattern IS NOT NULL AND tw_schedules.recurrence_pattern != '') OR COALESCE(tw_schedules.end_time, tw_schedules.start_time) >= ?", from.AddDate(0, 0, -1)).
+		Preload("Schedule").
+		Find(&participants).Error; err != nil {
+		return nil, err
+	}
+	if len(participants) == 0 {
+		return nil, nil
+	}
+
+	scheduleIds := make([]int, 0, len(participants))
+	for _, participant := range participants {
+		scheduleIds = append(scheduleIds, participant.ScheduleId)
+	}
+	var exceptions []models.TwRecurrenceException
+	if err := db.Where("schedule_id IN (?) AND deleted_at IS NULL", scheduleIds).Find(&exceptions).Error; err != nil {
+		return nil, err
+	}
+
+	creatorIds := make([]int, 0, len(participants))
+	for _, participant := range participants {
+		creatorIds = append(creatorIds, participant.Schedule.CreatedBy)
+	}
+	locations, err := preference.Locations(db, creatorIds)
+	if err != nil {
+		return nil, err
+	}
+
+	window := Interval{Start: from, End: to}
+	var occurrences []Occurrence
+	for _, participant := range participants {
+		taken, err := spans(participant.Schedule, exceptions, from, to, locations[participant.Schedule.CreatedBy])
+		if err != nil {
+			log.Printf("Skipping schedule %d with invalid recurrence pattern: %v", participant.ScheduleId, err)
+			continue
+		}
+		for _, span := range taken {
+			if span.overlaps(window) {
+				occurrences = append(occurrences, Occurrence{
+					WorkspaceUserId: participant.WorkspaceUserId,
+					Schedule:        participant.Schedule,
+					Interval:        span,
+				})
+			}
+		}
+	}
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].Start.Before(occurrences[j].Start)
+	})
+	return occurrences, nil
+}
+
+// spans expands schedule in its creator's timezone loc over [from, to),
+// widened by a day on each side for all-day occurrences, and returns the time
+// each occurrence takes up.
+func spans(schedule models.TwSchedule, exceptions []models.TwRecurrenceException, from, to time.Time, loc *time.Location) ([]Interval, error) {
+	occurrences, err := recurrence.Expand(schedule, exceptions, from.AddDate(0, 0, -1), to.AddDate(0, 0, 1), loc)
+	if err != nil {
+		return nil, err
+	}
+	var result []Interval
+	for _, occurrence := range occurrences {
+		span := Span(schedule, occurrence.Start, occurrence.End)
+		if span.End.After(span.Start) {
+			result = append(result, span)
+		}
+	}
+	return result, nil
+}
+
+// Busy merges the occurrences into the busy intervals of each workspace user.
+func Busy(occurrences []Occurrence) map[int][]Interval {
+	busy := make(map[int][]Interval)
+	for _, occurrence := range occurrences {
+		busy[occurrence.WorkspaceUserId] = append(busy[occurrence.WorkspaceUserId], occurrence.Interval)
+	}
+	for workspaceUserId, intervals := range busy {
+		busy[workspaceUserId] = Merge(intervals)
+	}
+	return busy
+}
+
+// Merge sorts intervals and joins the ones that overlap or touch.
+func Merge(intervals []Interval) []Interval {
+	sorted := append([]Interval(nil), intervals...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+	merged := make([]Interval, 0, len(sorted))
+	for _, interval := range sorted {
+		if n := len(merged); n > 0 && !interval.Start.After(merged[n-1].End) {
+			if interval.End.After(merged[n-1].End) {
+				merged[n-1].End = interval.End
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return merged
+}
+
+// Window returns the time a schedule is checked for conflicts over: the
+// schedule itself, or for a recurring schedule the Horizon from now or from
+// its start when that is later.
+func Window(schedule models.TwSchedule, now time.Time) (time.Time, time.Time) {
+	if schedule.StartTime == nil {
+		return now, now
+	}
+	start := *schedule.StartTime
+	if strings.TrimSpace(schedule.RecurrencePattern) == "" {
+		end := start
+		if schedule.EndTime != nil && schedule.EndTime.After(start) {
+			end = *schedule.EndTime
+		}
+		span := Span(schedule, start, end)
+		return span.Start, span.End
+	}
+	if now.After(start) {
+		start = now
+	}
+	return start, start.Add(Horizon)
+}
+
+// Conflicts returns the occurrences of other schedules that workspaceUserIds
+// attend and that overlap an occurrence of schedule within its Window.
+// schedule may be unsaved, and is checked as given rather than as stored.
+func Conflicts(db *gorm.DB, schedule models.TwSchedule, workspaceUserIds []int, now time.Time) ([]Conflict, error) {
+	conflicts := []Conflict{}
+	from, to := Window(schedule, now)
+	if !to.After(from) || len(workspaceUserIds) == 0 {
+		return conflicts, nil
+	}
+
+	var exceptions []models.TwRecurrenceException
+	if schedule.ID != 0 {
+		if err := db.Where("schedule_id = ? AND deleted_at IS NULL", schedule.ID).Find(&exceptions).Error; err != nil {
+			return nil, err
+		}
+	}
+	preferences, err := preference.LoadForWorkspaceUser(db, schedule.CreatedBy)
+	if err != nil {
+		return nil, err
+	}
+	own, err := spans(schedule, exceptions, from, to, preferences.Location)
+	if err != nil {
+		return nil, err
+	}
+	if len(own) == 0 {
+		return conflicts, nil
+	}
+	bounds := own[0]
+	for _, span := range own[1:] {
+		if span.End.After(bounds.End) {
+			bounds.End = span.End
+		}
+	}
+	others, err := Occurrences(db, workspaceUserIds, bounds.Start, bounds.End, schedule.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, other := range others {
+		for _, span := range own {
+			if !span.overlaps(other.Interval) {
+				continue
+			}
+			conflicts = append(conflicts, Conflict{
+				WorkspaceUserId: other.WorkspaceUserId,
+				ScheduleId:      other.Schedule.ID,
+				WorkspaceId:     other.Schedule.WorkspaceId,
+				Title:           other.Schedule.Title,
+				StartTime:       other.Start,
+				EndTime:         other.End,
+				AllDay:          other.Schedule.AllDay,
+			})
+			break
+		}
+	}
+	return conflicts, nil
+}
+
+// ParticipantIds returns the workspace users attending a schedule.
+func ParticipantIds(db *gorm.DB, scheduleId int) ([]int, error) {
+	var workspaceUserIds []int
+	err := db.Model(&models.TwScheduleParticipant{}).
+		Where("schedule_id = ? AND deleted_at IS NULL", scheduleId).
+		Where("invitation_status NOT IN (?)", notBusy).
+		Distinct().
+		Pluck("workspace_user_id", &workspaceUserIds).Error
+	return workspaceUserIds, err
+}
+
+// WorkspaceUserIdsForPeople returns workspaceUserIds together with the other
+// joined workspace users of the people they belong to, as
+// WorkspaceUserIdsForEmail finds them, so that a person is checked across
+// every workspace they joined.
+func WorkspaceUserIdsForPeople(db *gorm.DB, workspaceUserIds []int) ([]int, error) {
+	if len(workspaceUserIds) == 0 {
+		return workspaceUserIds, nil
+	}
+	var emails []string
+	if err := db.Table("tw_workspace_users").
+		Joins("JOIN tw_user_emails ON tw_user_emails.id = tw_workspace_users.user_email_id").
+		Where("tw_workspace_users.id IN (?)", workspaceUserIds).
+		Distinct().
+		Pluck("tw_user_emails.email", &emails).Error; err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]bool)
+	ids := make([]int, 0, len(workspaceUserIds))
+	add := func(more []int) {
+		for _, id := range more {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	add(workspaceUserIds)
+	for _, email := range emails {
+		more, err := WorkspaceUserIdsForEmail(db, email)
+		if err != nil {
+			return nil, err
+		}
+		add(more)
+	}
+	return ids, nil
+}
+
+// WorkspaceUserIdsForEmail returns the joined workspace users of the person
+// email belongs to: those of the email itself and of every email linked to
+// the same user.
+func WorkspaceUserIdsForEmail(db *gorm.DB, email string) ([]int, error) {
+	var rootUserIds []int
+	if err := db.Model(&models.TwUserEmail{}).
+		Where("email = ? AND deleted_at IS NULL", email).
+		Pluck("COALESCE(is_linked_to, user_id)", &rootUserIds).Error; err != nil {
+		return nil, err
+	}
+
+	query := db.Table("tw_workspace_users").
+		Select("tw_workspace_users.id").
+		Joins("JOIN tw_user_emails ON tw_user_emails.id = tw_workspace_users.user_email_id").
+		Where("tw_user_emails.deleted_at IS NULL AND tw_workspace_users.deleted_at IS NULL").
+		Where("tw_workspace_users.status = 'joined' AND tw_workspace_users.is_active = true")
+	if len(rootUserIds) > 0 {
+		query = query.Where("tw_user_emails.email = ? OR tw_user_emails.user_id IN (?) OR (tw_user_emails.is_linked_to IN (?) AND tw_user_emails.status = 'linked')",
+			email, rootUserIds, rootUserIds)
+	} else {
+		query = query.Where("tw_user_emails.email = ?", email)
+	}
+	var workspaceUserIds []int
+	err := query.Scan(&workspaceUserIds).Error
+	return workspaceUserIds, err
+}
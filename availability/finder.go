@@ -0,0 +1,216 @@
+package availability
+
+import (
+	"dbms/datetime"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultWorkingHours are the working hours of attendees a request gives none
+// for: 09:00 to 17:00, Monday to Friday.
+var DefaultWorkingHours = WorkingHours{
+	Start: "09:00",
+	End:   "17:00",
+	Days:  []string{"MO", "TU", "WE", "TH", "FR"},
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// WorkingHours is when someone works, as "15:04" clock times in their own
+// timezone on the given days, named as in recurrence rules (MO, TU, ...).
+type WorkingHours struct {
+	Start string   `json:"start"`
+	End   string   `json:"end"`
+	Days  []string `json:"days"`
+}
+
+type workingHours struct {
+	start, end time.Duration
+	days       map[time.Weekday]bool
+}
+
+func (w WorkingHours) parse() (workingHours, error) {
+	parsed := workingHours{days: make(map[time.Weekday]bool)}
+	var err error
+	if parsed.start, err = datetime.ParseClock(w.Start); err != nil {
+		return parsed, fmt.Errorf("working hours must be given as HH:MM, got %q", w.Start)
+	}
+	if parsed.end, err = datetime.ParseClock(w.End); err != nil {
+		return parsed, fmt.Errorf("working hours must be given as HH:MM, got %q", w.End)
+	}
+	if parsed.end <= parsed.start {
+		return parsed, errors.New("working hours must end after they start")
+	}
+	for _, day := range w.Days {
+		weekday, ok := weekdays[strings.ToUpper(day)]
+		if !ok {
+			return parsed, fmt.Errorf("unknown working day %q", day)
+		}
+		parsed.days[weekday] = true
+	}
+	if len(parsed.days) == 0 {
+		return parsed, errors.New("working hours need at least one day")
+	}
+	return parsed, nil
+}
+
+// Validate reports whether the working hours can be used.
+func (w WorkingHours) Validate() error {
+	_, err := w.parse()
+	return err
+}
+
+// fit returns the share of [start, end) within the working hours in loc.
+func (w workingHours) fit(start, end time.Time, loc *time.Location) float64 {
+	day := datetime.StartOfDay(start, loc)
+	var inside time.Duration
+	for ; day.Before(end); day = day.AddDate(0, 0, 1) {
+		if !w.days[day.Weekday()] {
+			continue
+		}
+		from, to := datetime.AtClock(day, w.start), datetime.AtClock(day, w.end)
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		if to.After(from) {
+			inside += to.Sub(from)
+		}
+	}
+	return float64(inside) / float64(end.Sub(start))
+}
+
+// Attendee is someone a meeting is planned for, with their busy time and
+// where and when they work.
+type Attendee struct {
+	// Name identifies the attendee in the slots, e.g. an email.
+	Name         string
+	Optional     bool
+	Busy         []Interval
+	Location     *time.Location
+	WorkingHours WorkingHours
+}
+
+func (a Attendee) free(slot Interval) bool {
+	for _, busy := range a.Busy {
+		if busy.overlaps(slot) {
+			return false
+		}
+	}
+	return true
+}
+
+// Slot is a suggested meeting time.
+type Slot struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Score is the share of optional attendees who are free plus
+	// WorkingHoursFit, between 0 and 2; higher is better.
+	Score float64 `json:"score"`
+	// WorkingHoursFit is the average share of the slot within the local
+	// working hours of the attendees who can come, between 0 and 1.
+	WorkingHoursFit float64 `json:"working_hours_fit"`
+	// Unavailable names the optional attendees who are busy.
+	Unavailable []string `json:"unavailable"`
+}
+
+// MeetingQuery is a search for meeting times.
+type MeetingQuery struct {
+	Attendees []Attendee
+	From      time.Time
+	To        time.Time
+	Duration  time.Duration
+	// Step is the time between candidate starts, which are aligned to it.
+	Step  time.Duration
+	Count int
+}
+
+// FindSlots returns up to Count slots of Duration within [From, To) at which
+// every required attendee is free, best first. Slots do not overlap one
+// another, so that they are real alternatives; ties go to the earlier slot.
+func FindSlots(query MeetingQuery) ([]Slot, error) {
+	hours := make([]workingHours, len(query.Attendees))
+	optional := 0
+	for i, attendee := range query.Attendees {
+		parsed, err := attendee.WorkingHours.parse()
+		if err != nil {
+			return nil, err
+		}
+		hours[i] = parsed
+		if attendee.Optional {
+			optional++
+		}
+	}
+
+	var candidates []Slot
+	start := query.From.Truncate(query.Step)
+	if start.Before(query.From) {
+		start = start.Add(query.Step)
+	}
+	for ; !start.Add(query.Duration).After(query.To); start = start.Add(query.Step) {
+		slot := Interval{Start: start, End: start.Add(query.Duration)}
+		candidate := Slot{Start: slot.Start, End: slot.End, Unavailable: []string{}}
+		available := true
+		var fit float64
+		coming := 0
+		for i, attendee := range query.Attendees {
+			if !attendee.free(slot) {
+				if !attendee.Optional {
+					available = false
+					break
+				}
+				candidate.Unavailable = append(candidate.Unavailable, attendee.Name)
+				continue
+			}
+			fit += hours[i].fit(slot.Start, slot.End, attendee.Location)
+			coming++
+		}
+		if !available {
+			continue
+		}
+		if coming > 0 {
+			candidate.WorkingHoursFit = fit / float64(coming)
+		}
+		candidate.Score = candidate.WorkingHoursFit
+		if optional > 0 {
+			candidate.Score += float64(optional-len(candidate.Unavailable)) / float64(optional)
+		} else {
+			candidate.Score++
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	slots := make([]Slot, 0, query.Count)
+	for _, candidate := range candidates {
+		if len(slots) == query.Count {
+			break
+		}
+		taken := false
+		for _, slot := range slots {
+			if candidate.Start.Before(slot.End) && slot.Start.Before(candidate.End) {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			slots = append(slots, candidate)
+		}
+	}
+	return slots, nil
+}
@@ -0,0 +1,164 @@
+// Package channel sends notifications to the places users read them outside
+// the app: email, Web Push, Slack and SMS. Users pick channels by saving a
+// target for them in their notification settings; see preference.Extra.
+package channel
+
+import (
+	"bytes"
+	"dbms/config"
+	"dbms/egress"
+	"dbms/email"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Channel names, as used in notification settings and deliveries.
+const (
+	Email   = "email"
+	WebPush = "web_push"
+	Slack   = "slack"
+	SMS     = "sms"
+)
+
+// ErrPermanent marks failures that retrying cannot fix, such as a push
+// subscription that expired or a Slack webhook that was removed.
+var ErrPermanent = errors.New("target rejected")
+
+// Message is a notification as channels send it.
+type Message struct {
+	Type  string
+	Title string
+	Body  string
+	Link  string
+	// Data is the notification's extra_data. Email renders invitations and
+	// email link requests from it with their own templates.
+	Data json.RawMessage
+}
+
+// Recipient is who a message goes to.
+type Recipient struct {
+	Email  string
+	Locale string
+	// Location is the recipient's timezone, which times are shown in.
+	Location *time.Location
+	// Target is the recipient's address on the channel, as saved in their
+	// notification settings. Email does not use one.
+	Target json.RawMessage
+}
+
+// NotificationChannel delivers messages on one channel.
+type NotificationChannel interface {
+	Name() string
+	Send(recipient Recipient, message Message) error
+}
+
+// DefaultClient gives up on slow providers so one cannot stall a batch, and
+// only connects to public addresses, since targets are given by users.
+var DefaultClient = egress.Client(10 * time.Second)
+
+// FromConfig returns the channels that are configured, keyed by name. Email
+// and Slack are always available; Web Push needs VAPID keys and SMS needs a
+// provider URL.
+func FromConfig(cfg *config.Config, sender email.EmailSender) (map[string]NotificationChannel, error) {
+	channels := map[string]NotificationChannel{
+		Email: &EmailChannel{Sender: sender, AppURL: cfg.AppURL},
+		Slack: &SlackChannel{Client: DefaultClient},
+	}
+	if cfg.VAPIDPrivateKey != "" {
+		webPush, err := NewWebPushChannel(cfg.VAPIDPublicKey, cfg.VAPIDPrivateKey, cfg.VAPIDSubject)
+		if err != nil {
+			return nil, err
+		}
+		channels[WebPush] = webPush
+	}
+	if cfg.SMSURL != "" {
+		if err := egress.CheckURL(cfg.SMSURL); err != nil {
+			return nil, fmt.Errorf("SMS.URL %w", err)
+		}
+		channels[SMS] = &SMSChannel{URL: cfg.SMSURL, Token: cfg.SMSToken, Client: DefaultClient}
+	}
+	return channels, nil
+}
+
+// ValidateTarget checks a target before it is saved in notification settings.
+func ValidateTarget(name string, target json.RawMessage) error {
+	switch name {
+	case WebPush:
+		var subscription PushSubscription
+		if err := json.Unmarshal(target, &subscription); err != nil {
+			return fmt.Errorf("web_push target must be a push subscription: %w", err)
+		}
+		if err := subscription.validate(); err != nil {
+			return err
+		}
+		if err := egress.CheckURL(subscription.Endpoint); err != nil {
+			return fmt.Errorf("web_push endpoint %w", err)
+		}
+		return nil
+	case Slack:
+		var slack SlackTarget
+		if err := json.Unmarshal(target, &slack); err != nil {
+			return fmt.Errorf("slack target must be an object: %w", err)
+		}
+		return slack.validate()
+	case SMS:
+		var sms SMSTarget
+		if err := json.Unmarshal(target, &sms); err != nil {
+			return fmt.Errorf("sms target must be an object: %w", err)
+		}
+		if !phonePattern.MatchString(sms.Phone) {
+			return errors.New("sms phone must be in E.164 format, e.g. +84901234567")
+		}
+		return nil
+	}
+	return fmt.Errorf("channel %q has no target", name)
+}
+
+func validateHttpsUrl(name string, rawUrl string) error {
+	parsed, err := url.Parse(rawUrl)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return fmt.Errorf("%s must be an absolute https URL", name)
+	}
+	return nil
+}
+
+// post sends body to a provider and turns its response into an error. 404
+// and 410 mean the target is gone, and other 4xx responses other than 429
+// mean the request will never be accepted; both are permanent.
+func post(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("provider responded with status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests &&
+		resp.StatusCode != http.StatusRequestTimeout {
+		return fmt.Errorf("%w: %w", ErrPermanent, err)
+	}
+	return err
+}
+
+func newJSONRequest(rawUrl string, payload interface{}) (*http.Request, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, rawUrl, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Timewise-Notifications/1.0")
+	return req, nil
+}
@@ -0,0 +1,87 @@
+package channel
+
+import (
+	"dbms/email"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// EmailChannel renders the notification template in the recipient's locale.
+// Invitations and email link requests, notifications whose type names their
+// template, are rendered with it from the notification's data instead.
+type EmailChannel struct {
+	Sender email.EmailSender
+	// AppURL is prefixed to links that are a path in the app.
+	AppURL string
+}
+
+func (c *EmailChannel) Name() string {
+	return Email
+}
+
+func (c *EmailChannel) Send(recipient Recipient, message Message) error {
+	rendered, err := c.render(recipient, message)
+	if err != nil {
+		return err
+	}
+	rendered.To = recipient.Email
+	return c.Sender.Send(rendered)
+}
+
+func (c *EmailChannel) render(recipient Recipient, message Message) (email.Message, error) {
+	message.Link = c.absolute(message.Link)
+	switch message.Type {
+	case email.TemplateInvitation:
+		var data email.InvitationData
+		if err := unmarshalData(message.Data, &data); err != nil {
+			return email.Message{}, err
+		}
+		if data.Recipient == "" {
+			data.Recipient = recipient.Email
+		}
+		data.Link = c.absolute(data.Link)
+		if data.Link == "" {
+			data.Link = message.Link
+		}
+		return email.Render(email.TemplateInvitation, recipient.Locale, data)
+	case email.TemplateEmailLink:
+		var data email.EmailLinkData
+		if err := unmarshalData(message.Data, &data); err != nil {
+			return email.Message{}, err
+		}
+		if data.Email == "" {
+			data.Email = recipient.Email
+		}
+		data.Link = c.absolute(data.Link)
+		if data.Link == "" {
+			data.Link = message.Link
+		}
+		data.Location = recipient.Location
+		return email.Render(email.TemplateEmailLink, recipient.Locale, data)
+	}
+	return email.Render(email.TemplateNotification, recipient.Locale, email.NotificationData{
+		Title:   message.Title,
+		Message: message.Body,
+		Link:    message.Link,
+	})
+}
+
+// absolute makes a link that is a path in the app absolute.
+func (c *EmailChannel) absolute(link string) string {
+	if c.AppURL == "" || !strings.HasPrefix(link, "/") || strings.HasPrefix(link, "//") {
+		return link
+	}
+	return strings.TrimRight(c.AppURL, "/") + link
+}
+
+// unmarshalData reads the data of a message, which may be empty.
+func unmarshalData(data json.RawMessage, v interface{}) error {
+	if len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%w: invalid notification data: %w", ErrPermanent, err)
+	}
+	return nil
+}
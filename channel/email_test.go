@@ -0,0 +1,134 @@
+package channel
+
+import (
+	"dbms/email"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmailChannelRendersInvitations(t *testing.T) {
+	data, _ := json.Marshal(email.InvitationData{
+		InviterName:    "Lan Nguyen",
+		WorkspaceTitle: "Team",
+		Role:           "member",
+		Link:           "/workspace/7/invitation",
+	})
+
+	tests := []struct {
+		locale  string
+		subject string
+		text    []string
+	}{
+		{
+			locale:  "en",
+			subject: "Lan Nguyen invited you to Team",
+			text:    []string{"Hello an@example.com,", "join the workspace Team as member", "https://app.example.com/workspace/7/invitation"},
+		},
+		{
+			locale:  "vi",
+			subject: "Lan Nguyen đã mời bạn tham gia Team",
+			text:    []string{"Xin chào an@example.com,", "với vai trò member", "https://app.example.com/workspace/7/invitation"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			sender := &email.MemorySender{}
+			channel := &EmailChannel{Sender: sender, AppURL: "https://app.example.com/"}
+			err := channel.Send(Recipient{Email: "an@example.com", Locale: tt.locale}, Message{
+				Type:  email.TemplateInvitation,
+				Title: "Workspace invitation",
+				Body:  "You are invited to join the workspace Team",
+				Link:  "/workspace/7/invitation",
+				Data:  data,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sent := sender.Messages()
+			if len(sent) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(sent))
+			}
+			if sent[0].To != "an@example.com" {
+				t.Errorf("To = %q", sent[0].To)
+			}
+			if sent[0].Subject != tt.subject {
+				t.Errorf("Subject = %q, want %q", sent[0].Subject, tt.subject)
+			}
+			for _, want := range tt.text {
+				if !strings.Contains(sent[0].Text, want) {
+					t.Errorf("Text does not contain %q:\n%s", want, sent[0].Text)
+				}
+			}
+			if !strings.Contains(sent[0].HTML, `href="https://app.example.com/workspace/7/invitation"`) {
+				t.Errorf("HTML does not link to the invitation:\n%s", sent[0].HTML)
+			}
+		})
+	}
+}
+
+func TestEmailChannelRendersEmailLinksInRecipientTimezone(t *testing.T) {
+	expiresAt := time.Date(2026, 10, 16, 2, 40, 0, 0, time.UTC)
+	data, _ := json.Marshal(email.EmailLinkData{
+		Email:     "an@example.com",
+		Link:      "/link-email?email=an%40example.com",
+		ExpiresAt: &expiresAt,
+	})
+	loc := time.FixedZone("ICT", 7*60*60)
+
+	tests := []struct {
+		locale string
+		text   []string
+	}{
+		{
+			locale: "en",
+			text:   []string{"Someone wants to link an@example.com", "This link expires at 16/10/2026 09:40 ICT."},
+		},
+		{
+			locale: "vi",
+			text:   []string{"Một người dùng muốn liên kết an@example.com", "Liên kết này hết hạn lúc 16/10/2026 09:40 ICT."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			sender := &email.MemorySender{}
+			channel := &EmailChannel{Sender: sender}
+			err := channel.Send(Recipient{Email: "an@example.com", Locale: tt.locale, Location: loc}, Message{
+				Type: email.TemplateEmailLink,
+				Data: data,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sent := sender.Messages()
+			if len(sent) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(sent))
+			}
+			for _, want := range tt.text {
+				if !strings.Contains(sent[0].Text, want) {
+					t.Errorf("Text does not contain %q:\n%s", want, sent[0].Text)
+				}
+			}
+		})
+	}
+}
+
+func TestEmailChannelRendersOtherTypesAsNotifications(t *testing.T) {
+	sender := &email.MemorySender{}
+	channel := &EmailChannel{Sender: sender}
+	err := channel.Send(Recipient{Email: "an@example.com"}, Message{
+		Type:  "comment",
+		Title: "New comment",
+		Body:  "Lan commented on Standup",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sent := sender.Messages()
+	if len(sent) != 1 || !strings.Contains(sent[0].Text, "Lan commented on Standup") {
+		t.Errorf("sent %+v", sent)
+	}
+}
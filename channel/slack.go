@@ -0,0 +1,64 @@
+package channel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// slackWebhookPrefix starts every Slack incoming webhook URL. Other URLs are
+// refused, so that a target cannot make the worker post anywhere else.
+const slackWebhookPrefix = "https://hooks.slack.com/"
+
+// SlackTarget is an incoming webhook the user created in their workspace.
+type SlackTarget struct {
+	WebhookUrl string `json:"webhook_url"`
+}
+
+func (t SlackTarget) validate() error {
+	if !strings.HasPrefix(t.WebhookUrl, slackWebhookPrefix) {
+		return errors.New("slack webhook_url must start with " + slackWebhookPrefix)
+	}
+	return validateHttpsUrl("slack webhook_url", t.WebhookUrl)
+}
+
+// SlackChannel posts to Slack incoming webhooks.
+type SlackChannel struct {
+	Client *http.Client
+}
+
+func (c *SlackChannel) Name() string {
+	return Slack
+}
+
+func (c *SlackChannel) Send(recipient Recipient, message Message) error {
+	var target SlackTarget
+	if err := json.Unmarshal(recipient.Target, &target); err != nil {
+		return err
+	}
+	if err := target.validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrPermanent, err)
+	}
+
+	var text strings.Builder
+	if message.Title != "" {
+		text.WriteString("*" + slackEscape(message.Title) + "*\n")
+	}
+	text.WriteString(slackEscape(message.Body))
+	if message.Link != "" {
+		text.WriteString("\n<" + message.Link + "|Open in Timewise>")
+	}
+
+	req, err := newJSONRequest(target.WebhookUrl, map[string]string{"text": text.String()})
+	if err != nil {
+		return err
+	}
+	return post(c.Client, req)
+}
+
+// slackEscape escapes the characters Slack reads as markup.
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
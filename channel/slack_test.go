@@ -0,0 +1,125 @@
+package channel
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// redirectTo sends every request of the returned client to server, keeping
+// its path, so that providers with fixed hosts can be tested against it.
+func redirectTo(server *httptest.Server) *http.Client {
+	target, _ := url.Parse(server.URL)
+	client := server.Client()
+	transport := client.Transport
+	client.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		req = req.Clone(req.Context())
+		req.URL.Scheme = target.Scheme
+		req.URL.Host = target.Host
+		return transport.RoundTrip(req)
+	})
+	return client
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestSlackChannelPostsMessage(t *testing.T) {
+	var got struct {
+		method      string
+		path        string
+		contentType string
+		body        map[string]string
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got.method = r.Method
+		got.path = r.URL.Path
+		got.contentType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got.body); err != nil {
+			t.Errorf("body is not JSON: %s", body)
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	channel := &SlackChannel{Client: redirectTo(server)}
+	err := channel.Send(Recipient{
+		Target: json.RawMessage(`{"webhook_url": "https://hooks.slack.com/services/T0/B0/secret"}`),
+	}, Message{Title: "Standup <moved>", Body: "Now at 10:00 & in room 2", Link: "https://app.example.com/schedule/1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.method != http.MethodPost || got.path != "/services/T0/B0/secret" {
+		t.Errorf("request = %s %s", got.method, got.path)
+	}
+	if got.contentType != "application/json" {
+		t.Errorf("Content-Type = %q", got.contentType)
+	}
+	want := "*Standup &lt;moved&gt;*\nNow at 10:00 &amp; in room 2\n<https://app.example.com/schedule/1|Open in Timewise>"
+	if got.body["text"] != want {
+		t.Errorf("text = %q, want %q", got.body["text"], want)
+	}
+}
+
+func TestSlackChannelReportsDeliveryStatus(t *testing.T) {
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{status: http.StatusNotFound, permanent: true},
+		{status: http.StatusGone, permanent: true},
+		{status: http.StatusBadRequest, permanent: true},
+		{status: http.StatusTooManyRequests, permanent: false},
+		{status: http.StatusInternalServerError, permanent: false},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "no_service", tt.status)
+			}))
+			defer server.Close()
+
+			channel := &SlackChannel{Client: redirectTo(server)}
+			err := channel.Send(Recipient{
+				Target: json.RawMessage(`{"webhook_url": "https://hooks.slack.com/services/T0/B0/secret"}`),
+			}, Message{Body: "Hello"})
+			if err == nil {
+				t.Fatal("Send succeeded")
+			}
+			if errors.Is(err, ErrPermanent) != tt.permanent {
+				t.Errorf("permanent = %v, want %v: %v", !tt.permanent, tt.permanent, err)
+			}
+		})
+	}
+}
+
+func TestSlackChannelRefusesOtherHosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request was sent")
+	}))
+	defer server.Close()
+
+	for _, webhookUrl := range []string{
+		server.URL + "/services/T0/B0/secret",
+		"https://hooks.slack.com.attacker.example/services",
+		"http://hooks.slack.com/services/T0/B0/secret",
+	} {
+		target, _ := json.Marshal(SlackTarget{WebhookUrl: webhookUrl})
+		if err := ValidateTarget(Slack, target); err == nil {
+			t.Errorf("ValidateTarget accepted %s", webhookUrl)
+		}
+		err := (&SlackChannel{Client: server.Client()}).Send(Recipient{Target: target}, Message{Body: "Hello"})
+		if !errors.Is(err, ErrPermanent) {
+			t.Errorf("Send to %s = %v, want a permanent error", webhookUrl, err)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package channel
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+)
+
+var phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// SMSTarget is a phone number in E.164 format.
+type SMSTarget struct {
+	Phone string `json:"phone"`
+}
+
+// SMSChannel posts {"to": phone, "message": text} to an SMS gateway, or to
+// any HTTP endpoint standing in for one, authenticated with a bearer token.
+type SMSChannel struct {
+	URL    string
+	Token  string
+	Client *http.Client
+}
+
+func (c *SMSChannel) Name() string {
+	return SMS
+}
+
+func (c *SMSChannel) Send(recipient Recipient, message Message) error {
+	var target SMSTarget
+	if err := json.Unmarshal(recipient.Target, &target); err != nil {
+		return err
+	}
+
+	text := message.Body
+	if message.Title != "" {
+		text = message.Title + ": " + text
+	}
+	if message.Link != "" {
+		text += " " + message.Link
+	}
+
+	req, err := newJSONRequest(c.URL, map[string]string{"to": target.Phone, "message": text})
+	if err != nil {
+		return err
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return post(c.Client, req)
+}
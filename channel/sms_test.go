@@ -0,0 +1,82 @@
+package channel
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSMSChannelPostsMessage(t *testing.T) {
+	var authorization string
+	var body map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		raw, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(raw, &body); err != nil {
+			t.Errorf("body is not JSON: %s", raw)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	channel := &SMSChannel{URL: server.URL + "/messages", Token: "gateway-token", Client: server.Client()}
+	err := channel.Send(Recipient{Target: json.RawMessage(`{"phone": "+84901234567"}`)},
+		Message{Title: "Reminder", Body: "Standup at 10:00", Link: "https://app.example.com/schedule/1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if authorization != "Bearer gateway-token" {
+		t.Errorf("Authorization = %q", authorization)
+	}
+	if body["to"] != "+84901234567" {
+		t.Errorf("to = %q", body["to"])
+	}
+	if want := "Reminder: Standup at 10:00 https://app.example.com/schedule/1"; body["message"] != want {
+		t.Errorf("message = %q, want %q", body["message"], want)
+	}
+}
+
+func TestSMSChannelReportsDeliveryStatus(t *testing.T) {
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{status: http.StatusUnprocessableEntity, permanent: true},
+		{status: http.StatusRequestTimeout, permanent: false},
+		{status: http.StatusBadGateway, permanent: false},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			channel := &SMSChannel{URL: server.URL, Client: server.Client()}
+			err := channel.Send(Recipient{Target: json.RawMessage(`{"phone": "+84901234567"}`)}, Message{Body: "Hello"})
+			if err == nil {
+				t.Fatal("Send succeeded")
+			}
+			if errors.Is(err, ErrPermanent) != tt.permanent {
+				t.Errorf("permanent = %v, want %v: %v", !tt.permanent, tt.permanent, err)
+			}
+		})
+	}
+}
+
+func TestDefaultClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request was sent")
+	}))
+	defer server.Close()
+
+	channel := &SMSChannel{URL: server.URL, Client: DefaultClient}
+	err := channel.Send(Recipient{Target: json.RawMessage(`{"phone": "+84901234567"}`)}, Message{Body: "Hello"})
+	if err == nil {
+		t.Fatal("Send to a loopback address succeeded")
+	}
+}
@@ -0,0 +1,249 @@
+package channel
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// PushSubscription is what the browser's PushManager.subscribe() returns.
+type PushSubscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+func (s PushSubscription) validate() error {
+	if err := validateHttpsUrl("web_push endpoint", s.Endpoint); err != nil {
+		return err
+	}
+	if key, err := decodeBase64(s.Keys.P256dh); err != nil || len(key) != 65 || key[0] != 4 {
+		return errors.New("web_push keys.p256dh must be an uncompressed P-256 public key")
+	}
+	if secret, err := decodeBase64(s.Keys.Auth); err != nil || len(secret) != 16 {
+		return errors.New("web_push keys.auth must be 16 bytes")
+	}
+	return nil
+}
+
+// WebPushChannel sends encrypted pushes (RFC 8291) to browser push services,
+// identifying itself with VAPID (RFC 8292).
+type WebPushChannel struct {
+	// Subject is a mailto: or https: contact for the push service operator.
+	Subject string
+	Client  *http.Client
+	// TTL is how long the push service keeps a push for an offline browser.
+	TTL time.Duration
+
+	privateKey *ecdsa.PrivateKey
+	publicKey  []byte
+}
+
+// NewWebPushChannel takes the VAPID key pair as base64url, the private key
+// being the raw 32 byte scalar. The public key is derived when left empty.
+func NewWebPushChannel(publicKey string, privateKey string, subject string) (*WebPushChannel, error) {
+	d, err := decodeBase64(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	key, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	public := key.PublicKey().Bytes()
+	if publicKey != "" {
+		if given, err := decodeBase64(publicKey); err != nil || !bytes.Equal(given, public) {
+			return nil, errors.New("VAPID public key does not match the private key")
+		}
+	}
+	return &WebPushChannel{
+		Subject: subject,
+		Client:  DefaultClient,
+		TTL:     24 * time.Hour,
+		privateKey: &ecdsa.PrivateKey{
+			PublicKey: ecdsa.PublicKey{
+				Curve: elliptic.P256(),
+				X:     new(big.Int).SetBytes(public[1:33]),
+				Y:     new(big.Int).SetBytes(public[33:]),
+			},
+			D: new(big.Int).SetBytes(d),
+		},
+		publicKey: public,
+	}, nil
+}
+
+// PublicKey returns the key browsers pass to PushManager.subscribe() as
+// applicationServerKey.
+func (c *WebPushChannel) PublicKey() string {
+	return base64.RawURLEncoding.EncodeToString(c.publicKey)
+}
+
+func (c *WebPushChannel) Name() string {
+	return WebPush
+}
+
+func (c *WebPushChannel) Send(recipient Recipient, message Message) error {
+	var subscription PushSubscription
+	if err := json.Unmarshal(recipient.Target, &subscription); err != nil {
+		return err
+	}
+	if err := subscription.validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrPermanent, err)
+	}
+
+	payload, err := json.Marshal(map[string]string{
+		"type":  message.Type,
+		"title": message.Title,
+		"body":  message.Body,
+		"url":   message.Link,
+	})
+	if err != nil {
+		return err
+	}
+	body, err := encryptPush(subscription, payload)
+	if err != nil {
+		return err
+	}
+	authorization, err := c.vapid(subscription.Endpoint)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprint(int(c.TTL.Seconds())))
+	req.Header.Set("Authorization", authorization)
+	return post(c.Client, req)
+}
+
+// vapid returns the Authorization header for a push to endpoint: an ES256
+// JWT for the endpoint's origin and the public key that verifies it.
+func (c *WebPushChannel) vapid(endpoint string) (string, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"aud": parsed.Scheme + "://" + parsed.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": c.Subject,
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, c.privateKey, hash[:])
+	if err != nil {
+		return "", err
+	}
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	token := unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	return "vapid t=" + token + ", k=" + c.PublicKey(), nil
+}
+
+// pushRecordSize is the record size written in the aes128gcm header; the
+// payload always fits in one record.
+const pushRecordSize = 4096
+
+// encryptPush encrypts payload for a subscription with the aes128gcm content
+// coding, as RFC 8291 describes.
+func encryptPush(subscription PushSubscription, payload []byte) ([]byte, error) {
+	userAgentPublic, err := decodeBase64(subscription.Keys.P256dh)
+	if err != nil {
+		return nil, err
+	}
+	authSecret, err := decodeBase64(subscription.Keys.Auth)
+	if err != nil {
+		return nil, err
+	}
+	userAgentKey, err := ecdh.P256().NewPublicKey(userAgentPublic)
+	if err != nil {
+		return nil, err
+	}
+	serverKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := serverKey.ECDH(userAgentKey)
+	if err != nil {
+		return nil, err
+	}
+	serverPublic := serverKey.PublicKey().Bytes()
+
+	keyInfo := append(append([]byte("WebPush: info\x00"), userAgentPublic...), serverPublic...)
+	ikm := hkdf(authSecret, sharedSecret, keyInfo, 32)
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	contentKey := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// 0x02 ends the last (and only) record.
+	plaintext := append(append([]byte{}, payload...), 2)
+	if len(plaintext)+gcm.Overhead() > pushRecordSize {
+		return nil, fmt.Errorf("%w: push payload is too large", ErrPermanent)
+	}
+
+	header := make([]byte, 0, 16+4+1+len(serverPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, pushRecordSize)
+	header = append(header, byte(len(serverPublic)))
+	header = append(header, serverPublic...)
+	return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// hkdf derives length bytes (at most 32) with HKDF-SHA-256.
+func hkdf(salt []byte, secret []byte, info []byte, length int) []byte {
+	extract := hmac.New(sha256.New, salt)
+	extract.Write(secret)
+	expand := hmac.New(sha256.New, extract.Sum(nil))
+	expand.Write(info)
+	expand.Write([]byte{1})
+	return expand.Sum(nil)[:length]
+}
+
+// decodeBase64 accepts base64 with or without padding, in either alphabet,
+// since browsers and key generators differ.
+func decodeBase64(value string) ([]byte, error) {
+	value = strings.TrimRight(value, "=")
+	value = strings.NewReplacer("+", "-", "/", "_").Replace(value)
+	return base64.RawURLEncoding.DecodeString(value)
+}
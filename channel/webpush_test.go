@@ -0,0 +1,256 @@
+package channel
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// browser is the user agent side of a push subscription.
+type browser struct {
+	key        *ecdh.PrivateKey
+	authSecret []byte
+}
+
+func newBrowser(t *testing.T) browser {
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+	return browser{key: key, authSecret: authSecret}
+}
+
+func (b browser) target(t *testing.T, endpoint string) json.RawMessage {
+	var subscription PushSubscription
+	subscription.Endpoint = endpoint
+	subscription.Keys.P256dh = base64.RawURLEncoding.EncodeToString(b.key.PublicKey().Bytes())
+	subscription.Keys.Auth = base64.RawURLEncoding.EncodeToString(b.authSecret)
+	target, err := json.Marshal(subscription)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return target
+}
+
+// decrypt reverses the aes128gcm encryption of RFC 8291.
+func (b browser) decrypt(t *testing.T, body []byte) []byte {
+	if len(body) < 21 {
+		t.Fatalf("body of %d bytes has no header", len(body))
+	}
+	salt := body[:16]
+	if recordSize := binary.BigEndian.Uint32(body[16:20]); recordSize != pushRecordSize {
+		t.Errorf("record size = %d, want %d", recordSize, pushRecordSize)
+	}
+	idLength := int(body[20])
+	serverPublic := body[21 : 21+idLength]
+	ciphertext := body[21+idLength:]
+
+	serverKey, err := ecdh.P256().NewPublicKey(serverPublic)
+	if err != nil {
+		t.Fatalf("key id is not a P-256 public key: %v", err)
+	}
+	sharedSecret, err := b.key.ECDH(serverKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyInfo := append(append([]byte("WebPush: info\x00"), b.key.PublicKey().Bytes()...), serverPublic...)
+	ikm := hkdf(b.authSecret, sharedSecret, keyInfo, 32)
+	contentKey := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(contentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		t.Fatalf("cannot decrypt the push: %v", err)
+	}
+	if len(plaintext) == 0 || plaintext[len(plaintext)-1] != 2 {
+		t.Fatalf("push does not end with the last record delimiter")
+	}
+	return plaintext[:len(plaintext)-1]
+}
+
+func newTestWebPushChannel(t *testing.T, client *http.Client) *WebPushChannel {
+	private := make([]byte, 32)
+	rand.Read(private)
+	private[0] &= 0x7f // keep the scalar below the group order
+	channel, err := NewWebPushChannel("", base64.RawURLEncoding.EncodeToString(private), "mailto:ops@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	channel.Client = client
+	channel.TTL = time.Hour
+	return channel
+}
+
+// verifyVAPID checks the Authorization header of a push against the
+// channel's public key and returns the JWT claims.
+func verifyVAPID(t *testing.T, channel *WebPushChannel, authorization string) map[string]interface{} {
+	rest, ok := strings.CutPrefix(authorization, "vapid t=")
+	if !ok {
+		t.Fatalf("Authorization = %q, want the vapid scheme", authorization)
+	}
+	token, key, ok := strings.Cut(rest, ", k=")
+	if !ok {
+		t.Fatalf("Authorization = %q has no k parameter", authorization)
+	}
+	if key != channel.PublicKey() {
+		t.Errorf("k = %q, want %q", key, channel.PublicKey())
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("token %q is not a JWT", token)
+	}
+	header, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	if string(header) != `{"typ":"JWT","alg":"ES256"}` {
+		t.Errorf("JWT header = %s", header)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || len(signature) != 64 {
+		t.Fatalf("JWT signature is not 64 bytes of base64url")
+	}
+	hash := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).SetBytes(signature[32:])
+	if !ecdsa.Verify(&channel.privateKey.PublicKey, hash[:], r, s) {
+		t.Error("JWT signature does not verify with the VAPID public key")
+	}
+
+	var claims map[string]interface{}
+	payload, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("JWT claims are not JSON: %s", payload)
+	}
+	return claims
+}
+
+func TestWebPushChannelSendsEncryptedPush(t *testing.T) {
+	var request *http.Request
+	var body []byte
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	browser := newBrowser(t)
+	channel := newTestWebPushChannel(t, server.Client())
+	err := channel.Send(Recipient{Target: browser.target(t, server.URL+"/push/abc")}, Message{
+		Type:  "reminder",
+		Title: "Standup",
+		Body:  "Starts in 10 minutes",
+		Link:  "https://app.example.com/schedule/1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if request.Method != http.MethodPost || request.URL.Path != "/push/abc" {
+		t.Errorf("request = %s %s", request.Method, request.URL.Path)
+	}
+	for header, want := range map[string]string{
+		"Content-Type":     "application/octet-stream",
+		"Content-Encoding": "aes128gcm",
+		"TTL":              "3600",
+	} {
+		if got := request.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	claims := verifyVAPID(t, channel, request.Header.Get("Authorization"))
+	if claims["aud"] != server.URL {
+		t.Errorf("aud = %v, want %s", claims["aud"], server.URL)
+	}
+	if claims["sub"] != "mailto:ops@example.com" {
+		t.Errorf("sub = %v", claims["sub"])
+	}
+	if exp, _ := claims["exp"].(float64); time.Unix(int64(exp), 0).Before(time.Now()) {
+		t.Errorf("exp = %v is in the past", claims["exp"])
+	}
+
+	var payload map[string]string
+	if err := json.Unmarshal(browser.decrypt(t, body), &payload); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"type":  "reminder",
+		"title": "Standup",
+		"body":  "Starts in 10 minutes",
+		"url":   "https://app.example.com/schedule/1",
+	}
+	for key, value := range want {
+		if payload[key] != value {
+			t.Errorf("payload %s = %q, want %q", key, payload[key], value)
+		}
+	}
+}
+
+func TestWebPushChannelReportsDeliveryStatus(t *testing.T) {
+	tests := []struct {
+		status    int
+		permanent bool
+	}{
+		{status: http.StatusGone, permanent: true},
+		{status: http.StatusNotFound, permanent: true},
+		{status: http.StatusRequestEntityTooLarge, permanent: true},
+		{status: http.StatusTooManyRequests, permanent: false},
+		{status: http.StatusServiceUnavailable, permanent: false},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			channel := newTestWebPushChannel(t, server.Client())
+			err := channel.Send(Recipient{Target: newBrowser(t).target(t, server.URL)}, Message{Body: "Hello"})
+			if err == nil {
+				t.Fatal("Send succeeded")
+			}
+			if errors.Is(err, ErrPermanent) != tt.permanent {
+				t.Errorf("permanent = %v, want %v: %v", !tt.permanent, tt.permanent, err)
+			}
+		})
+	}
+}
+
+func TestValidateWebPushTargetRefusesPrivateEndpoints(t *testing.T) {
+	browser := newBrowser(t)
+	for _, endpoint := range []string{
+		"https://127.0.0.1/push",
+		"https://10.0.0.8/push",
+		"https://169.254.169.254/latest/meta-data",
+		"https://[::1]/push",
+		"http://fcm.googleapis.com/fcm/send/abc",
+	} {
+		if err := ValidateTarget(WebPush, browser.target(t, endpoint)); err == nil {
+			t.Errorf("ValidateTarget accepted %s", endpoint)
+		}
+	}
+}
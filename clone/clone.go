@@ -0,0 +1,196 @@
+// Package clone copies schedules, with what hangs off them, and whole
+// workspaces. Workspace users do not carry across workspaces, so rows that
+// point at one are mapped to the workspace user of the same email in the
+// target workspace.
+package clone
+
+import (
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Members maps the joined workspace users of sourceWorkspaceId to those of
+// targetWorkspaceId with the same email. Within one workspace every workspace
+// user maps to itself.
+func Members(db *gorm.DB, sourceWorkspaceId int, targetWorkspaceId int) (map[int]int, error) {
+	var pairs []struct {
+		SourceId int
+		TargetId int
+	}
+	err := db.Table("tw_workspace_users AS source").
+		Select("source.id AS source_id, target.id AS target_id").
+		Joins("JOIN tw_workspace_users AS target ON target.user_email_id = source.user_email_id").
+		Where("source.workspace_id = ? AND target.workspace_id = ?", sourceWorkspaceId, targetWorkspaceId).
+		Where("source.deleted_at IS NULL AND target.deleted_at IS NULL").
+		Where("target.status = 'joined' AND target.is_active = true").
+		Scan(&pairs).Error
+	members := make(map[int]int, len(pairs))
+	for _, pair := range pairs {
+		members[pair.SourceId] = pair.TargetId
+	}
+	return members, err
+}
+
+// Schedule returns a copy of source, not yet saved, for boardColumnId of
+// workspaceId, created by createdBy at now.
+func Schedule(source models.TwSchedule, workspaceId int, boardColumnId int, createdBy int, now time.Time) models.TwSchedule {
+	return models.TwSchedule{
+		WorkspaceId:       workspaceId,
+		BoardColumnId:     boardColumnId,
+		Title:             source.Title,
+		Description:       source.Description,
+		StartTime:         source.StartTime,
+		EndTime:           source.EndTime,
+		Location:          source.Location,
+		CreatedBy:         createdBy,
+		CreatedAt:         &now,
+		UpdatedAt:         &now,
+		Status:            source.Status,
+		AllDay:            source.AllDay,
+		Visibility:        source.Visibility,
+		VideoTranscript:   source.VideoTranscript,
+		ExtraData:         source.ExtraData,
+		RecurrencePattern: source.RecurrencePattern,
+		Priority:          source.Priority,
+	}
+}
+
+// Details copies the participants, reminders, recurrence exceptions, document
+// metadata and, with comments, the comments of schedule sourceId to
+// targetId. Participants and reminders of workspace users without a match in
+// members are left out, as are participants targetId already has. Documents
+// and comments keep their content and go to fallbackId when their author has
+// no match; the files themselves are shared, not copied.
+func Details(tx *gorm.DB, sourceId int, targetId int, members map[int]int, fallbackId int, comments bool) error {
+	var existing []int
+	if err := tx.Model(&models.TwScheduleParticipant{}).
+		Where("schedule_id = ? AND deleted_at IS NULL", targetId).
+		Pluck("workspace_user_id", &existing).Error; err != nil {
+		return err
+	}
+	joined := make(map[int]bool, len(existing))
+	for _, id := range existing {
+		joined[id] = true
+	}
+
+	var participants []models.TwScheduleParticipant
+	if err := tx.Where("schedule_id = ? AND deleted_at IS NULL AND invitation_status != 'removed'", sourceId).
+		Order("id").
+		Find(&participants).Error; err != nil {
+		return err
+	}
+	for _, participant := range participants {
+		workspaceUserId, ok := members[participant.WorkspaceUserId]
+		if !ok || joined[workspaceUserId] {
+			continue
+		}
+		joined[workspaceUserId] = true
+		participant.ID = 0
+		participant.CreatedAt = time.Time{}
+		participant.UpdatedAt = time.Time{}
+		participant.ScheduleId = targetId
+		participant.WorkspaceUserId = workspaceUserId
+		if assignBy, ok := members[participant.AssignBy]; ok {
+			participant.AssignBy = assignBy
+		} else {
+			participant.AssignBy = fallbackId
+		}
+		if err := tx.Create(&participant).Error; err != nil {
+			return err
+		}
+	}
+
+	var reminders []models.TwReminder
+	if err := tx.Where("schedule_id = ? AND deleted_at IS NULL", sourceId).Order("id").Find(&reminders).Error; err != nil {
+		return err
+	}
+	for _, reminder := range reminders {
+		workspaceUserId, ok := members[reminder.WorkspaceUserID]
+		if !ok {
+			continue
+		}
+		// Reminders already sent for the source are not sent again for the
+		// copy, which has the same times.
+		copied := models.TwReminder{
+			ScheduleId:      targetId,
+			ReminderTime:    reminder.ReminderTime,
+			Method:          reminder.Method,
+			Type:            reminder.Type,
+			IsSent:          reminder.IsSent,
+			WorkspaceUserID: workspaceUserId,
+		}
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+	}
+
+	var exceptions []models.TwRecurrenceException
+	if err := tx.Where("schedule_id = ? AND deleted_at IS NULL", sourceId).Order("id").Find(&exceptions).Error; err != nil {
+		return err
+	}
+	for _, exception := range exceptions {
+		copied := models.TwRecurrenceException{
+			ScheduleId:    targetId,
+			ExceptionDate: exception.ExceptionDate,
+			NewStartTime:  exception.NewStartTime,
+			NewEndTime:    exception.NewEndTime,
+			IsCancelled:   exception.IsCancelled,
+			ExtraData:     exception.ExtraData,
+		}
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+	}
+
+	var documents []models.TwDocument
+	if err := tx.Where("schedule_id = ? AND is_deleted = false AND deleted_at IS NULL", sourceId).Order("id").Find(&documents).Error; err != nil {
+		return err
+	}
+	for _, document := range documents {
+		uploadedBy, ok := members[document.UploadedBy]
+		if !ok {
+			uploadedBy = fallbackId
+		}
+		copied := models.TwDocument{
+			FileName:    document.FileName,
+			FilePath:    document.FilePath,
+			FileSize:    document.FileSize,
+			FileType:    document.FileType,
+			ScheduleId:  targetId,
+			UploadedBy:  uploadedBy,
+			UploadedAt:  document.UploadedAt,
+			DownloadUrl: document.DownloadUrl,
+		}
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+	}
+
+	if !comments {
+		return nil
+	}
+	var sourceComments []models.TwComment
+	if err := tx.Where("schedule_id = ? AND is_deleted = false AND deleted_at IS NULL", sourceId).Order("id").Find(&sourceComments).Error; err != nil {
+		return err
+	}
+	for _, comment := range sourceComments {
+		workspaceUserId, ok := members[comment.WorkspaceUserId]
+		if !ok {
+			workspaceUserId = fallbackId
+		}
+		// Comments keep their time so that the thread reads as it did.
+		copied := models.TwComment{
+			CreatedAt:       comment.CreatedAt,
+			UpdatedAt:       comment.UpdatedAt,
+			ScheduleId:      targetId,
+			WorkspaceUserId: workspaceUserId,
+			Commenter:       comment.Commenter,
+			Content:         comment.Content,
+		}
+		if err := tx.Create(&copied).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,383 @@
+package clone
+
+import (
+	"crypto/rand"
+	"dbms/lexorank"
+	"encoding/hex"
+	"fmt"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"log"
+	"runtime/debug"
+	"time"
+)
+
+// batchSize is the number of schedules copied per transaction, and so the
+// step in which a workspace clone reports progress.
+const batchSize = 50
+
+// StaleAfter is how long a clone may go without progress before FailStale
+// takes it for interrupted. A running clone saves progress at least once per
+// batch of schedules, which takes far less.
+const StaleAfter = 15 * time.Minute
+
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// roles a member can be given in a clone.
+var roles = map[string]bool{"owner": true, "admin": true, "member": true, "guest": true}
+
+// Job is a workspace clone. Done counts the members, board columns and
+// schedules copied so far out of Total.
+type Job struct {
+	ID                int        `json:"id" gorm:"primary_key"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+	SourceWorkspaceId int        `json:"source_workspace_id" gorm:"index"`
+	TargetWorkspaceId int        `json:"target_workspace_id"`
+	CreatedBy         int        `json:"created_by"`
+	Status            string     `json:"status" gorm:"size:16"`
+	Total             int        `json:"total"`
+	Done              int        `json:"done"`
+	Error             string     `json:"error" gorm:"type:text"`
+	FinishedAt        *time.Time `json:"finished_at" gorm:"default:null"`
+}
+
+func (Job) TableName() string {
+	return "tw_clone_jobs"
+}
+
+// Options choose what a workspace clone copies.
+type Options struct {
+	// Title of the clone; the source title followed by " (copy)" when empty.
+	Title string `json:"title"`
+	// RoleMap gives members of a role another role in the clone, e.g.
+	// {"owner": "admin"}; members of a role mapped to "" are not copied.
+	// Whoever clones is always an owner of the clone.
+	RoleMap  map[string]string `json:"role_map"`
+	Comments bool              `json:"include_comments"`
+}
+
+// Validate reports whether the role map only names known roles.
+func (o Options) Validate() error {
+	for from, to := range o.RoleMap {
+		if !roles[from] {
+			return fmt.Errorf("unknown role %q in role_map", from)
+		}
+		if to != "" && !roles[to] {
+			return fmt.Errorf("unknown role %q in role_map", to)
+		}
+	}
+	return nil
+}
+
+// Run copies the workspace of job into a new workspace: its members with
+// their roles mapped, its board columns in order, and its schedules in their
+// positions with their details. Progress is saved on job as it goes; if the
+// clone fails, even by panicking, the partial copy is discarded and the error
+// kept on job.
+func Run(db *gorm.DB, job *Job, options Options) {
+	err := runRecovered(db, job, options)
+	now := time.Now()
+	updates := map[string]interface{}{"status": StatusSucceeded, "finished_at": now}
+	if err != nil {
+		log.Printf("Could not clone workspace %d: %v", job.SourceWorkspaceId, err)
+		updates = map[string]interface{}{"status": StatusFailed, "error": err.Error(), "finished_at": now}
+		if job.TargetWorkspaceId != 0 {
+			if err := discard(db, job.TargetWorkspaceId); err != nil {
+				log.Printf("Could not delete partial clone %d: %v", job.TargetWorkspaceId, err)
+			}
+		}
+	}
+	if err := db.Model(job).Updates(updates).Error; err != nil {
+		log.Printf("Could not finish clone job %d: %v", job.ID, err)
+	}
+}
+
+// runRecovered runs the clone, turning a panic into an error so that it fails
+// the job rather than the process.
+func runRecovered(db *gorm.DB, job *Job, options Options) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Clone job %d panicked: %v\n%s", job.ID, r, debug.Stack())
+			err = fmt.Errorf("clone stopped unexpectedly: %v", r)
+		}
+	}()
+	return run(db, job, options)
+}
+
+// FailStale fails the clones that have not made progress for StaleAfter, such
+// as those of a process that stopped, and discards their partial copies. The
+// updated_at of a job is its heartbeat: progress sets it with every step.
+func FailStale(db *gorm.DB, now time.Time) error {
+	var jobs []Job
+	if err := db.Where("status IN (?) AND updated_at < ?", []string{StatusPending, StatusRunning}, now.Add(-StaleAfter)).
+		Find(&jobs).Error; err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if job.TargetWorkspaceId != 0 {
+			if err := discard(db, job.TargetWorkspaceId); err != nil {
+				return err
+			}
+		}
+		// The status is checked again in case the clone finished meanwhile.
+		if err := db.Model(&Job{}).
+			Where("id = ? AND status IN (?)", job.ID, []string{StatusPending, StatusRunning}).
+			Updates(map[string]interface{}{"status": StatusFailed, "error": "clone was interrupted", "finished_at": now}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discard deletes the partial copy in targetWorkspaceId: the workspace, its
+// members, board columns and schedules, and their participants and reminders,
+// so that no one sees the copy and its reminders never fire.
+func discard(db *gorm.DB, targetWorkspaceId int) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		schedules := tx.Model(&models.TwSchedule{}).Select("id").Where("workspace_id = ?", targetWorkspaceId)
+		if err := tx.Model(&models.TwReminder{}).Where("schedule_id IN (?) AND deleted_at IS NULL", schedules).
+			Update("deleted_at", gorm.Expr("NOW()")).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.TwScheduleParticipant{}).Where("schedule_id IN (?) AND deleted_at IS NULL", schedules).
+			Update("deleted_at", gorm.Expr("NOW()")).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.TwSchedule{}).Where("workspace_id = ?", targetWorkspaceId).Updates(map[string]interface{}{
+			"deleted_at": gorm.Expr("COALESCE(deleted_at, NOW())"),
+			"is_deleted": true,
+		}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.TwBoardColumn{}).Where("workspace_id = ? AND deleted_at IS NULL", targetWorkspaceId).
+			Update("deleted_at", gorm.Expr("NOW()")).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.TwWorkspaceUser{}).Where("workspace_id = ?", targetWorkspaceId).Updates(map[string]interface{}{
+			"deleted_at": gorm.Expr("COALESCE(deleted_at, NOW())"),
+			"is_active":  false,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.TwWorkspace{}).Where("id = ?", targetWorkspaceId).Updates(map[string]interface{}{
+			"deleted_at": gorm.Expr("NOW()"),
+			"is_deleted": true,
+		}).Error
+	})
+}
+
+func run(db *gorm.DB, job *Job, options Options) error {
+	var source models.TwWorkspace
+	if err := db.Where("id = ? AND deleted_at IS NULL", job.SourceWorkspaceId).First(&source).Error; err != nil {
+		return err
+	}
+	var members []models.TwWorkspaceUser
+	if err := db.Where("workspace_id = ? AND deleted_at IS NULL AND status = 'joined' AND is_active = true", source.ID).
+		Order("id").
+		Find(&members).Error; err != nil {
+		return err
+	}
+	var columns []models.TwBoardColumn
+	if err := db.Where("workspace_id = ? AND deleted_at IS NULL", source.ID).
+		Order(lexorank.Order).
+		Find(&columns).Error; err != nil {
+		return err
+	}
+	columnIds := make([]int, 0, len(columns))
+	for _, column := range columns {
+		columnIds = append(columnIds, column.ID)
+	}
+	var schedules []models.TwSchedule
+	if len(columnIds) > 0 {
+		if err := db.Where("board_column_id IN (?) AND is_deleted = false AND deleted_at IS NULL", columnIds).
+			Order("board_column_id, " + lexorank.Order).
+			Find(&schedules).Error; err != nil {
+			return err
+		}
+	}
+
+	job.Status = StatusRunning
+	job.Total = len(members) + len(columns) + len(schedules)
+	if err := progress(db, job, map[string]interface{}{"status": job.Status, "total": job.Total}); err != nil {
+		return err
+	}
+
+	// The workspace, its members and its columns come first, so that the
+	// schedules have somewhere to go.
+	columnMap := make(map[int]int, len(columns))
+	var memberMap map[int]int
+	var actorId int
+	err := db.Transaction(func(tx *gorm.DB) error {
+		key, err := newKey()
+		if err != nil {
+			return err
+		}
+		title := options.Title
+		if title == "" {
+			title = source.Title + " (copy)"
+		}
+		target := models.TwWorkspace{
+			Title:       title,
+			ExtraData:   source.ExtraData,
+			Description: source.Description,
+			Key:         key,
+			Type:        source.Type,
+		}
+		if err := tx.Create(&target).Error; err != nil {
+			return err
+		}
+		job.TargetWorkspaceId = target.ID
+
+		for _, member := range members {
+			role := member.Role
+			if mapped, ok := options.RoleMap[role]; ok {
+				role = mapped
+			}
+			if member.ID == job.CreatedBy {
+				role = "owner"
+			}
+			if role == "" {
+				continue
+			}
+			copied := models.TwWorkspaceUser{
+				UserEmailId:  member.UserEmailId,
+				WorkspaceId:  target.ID,
+				WorkspaceKey: key,
+				Role:         role,
+				Status:       member.Status,
+				IsActive:     member.IsActive,
+				IsVerified:   member.IsVerified,
+				ExtraData:    member.ExtraData,
+			}
+			if err := tx.Create(&copied).Error; err != nil {
+				return err
+			}
+		}
+		if memberMap, err = Members(tx, source.ID, target.ID); err != nil {
+			return err
+		}
+		actorId = memberMap[job.CreatedBy]
+
+		keys := lexorank.Spread(len(columns))
+		for i, column := range columns {
+			copied := models.TwBoardColumn{
+				WorkspaceId: target.ID,
+				Name:        column.Name,
+				Position:    i + 1,
+			}
+			if err := tx.Create(&copied).Error; err != nil {
+				return err
+			}
+			if err := lexorank.BoardColumns.Set(tx, copied.ID, keys[i]); err != nil {
+				return err
+			}
+			columnMap[column.ID] = copied.ID
+		}
+
+		if err := tx.Create(&models.TwWorkspaceLog{
+			WorkspaceId:     target.ID,
+			WorkspaceUserId: actorId,
+			Action:          "clone workspace",
+			OldValue:        fmt.Sprint(source.ID),
+			NewValue:        fmt.Sprint(target.ID),
+			Description:     fmt.Sprintf("Cloned from workspace %q", source.Title),
+		}).Error; err != nil {
+			return err
+		}
+		job.Done = len(members) + len(columns)
+		return progress(tx, job, map[string]interface{}{
+			"target_workspace_id": job.TargetWorkspaceId,
+			"done":                job.Done,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	// Schedules keep their order within each column.
+	rankKeys := make(map[int]string, len(schedules))
+	positions := make(map[int]int, len(schedules))
+	for start := 0; start < len(schedules); {
+		end := start + 1
+		for end < len(schedules) && schedules[end].BoardColumnId == schedules[start].BoardColumnId {
+			end++
+		}
+		for i, key := range lexorank.Spread(end - start) {
+			rankKeys[schedules[start+i].ID] = key
+			positions[schedules[start+i].ID] = i + 1
+		}
+		start = end
+	}
+
+	for start := 0; start < len(schedules); start += batchSize {
+		end := start + batchSize
+		if end > len(schedules) {
+			end = len(schedules)
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			now := time.Now()
+			for _, schedule := range schedules[start:end] {
+				createdBy, ok := memberMap[schedule.CreatedBy]
+				if !ok {
+					createdBy = actorId
+				}
+				copied := Schedule(schedule, job.TargetWorkspaceId, columnMap[schedule.BoardColumnId], createdBy, now)
+				copied.Position = positions[schedule.ID]
+				if err := tx.Create(&copied).Error; err != nil {
+					return err
+				}
+				if err := lexorank.Schedules.Set(tx, copied.ID, rankKeys[schedule.ID]); err != nil {
+					return err
+				}
+				if err := tx.Create(&models.TwScheduleLog{
+					ScheduleId:      copied.ID,
+					WorkspaceUserId: actorId,
+					Action:          "create schedule",
+					Description:     fmt.Sprintf("Cloned from schedule %d", schedule.ID),
+				}).Error; err != nil {
+					return err
+				}
+				if err := Details(tx, schedule.ID, copied.ID, memberMap, actorId, options.Comments); err != nil {
+					return err
+				}
+			}
+			job.Done += end - start
+			return progress(tx, job, map[string]interface{}{"done": job.Done})
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.Create(&models.TwWorkspaceLog{
+		WorkspaceId:     source.ID,
+		WorkspaceUserId: job.CreatedBy,
+		Action:          "clone workspace",
+		OldValue:        fmt.Sprint(source.ID),
+		NewValue:        fmt.Sprint(job.TargetWorkspaceId),
+		Description:     fmt.Sprintf("Cloned to workspace %d with %d board columns and %d schedules", job.TargetWorkspaceId, len(columns), len(schedules)),
+	}).Error
+}
+
+// progress saves updates on job with the time they were made, the heartbeat
+// FailStale checks.
+func progress(db *gorm.DB, job *Job, updates map[string]interface{}) error {
+	job.UpdatedAt = time.Now()
+	updates["updated_at"] = job.UpdatedAt
+	return db.Model(job).Updates(updates).Error
+}
+
+// newKey returns a random key for a cloned workspace.
+func newKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
@@ -0,0 +1,295 @@
+package common
+
+import (
+	"dbms/datetime"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// FieldType is how the values of a filter field are parsed.
+type FieldType int
+
+const (
+	FieldString FieldType = iota
+	FieldInt
+	FieldBool
+	FieldTime
+)
+
+// FilterField is a column a list endpoint lets clients filter and sort by.
+type FilterField struct {
+	Column string
+	Type   FieldType
+	// Op is the operator of a parameter that names none, e.g. contains for
+	// a title=sync that has always searched titles. Empty means eq.
+	Op string
+}
+
+// FilterFields is the allow-list of a resource, keyed by the name clients use.
+type FilterFields map[string]FilterField
+
+// On returns the fields with their unqualified columns qualified by table,
+// for queries that alias the resource's table.
+func (fields FilterFields) On(table string) FilterFields {
+	qualified := make(FilterFields, len(fields))
+	for name, field := range fields {
+		if !strings.Contains(field.Column, ".") {
+			field.Column = table + "." + field.Column
+		}
+		qualified[name] = field
+	}
+	return qualified
+}
+
+// With returns the fields together with more.
+func (fields FilterFields) With(more FilterFields) FilterFields {
+	combined := make(FilterFields, len(fields)+len(more))
+	for name, field := range fields {
+		combined[name] = field
+	}
+	for name, field := range more {
+		combined[name] = field
+	}
+	return combined
+}
+
+// Filter operators. A parameter without an operator, e.g. status=done, is the
+// field's Op, or eq, or in when it lists several numbers, e.g.
+// workspace_id=1,2.
+const (
+	OpEq       = "eq"
+	OpNe       = "ne"
+	OpIn       = "in"
+	OpLt       = "lt"
+	OpLte      = "lte"
+	OpGt       = "gt"
+	OpGte      = "gte"
+	OpContains = "contains"
+)
+
+const maxInValues = 100
+
+var operators = map[string]string{
+	OpEq:  "=",
+	OpNe:  "<>",
+	OpLt:  "<",
+	OpLte: "<=",
+	OpGt:  ">",
+	OpGte: ">=",
+}
+
+// ErrInvalidFilter is returned for a filter or sort that cannot be used.
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// filterParam matches field[op] query parameter names.
+var filterParam = regexp.MustCompile(`^([a-z_]+)\[([a-z]+)\]$`)
+
+type filterCondition struct {
+	column   string
+	operator string
+	value    interface{}
+}
+
+// Filter is the set of conditions a list request asks for, read from query
+// parameters such as status=done, status[in]=todo,done,
+// start_time[gte]=2024-01-01T00:00:00Z or title[contains]=sync.
+type Filter struct {
+	conditions []filterCondition
+}
+
+// ParseFilter reads the conditions of a list request on fields. Parameters
+// that do not name a field are left to the handler, but an operator on an
+// unknown field is an error rather than a filter silently ignored.
+func ParseFilter(c *fiber.Ctx, fields FilterFields) (Filter, error) {
+	var filter Filter
+	var err error
+	c.Context().QueryArgs().VisitAll(func(key []byte, value []byte) {
+		if err != nil {
+			return
+		}
+		name, op := string(key), ""
+		if match := filterParam.FindStringSubmatch(name); match != nil {
+			name, op = match[1], match[2]
+			if _, ok := fields[name]; !ok {
+				err = fmt.Errorf("%w: cannot filter by %s", ErrInvalidFilter, name)
+				return
+			}
+		}
+		field, ok := fields[name]
+		if !ok {
+			return
+		}
+		if op == "" {
+			switch {
+			case field.Op != "":
+				op = field.Op
+			case field.Type != FieldString && strings.Contains(string(value), ","):
+				op = OpIn
+			default:
+				op = OpEq
+			}
+		}
+
+		var condition filterCondition
+		if condition, err = parseCondition(field, op, string(value)); err != nil {
+			err = fmt.Errorf("%w: %s[%s]: %w", ErrInvalidFilter, name, op, err)
+			return
+		}
+		filter.conditions = append(filter.conditions, condition)
+	})
+	return filter, err
+}
+
+func parseCondition(field FilterField, op string, raw string) (filterCondition, error) {
+	condition := filterCondition{column: field.Column}
+	switch op {
+	case OpIn:
+		values := strings.Split(raw, ",")
+		if len(values) > maxInValues {
+			return condition, fmt.Errorf("at most %d values", maxInValues)
+		}
+		parsed := make([]interface{}, 0, len(values))
+		for _, value := range values {
+			v, err := parseValue(field.Type, strings.TrimSpace(value))
+			if err != nil {
+				return condition, err
+			}
+			parsed = append(parsed, v)
+		}
+		condition.operator, condition.value = "IN", parsed
+	case OpContains:
+		if field.Type != FieldString {
+			return condition, errors.New("contains only applies to text")
+		}
+		condition.operator, condition.value = "LIKE", "%"+escapeLike(raw)+"%"
+	default:
+		operator, ok := operators[op]
+		if !ok {
+			return condition, errors.New("unknown operator")
+		}
+		if field.Type == FieldBool && op != OpEq && op != OpNe {
+			return condition, errors.New("only eq and ne apply to true/false")
+		}
+		value, err := parseValue(field.Type, raw)
+		if err != nil {
+			return condition, err
+		}
+		condition.operator, condition.value = operator, value
+	}
+	return condition, nil
+}
+
+func parseValue(fieldType FieldType, raw string) (interface{}, error) {
+	switch fieldType {
+	case FieldInt:
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", raw)
+		}
+		return value, nil
+	case FieldBool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not true or false", raw)
+		}
+		return value, nil
+	case FieldTime:
+		// A bare date is the floating date all-day schedules are stored at.
+		if len(raw) == len(datetime.DateLayout) {
+			return datetime.ParseDate(raw)
+		}
+		return datetime.Parse(raw)
+	default:
+		return raw, nil
+	}
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(value)
+}
+
+// Empty reports whether the request asked for no conditions.
+func (f Filter) Empty() bool {
+	return len(f.conditions) == 0
+}
+
+// Where adds the conditions to query.
+func (f Filter) Where(query *gorm.DB) *gorm.DB {
+	for _, condition := range f.conditions {
+		placeholder := "?"
+		if condition.operator == "IN" {
+			placeholder = "(?)"
+		}
+		query = query.Where(condition.column+" "+condition.operator+" "+placeholder, condition.value)
+	}
+	return query
+}
+
+type sortKey struct {
+	column string
+	desc   bool
+}
+
+// Sort is the order a list request asks for, read from the sort query
+// parameter: a comma separated list of fields, each prefixed with "-" for
+// descending order, e.g. sort=-start_time,title.
+type Sort struct {
+	keys []sortKey
+}
+
+// ParseSort reads the order of a list request on fields.
+func ParseSort(c *fiber.Ctx, fields FilterFields) (Sort, error) {
+	return SortBy(c.Query("sort"), fields)
+}
+
+// SortBy reads an order written like the sort query parameter, for endpoints
+// that also take it in other parameters.
+func SortBy(raw string, fields FilterFields) (Sort, error) {
+	var order Sort
+	if raw == "" {
+		return order, nil
+	}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		desc := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		field, ok := fields[name]
+		if !ok {
+			return order, fmt.Errorf("%w: sort must be made of %s", ErrInvalidFilter, strings.Join(fields.names(), ", "))
+		}
+		order.keys = append(order.keys, sortKey{column: field.Column, desc: desc})
+	}
+	return order, nil
+}
+
+// Empty reports whether the request asked for no order.
+func (s Sort) Empty() bool {
+	return len(s.keys) == 0
+}
+
+// Order adds the sort keys to query.
+func (s Sort) Order(query *gorm.DB) *gorm.DB {
+	for _, key := range s.keys {
+		if key.desc {
+			query = query.Order(key.column + " DESC")
+		} else {
+			query = query.Order(key.column)
+		}
+	}
+	return query
+}
+
+func (fields FilterFields) names() []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,269 @@
+package common
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 200
+
+	// HeaderTotalCount is the number of items in the whole collection.
+	HeaderTotalCount = "X-Total-Count"
+	// HeaderNextCursor is the cursor query value of the next page. It is
+	// left out on the last page.
+	HeaderNextCursor = "X-Next-Cursor"
+)
+
+// ErrInvalidPage is returned for a sort or cursor that cannot be used.
+var ErrInvalidPage = errors.New("invalid page")
+
+// Page is the part of a collection a list request asks for, read from the
+// limit, sort and cursor query parameters. Requests without a limit or a
+// cursor get the whole collection, as they did before lists were paged.
+//
+// Pages are sorted by a column and then by id, so items with the same value
+// keep their order, and continue after the last item of the previous page
+// rather than at an offset, so inserts and deletes between requests do not
+// shift items across pages.
+type Page struct {
+	// Limit is the most items on the page; zero means no limit.
+	Limit int
+	// Sort is the column to sort by; id when empty.
+	Sort string
+	Desc bool
+
+	// order holds the fields of an unpaged list sorted by several, which
+	// Find applies instead of Sort.
+	order Sort
+	after *pageCursor
+}
+
+// PageResult describes the page Find loaded.
+type PageResult struct {
+	Total int64
+	// NextCursor is empty on the last page.
+	NextCursor string
+}
+
+// pageCursor is the position of the last item of a page. It carries the sort
+// it was issued for so that it is not used with another one.
+type pageCursor struct {
+	Sort  string      `json:"s"`
+	Desc  bool        `json:"d,omitempty"`
+	Value interface{} `json:"v"`
+	// Time marks Value as a time, which JSON would otherwise turn into a
+	// string.
+	Time bool `json:"t,omitempty"`
+	ID   int  `json:"id"`
+}
+
+// ParsePage reads the page a list request asks for. sort is read as by
+// ParseSort on id and fields. The list is only paged when limit or cursor is
+// given, with DefaultPageLimit items unless limit says otherwise, and a page
+// is sorted by a single field, as its cursor carries one; the whole list may
+// be sorted by several.
+func ParsePage(c *fiber.Ctx, fields FilterFields) (Page, error) {
+	page := Page{Sort: "id"}
+	if c.Query("limit") != "" || c.Query("cursor") != "" {
+		page.Limit = c.QueryInt("limit", DefaultPageLimit)
+		if page.Limit <= 0 || page.Limit > MaxPageLimit {
+			page.Limit = MaxPageLimit
+		}
+	}
+
+	order, err := ParseSort(c, fields.With(FilterFields{"id": {Column: "id", Type: FieldInt}}))
+	if err != nil {
+		return page, err
+	}
+	switch {
+	case len(order.keys) == 1:
+		// Pages are read from the resource's own table.
+		column := order.keys[0].column
+		page.Sort, page.Desc = column[strings.LastIndex(column, ".")+1:], order.keys[0].desc
+	case len(order.keys) > 1 && page.Limit > 0:
+		return page, fmt.Errorf("%w: a page is sorted by a single field", ErrInvalidPage)
+	case len(order.keys) > 1:
+		page.order = order
+	}
+
+	if token := c.Query("cursor"); token != "" {
+		after, err := decodeCursor(token)
+		if err != nil {
+			return page, fmt.Errorf("%w: malformed cursor", ErrInvalidPage)
+		}
+		if after.Sort != page.Sort || after.Desc != page.Desc {
+			return page, fmt.Errorf("%w: the cursor was issued for another sort", ErrInvalidPage)
+		}
+		page.after = &after
+	}
+	return page, nil
+}
+
+// Find loads the page of query into dest, a pointer to a slice of models, and
+// counts the whole collection. preloads are applied to the page only, as the
+// count cannot load them. Without a limit, the whole collection is loaded
+// and there is no next page.
+func (p Page) Find(query *gorm.DB, dest interface{}, preloads ...string) (PageResult, error) {
+	var result PageResult
+	sortColumn := p.Sort
+	if sortColumn == "" {
+		sortColumn = "id"
+	}
+
+	if query.Statement.Model == nil {
+		query = query.Model(dest)
+	}
+	if err := query.Session(&gorm.Session{}).Count(&result.Total).Error; err != nil {
+		return result, err
+	}
+
+	pageQuery := query.Session(&gorm.Session{})
+	if p.after != nil {
+		pageQuery = pageQuery.Where(p.after.condition(sortColumn))
+	}
+	for _, preload := range preloads {
+		pageQuery = pageQuery.Preload(preload)
+	}
+	if !p.order.Empty() {
+		return result, p.order.Order(pageQuery).
+			Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: "id"}}).
+			Find(dest).Error
+	}
+	pageQuery = pageQuery.
+		Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: sortColumn}, Desc: p.Desc})
+	if sortColumn != "id" {
+		pageQuery = pageQuery.
+			Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: "id"}, Desc: p.Desc})
+	}
+	if p.Limit <= 0 {
+		return result, pageQuery.Find(dest).Error
+	}
+	// One more than the limit tells whether there is a next page.
+	pageQuery = pageQuery.Limit(p.Limit + 1).Find(dest)
+	if pageQuery.Error != nil {
+		return result, pageQuery.Error
+	}
+
+	items := reflect.ValueOf(dest).Elem()
+	if items.Len() <= p.Limit {
+		return result, nil
+	}
+	items.Set(items.Slice(0, p.Limit))
+
+	schema := pageQuery.Statement.Schema
+	sortField, idField := schema.LookUpField(sortColumn), schema.LookUpField("id")
+	if sortField == nil || idField == nil {
+		return result, fmt.Errorf("cannot page %s by %s", schema.Table, sortColumn)
+	}
+	last := reflect.Indirect(items.Index(p.Limit - 1))
+	next := pageCursor{Sort: p.Sort, Desc: p.Desc}
+	value, _ := sortField.ValueOf(pageQuery.Statement.Context, last)
+	if value = deref(value); value != nil {
+		_, next.Time = value.(time.Time)
+		next.Value = value
+	}
+	id, _ := idField.ValueOf(pageQuery.Statement.Context, last)
+	next.ID, _ = deref(id).(int)
+
+	token, err := json.Marshal(next)
+	if err != nil {
+		return result, err
+	}
+	result.NextCursor = base64.RawURLEncoding.EncodeToString(token)
+	return result, nil
+}
+
+// Write sets the total count and next cursor headers of a list response.
+func (r PageResult) Write(c *fiber.Ctx) {
+	c.Set(HeaderTotalCount, strconv.FormatInt(r.Total, 10))
+	if r.NextCursor != "" {
+		c.Set(HeaderNextCursor, r.NextCursor)
+	}
+}
+
+// condition selects the items after the cursor. MySQL sorts NULLs first, so
+// in ascending order they come before every value and in descending order
+// after every value.
+func (cursor pageCursor) condition(sortColumn string) clause.Expression {
+	column := clause.Column{Table: clause.CurrentTable, Name: sortColumn}
+	id := clause.Column{Table: clause.CurrentTable, Name: "id"}
+	comparison := ">"
+	if cursor.Desc {
+		comparison = "<"
+	}
+	if sortColumn == "id" {
+		return clause.Expr{SQL: "? " + comparison + " ?", Vars: []interface{}{id, cursor.ID}}
+	}
+
+	switch {
+	case cursor.Value == nil && cursor.Desc:
+		return clause.Expr{SQL: "? IS NULL AND ? < ?", Vars: []interface{}{column, id, cursor.ID}}
+	case cursor.Value == nil:
+		return clause.Expr{SQL: "? IS NOT NULL OR (? IS NULL AND ? > ?)", Vars: []interface{}{column, column, id, cursor.ID}}
+	case cursor.Desc:
+		return clause.Expr{
+			SQL:  "? < ? OR ? IS NULL OR (? = ? AND ? < ?)",
+			Vars: []interface{}{column, cursor.Value, column, column, cursor.Value, id, cursor.ID},
+		}
+	default:
+		return clause.Expr{
+			SQL:  "? > ? OR (? = ? AND ? > ?)",
+			Vars: []interface{}{column, cursor.Value, column, cursor.Value, id, cursor.ID},
+		}
+	}
+}
+
+func decodeCursor(token string) (pageCursor, error) {
+	var cursor pageCursor
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return cursor, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&cursor); err != nil {
+		return cursor, err
+	}
+
+	switch value := cursor.Value.(type) {
+	case string:
+		if cursor.Time {
+			cursor.Value, err = time.Parse(time.RFC3339Nano, value)
+		}
+	case json.Number:
+		if cursor.Value, err = value.Int64(); err != nil {
+			cursor.Value, err = value.Float64()
+		}
+	case nil, bool:
+	default:
+		err = errors.New("unsupported cursor value")
+	}
+	return cursor, err
+}
+
+// deref returns what a pointer field points to, or nil.
+func deref(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
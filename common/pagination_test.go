@@ -0,0 +1,294 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// dryRun returns a database that builds statements without running them.
+func dryRun(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(mysql.New(mysql.Config{DSN: "dry@/run", SkipInitializeWithVersion: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func encodeCursor(t *testing.T, cursor interface{}) string {
+	t.Helper()
+	raw, err := json.Marshal(cursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func TestDecodeCursor(t *testing.T) {
+	at := time.Date(2026, 10, 16, 9, 30, 0, 123, time.UTC)
+	tests := []struct {
+		name   string
+		cursor pageCursor
+		want   interface{}
+	}{
+		{name: "id", cursor: pageCursor{Sort: "id", ID: 42}},
+		{name: "int", cursor: pageCursor{Sort: "priority", Value: 3, ID: 42}, want: int64(3)},
+		{name: "large int", cursor: pageCursor{Sort: "size", Value: int64(1) << 60, ID: 42}, want: int64(1) << 60},
+		{name: "float", cursor: pageCursor{Sort: "score", Value: 2.5, ID: 42}, want: 2.5},
+		{name: "string", cursor: pageCursor{Sort: "title", Desc: true, Value: "2026-10-16", ID: 42}, want: "2026-10-16"},
+		{name: "time", cursor: pageCursor{Sort: "start_time", Value: at, Time: true, ID: 42}, want: at},
+		{name: "bool", cursor: pageCursor{Sort: "is_active", Value: true, ID: 42}, want: true},
+		{name: "null", cursor: pageCursor{Sort: "end_time", Desc: true, ID: 42}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeCursor(encodeCursor(t, tt.cursor))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Sort != tt.cursor.Sort || got.Desc != tt.cursor.Desc || got.ID != tt.cursor.ID {
+				t.Errorf("decodeCursor = %+v, want %+v", got, tt.cursor)
+			}
+			if wantTime, ok := tt.want.(time.Time); ok {
+				if gotTime, ok := got.Value.(time.Time); !ok || !gotTime.Equal(wantTime) {
+					t.Errorf("Value = %#v, want %s", got.Value, wantTime)
+				}
+				return
+			}
+			if got.Value != tt.want {
+				t.Errorf("Value = %#v, want %#v", got.Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	tests := map[string]string{
+		"not base64":      "%%%",
+		"not JSON":        base64.RawURLEncoding.EncodeToString([]byte("page 2")),
+		"object value":    encodeCursor(t, map[string]interface{}{"s": "title", "v": map[string]int{"a": 1}, "id": 1}),
+		"array value":     encodeCursor(t, map[string]interface{}{"s": "title", "v": []int{1}, "id": 1}),
+		"malformed time":  encodeCursor(t, map[string]interface{}{"s": "start_time", "v": "yesterday", "t": true, "id": 1}),
+		"string as an id": encodeCursor(t, map[string]interface{}{"s": "id", "id": "1"}),
+	}
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := decodeCursor(token); err == nil {
+				t.Errorf("decodeCursor(%q) succeeded, want an error", token)
+			}
+		})
+	}
+}
+
+func TestPageCursorCondition(t *testing.T) {
+	tests := []struct {
+		name   string
+		column string
+		cursor pageCursor
+		want   string
+	}{
+		{
+			name:   "id ascending",
+			column: "id",
+			cursor: pageCursor{ID: 42},
+			want:   "`tw_schedules`.`id` > 42",
+		},
+		{
+			name:   "id descending",
+			column: "id",
+			cursor: pageCursor{Desc: true, ID: 42},
+			want:   "`tw_schedules`.`id` < 42",
+		},
+		{
+			name:   "value ascending",
+			column: "title",
+			cursor: pageCursor{Value: "Standup", ID: 42},
+			want:   "(`tw_schedules`.`title` > 'Standup' OR (`tw_schedules`.`title` = 'Standup' AND `tw_schedules`.`id` > 42))",
+		},
+		{
+			name:   "value descending is followed by NULLs",
+			column: "title",
+			cursor: pageCursor{Desc: true, Value: "Standup", ID: 42},
+			want: "(`tw_schedules`.`title` < 'Standup' OR `tw_schedules`.`title` IS NULL OR " +
+				"(`tw_schedules`.`title` = 'Standup' AND `tw_schedules`.`id` < 42))",
+		},
+		{
+			name:   "NULL ascending is followed by every value",
+			column: "end_time",
+			cursor: pageCursor{ID: 42},
+			want:   "(`tw_schedules`.`end_time` IS NOT NULL OR (`tw_schedules`.`end_time` IS NULL AND `tw_schedules`.`id` > 42))",
+		},
+		{
+			name:   "NULL descending is followed by NULLs only",
+			column: "end_time",
+			cursor: pageCursor{Desc: true, ID: 42},
+			want:   "(`tw_schedules`.`end_time` IS NULL AND `tw_schedules`.`id` < 42)",
+		},
+	}
+	db := dryRun(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+				return tx.Table("tw_schedules").
+					Where("workspace_id = ?", 1).
+					Where(tt.cursor.condition(tt.column)).
+					Find(&[]map[string]interface{}{})
+			})
+			// The condition must stay within the scope of the list it pages.
+			want := "SELECT * FROM `tw_schedules` WHERE workspace_id = 1 AND " + tt.want
+			if got != want {
+				t.Errorf("condition =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+var pageFields = FilterFields{
+	"title":      {Column: "title", Type: FieldString},
+	"start_time": {Column: "tw_schedules.start_time", Type: FieldTime},
+}
+
+// parsePage runs ParsePage on a request with the given query string.
+func parsePage(t *testing.T, query string) (Page, error) {
+	t.Helper()
+	var page Page
+	var err error
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		page, err = ParsePage(c, pageFields)
+		return nil
+	})
+	if _, testErr := app.Test(httptest.NewRequest("GET", "/?"+query, nil)); testErr != nil {
+		t.Fatal(testErr)
+	}
+	return page, err
+}
+
+func TestParsePage(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  Page
+	}{
+		{name: "unpaged", query: "", want: Page{Sort: "id"}},
+		{name: "unpaged with a sort", query: "sort=-title", want: Page{Sort: "title", Desc: true}},
+		{name: "limit", query: "limit=10", want: Page{Limit: 10, Sort: "id"}},
+		{name: "limit above the maximum", query: "limit=1000", want: Page{Limit: MaxPageLimit, Sort: "id"}},
+		{name: "zero limit", query: "limit=0", want: Page{Limit: MaxPageLimit, Sort: "id"}},
+		{name: "qualified sort column", query: "limit=5&sort=start_time", want: Page{Limit: 5, Sort: "start_time"}},
+		{
+			name:  "unpaged by several fields",
+			query: "sort=title,-start_time",
+			want: Page{Sort: "id", order: Sort{keys: []sortKey{
+				{column: "title"},
+				{column: "tw_schedules.start_time", desc: true},
+			}}},
+		},
+		{
+			name:  "cursor without a limit",
+			query: "sort=title&cursor=" + encodeCursor(t, pageCursor{Sort: "title", Value: "a", ID: 7}),
+			want:  Page{Limit: DefaultPageLimit, Sort: "title", after: &pageCursor{Sort: "title", Value: "a", ID: 7}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePage(t, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParsePage = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePageRejects(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  error
+	}{
+		{name: "unknown sort field", query: "sort=password", want: ErrInvalidFilter},
+		{name: "malformed cursor", query: "cursor=page2", want: ErrInvalidPage},
+		{
+			name:  "cursor of another sort",
+			query: "sort=-title&cursor=" + encodeCursor(t, pageCursor{Sort: "title", Value: "a", ID: 7}),
+			want:  ErrInvalidPage,
+		},
+		{name: "paged by several fields", query: "limit=5&sort=title,-start_time", want: ErrInvalidPage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parsePage(t, tt.query); !errors.Is(err, tt.want) {
+				t.Errorf("ParsePage(%q) error = %v, want %v", tt.query, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPageFindOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "unpaged",
+			query: "",
+			want:  "SELECT * FROM `tw_schedules` WHERE workspace_id = 1 ORDER BY `tw_schedules`.`id`",
+		},
+		{
+			name:  "unpaged by a field",
+			query: "sort=-title",
+			want:  "SELECT * FROM `tw_schedules` WHERE workspace_id = 1 ORDER BY `tw_schedules`.`title` DESC,`tw_schedules`.`id` DESC",
+		},
+		{
+			name:  "unpaged by several fields",
+			query: "sort=title,-start_time",
+			want:  "SELECT * FROM `tw_schedules` WHERE workspace_id = 1 ORDER BY title,tw_schedules.start_time DESC,`tw_schedules`.`id`",
+		},
+		{
+			name:  "paged",
+			query: "limit=10&sort=start_time",
+			want:  "SELECT * FROM `tw_schedules` WHERE workspace_id = 1 ORDER BY `tw_schedules`.`start_time`,`tw_schedules`.`id` LIMIT 11",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := parsePage(t, tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			db := dryRun(t)
+			var statements []string
+			if err := db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+				statements = append(statements, tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...))
+			}); err != nil {
+				t.Fatal(err)
+			}
+
+			var items []map[string]interface{}
+			if _, err := page.Find(db.Table("tw_schedules").Where("workspace_id = ?", 1), &items); err != nil {
+				t.Fatal(err)
+			}
+			if len(statements) != 2 {
+				t.Fatalf("ran %d queries, want a count and a find: %v", len(statements), statements)
+			}
+			if statements[1] != tt.want {
+				t.Errorf("Find ran\n%s\nwant\n%s", statements[1], tt.want)
+			}
+		})
+	}
+}
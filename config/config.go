@@ -4,6 +4,8 @@ import (
 	"github.com/spf13/viper"
 	"log"
 	"os"
+	"strings"
+	"time"
 )
 
 type Config struct {
@@ -13,6 +15,44 @@ type Config struct {
 	DBName     string
 	DBHost     string
 	DBPort     string
+
+	TLSCertFile     string
+	TLSKeyFile      string
+	TLSClientCAFile string
+
+	AuthJWTSecret   string
+	AuthJWTIssuer   string
+	AuthJWTAudience string
+	// AuthClientScopes maps TLS client certificate common names to scopes,
+	// read from AUTH.MTLS_CLIENTS as "cron-worker=dbms.read;gateway=dbms.write".
+	AuthClientScopes map[string][]string
+	AuthDisabled     bool
+
+	// AppURL is the web app that relative links in emails point into.
+	AppURL string
+	// EmailSender is smtp (the default), file or memory.
+	EmailSender  string
+	EmailFrom    string
+	EmailFile    string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+
+	// VAPID key pair for Web Push, as base64url; the channel is off without
+	// a private key.
+	VAPIDPublicKey  string
+	VAPIDPrivateKey string
+	VAPIDSubject    string
+	// SMSURL is the gateway the SMS channel posts to; off when empty.
+	SMSURL   string
+	SMSToken string
+
+	// Reminder dispatch policy, see reminderlease.Policy.
+	ReminderCatchUp     time.Duration
+	ReminderLease       time.Duration
+	ReminderMaxAttempts int
+	ReminderRetryDelay  time.Duration
 }
 
 func LoadConfig() (*Config, error) {
@@ -29,6 +69,11 @@ func LoadConfig() (*Config, error) {
 
 	viper.SetConfigType("env")
 	viper.SetDefault("sever.port", "8089")
+	viper.SetDefault("SMTP.PORT", 587)
+	viper.SetDefault("REMINDER.CATCH_UP", "24h")
+	viper.SetDefault("REMINDER.LEASE", "5m")
+	viper.SetDefault("REMINDER.MAX_ATTEMPTS", 5)
+	viper.SetDefault("REMINDER.RETRY_DELAY", "1m")
 
 	if err := viper.ReadInConfig(); err != nil {
 		log.Printf("Error reading config file, %s", err)
@@ -43,6 +88,50 @@ func LoadConfig() (*Config, error) {
 		DBName:     viper.GetString("DB.NAME"),
 		DBHost:     viper.GetString("DB.HOST"),
 		DBPort:     viper.GetString("DB.PORT"),
+
+		TLSCertFile:     viper.GetString("WEB.TLS_CERT"),
+		TLSKeyFile:      viper.GetString("WEB.TLS_KEY"),
+		TLSClientCAFile: viper.GetString("WEB.TLS_CLIENT_CA"),
+
+		AuthJWTSecret:    viper.GetString("AUTH.JWT_SECRET"),
+		AuthJWTIssuer:    viper.GetString("AUTH.JWT_ISSUER"),
+		AuthJWTAudience:  viper.GetString("AUTH.JWT_AUDIENCE"),
+		AuthClientScopes: parseClientScopes(viper.GetString("AUTH.MTLS_CLIENTS")),
+		AuthDisabled:     viper.GetBool("AUTH.DISABLED"),
+
+		AppURL:       viper.GetString("APP.URL"),
+		EmailSender:  viper.GetString("EMAIL.SENDER"),
+		EmailFrom:    viper.GetString("EMAIL.FROM"),
+		EmailFile:    viper.GetString("EMAIL.FILE"),
+		SMTPHost:     viper.GetString("SMTP.HOST"),
+		SMTPPort:     viper.GetInt("SMTP.PORT"),
+		SMTPUsername: viper.GetString("SMTP.USERNAME"),
+		SMTPPassword: viper.GetString("SMTP.PASSWORD"),
+
+		VAPIDPublicKey:  viper.GetString("WEBPUSH.VAPID_PUBLIC_KEY"),
+		VAPIDPrivateKey: viper.GetString("WEBPUSH.VAPID_PRIVATE_KEY"),
+		VAPIDSubject:    viper.GetString("WEBPUSH.SUBJECT"),
+		SMSURL:          viper.GetString("SMS.URL"),
+		SMSToken:        viper.GetString("SMS.TOKEN"),
+
+		ReminderCatchUp:     viper.GetDuration("REMINDER.CATCH_UP"),
+		ReminderLease:       viper.GetDuration("REMINDER.LEASE"),
+		ReminderMaxAttempts: viper.GetInt("REMINDER.MAX_ATTEMPTS"),
+		ReminderRetryDelay:  viper.GetDuration("REMINDER.RETRY_DELAY"),
 	}
 	return config, nil
 }
+
+func parseClientScopes(value string) map[string][]string {
+	clientScopes := make(map[string][]string)
+	for _, entry := range strings.Split(value, ";") {
+		kv := strings.SplitN(entry, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			continue
+		}
+		clientScopes[strings.TrimSpace(kv[0])] = strings.FieldsFunc(kv[1], func(r rune) bool {
+			return r == ',' || r == ' '
+		})
+	}
+	return clientScopes
+}
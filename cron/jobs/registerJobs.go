@@ -1,27 +1,43 @@
 package jobs
 
 import (
-	"bytes"
+	"dbms/channel"
+	"dbms/clone"
+	"dbms/email"
+	"dbms/outbox"
+	"dbms/preference"
+	"dbms/reminderlease"
+	"dbms/repository"
+	"dbms/webhook"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/robfig/cron/v3"
-	"github.com/timewise-team/timewise-models/dtos/core_dtos/schedule_participant_dtos"
 	"github.com/timewise-team/timewise-models/models"
-	"gopkg.in/gomail.v2"
-	"io/ioutil"
-	"net/http"
+	"gorm.io/gorm"
+	"sort"
 	"time"
 )
 
-// RegisterJobs registers all cron jobs
-func RegisterJobs() {
+// RegisterJobs registers all cron jobs. They read and write through db, the
+// same database the DMS serves, send emails through sender and deliver
+// notifications on channels, keyed by channel name.
+func RegisterJobs(db *gorm.DB, sender email.EmailSender, channels map[string]channel.NotificationChannel, reminderPolicy reminderlease.Policy) {
 	c := cron.New()
+	worker := reminderlease.WorkerId()
 
 	// Add a sample job
 	_, err := c.AddFunc("@every 1m", func() {
-		checkReminder()
-		sendNotification()
+		checkReminder(db, sender, worker, reminderPolicy)
+		sendNotification(db, channels)
+	})
+	if err != nil {
+		fmt.Println("Error adding cron job:", err)
+		return
+	}
+
+	_, err = c.AddFunc("@every 1m", func() {
+		dispatchWebhooks(db)
 	})
 	if err != nil {
 		fmt.Println("Error adding cron job:", err)
@@ -29,7 +45,9 @@ func RegisterJobs() {
 	}
 
 	_, err = c.AddFunc("@every 10m", func() {
-		clearExpiredLinkEmailRequests()
+		clearExpiredLinkEmailRequests(db)
+		sendDigests(db, sender)
+		failStaleClones(db)
 	})
 
 	if err != nil {
@@ -43,421 +61,351 @@ func RegisterJobs() {
 	// Keep the program running
 	select {}
 }
-func createMessage(reminder models.TwReminder) string {
-	// Kiểm tra xem StartTime và EndTime có nil không trước khi format
-	var startTime, endTime string
-	if reminder.Schedule.StartTime != nil {
-		startTime = reminder.Schedule.StartTime.Format("02/01/2006 15:04")
-	} else {
-		startTime = "N/A" // Hoặc có thể để là một giá trị mặc định khác
+
+// sendReminderEmail sends the reminder to one recipient in their locale and
+// timezone.
+func sendReminderEmail(sender email.EmailSender, to string, locale string, loc *time.Location, reminder models.TwReminder) error {
+	if reminder.Schedule.AllDay {
+		// All-day times are stored as floating dates, which are not shifted.
+		loc = time.UTC
 	}
-	if reminder.Schedule.EndTime != nil {
-		endTime = reminder.Schedule.EndTime.Format("02/01/2006 15:04")
-	} else {
-		endTime = "N/A" // Hoặc có thể để là một giá trị mặc định khác
+	message, err := email.Render(email.TemplateReminder, locale, email.ReminderData{
+		Recipient:            to,
+		WorkspaceTitle:       reminder.WorkspaceUser.Workspace.Title,
+		WorkspaceDescription: reminder.WorkspaceUser.Workspace.Description,
+		ScheduleTitle:        reminder.Schedule.Title,
+		ScheduleDescription:  reminder.Schedule.Description,
+		StartTime:            reminder.Schedule.StartTime,
+		EndTime:              reminder.Schedule.EndTime,
+		Location:             loc,
+	})
+	if err != nil {
+		return err
 	}
-
-	return fmt.Sprintf(`
-    <!DOCTYPE html>
-    <html lang="en">
-    <head>
-        <meta charset="UTF-8">
-        <meta name="viewport" content="width=device-width, initial-scale=1.0">
-        <title>Reminder Email</title>
-        <style>
-            body {
-                font-family: Arial, sans-serif;
-                background-color: #f4f4f9;
-                margin: 0;
-                padding: 0;
-                color: #333;
-            }
-
-            .email-container {
-                max-width: 600px;
-                margin: 0 auto;
-                padding: 20px;
-                background-color: #ffffff;
-                border-radius: 8px;
-                box-shadow: 0 2px 10px rgba(0, 0, 0, 0.1);
-            }
-
-            .email-header {
-                font-size: 24px;
-                font-weight: bold;
-                color: #4CAF50;
-                margin-bottom: 20px;
-                text-align: center;
-            }
-
-            .email-body {
-                font-size: 16px;
-                line-height: 1.5;
-                margin-bottom: 30px;
-            }
-
-            .email-footer {
-                font-size: 14px;
-                color: #777;
-                text-align: center;
-            }
-
-            .message-text {
-                color: #333;
-                font-size: 16px;
-                margin: 20px 0;
-                padding: 10px;
-                background-color: #f9f9f9;
-                border-left: 5px solid #4CAF50;
-            }
-
-            .highlight {
-                color: #4CAF50;
-                font-weight: bold;
-            }
-        </style>
-    </head>
-    <body>
-        <div class="email-container">
-            <div class="email-header">
-                Reminder Notification
-            </div>
-            <div class="email-body">
-                <p>Hello <span class="highlight">%s</span>,</p>
-                <p>This is a reminder for you:</p>
-                <div class="message-text">
-                    <p><strong>Workspace:</strong> %s</p>
-                    <p><strong>Workspace Description:</strong> %s</p>
-                    <p><strong>Schedule:</strong> %s</p>
-                    <p><strong>Schedule Description:</strong> %s</p>
-                    <p><strong>Start Time:</strong> %s</p>
-                    <p><strong>End Time:</strong> %s</p>
-                </div>
-            </div>
-            <div class="email-footer">
-                <p>Thank you for using our service!</p>
-            </div>
-        </div>
-    </body>
-    </html>
-    `, reminder.WorkspaceUser.UserEmail.Email,
-		reminder.WorkspaceUser.Workspace.Title,
-		reminder.WorkspaceUser.Workspace.Description,
-		reminder.Schedule.Title,
-		reminder.Schedule.Description,
-		startTime,
-		endTime)
+	message.To = to
+	return sender.Send(message)
 }
 
-func checkReminder() {
+// checkReminder sends the reminders that are due. Reminders are claimed with
+// a lease first, so several workers can run at once without sending one twice.
+func checkReminder(db *gorm.DB, sender email.EmailSender, worker string, policy reminderlease.Policy) {
 	fmt.Println("Starting cron job: checkReminder at", time.Now())
 
-	reminders, err := GetReminders()
+	now := time.Now()
+	expired, err := reminderlease.Expire(db, policy, now)
 	if err != nil {
-		fmt.Println("Error getting reminders:", err)
+		fmt.Println("Error expiring reminders:", err)
 		return
 	}
-	now := time.Now()
-
-	nowFormatted := now.Format("2006-01-02 15:04:05")
-	now, _ = time.Parse("2006-01-02 15:04:05", nowFormatted)
-	fmt.Printf("Now: %s", now)
-	// Lấy thời gian trước đó 2 phút không có múi giờ
-	twoMinutesAgo := now.Add(-2 * time.Minute)
-	fmt.Printf("Two minutes ago: %s", twoMinutesAgo)
-	for _, reminder := range reminders {
-		reminderTimeFormatted := reminder.ReminderTime.Format("2006-01-02 15:04:05")
-		reminderTime, _ := time.Parse("2006-01-02 15:04:05", reminderTimeFormatted)
-		fmt.Println("Reminder time: %s", reminderTime)
-		fmt.Println("Reminder time before now: %t", reminderTime.Before(now))
-		fmt.Println("Reminder time after two minutes ago: %t", reminderTime.After(twoMinutesAgo))
-		fmt.Println("Reminder is sent: %t", reminder.IsSent)
-		fmt.Println(reminder.ReminderTime)
-		if reminderTime.After(twoMinutesAgo) && reminderTime.Before(now) && !reminder.IsSent {
-			if reminder.Type == "only me" {
-				// Send reminder
-				fmt.Printf("Sending reminder ID %d to email %d", reminder.ID, reminder.WorkspaceUser.UserEmail.Email)
-				// Update reminder to sent
-				err := updateReminderToSent(reminder.ID)
-				if err != nil {
-					fmt.Println("Error updating reminder to sent:", err)
-					continue
-				}
-				// Create message
-				message := createMessage(reminder)
-				// Send email
-				err = SendEmail(reminder.WorkspaceUser.UserEmail.Email, "Reminder", message)
-				if err != nil {
-					fmt.Println("Error sending email:", err)
-					continue
-				}
-				notificationMessage := fmt.Sprintf("Schedule %s is about to start at %s on %s", reminder.Schedule.Title, reminder.Schedule.StartTime.Format("15:04"), reminder.Schedule.StartTime.Format("02/01/2006"))
-				notification := models.TwNotifications{
-					UserEmailId:     reminder.WorkspaceUser.UserEmail.ID,
-					Type:            "reminder",
-					Message:         notificationMessage,
-					IsRead:          false,
-					RelatedItemId:   reminder.Schedule.ID,
-					RelatedItemType: "schedule",
-					ExtraData:       "",
-					IsSent:          false,
-					NotifiedAt:      &now,
-				}
-				PushNotification(notification)
-
-			} else {
-				// Send reminder
-				fmt.Printf("Sending reminder ID %d to all participants of schedule ID %d", reminder.ID, reminder.Schedule.ID)
-				// Update reminder to sent
-				err := updateReminderToSent(reminder.ID)
-				if err != nil {
-					fmt.Println("Error updating reminder to sent:", err)
-					continue
-				}
-				// Create message
-				message := createMessage(reminder)
-				participants, err := GetParticipantsByScheduleId(reminder.Schedule.ID)
-				if err != nil {
-					fmt.Println("Error getting participants:", err)
-					continue
-				}
-				fmt.Println("Participants:", participants)
-				// Send email
-				for _, participant := range participants {
-					err = SendEmail(participant.Email, "Reminder", message)
-					if err != nil {
-						fmt.Println("Error sending email:", err)
-						continue
-					}
-					notificationMessage := fmt.Sprintf("Schedule %s is about to start at %s on %s", reminder.Schedule.Title, reminder.Schedule.StartTime.Format("15:04"), reminder.Schedule.StartTime.Format("02/01/2006"))
-					notification := models.TwNotifications{
-						UserEmailId:     participant.UserId,
-						Type:            "reminder",
-						Message:         notificationMessage,
-						IsRead:          false,
-						RelatedItemId:   reminder.Schedule.ID,
-						RelatedItemType: "schedule",
-						ExtraData:       "",
-						IsSent:          false,
-						NotifiedAt:      &now,
-					}
-					PushNotification(notification)
-				}
-			}
+	if expired > 0 {
+		fmt.Printf("Expired %d reminders more than %s late\n", expired, policy.CatchUp)
+	}
 
+	for {
+		reminders, lease, err := reminderlease.Claim(db, worker, policy, now)
+		if err != nil {
+			fmt.Println("Error claiming reminders:", err)
+			return
+		}
+		if len(reminders) == 0 {
+			return
+		}
+		for _, reminder := range reminders {
+			dispatchReminder(db, sender, policy, lease, reminder, now)
 		}
 	}
 }
 
-func GetParticipantsByScheduleId(id int) ([]schedule_participant_dtos.ScheduleParticipantInfo, error) {
-	resp, err := http.Get(fmt.Sprintf("https://dms.timewise.space/dbms/v1/schedule_participant/schedule/%d", id))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	var participants []schedule_participant_dtos.ScheduleParticipantInfo
-	if err := json.Unmarshal(body, &participants); err != nil {
-		return nil, err
-	}
-
-	return participants, nil
-
-}
+// dispatchReminder sends a claimed reminder and records the outcome. The
+// in-app notifications are stored with the outcome, so a reminder whose lease
+// was lost does not notify twice.
+func dispatchReminder(db *gorm.DB, sender email.EmailSender, policy reminderlease.Policy, lease string, reminder models.TwReminder, now time.Time) {
+	fmt.Printf("Sending reminder ID %d of schedule ID %d\n", reminder.ID, reminder.Schedule.ID)
 
-func PushNotification(notifications models.TwNotifications) {
-	url := "https://dms.timewise.space/dbms/v1/notification"
-	jsonData, err := json.Marshal(notifications)
+	notifications, err := sendReminder(db, sender, reminder, now)
 	if err != nil {
-		fmt.Println("Error marshaling JSON:", err)
+		fmt.Println("Error sending reminder:", err)
+		if err := reminderlease.Release(db, reminder.ID, lease, policy, err); err != nil {
+			fmt.Println("Error releasing reminder:", err)
+		}
 		return
 	}
 
-	// Create a new request with the JSON payload
-	req, err := http.NewRequest(http.MethodPost, url, bytes.NewBuffer(jsonData))
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := reminderlease.Complete(tx, reminder.ID, lease); err != nil {
+			return err
+		}
+		for i := range notifications {
+			if err := repository.CreateNotification(tx, &notifications[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
-		fmt.Println("Error creating request:", err)
-		return
+		fmt.Println("Error updating reminder to sent:", err)
 	}
-	req.Header.Set("accept", "application/json")
-	req.Header.Set("Content-Type", "application/json")
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// sendReminder emails the reminder to its owner, or to every participant of
+// the schedule, and returns the notifications to store for them. Recipients
+// who turned reminder emails off only get the notification. Quiet hours do
+// not hold back reminders, which were asked for at their time. Each email
+// sent is recorded, so that when some fail, the retry only goes to the
+// recipients who did not get one.
+func sendReminder(db *gorm.DB, sender email.EmailSender, reminder models.TwReminder, now time.Time) ([]models.TwNotifications, error) {
+	sent, err := reminderlease.Sent(db, reminder.ID)
 	if err != nil {
-		fmt.Println("Error sending request:", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		fmt.Println("Failed to push notification:", resp.StatusCode)
+	send := func(userEmailId int, to string, locale string, loc *time.Location) error {
+		if sent[userEmailId] {
+			return nil
+		}
+		if err := sendReminderEmail(sender, to, locale, loc, reminder); err != nil {
+			return fmt.Errorf("%s: %w", to, err)
+		}
+		return reminderlease.RecordSent(db, reminder.ID, userEmailId)
 	}
 
-	fmt.Println("Notification pushed successfully")
-}
-func updateReminderToSent(reminderID int) error {
-	url := fmt.Sprintf("https://dms.timewise.space/dbms/v1/reminder/%d/is_sent", reminderID)
-	req, err := http.NewRequest(http.MethodPut, url, nil)
-	if err != nil {
-		return err
+	if reminder.Type == "only me" {
+		userEmail := reminder.WorkspaceUser.UserEmail
+		preferences, err := preference.Load(db, userEmail.UserId)
+		if err != nil {
+			return nil, err
+		}
+		if preferences.Allows(preference.ChannelEmail, preference.TypeReminder) {
+			if err := send(userEmail.ID, userEmail.Email, userEmail.User.Locale, preferences.Location); err != nil {
+				return nil, err
+			}
+		}
+		return []models.TwNotifications{reminderNotification(reminder, userEmail.ID, preferences.Location, now)}, nil
 	}
-	req.Header.Set("accept", "application/json")
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	participants, err := repository.FindScheduleParticipants(db, reminder.Schedule.ID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to update reminder: status code %d", resp.StatusCode)
+	userEmailIds := make([]int, 0, len(participants))
+	for _, participant := range participants {
+		userEmailIds = append(userEmailIds, participant.UserId)
 	}
-
-	return nil
-}
-
-func GetReminders() ([]models.TwReminder, error) {
-	//resp, err := http.Get("https://dms.timewise.space/dbms/v1/reminder")
-	resp, err := http.Get("https://dms.timewise.space/dbms/v1/reminder")
-
+	locales, err := repository.FindLocales(db, userEmailIds)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	notifications := make([]models.TwNotifications, 0, len(participants))
+	var failed []error
+	for _, participant := range participants {
+		preferences, err := preference.LoadForUserEmail(db, participant.UserId)
+		if err != nil {
+			return nil, err
+		}
+		if preferences.Allows(preference.ChannelEmail, preference.TypeReminder) {
+			// Go on with the others; the reminder is retried for this one.
+			if err := send(participant.UserId, participant.Email, locales[participant.UserId], preferences.Location); err != nil {
+				failed = append(failed, err)
+			}
+		}
+		notifications = append(notifications, reminderNotification(reminder, participant.UserId, preferences.Location, now))
 	}
-
-	var reminders []models.TwReminder
-	if err := json.Unmarshal(body, &reminders); err != nil {
-		return nil, err
+	if len(failed) > 0 {
+		return nil, errors.Join(failed...)
 	}
+	return notifications, nil
+}
 
-	return reminders, nil
-
+// reminderNotification is the in-app notification of a reminder, with the
+// start time in the recipient's timezone loc.
+func reminderNotification(reminder models.TwReminder, userEmailId int, loc *time.Location, now time.Time) models.TwNotifications {
+	message := fmt.Sprintf("Schedule %s is about to start", reminder.Schedule.Title)
+	if reminder.Schedule.StartTime != nil && reminder.Schedule.AllDay {
+		message = fmt.Sprintf("Schedule %s is on %s", reminder.Schedule.Title, reminder.Schedule.StartTime.Format("02/01/2006"))
+	} else if reminder.Schedule.StartTime != nil {
+		start := reminder.Schedule.StartTime.In(loc)
+		message = fmt.Sprintf("Schedule %s is about to start at %s on %s", reminder.Schedule.Title, start.Format("15:04 MST"), start.Format("02/01/2006"))
+	}
+	return models.TwNotifications{
+		UserEmailId:     userEmailId,
+		Type:            preference.TypeReminder,
+		Message:         message,
+		IsRead:          false,
+		RelatedItemId:   reminder.Schedule.ID,
+		RelatedItemType: "schedule",
+		ExtraData:       "",
+		IsSent:          false,
+		NotifiedAt:      &now,
+	}
 }
 
-func sendNotification() {
+// sendNotification sends the notifications that are due on every channel
+// through the outbox, which retries failures with backoff and dead-letters the
+// ones that keep failing.
+func sendNotification(db *gorm.DB, channels map[string]channel.NotificationChannel) {
 	fmt.Println("Starting cron job: sendNotification at", time.Now())
 
-	unsentNotifications, err := GetUnsentNotifications()
-	if err != nil {
-		fmt.Println("Error getting unsent notifications:", err)
-		return
+	names := make([]string, 0, len(channels))
+	for name := range channels {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	for _, notification := range unsentNotifications {
-		if notification.NotifiedAt != nil && notification.NotifiedAt.Before(time.Now()) {
-			// Send notification
-			fmt.Printf("Sending notification ID %d to email %d", notification.ID, notification.UserEmail.Email)
+	if _, err := outbox.Collect(db, names); err != nil {
+		fmt.Println("Error queueing notifications:", err)
+		return
+	}
+	for _, name := range names {
+		result, err := outbox.Dispatch(db, name, deliverOn(db, channels[name]))
+		if err != nil {
+			fmt.Printf("Error sending notifications on %s: %v\n", name, err)
+			continue
+		}
+		fmt.Printf("Notifications dispatched on %s: %+v\n", name, result)
+	}
+}
 
-			// Send email
-			err := SendEmail(notification.UserEmail.Email, "Notification", notification.Message)
-			if err != nil {
-				fmt.Println("Error sending email:", err)
-				continue
-			}
+// deliverOn sends notifications on one channel as their recipients' settings
+// allow: not at all, after their quiet hours, in their email digest, or now.
+func deliverOn(db *gorm.DB, notificationChannel channel.NotificationChannel) outbox.SendFunc {
+	name := notificationChannel.Name()
+	return func(notification models.TwNotifications) error {
+		preferences, err := preference.Load(db, notification.UserEmail.UserId)
+		if err != nil {
+			return err
+		}
+		if !preferences.Allows(name, notification.Type) {
+			return outbox.ErrSuppressed
+		}
+		if until, quiet := preferences.QuietUntil(time.Now()); quiet {
+			return outbox.DeferError{Until: until}
+		}
+		if name == channel.Email && preferences.Digests(notification.Type) {
+			return outbox.ErrHeld
+		}
 
-			// Update notification to sent
-			err = updateNotificationToSent(notification.ID)
-			if err != nil {
-				fmt.Println("Error updating notification to sent:", err)
-			}
+		var data json.RawMessage
+		if json.Valid([]byte(notification.ExtraData)) {
+			data = json.RawMessage(notification.ExtraData)
+		}
+		err = notificationChannel.Send(channel.Recipient{
+			Email:    notification.UserEmail.Email,
+			Locale:   notification.UserEmail.User.Locale,
+			Location: preferences.Location,
+			Target:   preferences.Target(name),
+		}, channel.Message{
+			Type:  notification.Type,
+			Title: notification.Title,
+			Body:  notification.Message,
+			Link:  notification.Link,
+			Data:  data,
+		})
+		if errors.Is(err, channel.ErrPermanent) {
+			return fmt.Errorf("%w: %w", outbox.ErrPermanent, err)
 		}
+		return err
 	}
 }
 
-func GetUnsentNotifications() ([]models.TwNotifications, error) {
-	resp, err := http.Get("https://dms.timewise.space/dbms/v1/notification")
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+// sendDigests emails each recipient the notifications held for their digest,
+// once the digest time in their timezone has passed.
+func sendDigests(db *gorm.DB, sender email.EmailSender) {
+	fmt.Println("Starting cron job: sendDigests at", time.Now())
 
-	body, err := ioutil.ReadAll(resp.Body)
+	held, err := outbox.Held(db, outbox.ChannelEmail)
 	if err != nil {
-		return nil, err
+		fmt.Println("Error getting held notifications:", err)
+		return
 	}
-
-	var notifications []models.TwNotifications
-	if err := json.Unmarshal(body, &notifications); err != nil {
-		return nil, err
+	byRecipient := make(map[int][]outbox.Delivery)
+	var recipients []int
+	for _, delivery := range held {
+		if delivery.Notification == nil {
+			continue
+		}
+		userEmailId := delivery.Notification.UserEmailId
+		if _, ok := byRecipient[userEmailId]; !ok {
+			recipients = append(recipients, userEmailId)
+		}
+		byRecipient[userEmailId] = append(byRecipient[userEmailId], delivery)
 	}
 
-	return notifications, nil
-}
-
-func updateNotificationToSent(notificationID int) error {
-	url := fmt.Sprintf("https://dms.timewise.space/dbms/v1/notification/%d", notificationID)
-	req, err := http.NewRequest(http.MethodPut, url, nil)
-	if err != nil {
-		return err
-	}
-	req.Header.Set("accept", "application/json")
+	now := time.Now()
+	for _, userEmailId := range recipients {
+		deliveries := byRecipient[userEmailId]
+		userEmail := deliveries[0].Notification.UserEmail
+		preferences, err := preference.Load(db, userEmail.UserId)
+		if err != nil {
+			fmt.Println("Error getting notification settings:", err)
+			continue
+		}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+		slot := preferences.DigestSlot(now)
+		var deliveryIds []int
+		data := email.DigestData{Recipient: userEmail.Email}
+		for _, delivery := range deliveries {
+			if delivery.LastAttemptAt == nil || !delivery.LastAttemptAt.Before(slot) {
+				continue
+			}
+			deliveryIds = append(deliveryIds, delivery.ID)
+			data.Notifications = append(data.Notifications, email.NotificationData{
+				Title:   delivery.Notification.Title,
+				Message: delivery.Notification.Message,
+				Link:    delivery.Notification.Link,
+			})
+		}
+		if len(deliveryIds) == 0 {
+			continue
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to update notification: status code %d", resp.StatusCode)
+		message, err := email.Render(email.TemplateDigest, userEmail.User.Locale, data)
+		if err != nil {
+			fmt.Println("Error rendering digest:", err)
+			continue
+		}
+		message.To = userEmail.Email
+		sent, err := outbox.SendHeld(db, deliveryIds, func() error {
+			return sender.Send(message)
+		})
+		if err != nil {
+			fmt.Println("Error sending digest:", err)
+			continue
+		}
+		if sent {
+			fmt.Printf("Sent a digest of %d notifications to %s\n", len(deliveryIds), userEmail.Email)
+		}
 	}
-
-	return nil
 }
 
-func SendEmail(to string, subject string, body string) error {
-	dialer := ConfigSMTP()
-	if dialer == nil {
-		return errors.New("failed to configure SMTP dialer")
-	}
-	m := gomail.NewMessage()
-	m.SetHeader("From", dialer.Username)
-	m.SetHeader("To", to)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", body)
+func clearExpiredLinkEmailRequests(db *gorm.DB) {
+	fmt.Println("Starting cron job: clearExpiredLinkEmailRequests at", time.Now())
 
-	if err := dialer.DialAndSend(m); err != nil {
-		return err
+	if err := repository.ClearExpiredUserEmails(db); err != nil {
+		fmt.Println("Error clearing expired link email requests:", err)
+		return
 	}
-
-	return nil
-}
-func ConfigSMTP() *gomail.Dialer {
-	SmtpHost := "smtp.gmail.com"
-	SmtpPort := 587
-	SmtpEmail := "timewise.space@gmail.com"
-	SmtpPassword := "dczt wlvd eisn cixf"
-	return gomail.NewDialer(SmtpHost, SmtpPort, SmtpEmail, SmtpPassword)
 }
 
-func clearExpiredLinkEmailRequests() {
-	fmt.Println("Starting cron job: clearExpiredLinkEmailRequests at", time.Now())
+// failStaleClones fails the workspace clones whose server stopped before
+// they finished.
+func failStaleClones(db *gorm.DB) {
+	fmt.Println("Starting cron job: failStaleClones at", time.Now())
 
-	err := DeleteLinkEmailRequest()
-	if err != nil {
-		fmt.Println("Error getting expired link email requests:", err)
+	if err := clone.FailStale(db, time.Now()); err != nil {
+		fmt.Println("Error failing stale workspace clones:", err)
 		return
 	}
 }
 
-func DeleteLinkEmailRequest() error {
-	resp, err := http.Get("https://dms.timewise.space/dbms/v1/user_email/clear-expired")
+// dispatchWebhooks sends the webhook deliveries that are due, which retries
+// failed ones with backoff.
+func dispatchWebhooks(db *gorm.DB) {
+	fmt.Println("Starting cron job: dispatchWebhooks at", time.Now())
+
+	result, err := webhook.Dispatch(db, webhook.DefaultClient)
 	if err != nil {
-		return err
+		fmt.Println("Error dispatching webhooks:", err)
+		return
 	}
-	defer resp.Body.Close()
-
-	return nil
+	fmt.Printf("Webhooks dispatched: %+v\n", result)
 }
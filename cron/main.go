@@ -1,9 +1,42 @@
 package main
 
 import (
+	"dbms/channel"
+	"dbms/config"
 	"dbms/cron/jobs"
+	"dbms/database"
+	"dbms/email"
+	"dbms/reminderlease"
+	"log"
 )
 
 func main() {
-	jobs.RegisterJobs()
+	// The worker reads the same .env as the server and connects to its
+	// database, so pointing DB.* at a local MySQL keeps it off production.
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Could not load config: %v", err)
+	}
+
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		log.Fatalf("Could not initialize database: %v", err)
+	}
+
+	sender, err := email.NewSender(cfg)
+	if err != nil {
+		log.Fatalf("Could not configure email: %v", err)
+	}
+
+	channels, err := channel.FromConfig(cfg, sender)
+	if err != nil {
+		log.Fatalf("Could not configure notification channels: %v", err)
+	}
+
+	jobs.RegisterJobs(db, sender, channels, reminderlease.Policy{
+		CatchUp:     cfg.ReminderCatchUp,
+		Lease:       cfg.ReminderLease,
+		MaxAttempts: cfg.ReminderMaxAttempts,
+		RetryDelay:  cfg.ReminderRetryDelay,
+	})
 }
@@ -0,0 +1,116 @@
+package datetime
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DateLayout is the layout of dates, such as those of all-day schedules.
+const DateLayout = "2006-01-02"
+
+// ErrInvalid is returned for a time, date or clock time that cannot be read.
+var ErrInvalid = errors.New("invalid time")
+
+// Parse reads an RFC 3339 time, e.g. 2024-05-01T09:30:00+07:00 or
+// 2024-05-01T02:30:00.000Z, and returns it in UTC. Times without an offset are
+// rejected, as they do not say which instant they mean.
+func Parse(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q is not an RFC 3339 time with an offset, e.g. 2024-05-01T09:30:00+07:00", ErrInvalid, value)
+	}
+	return t.UTC(), nil
+}
+
+// ParseDate reads a date, e.g. 2024-05-01, and returns it floating. An RFC
+// 3339 time is taken as the date it falls on in its own offset.
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if date, err := time.Parse(DateLayout, value); err == nil {
+		return date, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q is not a date, e.g. 2024-05-01", ErrInvalid, value)
+	}
+	return Floating(t), nil
+}
+
+// Floating returns the date of t, in t's location, as midnight UTC.
+//
+// All-day schedules store their dates floating, so that they fall on the same
+// date in every timezone rather than on the instant midnight was somewhere.
+// Like iCalendar's DTEND, their end is the date after their last day.
+func Floating(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// FloatingEnd returns the floating date that ends an all-day schedule
+// ending at t: the date of t when t is midnight, or the date after.
+func FloatingEnd(t time.Time) time.Time {
+	end := Floating(t)
+	if t.Hour() != 0 || t.Minute() != 0 || t.Second() != 0 || t.Nanosecond() != 0 {
+		end = end.AddDate(0, 0, 1)
+	}
+	return end
+}
+
+// Location returns the IANA timezone called name, or UTC when name is empty
+// or unknown.
+func Location(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// StartOfDay returns midnight of the day t falls on in loc.
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	local := t.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+}
+
+// ParseWindow reads the from and to of a [from, to) time window, which must
+// be at most max long, and returns them in UTC.
+func ParseWindow(fromValue, toValue string, max time.Duration) (time.Time, time.Time, error) {
+	if fromValue == "" || toValue == "" {
+		return time.Time{}, time.Time{}, errors.New("from and to are required")
+	}
+	from, err := Parse(fromValue)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("Invalid from, expected RFC 3339")
+	}
+	to, err := Parse(toValue)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("Invalid to, expected RFC 3339")
+	}
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, errors.New("to must be after from")
+	}
+	if to.Sub(from) > max {
+		return time.Time{}, time.Time{}, fmt.Errorf("time window must not exceed %d days", max/(24*time.Hour))
+	}
+	return from, to, nil
+}
+
+// ParseClock reads a "15:04" clock time as the time since midnight.
+func ParseClock(value string) (time.Duration, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not a HH:MM clock time", ErrInvalid, value)
+	}
+	return time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute, nil
+}
+
+// AtClock returns the wall clock time since midnight on the day of midnight,
+// which is not midnight plus the duration on days with a DST change.
+func AtClock(midnight time.Time, clock time.Duration) time.Time {
+	return time.Date(midnight.Year(), midnight.Month(), midnight.Day(),
+		int(clock/time.Hour), int(clock%time.Hour/time.Minute), 0, 0, midnight.Location())
+}
@@ -15,6 +15,103 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/dbms/v1/availability/free_busy": {
+            "get": {
+                "description": "Get when workspace users or people are busy within a time window. Busy time comes from the schedules they attend (participants who were removed or declined are free), with recurrences expanded, exceptions applied and all-day schedules taking up their whole dates in UTC. An email stands for its person: the workspaces of every email linked to the same user count. Schedule details are not returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "availability"
+                ],
+                "summary": "Get free/busy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated workspace user IDs",
+                        "name": "workspace_user_ids",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated emails",
+                        "name": "emails",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Window start (RFC 3339)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Window end (RFC 3339)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/availability.FreeBusyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid time window, workspace user or email",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/availability/meeting_times": {
+            "post": {
+                "description": "Suggest the best slots for a meeting of the given duration. Every required attendee is free in every slot. Slots are scored by the share of optional attendees who are free and by how well the slot fits in each attendee's working hours, in the timezone of their user. Busy time is the same as in free/busy. Slots do not overlap one another.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "availability"
+                ],
+                "summary": "Find meeting times",
+                "parameters": [
+                    {
+                        "description": "Attendees, duration and search window",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/availability.MeetingTimesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/availability.MeetingTimesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid attendee, duration, window or working hours",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
         "/dbms/v1/board_columns": {
             "post": {
                 "description": "Create board column",
@@ -119,7 +216,7 @@ const docTemplate = `{
         },
         "/dbms/v1/board_columns/update_position_after_deletion": {
             "put": {
-                "description": "Update position after deletion",
+                "description": "Kept for compatibility: positions now follow rank keys, so deletions leave no gaps and nothing is shifted",
                 "consumes": [
                     "application/json"
                 ],
@@ -219,7 +316,7 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Delete board column",
+                "description": "Delete board column. The acting workspace user is the caller's membership of the column's workspace, found through the user_id claim of its token. Requires the dbms.admin scope.",
                 "consumes": [
                     "application/json"
                 ],
@@ -242,6 +339,12 @@ const docTemplate = `{
                 "responses": {
                     "204": {
                         "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Permission denied or the token names no user",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
                     }
                 }
             }
@@ -331,9 +434,149 @@ const docTemplate = `{
                 }
             }
         },
+        "/dbms/v1/calendar/board_column/{board_column_id}/import": {
+            "post": {
+                "description": "Create schedules in the board column from the VEVENTs of an uploaded .ics file. RRULE, EXDATE and RECURRENCE-ID are turned into recurrence patterns and recurrence exceptions; recurring events keep the timezone of their TZID, given as an IANA or Windows name or through a VTIMEZONE. Events in a timezone that cannot be resolved are reported under errors and skipped. Events whose UID was already imported into the workspace are skipped. With dry_run=true nothing is written and the planned import, duplicates and time conflicts are reported.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Import an iCalendar file into a board column",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Board column ID",
+                        "name": "board_column_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Workspace user ID of the importer",
+                        "name": "workspace_user_id",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "iCalendar file",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only report what would be imported",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dry run report",
+                        "schema": {
+                            "$ref": "#/definitions/calendar.ImportCalendarResponse"
+                        }
+                    },
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/calendar.ImportCalendarResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or calendar file",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Board column not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/calendar/email/{email}/feed.ics": {
+            "get": {
+                "description": "Export every schedule the email participates in, across workspaces, as an iCalendar (.ics) feed",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Get user iCalendar feed",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "iCalendar feed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid email",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/calendar/workspace/{workspace_id}/feed.ics": {
+            "get": {
+                "description": "Export every schedule of the workspace as an iCalendar (.ics) feed",
+                "produces": [
+                    "text/calendar"
+                ],
+                "tags": [
+                    "calendar"
+                ],
+                "summary": "Get workspace iCalendar feed",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "iCalendar feed",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Workspace not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/dbms/v1/comment/schedule/{schedule_id}": {
             "get": {
-                "description": "Get comments by schedule",
+                "description": "Get comments by schedule. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, workspace_user_id, commenter, content, created_at and updated_at.",
                 "consumes": [
                     "application/json"
                 ],
@@ -351,6 +594,12 @@ const docTemplate = `{
                         "name": "schedule_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to sort by, each prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -362,13 +611,19 @@ const docTemplate = `{
                                 "$ref": "#/definitions/models.TwComment"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Invalid filter or sort",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
         "/dbms/v1/comment/schedule_id/{schedule_id}": {
             "get": {
-                "description": "Get comments by schedule",
+                "description": "Get the comments of a schedule with their authors. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, workspace_user_id, commenter, content, created_at and updated_at.",
                 "consumes": [
                     "application/json"
                 ],
@@ -386,6 +641,12 @@ const docTemplate = `{
                         "name": "schedule_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to sort by, each prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -397,6 +658,12 @@ const docTemplate = `{
                                 "$ref": "#/definitions/models.TwComment"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Invalid schedule ID, filter or sort",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
                     }
                 }
             }
@@ -575,7 +842,7 @@ const docTemplate = `{
         },
         "/dbms/v1/notification": {
             "get": {
-                "description": "Get unsent notifications",
+                "description": "Get a page of the unsent notifications. The X-Total-Count header holds the number of unsent notifications and X-Next-Cursor the cursor of the next page, if any.",
                 "consumes": [
                     "application/json"
                 ],
@@ -586,6 +853,26 @@ const docTemplate = `{
                     "notification"
                 ],
                 "summary": "Get unsent notifications",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of notifications (default 50, max 200); the whole list is returned when neither limit nor cursor is given",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by id, created_at or notified_at; prefix with - for descending order (default id)",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -594,12 +881,28 @@ const docTemplate = `{
                             "items": {
                                 "$ref": "#/definitions/models.TwNotifications"
                             }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of unsent notifications"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sort or cursor",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Create a new notification",
+                "description": "Create a new notification. Nothing is stored when the recipient turned its type off on every channel.",
                 "consumes": [
                     "application/json"
                 ],
@@ -631,48 +934,126 @@ const docTemplate = `{
                 }
             }
         },
-        "/dbms/v1/notification/update-status/read": {
-            "put": {
-                "description": "Update notification status",
-                "consumes": [
-                    "application/json"
-                ],
+        "/dbms/v1/notification/deliveries": {
+            "get": {
+                "description": "Get the outbox deliveries of notifications, newest first, with their attempts and last error. Dead deliveries are the ones that ran out of attempts. Requires the dbms.admin scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "notification"
                 ],
-                "summary": "Update notification status",
+                "summary": "Get notification deliveries",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Notification ID",
-                        "name": "notification_id",
-                        "in": "query",
-                        "required": true
+                        "description": "Filter by status (pending, sent, held, suppressed, dead)",
+                        "name": "status",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Is read",
-                        "name": "is_read",
-                        "in": "query",
-                        "required": true
+                        "description": "Filter by channel (email, web_push, slack, sms)",
+                        "name": "channel",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of deliveries (default 50, max 200); the whole list is returned when neither limit nor cursor is given",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.TwNotifications"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/outbox.Delivery"
+                            }
                         }
                     }
                 }
             }
         },
-        "/dbms/v1/notification/user-email-ids": {
-            "post": {
-                "description": "Get notifications by user email ids",
+        "/dbms/v1/notification/deliveries/{delivery_id}/requeue": {
+            "put": {
+                "description": "Send a dead notification delivery again with a fresh attempt count, e.g. after fixing the recipient's address. Requires the dbms.admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notification"
+                ],
+                "summary": "Requeue a notification delivery",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Delivery ID",
+                        "name": "delivery_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/outbox.Delivery"
+                        }
+                    },
+                    "404": {
+                        "description": "No dead delivery with this ID",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/notification/update-status/read": {
+            "put": {
+                "description": "Update notification status",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notification"
+                ],
+                "summary": "Update notification status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Notification ID",
+                        "name": "notification_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Is read",
+                        "name": "is_read",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TwNotifications"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/notification/user-email-ids": {
+            "post": {
+                "description": "Get the in-app notifications of user emails, leaving out the types their users turned off for the app",
                 "consumes": [
                     "application/json"
                 ],
@@ -742,9 +1123,41 @@ const docTemplate = `{
                 }
             }
         },
+        "/dbms/v1/notification/{notification_id}/deliveries": {
+            "get": {
+                "description": "Get the delivery status of a notification on each channel it was queued for, with attempts and the last error",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notification"
+                ],
+                "summary": "Get the deliveries of a notification",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Notification ID",
+                        "name": "notification_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/outbox.Delivery"
+                            }
+                        }
+                    }
+                }
+            }
+        },
         "/dbms/v1/notification_setting": {
             "post": {
-                "description": "Create notification setting",
+                "description": "extra_data holds the options the flags cannot express, as JSON: {\"channels\": {\"email\": {\"comment\": false}, \"in_app\": {...}}, \"quiet_hours\": {\"start\": \"22:00\", \"end\": \"07:00\"}, \"digest\": {\"enabled\": true, \"hour\": 8}, \"targets\": {\"slack\": {\"webhook_url\": \"https://hooks.slack.com/...\"}, \"sms\": {\"phone\": \"+84...\"}, \"web_push\": {\"endpoint\": \"...\", \"keys\": {\"p256dh\": \"...\", \"auth\": \"...\"}}}}. The web_push, slack and sms channels only deliver to users with a target for them; Slack webhooks must be on https://hooks.slack.com/ and push endpoints https URLs on public addresses. Quiet hours and the digest hour are in the user's timezone. Reminders follow notification_on_due_date, comments notification_on_comment, assignments notification_on_tag, schedule changes notification_on_schedule_change, and every email notification_on_email.",
                 "consumes": [
                     "application/json"
                 ],
@@ -808,7 +1221,7 @@ const docTemplate = `{
                 }
             },
             "put": {
-                "description": "Update notification setting",
+                "description": "extra_data holds the options the flags cannot express, as JSON: {\"channels\": {\"email\": {\"comment\": false}, \"in_app\": {...}}, \"quiet_hours\": {\"start\": \"22:00\", \"end\": \"07:00\"}, \"digest\": {\"enabled\": true, \"hour\": 8}, \"targets\": {\"slack\": {\"webhook_url\": \"https://hooks.slack.com/...\"}, \"sms\": {\"phone\": \"+84...\"}, \"web_push\": {\"endpoint\": \"...\", \"keys\": {\"p256dh\": \"...\", \"auth\": \"...\"}}}}. The web_push, slack and sms channels only deliver to users with a target for them; Slack webhooks must be on https://hooks.slack.com/ and push endpoints https URLs on public addresses. Quiet hours and the digest hour are in the user's timezone. Reminders follow notification_on_due_date, comments notification_on_comment, assignments notification_on_tag, schedule changes notification_on_schedule_change, and every email notification_on_email.",
                 "consumes": [
                     "application/json"
                 ],
@@ -849,7 +1262,7 @@ const docTemplate = `{
         },
         "/dbms/v1/recurrence_exception": {
             "get": {
-                "description": "Get all recurrence exceptions",
+                "description": "Get a page of the recurrence exceptions. The X-Total-Count header holds the number of recurrence exceptions and X-Next-Cursor the cursor of the next page, if any.",
                 "consumes": [
                     "application/json"
                 ],
@@ -860,6 +1273,26 @@ const docTemplate = `{
                     "recurrence_exception"
                 ],
                 "summary": "Get all recurrence exceptions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of recurrence exceptions (default 50, max 200); the whole list is returned when neither limit nor cursor is given",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by id or exception_date; prefix with - for descending order (default id)",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -868,6 +1301,22 @@ const docTemplate = `{
                             "items": {
                                 "$ref": "#/definitions/core_dtos.TwRecurrenceExceptionResponseDTO"
                             }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of recurrence exceptions"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sort or cursor",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
@@ -1005,7 +1454,7 @@ const docTemplate = `{
         },
         "/dbms/v1/reminder": {
             "get": {
-                "description": "Get all reminders",
+                "description": "Get a page of the reminders. The X-Total-Count header holds the number of reminders and X-Next-Cursor the cursor of the next page, if any.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1016,6 +1465,26 @@ const docTemplate = `{
                     "reminder"
                 ],
                 "summary": "Get all reminders",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of reminders (default 50, max 200); the whole list is returned when neither limit nor cursor is given",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by id, reminder_time or created_at; prefix with - for descending order (default id)",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -1024,6 +1493,22 @@ const docTemplate = `{
                             "items": {
                                 "$ref": "#/definitions/models.TwReminder"
                             }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of reminders"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sort or cursor",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
@@ -1231,7 +1716,7 @@ const docTemplate = `{
         },
         "/dbms/v1/schedule": {
             "get": {
-                "description": "Get all schedules",
+                "description": "Get a page of the schedules. The X-Total-Count header holds the number of schedules and X-Next-Cursor the cursor of the next page, if any. Filter with field=value or field[op]=value query parameters as in /schedule/schedules/filter.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1242,6 +1727,26 @@ const docTemplate = `{
                     "schedule"
                 ],
                 "summary": "Get all schedules",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of schedules (default 50, max 200); the whole list is returned when neither limit nor cursor is given",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by id or one of the fields of /schedule/schedules/filter, prefixed with - for descending order (default id)",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -1250,12 +1755,28 @@ const docTemplate = `{
                             "items": {
                                 "$ref": "#/definitions/core_dtos.TwScheduleResponse"
                             }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of schedules"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sort or cursor",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Create a new schedule",
+                "description": "Create a new schedule. start_time and end_time are RFC 3339 times with an offset, e.g. 2024-05-01T09:30:00+07:00; they default to now and an hour from now. The creator, workspace_user_id, must be one of the caller's workspace users, found through the user_id claim of its token, and be allowed to create schedules.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1275,6 +1796,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/core_dtos.TwCreateScheduleRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "warn to also return the creator's overlapping schedules in any of their workspaces, reject to refuse with 409 when there are any",
+                        "name": "conflicts",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1283,13 +1810,90 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/core_dtos.TwCreateShecduleResponse"
                         }
+                    },
+                    "400": {
+                        "description": "Invalid start or end time",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "409": {
+                        "description": "The creator has overlapping schedules",
+                        "schema": {
+                            "$ref": "#/definitions/availability.ConflictResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/schedule/from_template/{template_id}": {
+            "post": {
+                "description": "Create a schedule in a board column from a schedule template of its workspace, like POST /schedule: the acting workspace user, found through the user_id claim of its token, is its creator and the creation is logged. The template's participants and the assignee are added and notified, its reminders are set relative to the start time, skipping those that would already be due, and its checklist goes into extra_data. {{date}} and {{time}} are the start in the creator's timezone, {{assignee}} the assignee's name and {{workspace}} the workspace title; other placeholders take the request's variables.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedule"
+                ],
+                "summary": "Create a schedule from a template",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "template_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Schedule",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schedule.FromTemplateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/schedule.FromTemplateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid start time, assignee or missing variables",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied or the token names no user",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Template or board column not found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
                     }
                 }
             }
         },
         "/dbms/v1/schedule/schedules/filter": {
             "get": {
-                "description": "Filter schedules",
+                "description": "Filter schedules with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, e.g. status[in]=todo,done\u0026start_time[gte]=2024-01-01T00:00:00Z\u0026title[contains]=sync. The fields are id, workspace_id, board_column_id, title, location, status, priority, visibility, all_day, is_deleted, created_by, start_time, end_time, created_at and updated_at. Without an operator, title and location are contains, start_time is gte, end_time is lte and the others are eq, or in for a comma separated list.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1302,8 +1906,8 @@ const docTemplate = `{
                 "summary": "Filter schedule",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Workspace ID",
+                        "type": "string",
+                        "description": "Workspace ID, or a comma separated list of them",
                         "name": "workspace_id",
                         "in": "query"
                     },
@@ -1321,13 +1925,13 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Start time of the schedule (ISO8601 format, filter by schedules starting after this date)",
+                        "description": "RFC 3339 time; schedules starting at or after it",
                         "name": "start_time",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "End time of the schedule (ISO8601 format, filter by schedules ending before this date)",
+                        "description": "RFC 3339 time; schedules ending at or before it",
                         "name": "end_time",
                         "in": "query"
                     },
@@ -1339,7 +1943,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "User ID of the creator",
+                        "description": "Workspace user ID of the creator",
                         "name": "created_by",
                         "in": "query"
                     },
@@ -1357,9 +1961,15 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "description": "User ID assigned to the schedule",
+                        "description": "Workspace user ID among the schedule's joined participants",
                         "name": "assigned_to",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to sort by, each prefixed with - for descending order, e.g. -start_time,title",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1387,9 +1997,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/dbms/v1/schedule/workspace/{workspace_id}/board_column/{board_column_id}/filter": {
+        "/dbms/v1/schedule/user/{user_id}/agenda": {
             "get": {
-                "description": "Get schedules by board column with filters",
+                "description": "Get what a user is doing within a time window, across every workspace they or their linked emails joined: the schedules they created or take part in, with recurrences expanded and exceptions applied. Times are rendered in the user's timezone and occurrences are grouped by day; all-day schedules keep their date. Without from and to, the window is the 7 days starting today.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1399,39 +2009,101 @@ const docTemplate = `{
                 "tags": [
                     "schedule"
                 ],
-                "summary": "Get schedules by board column with filters",
+                "summary": "Get user agenda",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspace_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Board Column ID",
-                        "name": "board_column_id",
+                        "description": "User ID",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Search by schedule title",
-                        "name": "search",
+                        "description": "Window start (RFC 3339)",
+                        "name": "from",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Filter by member emails",
-                        "name": "member",
+                        "description": "Window end (RFC 3339)",
+                        "name": "to",
                         "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/schedule.AgendaResponse"
+                        }
                     },
-                    {
-                        "type": "string",
-                        "description": "Filter by due date (day, week, month)",
-                        "name": "due",
-                        "in": "query"
+                    "400": {
+                        "description": "Invalid time window",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/schedule/workspace/{workspace_id}/board_column/{board_column_id}/filter": {
+            "get": {
+                "description": "Get schedules by board column with filters. Besides the board shortcuts below, filter with field=value or field[op]=value query parameters as in /schedule/schedules/filter.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedule"
+                ],
+                "summary": "Get schedules by board column with filters",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Board Column ID",
+                        "name": "board_column_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Search by schedule title",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by member emails",
+                        "name": "member",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Workspace user whose timezone today, this week and overdue are computed in (default: UTC)",
+                        "name": "X-Workspace-User-Id",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by start date: today (day), the 7 days (week) or the 30 days (month) from today",
+                        "name": "due",
+                        "in": "query"
                     },
                     {
                         "type": "string",
@@ -1441,7 +2113,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Filter by overdue",
+                        "description": "Filter by overdue: started before today and not done",
                         "name": "overdue",
                         "in": "query"
                     },
@@ -1450,6 +2122,12 @@ const docTemplate = `{
                         "description": "Filter by not due",
                         "name": "notDue",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to sort by, each prefixed with - for descending order (default: board order)",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1477,6 +2155,61 @@ const docTemplate = `{
                 }
             }
         },
+        "/dbms/v1/schedule/workspace/{workspace_id}/occurrences": {
+            "get": {
+                "description": "Expand every schedule of the workspace into concrete occurrences within a time window, applying recurrence exceptions. Each pattern follows the wall clock of its creator's timezone, or of the timezone the schedule was imported with; all-day schedules keep their dates.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedule"
+                ],
+                "summary": "Get schedule occurrences in a workspace",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Window start (RFC 3339)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Window end (RFC 3339)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/schedule.ScheduleOccurrenceResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid time window",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
         "/dbms/v1/schedule/{schedule_id}": {
             "get": {
                 "description": "Get schedule by ID",
@@ -1504,12 +2237,18 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/core_dtos.TwScheduleResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Version to send back in If-Match when updating"
+                            }
                         }
                     }
                 }
             },
             "put": {
-                "description": "Update an existing schedule",
+                "description": "Update an existing schedule. start_time and end_time are RFC 3339 times with an offset. All-day schedules take dates such as 2024-05-01 instead and are stored as floating dates, ending on the date after their last day; a schedule made all-day keeps the dates its times fall on for the editor. The editor, workspace_user_id in the path, must be one of the caller's workspace users, found through the user_id claim of its token.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1536,6 +2275,18 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/core_dtos.TwUpdateScheduleRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag from GetScheduleById; the update is refused if the schedule changed since",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "warn to also return the participants' overlapping schedules in any of their workspaces, reject to refuse with 409 when there are any",
+                        "name": "conflicts",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1543,12 +2294,42 @@ const docTemplate = `{
                         "description": "OK",
                         "schema": {
                             "$ref": "#/definitions/core_dtos.TwUpdateScheduleResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "New version of the schedule"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid start or end time",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "409": {
+                        "description": "Participants have overlapping schedules",
+                        "schema": {
+                            "$ref": "#/definitions/availability.ConflictResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "Schedule changed since the If-Match version",
+                        "schema": {
+                            "$ref": "#/definitions/schedule.PreconditionFailedResponse"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Delete a schedule",
+                "description": "Delete a schedule. The workspace_user_id in the path must be one of the caller's workspace users, found through the user_id claim of its token.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1571,6 +2352,132 @@ const docTemplate = `{
                 "responses": {
                     "204": {
                         "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Permission denied",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/schedule/{schedule_id}/clone": {
+            "post": {
+                "description": "Copy a schedule to the end of a board column, of its own or another workspace, with its participants, reminders, recurrence exceptions, document metadata and, with include_comments, comments. The acting workspace user, found through the user_id claim of its token and who must be allowed to create schedules where the copy goes, is its creator. In another workspace they must also be a member of the schedule's workspace, and participants and reminders are kept for the workspace users of the same email; documents and comments of others go to the acting user. Requires the dbms.admin scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedule"
+                ],
+                "summary": "Clone schedule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Schedule ID",
+                        "name": "schedule_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Where to copy the schedule",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/schedule.CloneRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/schedule.CloneResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Board column not in the workspace",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied or the token names no user",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule or board column not found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/schedule/{schedule_id}/occurrences": {
+            "get": {
+                "description": "Expand the schedule's recurrence pattern into concrete occurrences within a time window, applying recurrence exceptions. The pattern follows the wall clock of the creator's timezone, or of the timezone the schedule was imported with; all-day schedules keep their dates.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedule"
+                ],
+                "summary": "Get occurrences of a schedule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Schedule ID",
+                        "name": "schedule_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Window start (RFC 3339)",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Window end (RFC 3339)",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/schedule.ScheduleOccurrenceResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid time window or recurrence pattern",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
@@ -1620,7 +2527,7 @@ const docTemplate = `{
         },
         "/dbms/v1/schedule_log": {
             "get": {
-                "description": "Get all schedule logs",
+                "description": "Get a page of the schedule logs. The X-Total-Count header holds the number of schedule logs and X-Next-Cursor the cursor of the next page, if any. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, schedule_id, workspace_user_id, action, field_changed and created_at.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1631,6 +2538,26 @@ const docTemplate = `{
                     "schedule_log"
                 ],
                 "summary": "Get all schedule logs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of schedule logs (default 50, max 200); the whole list is returned when neither limit nor cursor is given",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by one of the filter fields, prefixed with - for descending order (default id)",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -1639,11 +2566,27 @@ const docTemplate = `{
                             "items": {
                                 "$ref": "#/definitions/models.TwScheduleLog"
                             }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of schedule logs"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page"
+                            }
                         }
-                    }
-                }
-            },
-            "post": {
+                    },
+                    "400": {
+                        "description": "Invalid sort or cursor",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
                 "description": "Create a new schedule log",
                 "consumes": [
                     "application/json"
@@ -1676,6 +2619,53 @@ const docTemplate = `{
                 }
             }
         },
+        "/dbms/v1/schedule_log/schedule/{scheduleId}": {
+            "get": {
+                "description": "Get the logs of a schedule with the members who made the changes. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, schedule_id, workspace_user_id, action, field_changed and created_at.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedule_log"
+                ],
+                "summary": "Get schedule logs by schedule",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Schedule ID",
+                        "name": "scheduleId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to sort by, each prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/schedule_log_dtos.TwScheduleLogResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid schedule ID, filter or sort",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
         "/dbms/v1/schedule_log/{id}": {
             "get": {
                 "description": "Get schedule log by ID",
@@ -1779,7 +2769,7 @@ const docTemplate = `{
         },
         "/dbms/v1/schedule_participant": {
             "get": {
-                "description": "Get all schedule participants",
+                "description": "Get a page of the schedule participants. The X-Total-Count header holds the number of schedule participants and X-Next-Cursor the cursor of the next page, if any. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, schedule_id, workspace_user_id, status, invitation_status, assign_by, assign_at, response_time, invitation_sent_at and created_at.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1790,6 +2780,26 @@ const docTemplate = `{
                     "schedule_participant"
                 ],
                 "summary": "Get all schedule participants",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of schedule participants (default 50, max 200); the whole list is returned when neither limit nor cursor is given",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by one of the filter fields, prefixed with - for descending order (default id)",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -1798,6 +2808,22 @@ const docTemplate = `{
                             "items": {
                                 "$ref": "#/definitions/models.TwScheduleParticipant"
                             }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of schedule participants"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sort or cursor",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
@@ -1835,9 +2861,61 @@ const docTemplate = `{
                 }
             }
         },
+        "/dbms/v1/schedule_participant/invite": {
+            "post": {
+                "description": "Add a workspace user to a schedule",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "schedule_participant"
+                ],
+                "summary": "Invite to schedule",
+                "parameters": [
+                    {
+                        "description": "Participant",
+                        "name": "participant",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TwScheduleParticipant"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "warn to also return the invited user's overlapping schedules in any of their workspaces, reject to refuse with 409 when there are any",
+                        "name": "conflicts",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TwScheduleParticipant"
+                        }
+                    },
+                    "404": {
+                        "description": "Schedule not found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "409": {
+                        "description": "The workspace user has overlapping schedules",
+                        "schema": {
+                            "$ref": "#/definitions/availability.ConflictResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/dbms/v1/schedule_participant/schedule/{scheduleId}": {
             "get": {
-                "description": "Get schedule participants by schedule ID",
+                "description": "Get the participants of a schedule who are still joined to its workspace. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, schedule_id, workspace_user_id, status, invitation_status, assign_by, assign_at, response_time, invitation_sent_at and created_at.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1862,6 +2940,12 @@ const docTemplate = `{
                         "name": "workspaceId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to sort by, each prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1873,6 +2957,12 @@ const docTemplate = `{
                                 "$ref": "#/definitions/schedule_participant_dtos.ScheduleParticipantInfo"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Invalid schedule ID, filter or sort",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
                     }
                 }
             }
@@ -2065,9 +3155,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/dbms/v1/user": {
+        "/dbms/v1/schedule_template/workspace/{workspace_id}": {
             "get": {
-                "description": "Get all users",
+                "description": "Get the schedule templates of a workspace, by name",
                 "consumes": [
                     "application/json"
                 ],
@@ -2075,23 +3165,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "schedule_template"
+                ],
+                "summary": "Get schedule templates by workspace",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get all users",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.TwUser"
+                                "$ref": "#/definitions/scheduletemplate.Template"
                             }
                         }
+                    },
+                    "403": {
+                        "description": "Permission denied or the token names no user",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
                     }
                 }
             },
             "post": {
-                "description": "Create a new user",
+                "description": "Save a schedule template in a workspace. Schedules are created from it with POST /schedule/from_template/{template_id}.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2099,33 +3204,52 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "schedule_template"
                 ],
-                "summary": "Create a new user",
+                "summary": "Create schedule template",
                 "parameters": [
                     {
-                        "description": "User object",
-                        "name": "user",
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Template",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.TwUser"
+                            "$ref": "#/definitions/schedule_template.TemplateRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.TwUser"
+                            "$ref": "#/definitions/scheduletemplate.Template"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid template",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied or the token names no user",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
                         }
                     }
                 }
             }
         },
-        "/dbms/v1/user/get": {
+        "/dbms/v1/schedule_template/{template_id}": {
             "get": {
-                "description": "Get user by email",
+                "description": "Get a schedule template",
                 "consumes": [
                     "application/json"
                 ],
@@ -2133,15 +3257,15 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "schedule_template"
                 ],
-                "summary": "Get user by email",
+                "summary": "Get schedule template",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Email",
-                        "name": "email",
-                        "in": "query",
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "template_id",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -2149,15 +3273,25 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.TwUser"
+                            "$ref": "#/definitions/scheduletemplate.Template"
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied or the token names no user",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Template not found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
                         }
                     }
                 }
-            }
-        },
-        "/dbms/v1/user/{id}": {
+            },
             "put": {
-                "description": "Update user by ID",
+                "description": "Change a schedule template. Omitted fields are left unchanged; schedules already created from it are not.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2165,24 +3299,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "schedule_template"
                 ],
-                "summary": "Update user by ID",
+                "summary": "Update schedule template",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
+                        "description": "Template ID",
+                        "name": "template_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "User object",
-                        "name": "user",
+                        "description": "Template",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/core_dtos.UpdateUserRequest"
+                            "$ref": "#/definitions/schedule_template.TemplateRequest"
                         }
                     }
                 ],
@@ -2190,45 +3324,69 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.TwUser"
+                            "$ref": "#/definitions/scheduletemplate.Template"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid template",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied or the token names no user",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Template not found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
                         }
                     }
                 }
             },
             "delete": {
-                "description": "Delete user by ID",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Delete a schedule template. Schedules created from it are kept.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "schedule_template"
                 ],
-                "summary": "Delete user by ID",
+                "summary": "Delete schedule template",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
+                        "description": "Template ID",
+                        "name": "template_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "403": {
+                        "description": "Permission denied or the token names no user",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Template not found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
                         }
                     }
                 }
             }
         },
-        "/dbms/v1/user/{user_id}": {
+        "/dbms/v1/search/workspace/{workspace_id}": {
             "get": {
-                "description": "Get user by ID",
+                "description": "Search the schedules of a workspace by title, description and location, their comments, document file names and video transcripts. Every word must match, as a whole word or the start of one. Results are ranked best first and carry highlights: snippets of the matching fields, HTML-escaped, with the matched words wrapped in \u003cmark\u003e\u003c/mark\u003e. Guests only find the schedules they take part in. The acting workspace user is the caller's membership of the workspace, found through the user_id claim of its token.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2236,31 +3394,294 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "search"
                 ],
-                "summary": "Get user by ID",
+                "summary": "Search workspace",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User ID",
-                        "name": "id",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Words to search for",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated result types to search: schedule, transcript, comment, document (default all)",
+                        "name": "types",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of results (default 20, max 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.TwUser"
-                        }
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/search.Result"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid query or result type",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied or the token names no user",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/user": {
+            "get": {
+                "description": "Get a page of the users. The X-Total-Count header holds the number of users and X-Next-Cursor the cursor of the next page, if any.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get all users",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of users (default 50, max 200); the whole list is returned when neither limit nor cursor is given",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by id or created_at; prefix with - for descending order (default id)",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TwUser"
+                            }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of users"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sort or cursor",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Create a new user",
+                "parameters": [
+                    {
+                        "description": "User object",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TwUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TwUser"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/user/get": {
+            "get": {
+                "description": "Get user by email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get user by email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Email",
+                        "name": "email",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TwUser"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/user/{id}": {
+            "put": {
+                "description": "Update user by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Update user by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User object",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/core_dtos.UpdateUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TwUser"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Delete user by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Delete user by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/user/{user_id}": {
+            "get": {
+                "description": "Get user by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get user by ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TwUser"
+                        }
                     }
                 }
             }
         },
         "/dbms/v1/user_email": {
             "get": {
-                "description": "Get all user emails",
+                "description": "Get a page of the user emails. The X-Total-Count header holds the number of user emails and X-Next-Cursor the cursor of the next page, if any.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2271,6 +3692,32 @@ const docTemplate = `{
                     "user_email"
                 ],
                 "summary": "Get all user emails",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only the emails of this user",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of user emails (default 50, max 200); the whole list is returned when neither limit nor cursor is given",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "X-Next-Cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by id or created_at; prefix with - for descending order (default id)",
+                        "name": "sort",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -2279,6 +3726,22 @@ const docTemplate = `{
                             "items": {
                                 "$ref": "#/definitions/models.TwUserEmail"
                             }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of user emails"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sort or cursor",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
@@ -2360,7 +3823,7 @@ const docTemplate = `{
                 }
             },
             "patch": {
-                "description": "Update user_id in tw_user_email by email",
+                "description": "Update user_id in tw_user_email by email. Setting status to pending emails the address a link to confirm it.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2563,7 +4026,7 @@ const docTemplate = `{
         },
         "/dbms/v1/user_email/status": {
             "patch": {
-                "description": "Update user email status",
+                "description": "Update user email status. Setting status to pending emails the address a link to confirm linking it to the target user.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2680,33 +4143,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/dbms/v1/workspace": {
-            "get": {
-                "description": "Get all workspaces",
-                "consumes": [
-                    "application/json"
-                ],
+        "/dbms/v1/webhook/deliveries/dispatch": {
+            "post": {
+                "description": "Send the webhook deliveries that are due, retrying failures with exponential backoff. The cron worker runs the same dispatch against the database; this route requires the dbms.admin scope.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "workspace"
+                    "webhook"
                 ],
-                "summary": "Get all workspaces",
+                "summary": "Dispatch webhook deliveries",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.TwWorkspace"
-                            }
+                            "$ref": "#/definitions/webhook.DispatchResult"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Update workspace",
+            }
+        },
+        "/dbms/v1/webhook/workspace/{workspace_id}": {
+            "get": {
+                "description": "Get the webhook subscriptions of a workspace",
                 "consumes": [
                     "application/json"
                 ],
@@ -2714,31 +4173,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspace"
+                    "webhook"
                 ],
-                "summary": "Update workspace",
+                "summary": "Get webhooks by workspace",
                 "parameters": [
                     {
-                        "description": "Workspace",
-                        "name": "workspace",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.TwWorkspace"
-                        }
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.TwWorkspace"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/webhook_subscription.SubscriptionResponse"
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied or the token names no user",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
                         }
                     }
                 }
             },
             "post": {
-                "description": "Create workspace",
+                "description": "Subscribe a URL to the events of a workspace. Its host must resolve to public addresses only. Deliveries are signed with the secret in the X-Timewise-Signature header as \"t=\u003cunix\u003e,v1=\u003chex HMAC-SHA256 of t.body\u003e\". The secret is only returned here.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2746,33 +4212,52 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspace"
+                    "webhook"
                 ],
-                "summary": "Create workspace",
+                "summary": "Create webhook",
                 "parameters": [
                     {
-                        "description": "Workspace",
-                        "name": "workspace",
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Webhook",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.TwWorkspace"
+                            "$ref": "#/definitions/webhook_subscription.SubscriptionRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/models.TwWorkspace"
+                            "$ref": "#/definitions/webhook_subscription.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL or event type",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied or the token names no user",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
                         }
                     }
                 }
             }
         },
-        "/dbms/v1/workspace/email/{email}": {
-            "get": {
-                "description": "Get workspaces by email",
+        "/dbms/v1/webhook/{webhook_id}": {
+            "put": {
+                "description": "Change the URL, event types, secret or active flag of a webhook. Omitted fields are left unchanged.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2780,81 +4265,120 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspace"
+                    "webhook"
                 ],
-                "summary": "Get workspaces by email",
+                "summary": "Update webhook",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Email",
-                        "name": "email",
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Webhook",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/webhook_subscription.SubscriptionRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.TwWorkspace"
-                            }
+                            "$ref": "#/definitions/webhook_subscription.SubscriptionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid URL or event type",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied or the token names no user",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
                         }
                     }
                 }
-            }
-        },
-        "/dbms/v1/workspace/filter/workspace": {
-            "get": {
-                "description": "Filter workspaces",
-                "consumes": [
-                    "application/json"
-                ],
+            },
+            "delete": {
+                "description": "Delete a webhook. Pending deliveries to it are marked failed by the worker.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "workspace"
+                    "webhook"
                 ],
-                "summary": "Filter workspaces",
+                "summary": "Delete webhook",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Email",
-                        "name": "email",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
                     },
-                    {
-                        "type": "string",
-                        "description": "Role",
-                        "name": "role",
-                        "in": "query"
+                    "403": {
+                        "description": "Permission denied or the token names no user",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
                     },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/webhook/{webhook_id}/deliveries": {
+            "get": {
+                "description": "Get the delivery log of a webhook, newest first, with the payload, attempts and the subscriber's last response",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "webhook"
+                ],
+                "summary": "Get webhook deliveries",
+                "parameters": [
                     {
-                        "type": "string",
-                        "description": "Search",
-                        "name": "search",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "Webhook ID",
+                        "name": "webhook_id",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Sort by",
-                        "name": "sortBy",
+                        "description": "Filter by status (pending, succeeded, failed)",
+                        "name": "status",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Order",
-                        "name": "order",
+                        "type": "integer",
+                        "description": "Maximum number of deliveries (default 50, max 200); the whole list is returned when neither limit nor cursor is given",
+                        "name": "limit",
                         "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "User ID",
-                        "name": "userid",
-                        "in": "query",
-                        "required": true
                     }
                 ],
                 "responses": {
@@ -2863,16 +4387,28 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.TwWorkspace"
+                                "$ref": "#/definitions/webhook.Delivery"
                             }
                         }
+                    },
+                    "403": {
+                        "description": "Permission denied or the token names no user",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Webhook not found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
                     }
                 }
             }
         },
-        "/dbms/v1/workspace/is_active/{is_active}": {
+        "/dbms/v1/workspace": {
             "get": {
-                "description": "Get workspaces by is_active",
+                "description": "Get a page of the workspaces. The X-Total-Count header holds the number of workspaces and X-Next-Cursor the cursor of the next page, if any. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, title, key, type, is_deleted, created_at and updated_at.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2882,14 +4418,25 @@ const docTemplate = `{
                 "tags": [
                     "workspace"
                 ],
-                "summary": "Get workspaces by is_active",
+                "summary": "Get all workspaces",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of workspaces (default 50, max 200); the whole list is returned when neither limit nor cursor is given",
+                        "name": "limit",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "Is Active",
-                        "name": "is_active",
-                        "in": "path",
-                        "required": true
+                        "description": "X-Next-Cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by one of the filter fields, prefixed with - for descending order (default id)",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -2900,14 +4447,28 @@ const docTemplate = `{
                             "items": {
                                 "$ref": "#/definitions/models.TwWorkspace"
                             }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of workspaces"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sort or cursor",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
-            }
-        },
-        "/dbms/v1/workspace/status/{status}": {
-            "get": {
-                "description": "Get workspaces by status",
+            },
+            "put": {
+                "description": "Update workspace",
                 "consumes": [
                     "application/json"
                 ],
@@ -2917,32 +4478,29 @@ const docTemplate = `{
                 "tags": [
                     "workspace"
                 ],
-                "summary": "Get workspaces by status",
+                "summary": "Update workspace",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Status",
-                        "name": "status",
-                        "in": "path",
-                        "required": true
+                        "description": "Workspace",
+                        "name": "workspace",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TwWorkspace"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.TwWorkspace"
-                            }
+                            "$ref": "#/definitions/models.TwWorkspace"
                         }
                     }
                 }
-            }
-        },
-        "/dbms/v1/workspace/user/{user_id}": {
-            "get": {
-                "description": "Get workspaces by user ID",
+            },
+            "post": {
+                "description": "Create workspace",
                 "consumes": [
                     "application/json"
                 ],
@@ -2952,32 +4510,31 @@ const docTemplate = `{
                 "tags": [
                     "workspace"
                 ],
-                "summary": "Get workspaces by user ID",
+                "summary": "Create workspace",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "User ID",
-                        "name": "user_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Workspace",
+                        "name": "workspace",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TwWorkspace"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.TwWorkspace"
-                            }
+                            "$ref": "#/definitions/models.TwWorkspace"
                         }
                     }
                 }
             }
         },
-        "/dbms/v1/workspace/{workspace_id}": {
+        "/dbms/v1/workspace/clone/{job_id}": {
             "get": {
-                "description": "Get workspace by ID",
+                "description": "Get a workspace clone: its status (pending, running, succeeded or failed), how many of the total members, board columns and schedules are copied, the new workspace and, if it failed, the error. A failed clone is deleted with its members, schedules and reminders. A clone that makes no progress for 15 minutes, e.g. because the server stopped, fails.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2987,12 +4544,12 @@ const docTemplate = `{
                 "tags": [
                     "workspace"
                 ],
-                "summary": "Get workspace by ID",
+                "summary": "Get workspace clone progress",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspace_id",
+                        "description": "Clone job ID",
+                        "name": "job_id",
                         "in": "path",
                         "required": true
                     }
@@ -3001,42 +4558,27 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.TwWorkspace"
+                            "$ref": "#/definitions/clone.Job"
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied or the token names no user",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Clone job not found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
                         }
-                    }
-                }
-            },
-            "delete": {
-                "description": "Remove workspace by ID",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "workspace"
-                ],
-                "summary": "Remove workspace by ID",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspace_id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "204": {
-                        "description": "No Content"
                     }
                 }
             }
         },
-        "/dbms/v1/workspace/{workspace_id}/board_columns": {
+        "/dbms/v1/workspace/email/{email}": {
             "get": {
-                "description": "Get board columns by workspace",
+                "description": "Get workspaces by email",
                 "consumes": [
                     "application/json"
                 ],
@@ -3044,14 +4586,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "board_columns"
+                    "workspace"
                 ],
-                "summary": "Get board columns by workspace",
+                "summary": "Get workspaces by email",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspace_id",
+                        "type": "string",
+                        "description": "Email",
+                        "name": "email",
                         "in": "path",
                         "required": true
                     }
@@ -3062,16 +4604,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.TwBoardColumn"
+                                "$ref": "#/definitions/models.TwWorkspace"
                             }
                         }
                     }
                 }
             }
         },
-        "/dbms/v1/workspace_log": {
+        "/dbms/v1/workspace/filter/workspace": {
             "get": {
-                "description": "Get all workspace logs",
+                "description": "Filter the workspaces a user is a member of with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, e.g. role[in]=owner,admin\u0026title[contains]=team. The fields are id, title, key, type, is_deleted, created_at, updated_at, and role and email of the user's membership.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3079,57 +4621,64 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspace_log"
+                    "workspace"
+                ],
+                "summary": "Filter workspaces",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search the workspace titles",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to sort by, each prefixed with - for descending order, e.g. -created_at,title",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field to sort by when sort is not given, as in earlier versions",
+                        "name": "sortBy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "asc or desc, the order of sortBy (default asc)",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID",
+                        "name": "userid",
+                        "in": "query",
+                        "required": true
+                    }
                 ],
-                "summary": "Get all workspace logs",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.TwWorkspaceLog"
+                                "$ref": "#/definitions/models.TwWorkspace"
                             }
                         }
-                    }
-                }
-            },
-            "post": {
-                "description": "Create workspace log",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "workspace_log"
-                ],
-                "summary": "Create workspace log",
-                "parameters": [
-                    {
-                        "description": "Workspace log object",
-                        "name": "workspace_log",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.TwWorkspaceLog"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "400": {
+                        "description": "Invalid user ID, filter or sort",
                         "schema": {
-                            "$ref": "#/definitions/models.TwWorkspaceLog"
+                            "$ref": "#/definitions/fiber.Map"
                         }
                     }
                 }
             }
         },
-        "/dbms/v1/workspace_log/{workspace_log_id}": {
+        "/dbms/v1/workspace/is_active/{is_active}": {
             "get": {
-                "description": "Get workspace log by ID",
+                "description": "Get workspaces by is_active",
                 "consumes": [
                     "application/json"
                 ],
@@ -3137,14 +4686,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspace_log"
+                    "workspace"
                 ],
-                "summary": "Get workspace log by ID",
+                "summary": "Get workspaces by is_active",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Workspace log ID",
-                        "name": "workspace_log_id",
+                        "type": "string",
+                        "description": "Is Active",
+                        "name": "is_active",
                         "in": "path",
                         "required": true
                     }
@@ -3153,13 +4702,18 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.TwWorkspaceLog"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TwWorkspace"
+                            }
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Remove workspace log by ID",
+            }
+        },
+        "/dbms/v1/workspace/status/{status}": {
+            "get": {
+                "description": "Get workspaces by status",
                 "consumes": [
                     "application/json"
                 ],
@@ -3167,28 +4721,34 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspace_log"
+                    "workspace"
                 ],
-                "summary": "Remove workspace log by ID",
+                "summary": "Get workspaces by status",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Workspace log ID",
-                        "name": "workspace_log_id",
+                        "type": "string",
+                        "description": "Status",
+                        "name": "status",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TwWorkspace"
+                            }
+                        }
                     }
                 }
             }
         },
-        "/dbms/v1/workspace_user/check-existing/email/{email}/workspace/{workspace_id}": {
+        "/dbms/v1/workspace/user/{user_id}": {
             "get": {
-                "description": "Get existing linked workspace user",
+                "description": "Get workspaces by user ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -3196,21 +4756,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspace_user"
+                    "workspace"
                 ],
-                "summary": "Get existing linked workspace user",
+                "summary": "Get workspaces by user ID",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Email",
-                        "name": "email",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Workspace ID",
-                        "name": "workspace_id",
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "user_id",
                         "in": "path",
                         "required": true
                     }
@@ -3221,16 +4774,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.TwWorkspaceUser"
+                                "$ref": "#/definitions/models.TwWorkspace"
                             }
                         }
                     }
                 }
             }
         },
-        "/dbms/v1/workspace_user/disprove-invitation/workspace/{workspace_id}/email/{email}": {
-            "put": {
-                "description": "Disprove member's request invitation",
+        "/dbms/v1/workspace/{workspace_id}": {
+            "get": {
+                "description": "Get workspace by ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -3238,19 +4791,12 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspace_user"
+                    "workspace"
                 ],
-                "summary": "Disprove member's request invitation",
+                "summary": "Get workspace by ID",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Email",
-                        "name": "email",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
+                        "type": "integer",
                         "description": "Workspace ID",
                         "name": "workspace_id",
                         "in": "path",
@@ -3261,15 +4807,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/fiber.Map"
+                            "$ref": "#/definitions/models.TwWorkspace"
                         }
                     }
                 }
-            }
-        },
-        "/dbms/v1/workspace_user/email/{email}/workspace/{workspace_id}": {
-            "get": {
-                "description": "Get workspaces by email",
+            },
+            "delete": {
+                "description": "Remove workspace by ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -3279,17 +4823,10 @@ const docTemplate = `{
                 "tags": [
                     "workspace"
                 ],
-                "summary": "Get workspaces by email",
+                "summary": "Remove workspace by ID",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Email",
-                        "name": "email",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
+                        "type": "integer",
                         "description": "Workspace ID",
                         "name": "workspace_id",
                         "in": "path",
@@ -3297,18 +4834,15 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.TwWorkspace"
-                        }
+                    "204": {
+                        "description": "No Content"
                     }
                 }
             }
         },
-        "/dbms/v1/workspace_user/invitation/workspace/{workspace_id}": {
+        "/dbms/v1/workspace/{workspace_id}/board_columns": {
             "get": {
-                "description": "Get workspace user invitation list",
+                "description": "Get board columns by workspace",
                 "consumes": [
                     "application/json"
                 ],
@@ -3316,12 +4850,12 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspace_user"
+                    "board_columns"
                 ],
-                "summary": "Get workspace user invitation list",
+                "summary": "Get board columns by workspace",
                 "parameters": [
                     {
-                        "type": "string",
+                        "type": "integer",
                         "description": "Workspace ID",
                         "name": "workspace_id",
                         "in": "path",
@@ -3334,16 +4868,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/workspace_user_dtos.GetWorkspaceUserListResponse"
+                                "$ref": "#/definitions/models.TwBoardColumn"
                             }
                         }
                     }
                 }
             }
         },
-        "/dbms/v1/workspace_user/invitation_not_verified/workspace/{workspace_id}": {
-            "get": {
-                "description": "Get workspace user invitation not verified list",
+        "/dbms/v1/workspace/{workspace_id}/clone": {
+            "post": {
+                "description": "Start copying a workspace into a new one: its joined members with their roles mapped by role_map, its board columns in order and its schedules in their positions, with their participants, reminders, recurrence exceptions, document metadata and, with include_comments, comments. The acting workspace user, found through the user_id claim of its token, becomes an owner of the clone. The copy runs in the background; follow it with GET /workspace/clone/{job_id}. The clone is logged in both workspaces. Requires the dbms.admin scope.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3351,34 +4885,58 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspace_user"
+                    "workspace"
                 ],
-                "summary": "Get workspace user invitation not verified list",
+                "summary": "Clone workspace",
                 "parameters": [
                     {
-                        "type": "string",
+                        "type": "integer",
                         "description": "Workspace ID",
                         "name": "workspace_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Clone options",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/clone.Options"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "202": {
+                        "description": "Accepted",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/workspace_user_dtos.GetWorkspaceUserListResponse"
-                            }
+                            "$ref": "#/definitions/clone.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid role_map",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied or the token names no user",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Workspace not found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
                         }
                     }
                 }
             }
         },
-        "/dbms/v1/workspace_user/manage/workspace/{workspace_id}": {
+        "/dbms/v1/workspace_event/workspace/{workspace_id}": {
             "get": {
-                "description": "Get workspace users by workspace ID for manage",
+                "description": "Get the changes on a workspace board after a cursor, for clients that poll or catch up before streaming",
                 "consumes": [
                     "application/json"
                 ],
@@ -3386,75 +4944,84 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspace_user"
+                    "workspace_event"
                 ],
-                "summary": "Get workspace users by workspace ID for manage",
+                "summary": "Get workspace events",
                 "parameters": [
                     {
-                        "type": "string",
+                        "type": "integer",
                         "description": "Workspace ID",
                         "name": "workspace_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Return events after this cursor (default 0)",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of events (default 100, max 500)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/workspace_user_dtos.GetWorkspaceUserListResponse"
-                            }
+                            "$ref": "#/definitions/workspace_event.EventsResponse"
                         }
                     }
                 }
             }
         },
-        "/dbms/v1/workspace_user/role/workspace/{workspace_id}": {
-            "put": {
-                "description": "Update role of workspace user",
-                "consumes": [
-                    "application/json"
-                ],
+        "/dbms/v1/workspace_event/workspace/{workspace_id}/stream": {
+            "get": {
+                "description": "Stream the changes on a workspace board as server-sent events. Each event carries its cursor as the SSE id and its type (e.g. schedule.moved) as the SSE event name. A reconnecting client resumes after the Last-Event-ID header or the cursor query; without either, the stream starts with the next change.",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
-                    "workspace_user"
+                    "workspace_event"
                 ],
-                "summary": "Update role of workspace user",
+                "summary": "Stream workspace events",
                 "parameters": [
                     {
-                        "type": "string",
+                        "type": "integer",
                         "description": "Workspace ID",
                         "name": "workspace_id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Update role request",
-                        "name": "workspace_user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/workspace_user_dtos.UpdateWorkspaceUserRoleRequest"
-                        }
+                        "type": "integer",
+                        "description": "Resume after this cursor",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Resume after this cursor; takes precedence over the cursor query",
+                        "name": "Last-Event-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Event stream",
                         "schema": {
-                            "$ref": "#/definitions/fiber.Map"
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/dbms/v1/workspace_user/update-status/email/{email}/workspace/{workspace_id}/status/{status}/isActive/{isActive}": {
-            "put": {
-                "description": "Update workspace user status by email and workspace",
+        "/dbms/v1/workspace_log": {
+            "get": {
+                "description": "Get a page of the workspace logs. The X-Total-Count header holds the number of workspace logs and X-Next-Cursor the cursor of the next page, if any. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, workspace_id, workspace_user_id, action, field_changed and created_at.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3462,52 +5029,59 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspace_user"
+                    "workspace_log"
                 ],
-                "summary": "Update workspace user status by email and workspace",
+                "summary": "Get all workspace logs",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Email",
-                        "name": "email",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Workspace ID",
-                        "name": "workspace_id",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "Maximum number of workspace logs (default 50, max 200); the whole list is returned when neither limit nor cursor is given",
+                        "name": "limit",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Status",
-                        "name": "status",
-                        "in": "path",
-                        "required": true
+                        "description": "X-Next-Cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Is Active",
-                        "name": "isActive",
-                        "in": "path",
-                        "required": true
+                        "description": "Sort by one of the filter fields, prefixed with - for descending order (default id)",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.TwWorkspaceUser"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TwWorkspaceLog"
+                            }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of workspace logs"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sort or cursor",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
-            }
-        },
-        "/dbms/v1/workspace_user/update-status/{workspace_user_id}": {
-            "put": {
-                "description": "Update workspace user status",
+            },
+            "post": {
+                "description": "Create workspace log",
                 "consumes": [
                     "application/json"
                 ],
@@ -3515,24 +5089,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspace_user"
+                    "workspace_log"
                 ],
-                "summary": "Update workspace user status",
+                "summary": "Create workspace log",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Workspace User ID",
-                        "name": "workspace_user_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Update status request",
-                        "name": "workspace_user",
+                        "description": "Workspace log object",
+                        "name": "workspace_log",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.TwWorkspaceUser"
+                            "$ref": "#/definitions/models.TwWorkspaceLog"
                         }
                     }
                 ],
@@ -3540,15 +5107,15 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.TwWorkspaceUser"
+                            "$ref": "#/definitions/models.TwWorkspaceLog"
                         }
                     }
                 }
             }
         },
-        "/dbms/v1/workspace_user/user_email_id": {
-            "post": {
-                "description": "Get workspace user ID by user email ID",
+        "/dbms/v1/workspace_log/workspace/{workspace_id}": {
+            "get": {
+                "description": "Get the logs of a workspace. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, workspace_id, workspace_user_id, action, field_changed and created_at.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3556,21 +5123,22 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspace_user"
+                    "workspace_log"
                 ],
-                "summary": "Get workspace user ID by user email ID",
+                "summary": "Get workspace logs by workspace",
                 "parameters": [
                     {
-                        "description": "List of User Email IDs",
-                        "name": "user_email_ids",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
-                        }
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to sort by, each prefixed with - for descending order",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -3579,16 +5147,22 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.TwWorkspaceUser"
+                                "$ref": "#/definitions/models.TwWorkspaceLog"
                             }
                         }
+                    },
+                    "400": {
+                        "description": "Invalid filter or sort",
+                        "schema": {
+                            "type": "string"
+                        }
                     }
                 }
             }
         },
-        "/dbms/v1/workspace_user/verify-invitation/workspace/{workspace_id}/email/{email}": {
-            "put": {
-                "description": "Verify member's request invitation",
+        "/dbms/v1/workspace_log/{workspace_log_id}": {
+            "get": {
+                "description": "Get workspace log by ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -3596,21 +5170,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspace_user"
+                    "workspace_log"
                 ],
-                "summary": "Verify member's request invitation",
+                "summary": "Get workspace log by ID",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Email",
-                        "name": "email",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Workspace ID",
-                        "name": "workspace_id",
+                        "type": "integer",
+                        "description": "Workspace log ID",
+                        "name": "workspace_log_id",
                         "in": "path",
                         "required": true
                     }
@@ -3619,15 +5186,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/fiber.Map"
+                            "$ref": "#/definitions/models.TwWorkspaceLog"
                         }
                     }
                 }
-            }
-        },
-        "/dbms/v1/workspace_user/workspace/{workspace_id}": {
-            "get": {
-                "description": "Get workspace users by workspace ID",
+            },
+            "delete": {
+                "description": "Remove workspace log by ID",
                 "consumes": [
                     "application/json"
                 ],
@@ -3635,34 +5200,28 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspace_user"
+                    "workspace_log"
                 ],
-                "summary": "Get workspace users by workspace ID",
+                "summary": "Remove workspace log by ID",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Workspace ID",
-                        "name": "workspace_id",
+                        "type": "integer",
+                        "description": "Workspace log ID",
+                        "name": "workspace_log_id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/workspace_user_dtos.GetWorkspaceUserListResponse"
-                            }
-                        }
+                    "204": {
+                        "description": "No Content"
                     }
                 }
             }
         },
-        "/dbms/v1/workspace_user/{workspace_user_id}/info": {
+        "/dbms/v1/workspace_user": {
             "get": {
-                "description": "Get workspace user info by ID",
+                "description": "Get a page of the workspace users. The X-Total-Count header holds the number of workspace users and X-Next-Cursor the cursor of the next page, if any.",
                 "consumes": [
                     "application/json"
                 ],
@@ -3672,29 +5231,59 @@ const docTemplate = `{
                 "tags": [
                     "workspace_user"
                 ],
-                "summary": "Get workspace user info by ID",
+                "summary": "Get all workspace users",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of workspace users (default 50, max 200); the whole list is returned when neither limit nor cursor is given",
+                        "name": "limit",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "Workspace User ID",
-                        "name": "workspace_user_id",
-                        "in": "path",
-                        "required": true
+                        "description": "X-Next-Cursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Sort by id or created_at; prefix with - for descending order (default id)",
+                        "name": "sort",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/workspace_user_dtos.GetWorkspaceUserListResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TwWorkspaceUser"
+                            }
+                        },
+                        "headers": {
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of workspace users"
+                            },
+                            "X-Next-Cursor": {
+                                "type": "string",
+                                "description": "Cursor of the next page"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid sort or cursor",
+                        "schema": {
+                            "type": "string"
                         }
                     }
                 }
             }
         },
-        "/dbms/v1/workspace_user/{workspace_user_id}/workspace/{workspace_id}": {
-            "delete": {
-                "description": "Delete workspace user",
+        "/dbms/v1/workspace_user/check-existing/email/{email}/workspace/{workspace_id}": {
+            "get": {
+                "description": "Get existing linked workspace user",
                 "consumes": [
                     "application/json"
                 ],
@@ -3704,19 +5293,61 @@ const docTemplate = `{
                 "tags": [
                     "workspace_user"
                 ],
-                "summary": "Delete workspace user",
+                "summary": "Get existing linked workspace user",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "Workspace ID",
                         "name": "workspace_id",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TwWorkspaceUser"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/workspace_user/disprove-invitation/workspace/{workspace_id}/email/{email}": {
+            "put": {
+                "description": "Disprove member's request invitation",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace_user"
+                ],
+                "summary": "Disprove member's request invitation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Workspace User ID",
-                        "name": "workspace_user_id",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
                         "in": "path",
                         "required": true
                     }
@@ -3730,349 +5361,1896 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "board_columns.RageRequest": {
-            "type": "object",
-            "properties": {
-                "position1": {
-                    "type": "integer"
+        },
+        "/dbms/v1/workspace_user/email/{email}/workspace/{workspace_id}": {
+            "get": {
+                "description": "Get workspaces by email",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace"
+                ],
+                "summary": "Get workspaces by email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TwWorkspace"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/workspace_user/invitation/workspace/{workspace_id}": {
+            "get": {
+                "description": "Get workspace user invitation list",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace_user"
+                ],
+                "summary": "Get workspace user invitation list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/workspace_user_dtos.GetWorkspaceUserListResponse"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/workspace_user/invitation_not_verified/workspace/{workspace_id}": {
+            "get": {
+                "description": "Get workspace user invitation not verified list",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace_user"
+                ],
+                "summary": "Get workspace user invitation not verified list",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/workspace_user_dtos.GetWorkspaceUserListResponse"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/workspace_user/manage/workspace/{workspace_id}": {
+            "get": {
+                "description": "Get workspace users by workspace ID for manage",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace_user"
+                ],
+                "summary": "Get workspace users by workspace ID for manage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/workspace_user_dtos.GetWorkspaceUserListResponse"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/workspace_user/role/workspace/{workspace_id}": {
+            "put": {
+                "description": "Update role of workspace user. Requires the dbms.admin scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace_user"
+                ],
+                "summary": "Update role of workspace user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update role request",
+                        "name": "workspace_user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/workspace_user_dtos.UpdateWorkspaceUserRoleRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/workspace_user/update-status/email/{email}/workspace/{workspace_id}/status/{status}/isActive/{isActive}": {
+            "put": {
+                "description": "Update workspace user status by email and workspace",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace_user"
+                ],
+                "summary": "Update workspace user status by email and workspace",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Status",
+                        "name": "status",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Is Active",
+                        "name": "isActive",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TwWorkspaceUser"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/workspace_user/update-status/{workspace_user_id}": {
+            "put": {
+                "description": "Update workspace user status",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace_user"
+                ],
+                "summary": "Update workspace user status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace User ID",
+                        "name": "workspace_user_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update status request",
+                        "name": "workspace_user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.TwWorkspaceUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TwWorkspaceUser"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/workspace_user/user_email_id": {
+            "post": {
+                "description": "Get workspace user ID by user email ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace_user"
+                ],
+                "summary": "Get workspace user ID by user email ID",
+                "parameters": [
+                    {
+                        "description": "List of User Email IDs",
+                        "name": "user_email_ids",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.TwWorkspaceUser"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/workspace_user/verify-invitation/workspace/{workspace_id}/email/{email}": {
+            "put": {
+                "description": "Verify member's request invitation",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace_user"
+                ],
+                "summary": "Verify member's request invitation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Email",
+                        "name": "email",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/workspace_user/workspace/{workspace_id}": {
+            "get": {
+                "description": "Get workspace users by workspace ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace_user"
+                ],
+                "summary": "Get workspace users by workspace ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/workspace_user_dtos.GetWorkspaceUserListResponse"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/workspace_user/{workspace_user_id}/info": {
+            "get": {
+                "description": "Get workspace user info by ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace_user"
+                ],
+                "summary": "Get workspace user info by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace User ID",
+                        "name": "workspace_user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/workspace_user_dtos.GetWorkspaceUserListResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/workspace_user/{workspace_user_id}/workspace/{workspace_id}": {
+            "delete": {
+                "description": "Remove a workspace user. The acting workspace user is the caller's membership of the workspace, found through the user_id claim of its token. Anyone may leave; removing someone else needs a role above theirs. The last owner of a workspace cannot be removed. Requires the dbms.admin scope.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspace_user"
+                ],
+                "summary": "Delete workspace user",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Workspace User ID",
+                        "name": "workspace_user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "403": {
+                        "description": "Permission denied or the token names no user",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "409": {
+                        "description": "The last owner cannot be removed",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        },
+        "/dbms/v1/board_columns/workspace/{workspace_id}/rebalance": {
+            "put": {
+                "description": "Respread the rank keys of the workspace's board columns and of the schedules in each of them, keeping their order. Moves do this in the background once keys grow long; this runs it on demand. Requires the dbms.admin scope.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "board_columns"
+                ],
+                "summary": "Rebalance rank keys",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    },
+                    "404": {
+                        "description": "Workspace not found",
+                        "schema": {
+                            "$ref": "#/definitions/fiber.Map"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "availability.Conflict": {
+            "type": "object",
+            "properties": {
+                "all_day": {
+                    "type": "boolean"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "schedule_id": {
+                    "type": "integer"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                },
+                "workspace_user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "availability.ConflictResponse": {
+            "type": "object",
+            "properties": {
+                "conflicts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/availability.Conflict"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "availability.FreeBusyCalendar": {
+            "type": "object",
+            "properties": {
+                "busy": {
+                    "description": "Busy lists the merged intervals in which the calendar attends a\nschedule, sorted by start.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/availability.Interval"
+                    }
+                },
+                "email": {
+                    "type": "string"
+                },
+                "workspace_user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "availability.FreeBusyResponse": {
+            "type": "object",
+            "properties": {
+                "calendars": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/availability.FreeBusyCalendar"
+                    }
+                },
+                "from": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "availability.Interval": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "availability.MeetingAttendee": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "working_hours": {
+                    "description": "WorkingHours replaces the request's working hours for this attendee.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/availability.WorkingHours"
+                        }
+                    ]
+                },
+                "workspace_user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "availability.MeetingAttendeeResponse": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "name": {
+                    "description": "Name is how slots refer to the attendee: the email, or the workspace\nuser id.",
+                    "type": "string"
+                },
+                "optional": {
+                    "type": "boolean"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "workspace_user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "availability.MeetingTimesRequest": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "description": "Count is the number of slots to suggest (default 5, max 20).",
+                    "type": "integer"
+                },
+                "duration_minutes": {
+                    "type": "integer"
+                },
+                "from": {
+                    "description": "From and To bound the search; now and 7 days later by default, at\nmost 31 days apart.",
+                    "type": "string"
+                },
+                "optional": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/availability.MeetingAttendee"
+                    }
+                },
+                "required": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/availability.MeetingAttendee"
+                    }
+                },
+                "step_minutes": {
+                    "description": "StepMinutes is the time between candidate starts (default 30).",
+                    "type": "integer"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "working_hours": {
+                    "description": "WorkingHours applies to attendees without their own, in their own\ntimezone; 09:00 to 17:00, Monday to Friday by default.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/availability.WorkingHours"
+                        }
+                    ]
+                }
+            }
+        },
+        "availability.MeetingTimesResponse": {
+            "type": "object",
+            "properties": {
+                "attendees": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/availability.MeetingAttendeeResponse"
+                    }
+                },
+                "slots": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/availability.Slot"
+                    }
+                }
+            }
+        },
+        "availability.Slot": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "string"
+                },
+                "score": {
+                    "description": "Score is the share of optional attendees who are free plus\nWorkingHoursFit, between 0 and 2; higher is better.",
+                    "type": "number"
+                },
+                "start": {
+                    "type": "string"
+                },
+                "unavailable": {
+                    "description": "Unavailable names the optional attendees who are busy.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "working_hours_fit": {
+                    "description": "WorkingHoursFit is the average share of the slot within the local\nworking hours of the attendees who can come, between 0 and 1.",
+                    "type": "number"
+                }
+            }
+        },
+        "availability.WorkingHours": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "end": {
+                    "type": "string"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "board_columns.RageRequest": {
+            "type": "object",
+            "properties": {
+                "position1": {
+                    "type": "integer"
+                },
+                "position2": {
+                    "type": "integer"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "board_columns.RequestBody": {
+            "type": "object",
+            "properties": {
+                "position": {
+                    "type": "integer"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "board_columns_dtos.BoardColumnsRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "calendar.ImportCalendarResponse": {
+            "type": "object",
+            "properties": {
+                "conflicts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/calendar.ImportConflictResponse"
+                    }
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "duplicates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/calendar.ImportDuplicateResponse"
+                    }
+                },
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/calendar.ImportErrorResponse"
+                    }
+                },
+                "imported": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/calendar.ImportedScheduleResponse"
+                    }
+                }
+            }
+        },
+        "calendar.ImportConflictResponse": {
+            "type": "object",
+            "properties": {
+                "end_time": {
+                    "type": "string"
+                },
+                "existing_schedule_id": {
+                    "type": "integer"
+                },
+                "existing_title": {
+                    "type": "string"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "calendar.ImportDuplicateResponse": {
+            "type": "object",
+            "properties": {
+                "existing_schedule_id": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "calendar.ImportErrorResponse": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "calendar.ImportedScheduleResponse": {
+            "type": "object",
+            "properties": {
+                "end_time": {
+                    "type": "string"
+                },
+                "exceptions": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "recurrence_pattern": {
+                    "type": "string"
+                },
+                "schedule_id": {
+                    "type": "integer"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "clone.Job": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "integer"
+                },
+                "done": {
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "source_workspace_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "target_workspace_id": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "clone.Options": {
+            "type": "object",
+            "properties": {
+                "include_comments": {
+                    "type": "boolean"
+                },
+                "role_map": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "description": "RoleMap gives members of a role another role in the clone, e.g.\n{\"owner\": \"admin\"}; members of a role mapped to \"\" are not copied.\nWhoever clones is always an owner of the clone."
+                },
+                "title": {
+                    "type": "string",
+                    "description": "Title of the clone; the source title followed by \" (copy)\" when empty."
+                }
+            }
+        },
+        "core_dtos.PushNotificationDto": {
+            "type": "object",
+            "required": [
+                "message",
+                "type",
+                "user_email_id"
+            ],
+            "properties": {
+                "extra_data": {
+                    "description": "Optional: Additional data",
+                    "type": "string"
+                },
+                "message": {
+                    "description": "Required for the notification content",
+                    "type": "string"
+                },
+                "related_item_id": {
+                    "description": "Optional: ID of related item",
+                    "type": "integer"
+                },
+                "related_item_type": {
+                    "description": "Optional: Type of related item",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "Required to specify the notification type",
+                    "type": "string"
+                },
+                "user_email_id": {
+                    "description": "Required to specify the recipient",
+                    "type": "integer"
+                }
+            }
+        },
+        "core_dtos.TwCreateScheduleRequest": {
+            "type": "object",
+            "properties": {
+                "board_column_id": {
+                    "description": "Nullable field",
+                    "type": "integer"
+                },
+                "description": {
+                    "description": "Nullable field",
+                    "type": "string"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "title": {
+                    "description": "Nullable field",
+                    "type": "string"
+                },
+                "workspace_id": {
+                    "description": "Nullable field",
+                    "type": "integer"
+                },
+                "workspace_user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "core_dtos.TwCreateShecduleResponse": {
+            "type": "object",
+            "properties": {
+                "board_column_id": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "core_dtos.TwRecurrenceExceptionCreateDTO": {
+            "type": "object",
+            "required": [
+                "schedule_id"
+            ],
+            "properties": {
+                "exception_date": {
+                    "type": "string"
+                },
+                "extra_data": {
+                    "type": "string"
+                },
+                "is_cancelled": {
+                    "type": "boolean"
+                },
+                "new_end_time": {
+                    "type": "string"
+                },
+                "new_start_time": {
+                    "type": "string"
+                },
+                "schedule_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "core_dtos.TwRecurrenceExceptionResponseDTO": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "exception_date": {
+                    "type": "string"
+                },
+                "extra_data": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_cancelled": {
+                    "type": "boolean"
+                },
+                "new_end_time": {
+                    "type": "string"
+                },
+                "new_start_time": {
+                    "type": "string"
+                },
+                "schedule_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "core_dtos.TwRecurrenceExceptionUpdateDTO": {
+            "type": "object",
+            "properties": {
+                "exception_date": {
+                    "type": "string"
+                },
+                "extra_data": {
+                    "type": "string"
+                },
+                "is_cancelled": {
+                    "type": "boolean"
+                },
+                "new_end_time": {
+                    "type": "string"
+                },
+                "new_start_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "core_dtos.TwScheduleResponse": {
+            "type": "object",
+            "properties": {
+                "all_day": {
+                    "type": "boolean"
+                },
+                "board_column_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "extra_data": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_deleted": {
+                    "type": "boolean"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "priority": {
+                    "type": "string"
+                },
+                "recurrence_pattern": {
+                    "type": "string"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "video_transcript": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "type": "string"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "core_dtos.TwUpdateScheduleRequest": {
+            "type": "object",
+            "properties": {
+                "all_day": {
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "extra_data": {
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "priority": {
+                    "description": "Position          *int       ` + "`" + `json:\"position,omitempty\"` + "`" + `",
+                    "type": "string"
+                },
+                "recurrence_pattern": {
+                    "type": "string"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "title": {
+                    "description": "BoardColumnID     *int       ` + "`" + `json:\"board_column_id,omitempty\"` + "`" + `",
+                    "type": "string"
+                },
+                "video_transcript": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "type": "string"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "core_dtos.TwUpdateScheduleResponse": {
+            "type": "object",
+            "properties": {
+                "all_day": {
+                    "type": "boolean"
+                },
+                "board_column_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "extra_data": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_deleted": {
+                    "type": "boolean"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "priority": {
+                    "type": "string"
+                },
+                "recurrence_pattern": {
+                    "type": "string"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "video_transcript": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "type": "string"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "core_dtos.UpdateUserRequest": {
+            "type": "object",
+            "properties": {
+                "calendarSettings": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "firstName": {
+                    "type": "string"
+                },
+                "isActive": {
+                    "type": "boolean"
+                },
+                "isVerified": {
+                    "type": "boolean"
+                },
+                "lastLoginAt": {
+                    "type": "string"
+                },
+                "lastName": {
+                    "type": "string"
+                },
+                "locale": {
+                    "type": "string"
+                },
+                "notificationSettings": {
+                    "type": "string"
+                },
+                "profilePicture": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                },
+                "timezone": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "fiber.Error": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "fiber.Map": {
+            "type": "object",
+            "additionalProperties": true
+        },
+        "models.TwBoardColumn": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "workspace": {
+                    "$ref": "#/definitions/models.TwWorkspace"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TwComment": {
+            "type": "object",
+            "properties": {
+                "commenter": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_deleted": {
+                    "type": "boolean"
+                },
+                "schedule": {
+                    "$ref": "#/definitions/models.TwSchedule"
+                },
+                "schedule_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "workspaceUser": {
+                    "$ref": "#/definitions/models.TwWorkspaceUser"
+                },
+                "workspace_user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TwDocument": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "download_url": {
+                    "type": "string"
+                },
+                "file_name": {
+                    "type": "string"
+                },
+                "file_path": {
+                    "type": "string"
+                },
+                "file_size": {
+                    "type": "integer"
+                },
+                "file_type": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_deleted": {
+                    "type": "boolean"
+                },
+                "schedule": {
+                    "$ref": "#/definitions/models.TwSchedule"
+                },
+                "schedule_id": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uploaded_at": {
+                    "type": "string"
+                },
+                "workspaceUser": {
+                    "$ref": "#/definitions/models.TwWorkspaceUser"
+                },
+                "workspace_user_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TwNotificationSettings": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "extra_data": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "notification_on_comment": {
+                    "type": "boolean"
+                },
+                "notification_on_due_date": {
+                    "type": "boolean"
+                },
+                "notification_on_email": {
+                    "type": "boolean"
+                },
+                "notification_on_schedule_change": {
+                    "type": "boolean"
                 },
-                "position2": {
-                    "type": "integer"
+                "notification_on_tag": {
+                    "type": "boolean"
                 },
-                "workspace_id": {
-                    "type": "integer"
-                }
-            }
-        },
-        "board_columns.RequestBody": {
-            "type": "object",
-            "properties": {
-                "position": {
-                    "type": "integer"
+                "updated_at": {
+                    "type": "string"
                 },
-                "workspace_id": {
+                "user": {
+                    "$ref": "#/definitions/models.TwUser"
+                },
+                "user_id": {
                     "type": "integer"
                 }
             }
         },
-        "board_columns_dtos.BoardColumnsRequest": {
+        "models.TwNotifications": {
             "type": "object",
             "properties": {
-                "name": {
+                "created_at": {
                     "type": "string"
                 },
-                "position": {
-                    "type": "integer"
+                "deleted_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
                 },
-                "workspace_id": {
-                    "type": "integer"
-                }
-            }
-        },
-        "core_dtos.PushNotificationDto": {
-            "type": "object",
-            "required": [
-                "message",
-                "type",
-                "user_email_id"
-            ],
-            "properties": {
                 "extra_data": {
-                    "description": "Optional: Additional data",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_read": {
+                    "type": "boolean"
+                },
+                "is_sent": {
+                    "type": "boolean"
+                },
+                "link": {
                     "type": "string"
                 },
                 "message": {
-                    "description": "Required for the notification content",
+                    "type": "string"
+                },
+                "notified_at": {
                     "type": "string"
                 },
                 "related_item_id": {
-                    "description": "Optional: ID of related item",
                     "type": "integer"
                 },
                 "related_item_type": {
-                    "description": "Optional: Type of related item",
+                    "type": "string"
+                },
+                "title": {
                     "type": "string"
                 },
                 "type": {
-                    "description": "Required to specify the notification type",
                     "type": "string"
                 },
+                "updated_at": {
+                    "type": "string"
+                },
+                "userEmail": {
+                    "$ref": "#/definitions/models.TwUserEmail"
+                },
                 "user_email_id": {
-                    "description": "Required to specify the recipient",
                     "type": "integer"
                 }
             }
         },
-        "core_dtos.TwCreateScheduleRequest": {
+        "models.TwReminder": {
             "type": "object",
             "properties": {
-                "board_column_id": {
-                    "description": "Nullable field",
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "id": {
                     "type": "integer"
                 },
-                "description": {
-                    "description": "Nullable field",
+                "is_sent": {
+                    "type": "boolean"
+                },
+                "method": {
                     "type": "string"
                 },
-                "end_time": {
+                "reminder_time": {
                     "type": "string"
                 },
-                "start_time": {
+                "schedule": {
+                    "$ref": "#/definitions/models.TwSchedule"
+                },
+                "schedule_id": {
+                    "type": "integer"
+                },
+                "type": {
                     "type": "string"
                 },
-                "title": {
-                    "description": "Nullable field",
+                "updated_at": {
                     "type": "string"
                 },
-                "workspace_id": {
-                    "description": "Nullable field",
-                    "type": "integer"
+                "workspaceUser": {
+                    "$ref": "#/definitions/models.TwWorkspaceUser"
                 },
                 "workspace_user_id": {
                     "type": "integer"
                 }
             }
         },
-        "core_dtos.TwCreateShecduleResponse": {
+        "models.TwSchedule": {
             "type": "object",
             "properties": {
+                "all_day": {
+                    "type": "boolean"
+                },
+                "boardColumn": {
+                    "$ref": "#/definitions/models.TwBoardColumn"
+                },
                 "board_column_id": {
                     "type": "integer"
                 },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
                 "description": {
                     "type": "string"
                 },
                 "end_time": {
                     "type": "string"
                 },
+                "extra_data": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "is_deleted": {
+                    "type": "boolean"
+                },
+                "location": {
+                    "type": "string"
+                },
                 "position": {
                     "type": "integer"
                 },
-                "start_time": {
+                "priority": {
                     "type": "string"
                 },
-                "title": {
+                "recurrence_pattern": {
                     "type": "string"
                 },
-                "workspace_id": {
-                    "type": "integer"
-                }
-            }
-        },
-        "core_dtos.TwRecurrenceExceptionCreateDTO": {
-            "type": "object",
-            "required": [
-                "schedule_id"
-            ],
-            "properties": {
-                "exception_date": {
+                "start_time": {
                     "type": "string"
                 },
-                "extra_data": {
+                "status": {
                     "type": "string"
                 },
-                "is_cancelled": {
-                    "type": "boolean"
+                "title": {
+                    "type": "string"
                 },
-                "new_end_time": {
+                "updated_at": {
                     "type": "string"
                 },
-                "new_start_time": {
+                "video_transcript": {
                     "type": "string"
                 },
-                "schedule_id": {
+                "visibility": {
+                    "type": "string"
+                },
+                "workspace": {
+                    "$ref": "#/definitions/models.TwWorkspace"
+                },
+                "workspaceUser": {
+                    "$ref": "#/definitions/models.TwWorkspaceUser"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                },
+                "workspace_user_id": {
                     "type": "integer"
                 }
             }
         },
-        "core_dtos.TwRecurrenceExceptionResponseDTO": {
+        "models.TwScheduleLog": {
             "type": "object",
             "properties": {
+                "action": {
+                    "type": "string"
+                },
                 "created_at": {
                     "type": "string"
                 },
-                "exception_date": {
+                "deleted_at": {
                     "type": "string"
                 },
-                "extra_data": {
+                "description": {
+                    "type": "string"
+                },
+                "field_changed": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "is_cancelled": {
-                    "type": "boolean"
-                },
-                "new_end_time": {
+                "new_value": {
                     "type": "string"
                 },
-                "new_start_time": {
+                "old_value": {
                     "type": "string"
                 },
+                "schedule": {
+                    "$ref": "#/definitions/models.TwSchedule"
+                },
                 "schedule_id": {
                     "type": "integer"
                 },
                 "updated_at": {
                     "type": "string"
+                },
+                "workspaceUser": {
+                    "$ref": "#/definitions/models.TwWorkspaceUser"
+                },
+                "workspace_user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "core_dtos.TwRecurrenceExceptionUpdateDTO": {
+        "models.TwScheduleParticipant": {
             "type": "object",
             "properties": {
-                "exception_date": {
+                "assignByUser": {
+                    "$ref": "#/definitions/models.TwWorkspaceUser"
+                },
+                "assign_at": {
                     "type": "string"
                 },
-                "extra_data": {
+                "assign_by": {
+                    "type": "integer"
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "is_cancelled": {
-                    "type": "boolean"
+                "deleted_at": {
+                    "type": "string"
                 },
-                "new_end_time": {
+                "id": {
+                    "type": "integer"
+                },
+                "invitation_sent_at": {
                     "type": "string"
                 },
-                "new_start_time": {
+                "invitation_status": {
                     "type": "string"
-                }
-            }
-        },
-        "core_dtos.TwScheduleResponse": {
-            "type": "object",
-            "properties": {
-                "all_day": {
-                    "type": "boolean"
                 },
-                "board_column_id": {
+                "response_time": {
+                    "type": "string"
+                },
+                "schedule": {
+                    "$ref": "#/definitions/models.TwSchedule"
+                },
+                "schedule_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "workspaceUser": {
+                    "$ref": "#/definitions/models.TwWorkspaceUser"
+                },
+                "workspace_user_id": {
                     "type": "integer"
+                }
+            }
+        },
+        "models.TwUser": {
+            "type": "object",
+            "properties": {
+                "calendarSettings": {
+                    "type": "string"
                 },
                 "created_at": {
                     "type": "string"
                 },
-                "created_by": {
-                    "type": "integer"
+                "deleted_at": {
+                    "type": "string"
                 },
-                "description": {
+                "email": {
                     "type": "string"
                 },
-                "end_time": {
+                "firstName": {
                     "type": "string"
                 },
-                "extra_data": {
+                "googleId": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "is_deleted": {
+                "isActive": {
                     "type": "boolean"
                 },
-                "location": {
-                    "type": "string"
-                },
-                "position": {
-                    "type": "integer"
+                "isVerified": {
+                    "type": "boolean"
                 },
-                "priority": {
+                "lastLoginAt": {
                     "type": "string"
                 },
-                "recurrence_pattern": {
+                "lastName": {
                     "type": "string"
                 },
-                "start_time": {
+                "locale": {
                     "type": "string"
                 },
-                "status": {
+                "notificationSettings": {
                     "type": "string"
                 },
-                "title": {
+                "profilePicture": {
                     "type": "string"
                 },
-                "updated_at": {
+                "role": {
                     "type": "string"
                 },
-                "video_transcript": {
+                "timezone": {
                     "type": "string"
                 },
-                "visibility": {
+                "updated_at": {
                     "type": "string"
-                },
-                "workspace_id": {
-                    "type": "integer"
                 }
             }
         },
-        "core_dtos.TwUpdateScheduleRequest": {
+        "models.TwUserEmail": {
             "type": "object",
             "properties": {
-                "all_day": {
-                    "type": "boolean"
-                },
-                "description": {
-                    "type": "string"
-                },
-                "end_time": {
+                "created_at": {
                     "type": "string"
                 },
-                "extra_data": {
+                "deleted_at": {
                     "type": "string"
                 },
-                "location": {
+                "email": {
                     "type": "string"
                 },
-                "priority": {
-                    "description": "Position          *int       ` + "`" + `json:\"position,omitempty\"` + "`" + `",
+                "expires_at": {
                     "type": "string"
                 },
-                "recurrence_pattern": {
-                    "type": "string"
+                "id": {
+                    "type": "integer"
                 },
-                "start_time": {
-                    "type": "string"
+                "is_linked_to": {
+                    "type": "integer"
                 },
                 "status": {
                     "type": "string"
                 },
-                "title": {
-                    "description": "BoardColumnID     *int       ` + "`" + `json:\"board_column_id,omitempty\"` + "`" + `",
-                    "type": "string"
-                },
-                "video_transcript": {
+                "updated_at": {
                     "type": "string"
                 },
-                "visibility": {
-                    "type": "string"
+                "user": {
+                    "$ref": "#/definitions/models.TwUser"
                 },
-                "workspace_id": {
+                "user_id": {
                     "type": "integer"
                 }
             }
         },
-        "core_dtos.TwUpdateScheduleResponse": {
+        "models.TwWorkspace": {
             "type": "object",
             "properties": {
-                "all_day": {
-                    "type": "boolean"
-                },
-                "board_column_id": {
-                    "type": "integer"
-                },
                 "created_at": {
                     "type": "string"
                 },
-                "created_by": {
-                    "type": "integer"
-                },
-                "description": {
+                "deleted_at": {
                     "type": "string"
                 },
-                "end_time": {
+                "description": {
                     "type": "string"
                 },
                 "extra_data": {
@@ -4084,104 +7262,65 @@ const docTemplate = `{
                 "is_deleted": {
                     "type": "boolean"
                 },
-                "location": {
-                    "type": "string"
-                },
-                "position": {
-                    "type": "integer"
-                },
-                "priority": {
-                    "type": "string"
-                },
-                "recurrence_pattern": {
-                    "type": "string"
-                },
-                "start_time": {
-                    "type": "string"
-                },
-                "status": {
+                "key": {
                     "type": "string"
                 },
                 "title": {
                     "type": "string"
                 },
-                "updated_at": {
-                    "type": "string"
-                },
-                "video_transcript": {
+                "type": {
                     "type": "string"
                 },
-                "visibility": {
+                "updated_at": {
                     "type": "string"
-                },
-                "workspace_id": {
-                    "type": "integer"
                 }
             }
         },
-        "core_dtos.UpdateUserRequest": {
+        "models.TwWorkspaceLog": {
             "type": "object",
             "properties": {
-                "calendarSettings": {
+                "action": {
                     "type": "string"
                 },
-                "createdAt": {
+                "created_at": {
                     "type": "string"
                 },
-                "email": {
+                "deleted_at": {
                     "type": "string"
                 },
-                "firstName": {
+                "description": {
                     "type": "string"
                 },
-                "isActive": {
-                    "type": "boolean"
-                },
-                "isVerified": {
-                    "type": "boolean"
-                },
-                "lastLoginAt": {
+                "field_changed": {
                     "type": "string"
                 },
-                "lastName": {
-                    "type": "string"
+                "id": {
+                    "type": "integer"
                 },
-                "locale": {
+                "new_value": {
                     "type": "string"
                 },
-                "notificationSettings": {
+                "old_value": {
                     "type": "string"
                 },
-                "profilePicture": {
+                "updated_at": {
                     "type": "string"
                 },
-                "role": {
-                    "type": "string"
+                "workspace": {
+                    "$ref": "#/definitions/models.TwWorkspace"
                 },
-                "timezone": {
-                    "type": "string"
+                "workspaceUser": {
+                    "$ref": "#/definitions/models.TwWorkspaceUser"
                 },
-                "updatedAt": {
-                    "type": "string"
-                }
-            }
-        },
-        "fiber.Error": {
-            "type": "object",
-            "properties": {
-                "code": {
+                "workspace_id": {
                     "type": "integer"
                 },
-                "message": {
-                    "type": "string"
+                "workspace_user_id": {
+                    "type": "integer"
                 }
             }
         },
-        "fiber.Map": {
-            "type": "object",
-            "additionalProperties": true
-        },
-        "models.TwBoardColumn": {
+        "models.TwWorkspaceUser": {
             "type": "object",
             "properties": {
                 "created_at": {
@@ -4190,296 +7329,389 @@ const docTemplate = `{
                 "deleted_at": {
                     "type": "string"
                 },
+                "extra_data": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
-                "name": {
+                "is_active": {
+                    "type": "boolean"
+                },
+                "is_verified": {
+                    "type": "boolean"
+                },
+                "role": {
                     "type": "string"
                 },
-                "position": {
-                    "type": "integer"
+                "status": {
+                    "type": "string"
                 },
                 "updated_at": {
                     "type": "string"
                 },
+                "userEmail": {
+                    "$ref": "#/definitions/models.TwUserEmail"
+                },
+                "user_email_id": {
+                    "type": "integer"
+                },
                 "workspace": {
                     "$ref": "#/definitions/models.TwWorkspace"
                 },
                 "workspace_id": {
                     "type": "integer"
+                },
+                "workspace_key": {
+                    "type": "string"
                 }
             }
         },
-        "models.TwComment": {
+        "outbox.Delivery": {
             "type": "object",
             "properties": {
-                "commenter": {
-                    "type": "string"
+                "attempts": {
+                    "type": "integer"
                 },
-                "content": {
+                "channel": {
                     "type": "string"
                 },
                 "created_at": {
                     "type": "string"
                 },
-                "deleted_at": {
-                    "type": "string"
-                },
                 "id": {
                     "type": "integer"
                 },
-                "is_deleted": {
-                    "type": "boolean"
+                "last_attempt_at": {
+                    "type": "string"
                 },
-                "schedule": {
-                    "$ref": "#/definitions/models.TwSchedule"
+                "last_error": {
+                    "type": "string"
                 },
-                "schedule_id": {
+                "next_attempt_at": {
+                    "type": "string"
+                },
+                "notification": {
+                    "description": "Notification is only loaded for the admin listing.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.TwNotifications"
+                        }
+                    ]
+                },
+                "notification_id": {
                     "type": "integer"
                 },
-                "updated_at": {
+                "sent_at": {
                     "type": "string"
                 },
-                "workspaceUser": {
-                    "$ref": "#/definitions/models.TwWorkspaceUser"
+                "status": {
+                    "type": "string"
                 },
-                "workspace_user_id": {
-                    "type": "integer"
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "models.TwDocument": {
+        "realtime.Event": {
             "type": "object",
             "properties": {
                 "created_at": {
                     "type": "string"
                 },
-                "deleted_at": {
-                    "type": "string"
+                "cursor": {
+                    "type": "integer"
                 },
-                "download_url": {
-                    "type": "string"
+                "data": {
+                    "type": "object"
                 },
-                "file_name": {
+                "entity_id": {
+                    "type": "integer"
+                },
+                "type": {
                     "type": "string"
                 },
-                "file_path": {
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "schedule.AgendaDay": {
+            "type": "object",
+            "properties": {
+                "date": {
                     "type": "string"
                 },
-                "file_size": {
+                "occurrences": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/schedule.AgendaOccurrence"
+                    }
+                }
+            }
+        },
+        "schedule.AgendaOccurrence": {
+            "type": "object",
+            "properties": {
+                "all_day": {
+                    "type": "boolean"
+                },
+                "board_column_id": {
                     "type": "integer"
                 },
-                "file_type": {
+                "end_time": {
                     "type": "string"
                 },
-                "id": {
+                "exception_id": {
                     "type": "integer"
                 },
-                "is_deleted": {
+                "is_moved": {
                     "type": "boolean"
                 },
-                "schedule": {
-                    "$ref": "#/definitions/models.TwSchedule"
+                "is_recurring": {
+                    "type": "boolean"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "original_start_time": {
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "string"
                 },
                 "schedule_id": {
                     "type": "integer"
                 },
-                "updated_at": {
+                "start_time": {
                     "type": "string"
                 },
-                "uploaded_at": {
+                "status": {
                     "type": "string"
                 },
-                "workspaceUser": {
-                    "$ref": "#/definitions/models.TwWorkspaceUser"
+                "title": {
+                    "type": "string"
                 },
-                "workspace_user_id": {
+                "workspace_id": {
                     "type": "integer"
+                },
+                "workspace_title": {
+                    "type": "string"
                 }
             }
         },
-        "models.TwNotificationSettings": {
+        "schedule.AgendaResponse": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/schedule.AgendaDay"
+                    }
                 },
-                "deleted_at": {
+                "from": {
                     "type": "string"
                 },
-                "extra_data": {
+                "timezone": {
+                    "description": "Timezone is the IANA name the agenda is rendered in; UTC when the user\nhas none or it is unknown.",
                     "type": "string"
                 },
-                "id": {
-                    "type": "integer"
-                },
-                "notification_on_comment": {
-                    "type": "boolean"
-                },
-                "notification_on_due_date": {
-                    "type": "boolean"
-                },
-                "notification_on_email": {
-                    "type": "boolean"
-                },
-                "notification_on_schedule_change": {
-                    "type": "boolean"
-                },
-                "notification_on_tag": {
-                    "type": "boolean"
-                },
-                "updated_at": {
+                "to": {
                     "type": "string"
                 },
-                "user": {
-                    "$ref": "#/definitions/models.TwUser"
-                },
                 "user_id": {
                     "type": "integer"
                 }
             }
         },
-        "models.TwNotifications": {
+        "schedule.CloneRequest": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
+                "board_column_id": {
+                    "type": "integer",
+                    "description": "BoardColumnID is the column the copy goes to."
                 },
-                "deleted_at": {
-                    "type": "string"
+                "include_comments": {
+                    "type": "boolean"
+                },
+                "workspace_id": {
+                    "type": "integer",
+                    "description": "WorkspaceID, when given without a board column, picks the column of the\nworkspace named like the schedule's, or else its first column."
+                }
+            }
+        },
+        "schedule.CloneResponse": {
+            "type": "object",
+            "properties": {
+                "board_column_id": {
+                    "type": "integer"
                 },
                 "description": {
                     "type": "string"
                 },
-                "extra_data": {
+                "end_time": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "is_read": {
-                    "type": "boolean"
-                },
-                "is_sent": {
-                    "type": "boolean"
+                "position": {
+                    "type": "integer"
                 },
-                "link": {
-                    "type": "string"
+                "source_id": {
+                    "type": "integer"
                 },
-                "message": {
+                "start_time": {
                     "type": "string"
                 },
-                "notified_at": {
+                "title": {
                     "type": "string"
                 },
-                "related_item_id": {
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "schedule.FromTemplateReminder": {
+            "type": "object",
+            "properties": {
+                "id": {
                     "type": "integer"
                 },
-                "related_item_type": {
+                "method": {
                     "type": "string"
                 },
-                "title": {
+                "reminder_time": {
                     "type": "string"
                 },
                 "type": {
                     "type": "string"
+                }
+            }
+        },
+        "schedule.FromTemplateRequest": {
+            "type": "object",
+            "properties": {
+                "assignee_id": {
+                    "type": "integer",
+                    "description": "AssigneeID is a workspace user who fills in {{assignee}} and is added\nalong with the template's participants."
                 },
-                "updated_at": {
-                    "type": "string"
+                "board_column_id": {
+                    "type": "integer"
                 },
-                "userEmail": {
-                    "$ref": "#/definitions/models.TwUserEmail"
+                "start_time": {
+                    "type": "string",
+                    "description": "StartTime is an RFC 3339 time; the schedule starts now when it is omitted."
                 },
-                "user_email_id": {
-                    "type": "integer"
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    },
+                    "description": "Variables fill in the template's own placeholders, and may override\ndate, time, assignee and workspace."
                 }
             }
         },
-        "models.TwReminder": {
+        "schedule.FromTemplateResponse": {
             "type": "object",
             "properties": {
-                "created_at": {
+                "board_column_id": {
+                    "type": "integer"
+                },
+                "checklist": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scheduletemplate.ChecklistItem"
+                    }
+                },
+                "description": {
                     "type": "string"
                 },
-                "deleted_at": {
+                "end_time": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "is_sent": {
-                    "type": "boolean"
+                "participants": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "description": "Participants are the workspace users added besides the creator."
                 },
-                "method": {
-                    "type": "string"
+                "position": {
+                    "type": "integer"
                 },
-                "reminder_time": {
-                    "type": "string"
+                "reminders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/schedule.FromTemplateReminder"
+                    }
                 },
-                "schedule": {
-                    "$ref": "#/definitions/models.TwSchedule"
+                "start_time": {
+                    "type": "string"
                 },
-                "schedule_id": {
+                "template_id": {
                     "type": "integer"
                 },
-                "type": {
+                "title": {
                     "type": "string"
                 },
-                "updated_at": {
-                    "type": "string"
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "schedule.PreconditionFailedResponse": {
+            "type": "object",
+            "properties": {
+                "changed_fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "workspaceUser": {
-                    "$ref": "#/definitions/models.TwWorkspaceUser"
+                "etag": {
+                    "type": "string"
                 },
-                "workspace_user_id": {
-                    "type": "integer"
+                "message": {
+                    "type": "string"
                 }
             }
         },
-        "models.TwSchedule": {
+        "schedule.ScheduleOccurrenceResponse": {
             "type": "object",
             "properties": {
                 "all_day": {
                     "type": "boolean"
                 },
-                "boardColumn": {
-                    "$ref": "#/definitions/models.TwBoardColumn"
-                },
                 "board_column_id": {
                     "type": "integer"
                 },
-                "created_at": {
-                    "type": "string"
-                },
-                "deleted_at": {
-                    "type": "string"
-                },
-                "description": {
-                    "type": "string"
-                },
                 "end_time": {
                     "type": "string"
                 },
-                "extra_data": {
-                    "type": "string"
-                },
-                "id": {
+                "exception_id": {
                     "type": "integer"
                 },
-                "is_deleted": {
+                "is_moved": {
+                    "type": "boolean"
+                },
+                "is_recurring": {
                     "type": "boolean"
                 },
                 "location": {
                     "type": "string"
                 },
-                "position": {
-                    "type": "integer"
+                "original_start_time": {
+                    "type": "string"
                 },
                 "priority": {
                     "type": "string"
                 },
-                "recurrence_pattern": {
-                    "type": "string"
+                "schedule_id": {
+                    "type": "integer"
                 },
                 "start_time": {
                     "type": "string"
@@ -4490,30 +7722,12 @@ const docTemplate = `{
                 "title": {
                     "type": "string"
                 },
-                "updated_at": {
-                    "type": "string"
-                },
-                "video_transcript": {
-                    "type": "string"
-                },
-                "visibility": {
-                    "type": "string"
-                },
-                "workspace": {
-                    "$ref": "#/definitions/models.TwWorkspace"
-                },
-                "workspaceUser": {
-                    "$ref": "#/definitions/models.TwWorkspaceUser"
-                },
                 "workspace_id": {
                     "type": "integer"
-                },
-                "workspace_user_id": {
-                    "type": "integer"
                 }
             }
         },
-        "models.TwScheduleLog": {
+        "schedule_log_dtos.TwScheduleLogResponse": {
             "type": "object",
             "properties": {
                 "action": {
@@ -4522,73 +7736,94 @@ const docTemplate = `{
                 "created_at": {
                     "type": "string"
                 },
-                "deleted_at": {
+                "description": {
                     "type": "string"
                 },
-                "description": {
+                "email": {
                     "type": "string"
                 },
                 "field_changed": {
                     "type": "string"
                 },
+                "first_name": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "is_verified": {
+                    "type": "boolean"
+                },
+                "last_name": {
+                    "type": "string"
+                },
                 "new_value": {
                     "type": "string"
                 },
                 "old_value": {
                     "type": "string"
                 },
-                "schedule": {
-                    "$ref": "#/definitions/models.TwSchedule"
+                "profile_picture": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
                 },
                 "schedule_id": {
                     "type": "integer"
                 },
+                "status_workspace_user": {
+                    "type": "string"
+                },
                 "updated_at": {
                     "type": "string"
                 },
-                "workspaceUser": {
-                    "$ref": "#/definitions/models.TwWorkspaceUser"
+                "user_id": {
+                    "type": "integer"
                 },
                 "workspace_user_id": {
                     "type": "integer"
                 }
             }
         },
-        "models.TwScheduleParticipant": {
+        "schedule_participant_dtos.ScheduleParticipantInfo": {
             "type": "object",
             "properties": {
-                "assignByUser": {
-                    "$ref": "#/definitions/models.TwWorkspaceUser"
-                },
                 "assign_at": {
                     "type": "string"
                 },
                 "assign_by": {
                     "type": "integer"
                 },
-                "created_at": {
+                "email": {
                     "type": "string"
                 },
-                "deleted_at": {
+                "first_name": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "invitation_sent_at": {
+                "invitation_sent_at": {
+                    "type": "string"
+                },
+                "invitation_status": {
+                    "type": "string"
+                },
+                "is_verified": {
+                    "type": "boolean"
+                },
+                "last_name": {
                     "type": "string"
                 },
-                "invitation_status": {
+                "profile_picture": {
                     "type": "string"
                 },
                 "response_time": {
                     "type": "string"
                 },
-                "schedule": {
-                    "$ref": "#/definitions/models.TwSchedule"
+                "role": {
+                    "type": "string"
                 },
                 "schedule_id": {
                     "type": "integer"
@@ -4596,307 +7831,341 @@ const docTemplate = `{
                 "status": {
                     "type": "string"
                 },
-                "updated_at": {
+                "status_workspace_user": {
                     "type": "string"
                 },
-                "workspaceUser": {
-                    "$ref": "#/definitions/models.TwWorkspaceUser"
+                "user_id": {
+                    "type": "integer"
                 },
                 "workspace_user_id": {
                     "type": "integer"
                 }
             }
         },
-        "models.TwUser": {
+        "schedule_template.TemplateRequest": {
             "type": "object",
             "properties": {
-                "calendarSettings": {
-                    "type": "string"
-                },
-                "created_at": {
-                    "type": "string"
+                "checklist": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scheduletemplate.ChecklistItem"
+                    }
                 },
-                "deleted_at": {
+                "description": {
                     "type": "string"
                 },
-                "email": {
-                    "type": "string"
+                "duration_minutes": {
+                    "type": "integer"
                 },
-                "firstName": {
+                "location": {
                     "type": "string"
                 },
-                "googleId": {
+                "name": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "integer"
-                },
-                "isActive": {
-                    "type": "boolean"
-                },
-                "isVerified": {
-                    "type": "boolean"
+                "participants": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "description": "Participants are workspace user IDs added besides the creator."
                 },
-                "lastLoginAt": {
+                "priority": {
                     "type": "string"
                 },
-                "lastName": {
-                    "type": "string"
+                "reminders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scheduletemplate.Reminder"
+                    }
                 },
-                "locale": {
+                "title": {
                     "type": "string"
                 },
-                "notificationSettings": {
+                "visibility": {
                     "type": "string"
+                }
+            }
+        },
+        "scheduletemplate.ChecklistItem": {
+            "type": "object",
+            "properties": {
+                "done": {
+                    "type": "boolean"
                 },
-                "profilePicture": {
+                "text": {
                     "type": "string"
-                },
-                "role": {
+                }
+            }
+        },
+        "scheduletemplate.Reminder": {
+            "type": "object",
+            "properties": {
+                "method": {
                     "type": "string"
                 },
-                "timezone": {
-                    "type": "string"
+                "minutes_before": {
+                    "type": "integer",
+                    "description": "MinutesBefore is how long before the start the reminder goes off."
                 },
-                "updated_at": {
-                    "type": "string"
+                "type": {
+                    "type": "string",
+                    "description": "Type is \"only me\" for the creator alone, or anything else for every\nparticipant, as on reminders."
                 }
             }
         },
-        "models.TwUserEmail": {
+        "scheduletemplate.Template": {
             "type": "object",
             "properties": {
+                "checklist": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scheduletemplate.ChecklistItem"
+                    }
+                },
                 "created_at": {
                     "type": "string"
                 },
+                "created_by": {
+                    "type": "integer"
+                },
                 "deleted_at": {
                     "type": "string"
                 },
-                "email": {
+                "description": {
                     "type": "string"
                 },
-                "expires_at": {
-                    "type": "string"
+                "duration_minutes": {
+                    "type": "integer",
+                    "description": "DurationMinutes is the time from the start to the end of a schedule."
                 },
                 "id": {
                     "type": "integer"
                 },
-                "is_linked_to": {
-                    "type": "integer"
+                "location": {
+                    "type": "string"
                 },
-                "status": {
+                "name": {
+                    "type": "string",
+                    "description": "Name tells templates apart; Title is the title of the schedules."
+                },
+                "participants": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "description": "Participants are the workspace users added besides the creator."
+                },
+                "priority": {
+                    "type": "string"
+                },
+                "reminders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/scheduletemplate.Reminder"
+                    }
+                },
+                "title": {
                     "type": "string"
                 },
                 "updated_at": {
                     "type": "string"
                 },
-                "user": {
-                    "$ref": "#/definitions/models.TwUser"
+                "visibility": {
+                    "type": "string"
                 },
-                "user_id": {
+                "workspace_id": {
                     "type": "integer"
                 }
             }
         },
-        "models.TwWorkspace": {
+        "search.Highlight": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "deleted_at": {
+                "field": {
                     "type": "string"
                 },
-                "description": {
-                    "type": "string"
-                },
-                "extra_data": {
+                "snippet": {
                     "type": "string"
+                }
+            }
+        },
+        "search.Result": {
+            "type": "object",
+            "properties": {
+                "highlights": {
+                    "description": "Highlights are the matching fields, with the matched words marked.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/search.Highlight"
+                    }
                 },
                 "id": {
+                    "description": "ID is the id of the schedule, comment or document; transcripts use\ntheir schedule's id.",
                     "type": "integer"
                 },
-                "is_deleted": {
-                    "type": "boolean"
+                "schedule_id": {
+                    "type": "integer"
                 },
-                "key": {
+                "schedule_title": {
                     "type": "string"
                 },
-                "title": {
-                    "type": "string"
+                "score": {
+                    "type": "number"
                 },
                 "type": {
                     "type": "string"
-                },
-                "updated_at": {
-                    "type": "string"
                 }
             }
         },
-        "models.TwWorkspaceLog": {
+        "user_email_dtos.SearchUserEmailResponse": {
             "type": "object",
             "properties": {
-                "action": {
-                    "type": "string"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "deleted_at": {
-                    "type": "string"
-                },
-                "description": {
+                "email": {
                     "type": "string"
                 },
-                "field_changed": {
+                "first_name": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "new_value": {
-                    "type": "string"
-                },
-                "old_value": {
+                "last_name": {
                     "type": "string"
                 },
-                "updated_at": {
+                "profile_picture": {
                     "type": "string"
-                },
-                "workspace": {
-                    "$ref": "#/definitions/models.TwWorkspace"
-                },
-                "workspaceUser": {
-                    "$ref": "#/definitions/models.TwWorkspaceUser"
-                },
-                "workspace_id": {
-                    "type": "integer"
-                },
-                "workspace_user_id": {
-                    "type": "integer"
                 }
             }
         },
-        "models.TwWorkspaceUser": {
+        "webhook.Delivery": {
             "type": "object",
             "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
                 "created_at": {
                     "type": "string"
                 },
-                "deleted_at": {
+                "error": {
                     "type": "string"
                 },
-                "extra_data": {
+                "event_type": {
                     "type": "string"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "is_active": {
-                    "type": "boolean"
-                },
-                "is_verified": {
-                    "type": "boolean"
-                },
-                "role": {
+                "last_attempt_at": {
                     "type": "string"
                 },
-                "status": {
+                "next_attempt_at": {
                     "type": "string"
                 },
-                "updated_at": {
+                "payload": {
                     "type": "string"
                 },
-                "userEmail": {
-                    "$ref": "#/definitions/models.TwUserEmail"
+                "response_body": {
+                    "type": "string"
                 },
-                "user_email_id": {
+                "response_status": {
                     "type": "integer"
                 },
-                "workspace": {
-                    "$ref": "#/definitions/models.TwWorkspace"
+                "status": {
+                    "type": "string"
                 },
-                "workspace_id": {
+                "subscription_id": {
                     "type": "integer"
                 },
-                "workspace_key": {
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "schedule_participant_dtos.ScheduleParticipantInfo": {
+        "webhook.DispatchResult": {
             "type": "object",
             "properties": {
-                "assign_at": {
-                    "type": "string"
-                },
-                "assign_by": {
+                "failed": {
                     "type": "integer"
                 },
-                "email": {
-                    "type": "string"
-                },
-                "first_name": {
-                    "type": "string"
-                },
-                "id": {
+                "retrying": {
                     "type": "integer"
                 },
-                "invitation_sent_at": {
-                    "type": "string"
-                },
-                "invitation_status": {
-                    "type": "string"
+                "succeeded": {
+                    "type": "integer"
+                }
+            }
+        },
+        "webhook_subscription.SubscriptionRequest": {
+            "type": "object",
+            "properties": {
+                "event_types": {
+                    "description": "EventTypes filters the events sent; empty means all of them.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "is_verified": {
+                "is_active": {
                     "type": "boolean"
                 },
-                "last_name": {
+                "secret": {
+                    "description": "Secret signs the deliveries; one is generated when it is left empty on create.",
                     "type": "string"
                 },
-                "profile_picture": {
+                "url": {
                     "type": "string"
-                },
-                "response_time": {
+                }
+            }
+        },
+        "webhook_subscription.SubscriptionResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
                     "type": "string"
                 },
-                "role": {
-                    "type": "string"
+                "created_by": {
+                    "type": "integer"
                 },
-                "schedule_id": {
+                "event_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
                     "type": "integer"
                 },
-                "status": {
+                "is_active": {
+                    "type": "boolean"
+                },
+                "secret": {
+                    "description": "Secret is only returned when the subscription is created.",
                     "type": "string"
                 },
-                "status_workspace_user": {
+                "updated_at": {
                     "type": "string"
                 },
-                "user_id": {
-                    "type": "integer"
+                "url": {
+                    "type": "string"
                 },
-                "workspace_user_id": {
+                "workspace_id": {
                     "type": "integer"
                 }
             }
         },
-        "user_email_dtos.SearchUserEmailResponse": {
+        "workspace_event.EventsResponse": {
             "type": "object",
             "properties": {
-                "email": {
-                    "type": "string"
-                },
-                "first_name": {
-                    "type": "string"
-                },
-                "id": {
+                "cursor": {
+                    "description": "Cursor is the value to resume from: the last event returned, or the\nrequested cursor when there is nothing new.",
                     "type": "integer"
                 },
-                "last_name": {
-                    "type": "string"
-                },
-                "profile_picture": {
-                    "type": "string"
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/realtime.Event"
+                    }
                 }
             }
         },
@@ -4964,7 +8233,20 @@ const docTemplate = `{
                 }
             }
         }
-    }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "description": "Service token sent as \"Bearer \u003cJWT\u003e\".",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        }
+    },
+    "security": [
+        {
+            "BearerAuth": []
+        }
+    ]
 }`
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
@@ -4974,7 +8256,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "",
 	Schemes:          []string{},
 	Title:            "timewise-dbms",
-	Description:      "Timewise database management system",
+	Description:      "Timewise database management system\nEvery /dbms/v1 route requires either a verified TLS client certificate or an HS256 service token with the dbms.read, dbms.write or dbms.admin scope.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
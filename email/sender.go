@@ -0,0 +1,146 @@
+// Package email composes and sends the emails of the DMS. Messages are
+// rendered from the templates in templates/ and sent through an EmailSender,
+// so that tests and local runs can capture them instead of using SMTP.
+package email
+
+import (
+	"bytes"
+	"dbms/config"
+	"errors"
+	"fmt"
+	"gopkg.in/gomail.v2"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	SenderSMTP   = "smtp"
+	SenderFile   = "file"
+	SenderMemory = "memory"
+)
+
+// Message is an email with an HTML body and its plain text alternative.
+type Message struct {
+	To      string
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// EmailSender delivers messages.
+type EmailSender interface {
+	Send(message Message) error
+}
+
+// NewSender returns the sender chosen by EMAIL.SENDER, SMTP by default.
+func NewSender(cfg *config.Config) (EmailSender, error) {
+	switch cfg.EmailSender {
+	case "", SenderSMTP:
+		if cfg.SMTPHost == "" {
+			return nil, errors.New("SMTP.HOST is not set")
+		}
+		return NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.EmailFrom), nil
+	case SenderFile:
+		if cfg.EmailFile == "" {
+			return nil, errors.New("EMAIL.FILE is not set")
+		}
+		return &FileSender{Path: cfg.EmailFile, From: cfg.EmailFrom}, nil
+	case SenderMemory:
+		return &MemorySender{}, nil
+	}
+	return nil, fmt.Errorf("unknown EMAIL.SENDER %q", cfg.EmailSender)
+}
+
+// SMTPSender sends messages through an SMTP server.
+type SMTPSender struct {
+	Dialer *gomail.Dialer
+	From   string
+}
+
+// NewSMTPSender sends as from, or as username when from is empty.
+func NewSMTPSender(host string, port int, username string, password string, from string) *SMTPSender {
+	if from == "" {
+		from = username
+	}
+	return &SMTPSender{Dialer: gomail.NewDialer(host, port, username, password), From: from}
+}
+
+func (s *SMTPSender) Send(message Message) error {
+	return s.Dialer.DialAndSend(compose(s.From, message))
+}
+
+// FileSender appends messages to an mbox file, which mail clients can open.
+// It is meant for development.
+type FileSender struct {
+	Path string
+	From string
+
+	mu sync.Mutex
+}
+
+func (s *FileSender) Send(message Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	file, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	from := s.From
+	if from == "" {
+		from = "dms@localhost"
+	}
+	var raw bytes.Buffer
+	if _, err := compose(from, message).WriteTo(&raw); err != nil {
+		return err
+	}
+	// Quote body lines that would read as the start of the next message.
+	body := fromLine.ReplaceAll(raw.Bytes(), []byte(">$1"))
+	_, err = fmt.Fprintf(file, "From %s %s\n%s\n\n", from, time.Now().UTC().Format(time.ANSIC), body)
+	return err
+}
+
+var fromLine = regexp.MustCompile(`(?m)^(>*From )`)
+
+// MemorySender keeps the messages it is given.
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func (s *MemorySender) Send(message Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, message)
+	return nil
+}
+
+// Messages returns the messages sent so far.
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// Reset forgets the messages sent so far.
+func (s *MemorySender) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+}
+
+func compose(from string, message Message) *gomail.Message {
+	m := gomail.NewMessage()
+	m.SetHeader("From", from)
+	m.SetHeader("To", message.To)
+	m.SetHeader("Subject", message.Subject)
+	m.SetBody("text/plain", message.Text)
+	if message.HTML != "" {
+		m.AddAlternative("text/html", message.HTML)
+	}
+	return m
+}
@@ -0,0 +1,187 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+	"time"
+)
+
+// Templates are read from templates/<locale>/<name>.html and <name>.txt. The
+// text template also defines "subject". Every HTML template is rendered in
+// templates/layout.html.
+//
+//go:embed templates
+var templateFS embed.FS
+
+const DefaultLocale = "en"
+
+const (
+	TemplateReminder     = "reminder"
+	TemplateNotification = "notification"
+	TemplateInvitation   = "invitation"
+	TemplateEmailLink    = "email_link"
+	TemplateDigest       = "digest"
+)
+
+// ReminderData fills the reminder template.
+type ReminderData struct {
+	Recipient            string
+	WorkspaceTitle       string
+	WorkspaceDescription string
+	ScheduleTitle        string
+	ScheduleDescription  string
+	StartTime            *time.Time
+	EndTime              *time.Time
+	// Location is the recipient's timezone, which times are shown in; UTC
+	// when nil.
+	Location *time.Location
+}
+
+// NotificationData fills the notification template. Link is optional.
+type NotificationData struct {
+	Title   string
+	Message string
+	Link    string
+}
+
+// DigestData fills the daily digest template.
+type DigestData struct {
+	Recipient     string
+	Notifications []NotificationData
+}
+
+// InvitationData fills the workspace invitation template. It is also the
+// extra_data of invitation notifications, which are emailed with it.
+type InvitationData struct {
+	Recipient      string `json:"recipient"`
+	InviterName    string `json:"inviter_name"`
+	WorkspaceTitle string `json:"workspace_title"`
+	Role           string `json:"role"`
+	Link           string `json:"link"`
+}
+
+// EmailLinkData fills the template that asks the owner of Email to confirm
+// linking it to the account of UserName. It is also the extra_data of
+// email_link notifications, which are emailed with it.
+type EmailLinkData struct {
+	Email     string     `json:"email"`
+	UserName  string     `json:"user_name"`
+	Link      string     `json:"link"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	// Location is the recipient's timezone, which ExpiresAt is shown in; UTC
+	// when nil.
+	Location *time.Location `json:"-"`
+}
+
+var funcs = map[string]interface{}{
+	// datetime shows t in loc, with the zone so that it cannot be misread.
+	"datetime": func(t time.Time, loc *time.Location) string {
+		if loc == nil {
+			loc = time.UTC
+		}
+		return t.In(loc).Format("02/01/2006 15:04 MST")
+	},
+}
+
+type localeTemplates struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+var (
+	parseOnce sync.Once
+	parsed    map[string]map[string]localeTemplates
+	parseErr  error
+)
+
+// Render renders the template name in locale, falling back to DefaultLocale
+// when the locale has no translation. The returned message has no recipient.
+func Render(name string, locale string, data interface{}) (Message, error) {
+	parseOnce.Do(func() { parsed, parseErr = parseTemplates() })
+	if parseErr != nil {
+		return Message{}, parseErr
+	}
+
+	tmpl, ok := parsed[NormalizeLocale(locale)][name]
+	if !ok {
+		if tmpl, ok = parsed[DefaultLocale][name]; !ok {
+			return Message{}, fmt.Errorf("unknown email template %q", name)
+		}
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := tmpl.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.text.Execute(&text, data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Message{}, err
+	}
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    html.String(),
+	}, nil
+}
+
+// NormalizeLocale reduces a locale such as "vi-VN" or "vi_VN" to its language.
+func NormalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "" {
+		return DefaultLocale
+	}
+	return locale
+}
+
+func parseTemplates() (map[string]map[string]localeTemplates, error) {
+	layout, err := htmltemplate.New("layout.html").Funcs(funcs).ParseFS(templateFS, "templates/layout.html")
+	if err != nil {
+		return nil, err
+	}
+
+	locales, err := templateFS.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]map[string]localeTemplates)
+	for _, locale := range locales {
+		if !locale.IsDir() {
+			continue
+		}
+		files, err := templateFS.ReadDir("templates/" + locale.Name())
+		if err != nil {
+			return nil, err
+		}
+		result[locale.Name()] = make(map[string]localeTemplates)
+		for _, file := range files {
+			name, ok := strings.CutSuffix(file.Name(), ".txt")
+			if !ok {
+				continue
+			}
+			dir := "templates/" + locale.Name() + "/"
+			text, err := texttemplate.New(file.Name()).Funcs(funcs).ParseFS(templateFS, dir+file.Name())
+			if err != nil {
+				return nil, err
+			}
+			html, err := layout.Clone()
+			if err != nil {
+				return nil, err
+			}
+			if html, err = html.ParseFS(templateFS, dir+name+".html"); err != nil {
+				return nil, err
+			}
+			result[locale.Name()][name] = localeTemplates{html: html, text: text}
+		}
+	}
+	return result, nil
+}
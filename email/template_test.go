@@ -0,0 +1,102 @@
+package email
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderReminderInRecipientLocaleAndTimezone(t *testing.T) {
+	start := time.Date(2026, 10, 16, 2, 30, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	data := ReminderData{
+		Recipient:      "an@example.com",
+		WorkspaceTitle: "Team",
+		ScheduleTitle:  "Standup",
+		StartTime:      &start,
+		EndTime:        &end,
+		Location:       time.FixedZone("ICT", 7*60*60),
+	}
+
+	tests := []struct {
+		locale  string
+		subject string
+		text    []string
+		html    []string
+	}{
+		{
+			locale:  "en",
+			subject: "Reminder: Standup",
+			text:    []string{"Hello an@example.com,", "Start Time: 16/10/2026 09:30 ICT", "End Time: 16/10/2026 10:30 ICT"},
+			html:    []string{"<strong>Start Time:</strong> 16/10/2026 09:30 ICT"},
+		},
+		{
+			locale:  "vi-VN",
+			subject: "Nhắc nhở: Standup",
+			text:    []string{"Xin chào an@example.com,", "Bắt đầu: 16/10/2026 09:30 ICT", "Kết thúc: 16/10/2026 10:30 ICT"},
+			html:    []string{"<strong>Bắt đầu:</strong> 16/10/2026 09:30 ICT"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			sender := &MemorySender{}
+			message, err := Render(TemplateReminder, tt.locale, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			message.To = data.Recipient
+			if err := sender.Send(message); err != nil {
+				t.Fatal(err)
+			}
+
+			sent := sender.Messages()
+			if len(sent) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(sent))
+			}
+			if sent[0].To != data.Recipient {
+				t.Errorf("To = %q, want %q", sent[0].To, data.Recipient)
+			}
+			if sent[0].Subject != tt.subject {
+				t.Errorf("Subject = %q, want %q", sent[0].Subject, tt.subject)
+			}
+			for _, want := range tt.text {
+				if !strings.Contains(sent[0].Text, want) {
+					t.Errorf("Text does not contain %q:\n%s", want, sent[0].Text)
+				}
+			}
+			for _, want := range tt.html {
+				if !strings.Contains(sent[0].HTML, want) {
+					t.Errorf("HTML does not contain %q:\n%s", want, sent[0].HTML)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderWithoutLocationUsesUTC(t *testing.T) {
+	start := time.Date(2026, 10, 16, 2, 30, 0, 0, time.UTC)
+	message, err := Render(TemplateReminder, "en", ReminderData{ScheduleTitle: "Standup", StartTime: &start})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Start Time: 16/10/2026 02:30 UTC"; !strings.Contains(message.Text, want) {
+		t.Errorf("Text does not contain %q:\n%s", want, message.Text)
+	}
+	if want := "End Time: N/A"; !strings.Contains(message.Text, want) {
+		t.Errorf("Text does not contain %q:\n%s", want, message.Text)
+	}
+}
+
+func TestRenderFallsBackToDefaultLocale(t *testing.T) {
+	message, err := Render(TemplateNotification, "fr", NotificationData{Title: "Hello", Message: "World"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	english, err := Render(TemplateNotification, DefaultLocale, NotificationData{Title: "Hello", Message: "World"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message != english {
+		t.Errorf("fr rendered %+v, want the %s message %+v", message, DefaultLocale, english)
+	}
+}
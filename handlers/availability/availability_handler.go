@@ -0,0 +1,142 @@
+package availability
+
+import (
+	"dbms/availability"
+	"dbms/datetime"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxFreeBusyWindow bounds how far a single request may expand recurrences.
+	maxFreeBusyWindow = 366 * 24 * time.Hour
+	// maxCalendars bounds the workspace users and emails of one request.
+	maxCalendars = 50
+)
+
+type AvailabilityHandler struct {
+	Router fiber.Router
+	DB     *gorm.DB
+}
+
+type FreeBusyResponse struct {
+	From      time.Time          `json:"from"`
+	To        time.Time          `json:"to"`
+	Calendars []FreeBusyCalendar `json:"calendars"`
+}
+
+// FreeBusyCalendar is the busy time of one requested workspace user or email.
+type FreeBusyCalendar struct {
+	WorkspaceUserId int    `json:"workspace_user_id,omitempty"`
+	Email           string `json:"email,omitempty"`
+	// Busy lists the merged intervals in which the calendar attends a
+	// schedule, sorted by start.
+	Busy []availability.Interval `json:"busy"`
+}
+
+// getFreeBusy godoc
+// @Summary Get free/busy
+// @Description Get when workspace users or people are busy within a time window. Busy time comes from the schedules they attend (participants who were removed or declined are free), with recurrences expanded, exceptions applied and all-day schedules taking up their whole dates in UTC. An email stands for its person: the workspaces of every email linked to the same user count. Schedule details are not returned.
+// @Tags availability
+// @Accept json
+// @Produce json
+// @Param workspace_user_ids query string false "Comma separated workspace user IDs"
+// @Param emails query string false "Comma separated emails"
+// @Param from query string true "Window start (RFC 3339)"
+// @Param to query string true "Window end (RFC 3339)"
+// @Success 200 {object} availability.FreeBusyResponse
+// @Failure 400 {object} fiber.Map "Invalid time window, workspace user or email"
+// @Router /dbms/v1/availability/free_busy [get]
+func (h *AvailabilityHandler) getFreeBusy(c *fiber.Ctx) error {
+	from, to, err := parseWindow(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := FreeBusyResponse{From: from, To: to, Calendars: []FreeBusyCalendar{}}
+	var emailWorkspaceUserIds [][]int
+	var workspaceUserIds []int
+	for _, value := range splitList(c.Query("workspace_user_ids")) {
+		workspaceUserId, err := strconv.Atoi(value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid workspace user id " + value,
+			})
+		}
+		response.Calendars = append(response.Calendars, FreeBusyCalendar{WorkspaceUserId: workspaceUserId})
+		workspaceUserIds = append(workspaceUserIds, workspaceUserId)
+	}
+	for _, email := range splitList(c.Query("emails")) {
+		ids, err := availability.WorkspaceUserIdsForEmail(h.DB, email)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+		response.Calendars = append(response.Calendars, FreeBusyCalendar{Email: email})
+		emailWorkspaceUserIds = append(emailWorkspaceUserIds, ids)
+		workspaceUserIds = append(workspaceUserIds, ids...)
+	}
+	if len(response.Calendars) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "workspace_user_ids or emails is required",
+		})
+	}
+	if len(response.Calendars) > maxCalendars {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "at most " + strconv.Itoa(maxCalendars) + " workspace users and emails",
+		})
+	}
+
+	occurrences, err := availability.Occurrences(h.DB, workspaceUserIds, from, to, 0)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	busy := availability.Busy(occurrences)
+	emailIndex := 0
+	for i := range response.Calendars {
+		calendar := &response.Calendars[i]
+		var intervals []availability.Interval
+		if calendar.Email == "" {
+			intervals = busy[calendar.WorkspaceUserId]
+		} else {
+			for _, workspaceUserId := range emailWorkspaceUserIds[emailIndex] {
+				intervals = append(intervals, busy[workspaceUserId]...)
+			}
+			emailIndex++
+		}
+		calendar.Busy = clip(availability.Merge(intervals), from, to)
+	}
+	return c.JSON(response)
+}
+
+// clip cuts intervals down to [from, to), as all-day schedules can start
+// before the window or end after it.
+func clip(intervals []availability.Interval, from, to time.Time) []availability.Interval {
+	for i := range intervals {
+		if intervals[i].Start.Before(from) {
+			intervals[i].Start = from
+		}
+		if intervals[i].End.After(to) {
+			intervals[i].End = to
+		}
+	}
+	return intervals
+}
+
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
+
+func parseWindow(c *fiber.Ctx) (time.Time, time.Time, error) {
+	return datetime.ParseWindow(c.Query("from"), c.Query("to"), maxFreeBusyWindow)
+}
@@ -0,0 +1,248 @@
+package availability
+
+import (
+	"dbms/availability"
+	"dbms/preference"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultMeetingHorizon = 7 * 24 * time.Hour
+	maxMeetingHorizon     = 31 * 24 * time.Hour
+	defaultSlotStep       = 30
+	minSlotStep           = 5
+	defaultSlotCount      = 5
+	maxSlotCount          = 20
+)
+
+// MeetingAttendee is a workspace user or a person, by email. An email stands
+// for every workspace user of the person.
+type MeetingAttendee struct {
+	WorkspaceUserId int    `json:"workspace_user_id,omitempty"`
+	Email           string `json:"email,omitempty"`
+	// WorkingHours replaces the request's working hours for this attendee.
+	WorkingHours *availability.WorkingHours `json:"working_hours,omitempty"`
+}
+
+type MeetingTimesRequest struct {
+	Required        []MeetingAttendee `json:"required"`
+	Optional        []MeetingAttendee `json:"optional"`
+	DurationMinutes int               `json:"duration_minutes"`
+	// From and To bound the search; now and 7 days later by default, at
+	// most 31 days apart.
+	From *time.Time `json:"from"`
+	To   *time.Time `json:"to"`
+	// WorkingHours applies to attendees without their own, in their own
+	// timezone; 09:00 to 17:00, Monday to Friday by default.
+	WorkingHours *availability.WorkingHours `json:"working_hours"`
+	// StepMinutes is the time between candidate starts (default 30).
+	StepMinutes int `json:"step_minutes"`
+	// Count is the number of slots to suggest (default 5, max 20).
+	Count int `json:"count"`
+}
+
+type MeetingTimesResponse struct {
+	Attendees []MeetingAttendeeResponse `json:"attendees"`
+	Slots     []availability.Slot       `json:"slots"`
+}
+
+type MeetingAttendeeResponse struct {
+	// Name is how slots refer to the attendee: the email, or the workspace
+	// user id.
+	Name            string `json:"name"`
+	WorkspaceUserId int    `json:"workspace_user_id,omitempty"`
+	Email           string `json:"email,omitempty"`
+	Optional        bool   `json:"optional"`
+	Timezone        string `json:"timezone"`
+}
+
+// findMeetingTimes godoc
+// @Summary Find meeting times
+// @Description Suggest the best slots for a meeting of the given duration. Every required attendee is free in every slot. Slots are scored by the share of optional attendees who are free and by how well the slot fits in each attendee's working hours, in the timezone of their user. Busy time is the same as in free/busy. Slots do not overlap one another.
+// @Tags availability
+// @Accept json
+// @Produce json
+// @Param body body availability.MeetingTimesRequest true "Attendees, duration and search window"
+// @Success 200 {object} availability.MeetingTimesResponse
+// @Failure 400 {object} fiber.Map "Invalid attendee, duration, window or working hours"
+// @Router /dbms/v1/availability/meeting_times [post]
+func (h *AvailabilityHandler) findMeetingTimes(c *fiber.Ctx) error {
+	var request MeetingTimesRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	query, err := request.query(time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := MeetingTimesResponse{Attendees: []MeetingAttendeeResponse{}}
+	workingHours := availability.DefaultWorkingHours
+	if request.WorkingHours != nil {
+		workingHours = *request.WorkingHours
+	}
+	var attendeeWorkspaceUserIds [][]int
+	var workspaceUserIds []int
+	add := func(attendee MeetingAttendee, optional bool) error {
+		resolved, ids, err := h.resolveAttendee(attendee)
+		if err != nil {
+			return err
+		}
+		resolved.Optional = optional
+		resolved.WorkingHours = workingHours
+		if attendee.WorkingHours != nil {
+			resolved.WorkingHours = *attendee.WorkingHours
+		}
+		if err := resolved.WorkingHours.Validate(); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		query.Attendees = append(query.Attendees, resolved)
+		response.Attendees = append(response.Attendees, MeetingAttendeeResponse{
+			Name:            resolved.Name,
+			WorkspaceUserId: attendee.WorkspaceUserId,
+			Email:           attendee.Email,
+			Optional:        optional,
+			Timezone:        resolved.Location.String(),
+		})
+		attendeeWorkspaceUserIds = append(attendeeWorkspaceUserIds, ids)
+		workspaceUserIds = append(workspaceUserIds, ids...)
+		return nil
+	}
+	for _, attendee := range request.Required {
+		if err := add(attendee, false); err != nil {
+			return respond(c, err)
+		}
+	}
+	for _, attendee := range request.Optional {
+		if err := add(attendee, true); err != nil {
+			return respond(c, err)
+		}
+	}
+
+	occurrences, err := availability.Occurrences(h.DB, workspaceUserIds, query.From, query.To, 0)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	busy := availability.Busy(occurrences)
+	for i := range query.Attendees {
+		var intervals []availability.Interval
+		for _, workspaceUserId := range attendeeWorkspaceUserIds[i] {
+			intervals = append(intervals, busy[workspaceUserId]...)
+		}
+		query.Attendees[i].Busy = availability.Merge(intervals)
+	}
+
+	if response.Slots, err = availability.FindSlots(query); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(response)
+}
+
+// query checks the request and fills in its defaults. Attendees are added
+// once they are resolved.
+func (r MeetingTimesRequest) query(now time.Time) (availability.MeetingQuery, error) {
+	query := availability.MeetingQuery{
+		Duration: time.Duration(r.DurationMinutes) * time.Minute,
+		Step:     time.Duration(r.StepMinutes) * time.Minute,
+		Count:    r.Count,
+	}
+	if len(r.Required) == 0 {
+		return query, errors.New("at least one required attendee is needed")
+	}
+	if len(r.Required)+len(r.Optional) > maxCalendars {
+		return query, errors.New("at most " + strconv.Itoa(maxCalendars) + " attendees")
+	}
+	if r.DurationMinutes <= 0 || r.DurationMinutes > 24*60 {
+		return query, errors.New("duration_minutes must be between 1 and 1440")
+	}
+	if r.StepMinutes == 0 {
+		query.Step = defaultSlotStep * time.Minute
+	} else if r.StepMinutes < minSlotStep {
+		return query, errors.New("step_minutes must be at least " + strconv.Itoa(minSlotStep))
+	}
+	if query.Count <= 0 {
+		query.Count = defaultSlotCount
+	} else if query.Count > maxSlotCount {
+		query.Count = maxSlotCount
+	}
+
+	query.From = now.UTC()
+	if r.From != nil {
+		query.From = r.From.UTC()
+	}
+	query.To = query.From.Add(defaultMeetingHorizon)
+	if r.To != nil {
+		query.To = r.To.UTC()
+	}
+	if query.To.Sub(query.From) < query.Duration {
+		return query, errors.New("the search window is shorter than the meeting")
+	}
+	if query.To.Sub(query.From) > maxMeetingHorizon {
+		return query, errors.New("the search window must not exceed 31 days")
+	}
+	return query, nil
+}
+
+// resolveAttendee finds the workspace users and timezone of an attendee.
+func (h *AvailabilityHandler) resolveAttendee(attendee MeetingAttendee) (availability.Attendee, []int, error) {
+	resolved := availability.Attendee{Location: time.UTC}
+	var userEmailId int
+	var workspaceUserIds []int
+	switch {
+	case attendee.Email != "":
+		resolved.Name = attendee.Email
+		var userEmail models.TwUserEmail
+		err := h.DB.Where("email = ? AND deleted_at IS NULL", attendee.Email).First(&userEmail).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return resolved, nil, fiber.NewError(fiber.StatusBadRequest, "unknown email "+attendee.Email)
+		}
+		if err != nil {
+			return resolved, nil, err
+		}
+		userEmailId = userEmail.ID
+		if workspaceUserIds, err = availability.WorkspaceUserIdsForEmail(h.DB, attendee.Email); err != nil {
+			return resolved, nil, err
+		}
+	case attendee.WorkspaceUserId != 0:
+		resolved.Name = strconv.Itoa(attendee.WorkspaceUserId)
+		var workspaceUser models.TwWorkspaceUser
+		err := h.DB.Where("id = ? AND deleted_at IS NULL", attendee.WorkspaceUserId).First(&workspaceUser).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return resolved, nil, fiber.NewError(fiber.StatusBadRequest, "unknown workspace user "+resolved.Name)
+		}
+		if err != nil {
+			return resolved, nil, err
+		}
+		userEmailId = workspaceUser.UserEmailId
+		workspaceUserIds = []int{workspaceUser.ID}
+	default:
+		return resolved, nil, fiber.NewError(fiber.StatusBadRequest, "an attendee needs a workspace_user_id or an email")
+	}
+
+	preferences, err := preference.LoadForUserEmail(h.DB, userEmailId)
+	if err != nil {
+		return resolved, nil, err
+	}
+	resolved.Location = preferences.Location
+	return resolved, workspaceUserIds, nil
+}
+
+// respond writes the response for an error from resolving an attendee.
+func respond(c *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(fiber.Map{
+			"error": fiberErr.Message,
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+}
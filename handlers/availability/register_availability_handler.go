@@ -0,0 +1,17 @@
+package availability
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func RegisterAvailabilityHandler(router fiber.Router, db *gorm.DB) {
+	availabilityHandler := AvailabilityHandler{
+		Router: router,
+		DB:     db,
+	}
+
+	// Register all endpoints here
+	router.Get("/free_busy", availabilityHandler.getFreeBusy)
+	router.Post("/meeting_times", availabilityHandler.findMeetingTimes)
+}
@@ -1,11 +1,15 @@
 package board_columns
 
 import (
+	"dbms/lexorank"
+	"dbms/permission"
+	"dbms/realtime"
 	"errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/timewise-team/timewise-models/dtos/core_dtos/board_columns_dtos"
 	"github.com/timewise-team/timewise-models/models"
 	"gorm.io/gorm"
+	"math"
 )
 
 // getBoardColumnsByWorkspace godoc
@@ -29,7 +33,7 @@ func (h *BoardColumnsHandler) getBoardColumnsByWorkspace(c *fiber.Ctx) error {
 	// Get the board columns
 	if result := h.DB.Where("workspace_id = ?", workspaceID).
 		Where("deleted_at IS NULL").
-		Order("position").
+		Order(lexorank.Order).
 		Find(&boardColumns); result.Error != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": result.Error.Error(),
@@ -40,6 +44,9 @@ func (h *BoardColumnsHandler) getBoardColumnsByWorkspace(c *fiber.Ctx) error {
 			"message": "Failed to get board columns",
 		})
 	}
+	for i := range boardColumns {
+		boardColumns[i].Position = i + 1
+	}
 	// Return the response
 	return c.JSON(boardColumns)
 }
@@ -63,17 +70,23 @@ func (h *BoardColumnsHandler) getBoardColumnById(c *fiber.Ctx) error {
 		}
 		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
+	position, err := lexorank.BoardColumns.Position(h.DB, boardColumn.WorkspaceId, boardColumn.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	boardColumn.Position = position
 	return c.JSON(boardColumn)
 }
 
 // deleteBoardColumn godoc
 // @Summary Delete board column
-// @Description Delete board column
+// @Description Delete board column. The acting workspace user is the caller's membership of the column's workspace, found through the user_id claim of its token. Requires the dbms.admin scope.
 // @Tags board_columns
 // @Accept json
 // @Produce json
 // @Param id path int true "Board column ID"
 // @Success 204
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
 // @Router /dbms/v1/board_columns/{id} [delete]
 func (h *BoardColumnsHandler) deleteBoardColumn(c *fiber.Ctx) error {
 	boardColumnId := c.Params("board_column_id")
@@ -87,8 +100,22 @@ func (h *BoardColumnsHandler) deleteBoardColumn(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
+	actorId, err := permission.Actor(c, h.DB, boardColumn.WorkspaceId)
+	if err != nil {
+		return permission.Respond(c, err)
+	}
+	if _, err := permission.Authorize(h.DB, boardColumn.WorkspaceId, actorId, permission.ActionDeleteBoardColumn); err != nil {
+		return permission.Respond(c, err)
+	}
+
 	// Update the deleted_at field using gorm.Expr("NOW()")
-	if err := h.DB.Model(&boardColumn).Update("deleted_at", gorm.Expr("NOW()")).Error; err != nil {
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&boardColumn).Update("deleted_at", gorm.Expr("NOW()")).Error; err != nil {
+			return err
+		}
+		return publishColumnEvent(tx, realtime.ColumnDeleted, boardColumn, 0)
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
@@ -121,11 +148,17 @@ func (h *BoardColumnsHandler) updateBoardColumn(c *fiber.Ctx) error {
 			"message": err.Error(),
 		})
 	}
-	if err := h.DB.Model(&boardColumn).
-		Updates(map[string]interface{}{
-			"name":       updatedBoardColumn.Name,
-			"updated_at": gorm.Expr("NOW()"),
-		}).Error; err != nil {
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&boardColumn).
+			Updates(map[string]interface{}{
+				"name":       updatedBoardColumn.Name,
+				"updated_at": gorm.Expr("NOW()"),
+			}).Error; err != nil {
+			return err
+		}
+		return publishColumnEvent(tx, realtime.ColumnUpdated, boardColumn, 0)
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
@@ -180,8 +213,12 @@ func (h *BoardColumnsHandler) getBoardColumnField(c *fiber.Ctx) error {
 			}
 			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 		}
+		position, err := lexorank.BoardColumns.Position(h.DB, boardColumn.WorkspaceId, boardColumn.ID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
 		return c.JSON(fiber.Map{
-			"position": boardColumn.Position,
+			"position": position,
 		})
 	}
 	return c.Status(fiber.StatusInternalServerError).SendString("Invalid field")
@@ -250,11 +287,18 @@ func (h *BoardColumnsHandler) updateBoardColumnField(c *fiber.Ctx) error {
 				"message": err.Error(),
 			})
 		}
-		if result := h.DB.Model(&boardColumn).Update("position", updateBoardColumnRequest.Position); result.Error != nil {
-			return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+		if updateBoardColumnRequest.Position < 1 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"message": "Invalid position",
+			})
+		}
+		// Columns are ordered by rank key, so a new position is a move.
+		position, err := h.moveBoardColumn(boardColumn, updateBoardColumnRequest.Position)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 		}
 		return c.JSON(fiber.Map{
-			"position": boardColumn.Position,
+			"position": position,
 		})
 	}
 	return c.Status(fiber.StatusInternalServerError).SendString("Invalid field")
@@ -282,9 +326,43 @@ func (h *BoardColumnsHandler) createBoardColumn(c *fiber.Ctx) error {
 		Position:    createBoardColumnRequest.Position,
 		WorkspaceId: createBoardColumnRequest.WorkspaceId,
 	}
-	// Create the board column
-	if result := h.DB.Create(&boardColumn); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	if boardColumn.Position <= 0 {
+		// No position given: append to the end of the board.
+		boardColumn.Position = math.MaxInt32
+	}
+	// Create the board column with a rank key at the requested position
+	var rankKey string
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lexorank.BoardColumns.Lock(tx, boardColumn.WorkspaceId); err != nil {
+			return err
+		}
+		var err error
+		rankKey, err = lexorank.BoardColumns.KeyAt(tx, boardColumn.WorkspaceId, boardColumn.Position, 0)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&boardColumn).Error; err != nil {
+			return err
+		}
+		if err := lexorank.BoardColumns.Set(tx, boardColumn.ID, rankKey); err != nil {
+			return err
+		}
+		boardColumn.Position, err = lexorank.BoardColumns.Position(tx, boardColumn.WorkspaceId, boardColumn.ID)
+		if err != nil {
+			return err
+		}
+		return publishColumnEvent(tx, realtime.ColumnCreated, boardColumn, boardColumn.Position)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Workspace not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	if lexorank.NeedsRebalance(rankKey) {
+		lexorank.BoardColumns.RebalanceInBackground(h.DB, boardColumn.WorkspaceId)
 	}
 	return c.JSON(boardColumn)
 }
@@ -318,7 +396,7 @@ type RequestBody struct {
 
 // updatePositionAfterDeletion godoc
 // @Summary Update position after deletion
-// @Description Update position after deletion
+// @Description Kept for compatibility: positions now follow rank keys, so deletions leave no gaps and nothing is shifted
 // @Tags board_columns
 // @Accept json
 // @Produce json
@@ -347,21 +425,8 @@ func (h *BoardColumnsHandler) updatePositionAfterDeletion(c *fiber.Ctx) error {
 			"message": "Invalid workspace ID",
 		})
 	}
-	// Update query to decrement the position of all columns with position greater than the specified position in the same workspace
-	err := h.DB.Model(&models.TwBoardColumn{}).
-		Where("position > ? AND workspace_id = ?", position, workspaceId).
-		Where("deleted_at IS NULL").
-		UpdateColumns(map[string]interface{}{
-			"position":   gorm.Expr("position - 1"),
-			"updated_at": gorm.Expr("NOW()"), // Cập nhật trường updated_at
-		}).Error
-
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"message": err.Error(),
-		})
-	}
-
+	// Positions are derived from rank keys, which a deletion leaves without
+	// gaps, so there is nothing left to shift. Kept for older clients.
 	return c.SendStatus(fiber.StatusOK)
 }
 
@@ -413,8 +478,9 @@ func (h *BoardColumnsHandler) getRage(c *fiber.Ctx) error {
 
 	// Lấy các cột trong phạm vi vị trí và workspaceId
 	var columns []models.TwBoardColumn
-	if result := h.DB.Where("position >= ? AND position <= ? AND workspace_id = ?", position1, position2, workspaceId).
+	if result := h.DB.Where("workspace_id = ?", workspaceId).
 		Where("deleted_at IS NULL").
+		Order(lexorank.Order).
 		Find(&columns); result.Error != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": result.Error.Error(),
@@ -425,6 +491,14 @@ func (h *BoardColumnsHandler) getRage(c *fiber.Ctx) error {
 			"message": "Failed to get columns",
 		})
 	}
+	inRange := make([]models.TwBoardColumn, 0, len(columns))
+	for i, column := range columns {
+		column.Position = i + 1
+		if column.Position >= position1 && column.Position <= position2 {
+			inRange = append(inRange, column)
+		}
+	}
+	columns = inRange
 
 	// Trả về danh sách cột
 	return c.JSON(columns)
@@ -453,12 +527,7 @@ func (h *BoardColumnsHandler) updatePosition(c *fiber.Ctx) error {
 			"message": "Failed to find the board column",
 		})
 	}
-	err := h.DB.Model(&oldBoardColumn).UpdateColumns(map[string]interface{}{
-		"position":   boardColumn.Position,
-		"updated_at": gorm.Expr("NOW()"),
-	}).Error
-	if err != nil {
-
+	if _, err := h.moveBoardColumn(oldBoardColumn, boardColumn.Position); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": err.Error(),
 		})
@@ -468,3 +537,107 @@ func (h *BoardColumnsHandler) updatePosition(c *fiber.Ctx) error {
 	})
 
 }
+
+// moveBoardColumn moves boardColumn to position in its workspace and returns
+// the position it ends up at. Only the moved column is written: it gets a
+// rank key between its new neighbours, under the workspace lock.
+func (h *BoardColumnsHandler) moveBoardColumn(boardColumn models.TwBoardColumn, position int) (int, error) {
+	var rankKey string
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lexorank.BoardColumns.Lock(tx, boardColumn.WorkspaceId); err != nil {
+			return err
+		}
+		var err error
+		rankKey, err = lexorank.BoardColumns.KeyAt(tx, boardColumn.WorkspaceId, position, boardColumn.ID)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&boardColumn).UpdateColumns(map[string]interface{}{
+			"position":      position,
+			lexorank.Column: rankKey,
+			"updated_at":    gorm.Expr("NOW()"),
+		}).Error; err != nil {
+			return err
+		}
+		position, err = lexorank.BoardColumns.Position(tx, boardColumn.WorkspaceId, boardColumn.ID)
+		if err != nil {
+			return err
+		}
+		return publishColumnEvent(tx, realtime.ColumnMoved, boardColumn, position)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if lexorank.NeedsRebalance(rankKey) {
+		lexorank.BoardColumns.RebalanceInBackground(h.DB, boardColumn.WorkspaceId)
+	}
+	return position, nil
+}
+
+// publishColumnEvent records a board column change on the workspace change feed.
+func publishColumnEvent(tx *gorm.DB, eventType string, boardColumn models.TwBoardColumn, position int) error {
+	return realtime.Publish(tx, boardColumn.WorkspaceId, eventType, boardColumn.ID, realtime.ColumnData{
+		ID:       boardColumn.ID,
+		Name:     boardColumn.Name,
+		Position: position,
+	})
+}
+
+// rebalanceBoardColumns godoc
+// @Summary Rebalance rank keys
+// @Description Respread the rank keys of the workspace's board columns and of the schedules in each of them, keeping their order. Moves do this in the background once keys grow long; this runs it on demand. Requires the dbms.admin scope.
+// @Tags board_columns
+// @Produce json
+// @Param workspace_id path int true "Workspace ID"
+// @Success 200 {object} fiber.Map
+// @Failure 404 {object} fiber.Map "Workspace not found"
+// @Router /dbms/v1/board_columns/workspace/{workspace_id}/rebalance [put]
+func (h *BoardColumnsHandler) rebalanceBoardColumns(c *fiber.Ctx) error {
+	workspaceId, err := c.ParamsInt("workspace_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": "Invalid workspace ID",
+		})
+	}
+
+	var boardColumnIds []int
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lexorank.BoardColumns.Lock(tx, workspaceId); err != nil {
+			return err
+		}
+		if err := lexorank.BoardColumns.Rebalance(tx, workspaceId); err != nil {
+			return err
+		}
+		return tx.Model(&models.TwBoardColumn{}).Where("workspace_id = ?", workspaceId).Pluck("id", &boardColumnIds).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"message": "Workspace not found",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+
+	// Each column is respread under its own lock, so that moves elsewhere on
+	// the board are not held up by the whole rebalance.
+	for _, boardColumnId := range boardColumnIds {
+		err := h.DB.Transaction(func(tx *gorm.DB) error {
+			if err := lexorank.Schedules.Lock(tx, boardColumnId); err != nil {
+				return err
+			}
+			return lexorank.Schedules.Rebalance(tx, boardColumnId)
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+	}
+	return c.JSON(fiber.Map{
+		"message":       "Rank keys rebalanced successfully",
+		"board_columns": len(boardColumnIds),
+	})
+}
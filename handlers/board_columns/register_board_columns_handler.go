@@ -1,6 +1,7 @@
 package board_columns
 
 import (
+	"dbms/middleware"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -21,7 +22,7 @@ func RegisterBoardColumnsHandler(router fiber.Router, db *gorm.DB) {
 	router.Get("/:board_column_id/workspace/:workspace_id", boardColumnsHandler.getBoardColumnById)
 	router.Post("", boardColumnsHandler.createBoardColumn)
 	router.Put("/:board_column_id", boardColumnsHandler.updateBoardColumn)
-	router.Delete("/:board_column_id", boardColumnsHandler.deleteBoardColumn)
+	router.Delete("/:board_column_id", middleware.RequireScope(middleware.ScopeAdmin), boardColumnsHandler.deleteBoardColumn)
 	//router.Get("/:board_column_id/:field", boardColumnsHandler.getBoardColumnField)
 	//router.Put("/:board_column_id/:field", boardColumnsHandler.updateBoardColumnField)
 	router.Get("/workspace/:workspace_id/board_column/:board_column_id", boardColumnsHandler.GetSchedulesByBoardColumn)
@@ -29,5 +30,6 @@ func RegisterBoardColumnsHandler(router fiber.Router, db *gorm.DB) {
 	router.Put("/update_position_after_deletion/position", boardColumnsHandler.updatePositionAfterDeletion)
 	router.Get("/range/position", boardColumnsHandler.getRage)
 	router.Put("/update_position/position", boardColumnsHandler.updatePosition)
+	router.Put("/workspace/:workspace_id/rebalance", middleware.RequireScope(middleware.ScopeAdmin), boardColumnsHandler.rebalanceBoardColumns)
 
 }
@@ -0,0 +1,298 @@
+package calendar
+
+import (
+	"dbms/ical"
+	"dbms/recurrence"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+type scheduleAttendee struct {
+	ScheduleId       int
+	Status           string
+	InvitationStatus string
+	Email            string
+	FirstName        string
+	LastName         string
+}
+
+// getWorkspaceFeed godoc
+// @Summary Get workspace iCalendar feed
+// @Description Export every schedule of the workspace as an iCalendar (.ics) feed
+// @Tags calendar
+// @Produce text/calendar
+// @Param workspace_id path int true "Workspace ID"
+// @Success 200 {string} string "iCalendar feed"
+// @Failure 404 {string} string "Workspace not found"
+// @Router /dbms/v1/calendar/workspace/{workspace_id}/feed.ics [get]
+func (h *CalendarHandler) getWorkspaceFeed(c *fiber.Ctx) error {
+	var workspace models.TwWorkspace
+	if err := h.DB.Where("id = ? AND deleted_at IS NULL", c.Params("workspace_id")).First(&workspace).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).SendString("Workspace not found")
+		}
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	var schedules []models.TwSchedule
+	if err := h.DB.
+		Where("workspace_id = ? AND is_deleted = false AND start_time IS NOT NULL", workspace.ID).
+		Order("start_time").
+		Find(&schedules).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	calendar, err := h.buildCalendar(workspace.Title, schedules, nil)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	return sendCalendar(c, calendar)
+}
+
+// getUserEmailFeed godoc
+// @Summary Get user iCalendar feed
+// @Description Export every schedule the email participates in, across workspaces, as an iCalendar (.ics) feed
+// @Tags calendar
+// @Produce text/calendar
+// @Param email path string true "Email"
+// @Success 200 {string} string "iCalendar feed"
+// @Failure 400 {string} string "Invalid email"
+// @Router /dbms/v1/calendar/email/{email}/feed.ics [get]
+func (h *CalendarHandler) getUserEmailFeed(c *fiber.Ctx) error {
+	email, err := url.QueryUnescape(c.Params("email"))
+	if err != nil || email == "" {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid email")
+	}
+
+	var workspaceUserIds []int
+	if err := h.DB.Table("tw_workspace_users").
+		Select("tw_workspace_users.id").
+		Joins("JOIN tw_user_emails ON tw_user_emails.id = tw_workspace_users.user_email_id").
+		Where("tw_user_emails.email = ?", email).
+		Where("tw_user_emails.deleted_at IS NULL AND tw_workspace_users.deleted_at IS NULL").
+		Where("tw_workspace_users.status = 'joined' AND tw_workspace_users.is_active = true").
+		Scan(&workspaceUserIds).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	schedules := make([]models.TwSchedule, 0)
+	if len(workspaceUserIds) > 0 {
+		if err := h.DB.Table("tw_schedules").
+			Select("DISTINCT tw_schedules.*").
+			Joins("JOIN tw_schedule_participants ON tw_schedule_participants.schedule_id = tw_schedules.id").
+			Where("tw_schedule_participants.workspace_user_id IN (?)", workspaceUserIds).
+			Where("tw_schedule_participants.deleted_at IS NULL AND tw_schedule_participants.invitation_status != 'removed'").
+			Where("tw_schedules.is_deleted = false AND tw_schedules.start_time IS NOT NULL").
+			Order("tw_schedules.start_time").
+			Find(&schedules).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+	}
+
+	calendar, err := h.buildCalendar(email, schedules, workspaceUserIds)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	return sendCalendar(c, calendar)
+}
+
+// buildCalendar converts schedules into VEVENTs. Personal ("only me")
+// reminders are only exported when they belong to one of ownerWorkspaceUserIds.
+func (h *CalendarHandler) buildCalendar(name string, schedules []models.TwSchedule, ownerWorkspaceUserIds []int) (ical.Calendar, error) {
+	calendar := ical.Calendar{Name: name}
+	if len(schedules) == 0 {
+		return calendar, nil
+	}
+
+	scheduleIds := make([]int, 0, len(schedules))
+	for _, schedule := range schedules {
+		scheduleIds = append(scheduleIds, schedule.ID)
+	}
+
+	var exceptions []models.TwRecurrenceException
+	if err := h.DB.Where("schedule_id IN (?) AND deleted_at IS NULL", scheduleIds).Find(&exceptions).Error; err != nil {
+		return calendar, err
+	}
+	var reminders []models.TwReminder
+	if err := h.DB.Where("schedule_id IN (?) AND deleted_at IS NULL", scheduleIds).Find(&reminders).Error; err != nil {
+		return calendar, err
+	}
+	var attendees []scheduleAttendee
+	if err := h.DB.Table("tw_schedule_participants AS sp").
+		Select("sp.schedule_id, sp.status, sp.invitation_status, ue.email, u.first_name, u.last_name").
+		Joins("JOIN tw_workspace_users AS wu ON wu.id = sp.workspace_user_id").
+		Joins("JOIN tw_user_emails AS ue ON ue.id = wu.user_email_id").
+		Joins("LEFT JOIN tw_users AS u ON u.id = ue.user_id").
+		Where("sp.schedule_id IN (?)", scheduleIds).
+		Where("sp.deleted_at IS NULL AND wu.deleted_at IS NULL AND ue.deleted_at IS NULL").
+		Scan(&attendees).Error; err != nil {
+		return calendar, err
+	}
+
+	owners := make(map[int]bool)
+	for _, id := range ownerWorkspaceUserIds {
+		owners[id] = true
+	}
+
+	for _, schedule := range schedules {
+		event := ical.Event{
+			UID:          ScheduleUID(schedule.ID),
+			Start:        *schedule.StartTime,
+			AllDay:       schedule.AllDay,
+			Summary:      schedule.Title,
+			Description:  schedule.Description,
+			Location:     schedule.Location,
+			Class:        classFromVisibility(schedule.Visibility),
+			Priority:     priorityFromString(schedule.Priority),
+			LastModified: derefTime(schedule.UpdatedAt),
+			Created:      derefTime(schedule.CreatedAt),
+		}
+		if schedule.EndTime != nil {
+			event.End = *schedule.EndTime
+		}
+
+		for _, attendee := range attendees {
+			if attendee.ScheduleId != schedule.ID {
+				continue
+			}
+			if attendee.Status == "creator" {
+				event.Organizer = &ical.Attendee{Email: attendee.Email, Name: fullName(attendee)}
+			}
+			partStat, ok := partStatFromInvitationStatus(attendee.InvitationStatus)
+			if !ok {
+				continue
+			}
+			event.Attendees = append(event.Attendees, ical.Attendee{
+				Email:    attendee.Email,
+				Name:     fullName(attendee),
+				PartStat: partStat,
+			})
+		}
+
+		for _, reminder := range reminders {
+			if reminder.ScheduleId != schedule.ID {
+				continue
+			}
+			if reminder.Type == "only me" && !owners[reminder.WorkspaceUserID] {
+				continue
+			}
+			alarm := ical.Alarm{Description: "Reminder: " + schedule.Title}
+			if strings.TrimSpace(schedule.RecurrencePattern) != "" {
+				// Keep the alarm attached to every instance of a recurring schedule.
+				alarm.Offset = reminder.ReminderTime.Sub(*schedule.StartTime)
+			} else {
+				reminderTime := reminder.ReminderTime
+				alarm.At = &reminderTime
+			}
+			event.Alarms = append(event.Alarms, alarm)
+		}
+
+		var overrides []ical.Event
+		if strings.TrimSpace(schedule.RecurrencePattern) != "" {
+			rule, err := recurrence.Parse(schedule.RecurrencePattern)
+			if err != nil {
+				log.Printf("Exporting schedule %d without invalid recurrence pattern: %v", schedule.ID, err)
+			} else {
+				event.RRule = rule.String()
+				for _, exception := range exceptions {
+					if exception.ScheduleId != schedule.ID {
+						continue
+					}
+					originalStart, ok := rule.OccurrenceOn(*schedule.StartTime, exception.ExceptionDate)
+					if !ok {
+						continue
+					}
+					if exception.IsCancelled {
+						event.ExDates = append(event.ExDates, originalStart)
+						continue
+					}
+					if exception.NewStartTime.IsZero() {
+						continue
+					}
+					override := event
+					override.RRule = ""
+					override.ExDates = nil
+					override.RecurrenceID = &originalStart
+					override.Start = exception.NewStartTime
+					override.End = exception.NewEndTime
+					if override.End.IsZero() && !event.End.IsZero() {
+						override.End = exception.NewStartTime.Add(event.End.Sub(event.Start))
+					}
+					overrides = append(overrides, override)
+				}
+			}
+		}
+
+		calendar.Events = append(calendar.Events, event)
+		calendar.Events = append(calendar.Events, overrides...)
+	}
+	return calendar, nil
+}
+
+// ScheduleUID returns the stable iCalendar UID of a schedule.
+func ScheduleUID(scheduleId int) string {
+	return fmt.Sprintf("schedule-%d@timewise.space", scheduleId)
+}
+
+func sendCalendar(c *fiber.Ctx, calendar ical.Calendar) error {
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="calendar.ics"`)
+	return c.SendString(calendar.Encode())
+}
+
+func partStatFromInvitationStatus(status string) (string, bool) {
+	switch strings.ToLower(status) {
+	case "removed":
+		return "", false
+	case "joined", "accepted":
+		return "ACCEPTED", true
+	case "declined", "rejected":
+		return "DECLINED", true
+	case "tentative":
+		return "TENTATIVE", true
+	default:
+		return "NEEDS-ACTION", true
+	}
+}
+
+func classFromVisibility(visibility string) string {
+	switch strings.ToLower(visibility) {
+	case "private":
+		return "PRIVATE"
+	case "public":
+		return "PUBLIC"
+	default:
+		return ""
+	}
+}
+
+func priorityFromString(priority string) int {
+	switch strings.ToLower(priority) {
+	case "high", "urgent":
+		return 1
+	case "medium", "normal":
+		return 5
+	case "low":
+		return 9
+	default:
+		return 0
+	}
+}
+
+func fullName(attendee scheduleAttendee) string {
+	return strings.TrimSpace(attendee.FirstName + " " + attendee.LastName)
+}
+
+func derefTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
@@ -0,0 +1,474 @@
+package calendar
+
+import (
+	"dbms/ical"
+	"dbms/lexorank"
+	"dbms/preference"
+	"dbms/realtime"
+	"dbms/recurrence"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ImportedScheduleResponse struct {
+	UID        string    `json:"uid"`
+	ScheduleID int       `json:"schedule_id,omitempty"`
+	Title      string    `json:"title"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	Position   int       `json:"position"`
+	Recurrence string    `json:"recurrence_pattern,omitempty"`
+	Exceptions int       `json:"exceptions"`
+}
+
+type ImportDuplicateResponse struct {
+	UID                string `json:"uid"`
+	Title              string `json:"title"`
+	ExistingScheduleID int    `json:"existing_schedule_id,omitempty"`
+	Reason             string `json:"reason"`
+}
+
+type ImportConflictResponse struct {
+	UID                string    `json:"uid"`
+	Title              string    `json:"title"`
+	StartTime          time.Time `json:"start_time"`
+	EndTime            time.Time `json:"end_time"`
+	ExistingScheduleID int       `json:"existing_schedule_id"`
+	ExistingTitle      string    `json:"existing_title"`
+}
+
+type ImportErrorResponse struct {
+	UID    string `json:"uid"`
+	Title  string `json:"title"`
+	Reason string `json:"reason"`
+}
+
+type ImportCalendarResponse struct {
+	DryRun     bool                       `json:"dry_run"`
+	Imported   []ImportedScheduleResponse `json:"imported"`
+	Duplicates []ImportDuplicateResponse  `json:"duplicates"`
+	Conflicts  []ImportConflictResponse   `json:"conflicts"`
+	Errors     []ImportErrorResponse      `json:"errors"`
+}
+
+// scheduleExtraData is stored in TwSchedule.ExtraData for imported schedules
+// so that re-importing the same calendar can be detected by UID. Timezone is
+// the TZID of the event's DTSTART, which recurrence.Location reads so that
+// the event recurs on the wall clock it was written in.
+type scheduleExtraData struct {
+	IcalUID  string `json:"ical_uid,omitempty"`
+	Timezone string `json:"timezone,omitempty"`
+}
+
+type pendingImport struct {
+	event      ical.Event
+	schedule   models.TwSchedule
+	exceptions []models.TwRecurrenceException
+}
+
+// importCalendar godoc
+// @Summary Import an iCalendar file into a board column
+// @Description Create schedules in the board column from the VEVENTs of an uploaded .ics file. RRULE, EXDATE and RECURRENCE-ID are turned into recurrence patterns and recurrence exceptions; recurring events keep the timezone of their TZID, given as an IANA or Windows name or through a VTIMEZONE. Events in a timezone that cannot be resolved are reported under errors and skipped. Events whose UID was already imported into the workspace are skipped. With dry_run=true nothing is written and the planned import, duplicates and time conflicts are reported.
+// @Tags calendar
+// @Accept multipart/form-data
+// @Produce json
+// @Param board_column_id path int true "Board column ID"
+// @Param workspace_user_id formData int true "Workspace user ID of the importer"
+// @Param file formData file true "iCalendar file"
+// @Param dry_run query bool false "Only report what would be imported"
+// @Success 200 {object} ImportCalendarResponse "Dry run report"
+// @Success 201 {object} ImportCalendarResponse
+// @Failure 400 {string} string "Invalid request or calendar file"
+// @Failure 404 {string} string "Board column not found"
+// @Router /dbms/v1/calendar/board_column/{board_column_id}/import [post]
+func (h *CalendarHandler) importCalendar(c *fiber.Ctx) error {
+	dryRun := c.QueryBool("dry_run", false)
+
+	var boardColumn models.TwBoardColumn
+	if err := h.DB.Where("id = ? AND deleted_at IS NULL", c.Params("board_column_id")).First(&boardColumn).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).SendString("Board column not found")
+		}
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	workspaceUserId, err := strconv.Atoi(c.FormValue("workspace_user_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid workspace_user_id")
+	}
+	var workspaceUser models.TwWorkspaceUser
+	if err := h.DB.
+		Where("id = ? AND workspace_id = ? AND status = 'joined' AND deleted_at IS NULL", workspaceUserId, boardColumn.WorkspaceId).
+		First(&workspaceUser).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusBadRequest).SendString("Workspace user is not a member of the board column's workspace")
+		}
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Missing iCalendar file")
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	defer file.Close()
+	calendar, err := ical.Decode(file)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid iCalendar file: " + err.Error())
+	}
+
+	var existingSchedules []models.TwSchedule
+	if err := h.DB.
+		Where("workspace_id = ? AND is_deleted = false", boardColumn.WorkspaceId).
+		Find(&existingSchedules).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	existingIds := make([]int, 0, len(existingSchedules))
+	creatorIds := make([]int, 0, len(existingSchedules))
+	for _, schedule := range existingSchedules {
+		existingIds = append(existingIds, schedule.ID)
+		creatorIds = append(creatorIds, schedule.CreatedBy)
+	}
+	locations, err := preference.Locations(h.DB, creatorIds)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	var existingExceptions []models.TwRecurrenceException
+	if len(existingIds) > 0 {
+		if err := h.DB.Where("schedule_id IN (?) AND deleted_at IS NULL", existingIds).Find(&existingExceptions).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+	}
+
+	position := 1
+	for _, schedule := range existingSchedules {
+		if schedule.BoardColumnId == boardColumn.ID {
+			position++
+		}
+	}
+
+	response := ImportCalendarResponse{
+		DryRun:     dryRun,
+		Imported:   make([]ImportedScheduleResponse, 0),
+		Duplicates: make([]ImportDuplicateResponse, 0),
+		Conflicts:  make([]ImportConflictResponse, 0),
+		Errors:     make([]ImportErrorResponse, 0),
+	}
+
+	overrides := make(map[string][]ical.Event)
+	for _, event := range calendar.Events {
+		if event.Err != nil {
+			response.Errors = append(response.Errors, ImportErrorResponse{UID: event.UID, Title: event.Summary, Reason: event.Err.Error()})
+			continue
+		}
+		if event.RecurrenceID != nil {
+			overrides[event.UID] = append(overrides[event.UID], event)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var pending []pendingImport
+	for _, event := range calendar.Events {
+		if event.Err != nil || event.RecurrenceID != nil {
+			continue
+		}
+		if event.UID != "" {
+			if seen[event.UID] {
+				response.Duplicates = append(response.Duplicates, ImportDuplicateResponse{
+					UID:    event.UID,
+					Title:  event.Summary,
+					Reason: "UID appears more than once in the file",
+				})
+				continue
+			}
+			seen[event.UID] = true
+			if existingId := findScheduleByUID(existingSchedules, event.UID); existingId != 0 {
+				response.Duplicates = append(response.Duplicates, ImportDuplicateResponse{
+					UID:                event.UID,
+					Title:              event.Summary,
+					ExistingScheduleID: existingId,
+					Reason:             "UID already exists in the workspace",
+				})
+				continue
+			}
+		}
+
+		item, err := buildImport(event, overrides[event.UID], boardColumn, workspaceUserId, position)
+		if err != nil {
+			response.Errors = append(response.Errors, ImportErrorResponse{UID: event.UID, Title: event.Summary, Reason: err.Error()})
+			continue
+		}
+		position++
+		pending = append(pending, item)
+		response.Conflicts = append(response.Conflicts, findConflicts(item, existingSchedules, existingExceptions, locations)...)
+	}
+
+	for uid, events := range overrides {
+		if uid == "" || !seen[uid] {
+			for _, event := range events {
+				response.Errors = append(response.Errors, ImportErrorResponse{
+					UID:    event.UID,
+					Title:  event.Summary,
+					Reason: "RECURRENCE-ID without a matching recurring event",
+				})
+			}
+		}
+	}
+
+	if !dryRun && len(pending) > 0 {
+		var rankKeys []string
+		err := h.DB.Transaction(func(tx *gorm.DB) error {
+			if err := lexorank.Schedules.Lock(tx, boardColumn.ID); err != nil {
+				return err
+			}
+			var err error
+			rankKeys, err = lexorank.Schedules.AppendKeys(tx, boardColumn.ID, len(pending))
+			if err != nil {
+				return err
+			}
+			for i := range pending {
+				if err := createImportedSchedule(tx, &pending[i], workspaceUserId, rankKeys[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+		if lexorank.NeedsRebalance(rankKeys[len(rankKeys)-1]) {
+			lexorank.Schedules.RebalanceInBackground(h.DB, boardColumn.ID)
+		}
+	}
+
+	for _, item := range pending {
+		response.Imported = append(response.Imported, ImportedScheduleResponse{
+			UID:        item.event.UID,
+			ScheduleID: item.schedule.ID,
+			Title:      item.schedule.Title,
+			StartTime:  *item.schedule.StartTime,
+			EndTime:    *item.schedule.EndTime,
+			Position:   item.schedule.Position,
+			Recurrence: item.schedule.RecurrencePattern,
+			Exceptions: len(item.exceptions),
+		})
+	}
+
+	if dryRun {
+		return c.JSON(response)
+	}
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+// buildImport maps a master VEVENT and its RECURRENCE-ID overrides onto a
+// schedule and its recurrence exceptions.
+func buildImport(event ical.Event, overrides []ical.Event, boardColumn models.TwBoardColumn, workspaceUserId int, position int) (pendingImport, error) {
+	start := event.Start.UTC()
+	end := event.End.UTC()
+	if event.End.IsZero() || !event.End.After(event.Start) {
+		if event.AllDay {
+			end = start.AddDate(0, 0, 1)
+		} else {
+			end = start
+		}
+	}
+
+	title := strings.TrimSpace(event.Summary)
+	if title == "" {
+		title = "Untitled event"
+	}
+
+	now := time.Now()
+	schedule := models.TwSchedule{
+		WorkspaceId:   boardColumn.WorkspaceId,
+		BoardColumnId: boardColumn.ID,
+		Title:         title,
+		Description:   event.Description,
+		Location:      event.Location,
+		StartTime:     &start,
+		EndTime:       &end,
+		AllDay:        event.AllDay,
+		CreatedBy:     workspaceUserId,
+		CreatedAt:     &now,
+		UpdatedAt:     &now,
+		Position:      position,
+		Status:        "not yet",
+		Visibility:    visibilityFromClass(event.Class),
+		Priority:      priorityFromInt(event.Priority),
+	}
+	extraData := scheduleExtraData{IcalUID: event.UID}
+	if loc := event.Start.Location(); !event.AllDay && loc != time.UTC {
+		extraData.Timezone = loc.String()
+	}
+	if extraData != (scheduleExtraData{}) {
+		data, err := json.Marshal(extraData)
+		if err != nil {
+			return pendingImport{}, err
+		}
+		schedule.ExtraData = string(data)
+	}
+
+	item := pendingImport{event: event, schedule: schedule}
+	if event.RRule == "" {
+		if len(overrides) > 0 {
+			return item, errors.New("RECURRENCE-ID on an event without RRULE")
+		}
+		return item, nil
+	}
+
+	rule, err := recurrence.Parse(event.RRule)
+	if err != nil {
+		return item, fmt.Errorf("unsupported RRULE: %w", err)
+	}
+	item.schedule.RecurrencePattern = rule.String()
+
+	for _, exDate := range event.ExDates {
+		exDate = exDate.UTC()
+		item.exceptions = append(item.exceptions, models.TwRecurrenceException{
+			ExceptionDate: exDate,
+			NewStartTime:  exDate,
+			NewEndTime:    exDate,
+			IsCancelled:   true,
+		})
+	}
+	duration := end.Sub(start)
+	for _, override := range overrides {
+		newStart := override.Start.UTC()
+		newEnd := override.End.UTC()
+		if override.End.IsZero() || !override.End.After(override.Start) {
+			newEnd = newStart.Add(duration)
+		}
+		item.exceptions = append(item.exceptions, models.TwRecurrenceException{
+			ExceptionDate: override.RecurrenceID.UTC(),
+			NewStartTime:  newStart,
+			NewEndTime:    newEnd,
+		})
+	}
+	return item, nil
+}
+
+func createImportedSchedule(tx *gorm.DB, item *pendingImport, workspaceUserId int, rankKey string) error {
+	if err := tx.Create(&item.schedule).Error; err != nil {
+		return err
+	}
+	if err := lexorank.Schedules.Set(tx, item.schedule.ID, rankKey); err != nil {
+		return err
+	}
+
+	scheduleLog := models.TwScheduleLog{
+		ScheduleId:      item.schedule.ID,
+		WorkspaceUserId: workspaceUserId,
+		Action:          "create schedule",
+		Description:     "Imported from iCalendar",
+	}
+	if err := tx.Create(&scheduleLog).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	participant := models.TwScheduleParticipant{
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		ScheduleId:       item.schedule.ID,
+		WorkspaceUserId:  workspaceUserId,
+		AssignAt:         &now,
+		AssignBy:         workspaceUserId,
+		Status:           "creator",
+		ResponseTime:     &now,
+		InvitationSentAt: &now,
+		InvitationStatus: "joined",
+	}
+	if err := tx.Create(&participant).Error; err != nil {
+		return err
+	}
+
+	for i := range item.exceptions {
+		item.exceptions[i].ScheduleId = item.schedule.ID
+		if err := tx.Create(&item.exceptions[i]).Error; err != nil {
+			return err
+		}
+	}
+	return realtime.Publish(tx, item.schedule.WorkspaceId, realtime.ScheduleCreated, item.schedule.ID, realtime.ScheduleData{
+		ID:            item.schedule.ID,
+		BoardColumnID: item.schedule.BoardColumnId,
+		Title:         item.schedule.Title,
+	})
+}
+
+// findScheduleByUID returns the id of the schedule that was imported with uid,
+// or that was exported by this service under uid, or 0.
+func findScheduleByUID(schedules []models.TwSchedule, uid string) int {
+	for _, schedule := range schedules {
+		if ScheduleUID(schedule.ID) == uid {
+			return schedule.ID
+		}
+		if schedule.ExtraData == "" {
+			continue
+		}
+		var extraData scheduleExtraData
+		if err := json.Unmarshal([]byte(schedule.ExtraData), &extraData); err == nil && extraData.IcalUID == uid {
+			return schedule.ID
+		}
+	}
+	return 0
+}
+
+// findConflicts reports existing schedules overlapping the first instance of
+// the imported event. Recurring schedules are expanded in the timezone of
+// their creator, from locations.
+func findConflicts(item pendingImport, schedules []models.TwSchedule, exceptions []models.TwRecurrenceException, locations map[int]*time.Location) []ImportConflictResponse {
+	from := *item.schedule.StartTime
+	to := *item.schedule.EndTime
+	if !to.After(from) {
+		to = from.Add(time.Minute)
+	}
+
+	var conflicts []ImportConflictResponse
+	for _, schedule := range schedules {
+		occurrences, err := recurrence.Expand(schedule, exceptions, from, to, locations[schedule.CreatedBy])
+		if err != nil || len(occurrences) == 0 {
+			continue
+		}
+		conflicts = append(conflicts, ImportConflictResponse{
+			UID:                item.event.UID,
+			Title:              item.schedule.Title,
+			StartTime:          from,
+			EndTime:            *item.schedule.EndTime,
+			ExistingScheduleID: schedule.ID,
+			ExistingTitle:      schedule.Title,
+		})
+	}
+	return conflicts
+}
+
+func visibilityFromClass(class string) string {
+	switch strings.ToUpper(class) {
+	case "PRIVATE", "CONFIDENTIAL":
+		return "private"
+	default:
+		return "public"
+	}
+}
+
+func priorityFromInt(priority int) string {
+	switch {
+	case priority >= 1 && priority <= 4:
+		return "high"
+	case priority == 5:
+		return "medium"
+	case priority >= 6 && priority <= 9:
+		return "low"
+	default:
+		return ""
+	}
+}
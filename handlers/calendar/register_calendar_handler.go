@@ -0,0 +1,23 @@
+package calendar
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+type CalendarHandler struct {
+	Router fiber.Router
+	DB     *gorm.DB
+}
+
+func RegisterCalendarHandler(router fiber.Router, db *gorm.DB) {
+	calendarHandler := CalendarHandler{
+		Router: router,
+		DB:     db,
+	}
+
+	// Register all endpoints here
+	router.Get("/workspace/:workspace_id/feed.ics", calendarHandler.getWorkspaceFeed)
+	router.Get("/email/:email/feed.ics", calendarHandler.getUserEmailFeed)
+	router.Post("/board_column/:board_column_id/import", calendarHandler.importCalendar)
+}
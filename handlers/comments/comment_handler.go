@@ -1,28 +1,52 @@
 package document
 
 import (
+	"dbms/common"
+	"dbms/realtime"
+	"dbms/webhook"
 	"github.com/gofiber/fiber/v2"
 	"github.com/timewise-team/timewise-models/dtos/core_dtos/comment_dtos"
 	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
 	"log"
 )
 
+// commentFields are the comment columns list endpoints filter and sort by.
+var commentFields = common.FilterFields{
+	"id":                {Column: "id", Type: common.FieldInt},
+	"workspace_user_id": {Column: "workspace_user_id", Type: common.FieldInt},
+	"commenter":         {Column: "commenter", Type: common.FieldString},
+	"content":           {Column: "content", Type: common.FieldString},
+	"created_at":        {Column: "created_at", Type: common.FieldTime},
+	"updated_at":        {Column: "updated_at", Type: common.FieldTime},
+}
+
 // getCommentsBySchedule godoc
 // @Summary Get comments by schedule
-// @Description Get comments by schedule
+// @Description Get comments by schedule. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, workspace_user_id, commenter, content, created_at and updated_at.
 // @Tags comments
 // @Accept json
 // @Produce json
 // @Param schedule_id path string true "Schedule ID"
+// @Param sort query string false "Comma separated fields to sort by, each prefixed with - for descending order"
 // @Success 200 {array} models.TwComment
+// @Failure 400 {string} string "Invalid filter or sort"
 // @Router /dbms/v1/comment/schedule/{schedule_id} [get]
 func (h *CommentHandler) getCommentsBySchedule(c *fiber.Ctx) error {
 	scheduleId := c.Params("schedule_id")
 	if scheduleId == "" {
 		return c.SendStatus(fiber.StatusBadRequest)
 	}
+	filter, err := common.ParseFilter(c, commentFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	order, err := common.ParseSort(c, commentFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
 	var Comments []models.TwComment
-	if err := h.DB.
+	if err := order.Order(filter.Where(h.DB)).
 		Where("schedule_id = ?", scheduleId).
 		Where("deleted_at IS NULL").
 		Find(&Comments).Error; err != nil {
@@ -55,12 +79,14 @@ func (h *CommentHandler) getCommentsById(c *fiber.Ctx) error {
 
 // getCommentsBySchedule godoc
 // @Summary Get comments by schedule
-// @Description Get comments by schedule
+// @Description Get the comments of a schedule with their authors. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, workspace_user_id, commenter, content, created_at and updated_at.
 // @Tags comments
 // @Accept json
 // @Produce json
 // @Param schedule_id path string true "Schedule ID"
+// @Param sort query string false "Comma separated fields to sort by, each prefixed with - for descending order"
 // @Success 200 {array} models.TwComment
+// @Failure 400 {object} fiber.Map "Invalid schedule ID, filter or sort"
 // @Router /dbms/v1/comment/schedule_id/{schedule_id} [get]
 func (h *CommentHandler) getCommentsByScheduleID(c *fiber.Ctx) error {
 	var scheduleComments []comment_dtos.TwCommentResponse
@@ -72,8 +98,22 @@ func (h *CommentHandler) getCommentsByScheduleID(c *fiber.Ctx) error {
 		})
 	}
 
+	fields := commentFields.On("c")
+	filter, err := common.ParseFilter(c, fields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	order, err := common.ParseSort(c, fields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
 	// Perform the SQL query with multiple joins
-	err := h.DB.Table("tw_comments AS c").
+	err = order.Order(filter.Where(h.DB)).Table("tw_comments AS c").
 		Select(`
             c.id AS id,
             c.created_at,
@@ -119,8 +159,23 @@ func (h *CommentHandler) createComment(c *fiber.Ctx) error {
 	if err := c.BodyParser(&comment); err != nil {
 		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
 	}
-	if result := h.DB.Create(&comment); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&comment).Error; err != nil {
+			return err
+		}
+		if err := webhook.EnqueueForSchedule(tx, comment.ScheduleId, webhook.EventCommentCreated, map[string]interface{}{
+			"comment_id":        comment.ID,
+			"schedule_id":       comment.ScheduleId,
+			"workspace_user_id": comment.WorkspaceUserId,
+			"commenter":         comment.Commenter,
+			"content":           comment.Content,
+		}); err != nil {
+			return err
+		}
+		return publishCommentEvent(tx, realtime.CommentCreated, comment)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 	return c.JSON(comment)
 }
@@ -145,8 +200,14 @@ func (h *CommentHandler) updateComment(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
 	}
 
-	if result := h.DB.Omit("deleted_at").Save(&comment); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("deleted_at").Save(&comment).Error; err != nil {
+			return err
+		}
+		return publishCommentEvent(tx, realtime.CommentUpdated, comment)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 	updateComment := models.TwComment{
 		ID:              comment.ID,
@@ -182,8 +243,14 @@ func (h *CommentHandler) deleteComment(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
 	}
 
-	if result := h.DB.Save(&comment); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&comment).Error; err != nil {
+			return err
+		}
+		return publishCommentEvent(tx, realtime.CommentDeleted, comment)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 	updateComment := models.TwComment{
 		ID:              comment.ID,
@@ -198,3 +265,12 @@ func (h *CommentHandler) deleteComment(c *fiber.Ctx) error {
 	}
 	return c.JSON(updateComment)
 }
+
+// publishCommentEvent records a comment change on the workspace change feed.
+func publishCommentEvent(tx *gorm.DB, eventType string, comment models.TwComment) error {
+	return realtime.PublishItem(tx, eventType, realtime.ItemData{
+		ID:              comment.ID,
+		ScheduleID:      comment.ScheduleId,
+		WorkspaceUserID: comment.WorkspaceUserId,
+	})
+}
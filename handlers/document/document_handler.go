@@ -1,6 +1,8 @@
 package document
 
 import (
+	"dbms/realtime"
+	"dbms/webhook"
 	"errors"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
@@ -103,7 +105,22 @@ func (h *DocumentHandler) createDocument(c *fiber.Ctx) error {
 	if err := c.BodyParser(&document); err != nil {
 		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
 	}
-	if err := h.DB.Create(&document).Error; err != nil {
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&document).Error; err != nil {
+			return err
+		}
+		if err := webhook.EnqueueForSchedule(tx, document.ScheduleId, webhook.EventDocumentUploaded, map[string]interface{}{
+			"document_id":       document.ID,
+			"schedule_id":       document.ScheduleId,
+			"workspace_user_id": document.UploadedBy,
+			"file_name":         document.FileName,
+			"download_url":      document.DownloadUrl,
+		}); err != nil {
+			return err
+		}
+		return publishDocumentEvent(tx, realtime.DocumentCreated, document)
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 	return c.JSON(document)
@@ -128,7 +145,22 @@ func (h *DocumentHandler) deleteDocument(c *fiber.Ctx) error {
 	if fileName == "" {
 		return c.SendStatus(fiber.StatusBadRequest)
 	}
-	if err := h.DB.Where("schedule_id = ? AND file_name = ?", scheduleID, fileName).Delete(&models.TwDocument{}).Error; err != nil {
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		var documents []models.TwDocument
+		if err := tx.Where("schedule_id = ? AND file_name = ?", scheduleID, fileName).Find(&documents).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("schedule_id = ? AND file_name = ?", scheduleID, fileName).Delete(&models.TwDocument{}).Error; err != nil {
+			return err
+		}
+		for _, document := range documents {
+			if err := publishDocumentEvent(tx, realtime.DocumentDeleted, document); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		return fmt.Errorf("failed to delete document from database: %v", err)
 	}
 	return c.SendStatus(fiber.StatusNoContent)
@@ -157,3 +189,12 @@ func (h *DocumentHandler) getDocumentsById(c *fiber.Ctx) error {
 	}
 	return c.JSON(document)
 }
+
+// publishDocumentEvent records a document change on the workspace change feed.
+func publishDocumentEvent(tx *gorm.DB, eventType string, document models.TwDocument) error {
+	return realtime.PublishItem(tx, eventType, realtime.ItemData{
+		ID:              document.ID,
+		ScheduleID:      document.ScheduleId,
+		WorkspaceUserID: document.UploadedBy,
+	})
+}
@@ -0,0 +1,101 @@
+package notification
+
+import (
+	"dbms/outbox"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// getDeliveries godoc
+// @Summary Get notification deliveries
+// @Description Get the outbox deliveries of notifications, newest first, with their attempts and last error. Dead deliveries are the ones that ran out of attempts. Requires the dbms.admin scope.
+// @Tags notification
+// @Produce json
+// @Param status query string false "Filter by status (pending, sent, held, suppressed, dead)"
+// @Param channel query string false "Filter by channel (email, web_push, slack, sms)"
+// @Param limit query int false "Maximum number of deliveries (default 50, max 200)"
+// @Success 200 {array} outbox.Delivery
+// @Router /dbms/v1/notification/deliveries [get]
+func (h *NotificationHandler) getDeliveries(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 200 {
+		limit = 200
+	}
+
+	query := h.DB.Preload("Notification").Preload("Notification.UserEmail")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if channel := c.Query("channel"); channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+	var deliveries []outbox.Delivery
+	if err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(deliveries)
+}
+
+// getNotificationDeliveries godoc
+// @Summary Get the deliveries of a notification
+// @Description Get the delivery status of a notification on each channel it was queued for, with attempts and the last error
+// @Tags notification
+// @Produce json
+// @Param notification_id path int true "Notification ID"
+// @Success 200 {array} outbox.Delivery
+// @Router /dbms/v1/notification/{notification_id}/deliveries [get]
+func (h *NotificationHandler) getNotificationDeliveries(c *fiber.Ctx) error {
+	notificationId, err := c.ParamsInt("notification_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid notification_id",
+		})
+	}
+
+	var deliveries []outbox.Delivery
+	if err := h.DB.Where("notification_id = ?", notificationId).Order("channel").Find(&deliveries).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(deliveries)
+}
+
+// requeueDelivery godoc
+// @Summary Requeue a notification delivery
+// @Description Send a dead notification delivery again with a fresh attempt count, e.g. after fixing the recipient's address. Requires the dbms.admin scope.
+// @Tags notification
+// @Produce json
+// @Param delivery_id path int true "Delivery ID"
+// @Success 200 {object} outbox.Delivery
+// @Failure 404 {object} fiber.Map "No dead delivery with this ID"
+// @Router /dbms/v1/notification/deliveries/{delivery_id}/requeue [put]
+func (h *NotificationHandler) requeueDelivery(c *fiber.Ctx) error {
+	deliveryId, err := c.ParamsInt("delivery_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid delivery_id",
+		})
+	}
+	if err := outbox.Requeue(h.DB, deliveryId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "No dead delivery with this ID",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var delivery outbox.Delivery
+	if err := h.DB.First(&delivery, deliveryId).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.JSON(delivery)
+}
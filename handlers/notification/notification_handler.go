@@ -1,6 +1,9 @@
 package notification
 
 import (
+	"dbms/common"
+	"dbms/preference"
+	"dbms/repository"
 	"errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/timewise-team/timewise-models/models"
@@ -13,7 +16,7 @@ type NotificationHandler struct {
 
 // CreateNotification godoc
 // @Summary Create a new notification
-// @Description Create a new notification
+// @Description Create a new notification. Nothing is stored when the recipient turned its type off on every channel.
 // @Tags notification
 // @Accept json
 // @Produce json
@@ -26,34 +29,53 @@ func (h *NotificationHandler) CreateNotification(c *fiber.Ctx) error {
 	if err := c.BodyParser(&request); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
-	if result := h.DB.Create(&request); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": result.Error.Error()})
+	if err := repository.CreateNotification(h.DB, &request); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 	// Insert data into database
 	return nil
 }
 
+// notificationFields are the notification columns the list endpoint sorts by.
+var notificationFields = common.FilterFields{
+	"created_at":  {Column: "created_at", Type: common.FieldTime},
+	"notified_at": {Column: "notified_at", Type: common.FieldTime},
+}
+
 // GetUnsentNotifications godoc
 // @Summary Get unsent notifications
-// @Description Get unsent notifications
+// @Description Get a page of the unsent notifications. The X-Total-Count header holds the number of unsent notifications and X-Next-Cursor the cursor of the next page, if any.
 // @Tags notification
 // @Accept json
 // @Produce json
+// @Param limit query int false "Maximum number of notifications (default 50, max 200); the whole list is returned when neither limit nor cursor is given"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Param sort query string false "Sort by id, created_at or notified_at; prefix with - for descending order (default id)"
 // @Success 200 {array} models.TwNotifications
+// @Header 200 {integer} X-Total-Count "Number of unsent notifications"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {object} fiber.Map "Invalid sort or cursor"
 // @Router /dbms/v1/notification [get]
 func (h *NotificationHandler) GetUnsentNotifications(ctx *fiber.Ctx) error {
-	var notifications []models.TwNotifications
-	if err := h.DB.Where("is_sent = ?", false).Preload("UserEmail").Find(&notifications).Error; err != nil {
+	page, err := common.ParsePage(ctx, notificationFields)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	notifications, result, err := repository.FindUnsentNotifications(h.DB, page)
+	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
+	result.Write(ctx)
 	return ctx.JSON(notifications)
 }
 
 // GetNotiByUserEmailIds godoc
 // @Summary Get notifications by user email ids
-// @Description Get notifications by user email ids
+// @Description Get the in-app notifications of user emails, leaving out the types their users turned off for the app
 // @Tags notification
 // @Accept json
 // @Produce json
@@ -69,7 +91,26 @@ func (h *NotificationHandler) GetNotiByUserEmailIds(ctx *fiber.Ctx) error {
 			"error": err.Error(),
 		})
 	}
-	return ctx.JSON(notifications)
+
+	// Leave out the types each user turned off in the app
+	preferences := make(map[int]preference.Preferences)
+	inApp := make([]models.TwNotifications, 0, len(notifications))
+	for _, notification := range notifications {
+		userId := notification.UserEmail.UserId
+		if _, ok := preferences[userId]; !ok {
+			loaded, err := preference.Load(h.DB, userId)
+			if err != nil {
+				return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": err.Error(),
+				})
+			}
+			preferences[userId] = loaded
+		}
+		if preferences[userId].Allows(preference.ChannelInApp, notification.Type) {
+			inApp = append(inApp, notification)
+		}
+	}
+	return ctx.JSON(inApp)
 }
 
 // UpdateNotiStatus godoc
@@ -148,8 +189,7 @@ func (h *NotificationHandler) updateNotificationToSent(ctx *fiber.Ctx) error {
 		})
 	}
 
-	notification.IsSent = true
-	if err := h.DB.Save(&notification).Error; err != nil {
+	if err := repository.MarkNotificationSent(h.DB, notification.ID); err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -2,6 +2,7 @@ package notification
 
 import (
 	"dbms/common"
+	"dbms/middleware"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -16,5 +17,8 @@ func RegisterNotificationHandler(router fiber.Router, db *gorm.DB) {
 		handler.Router.Get("/", notification.GetUnsentNotifications)
 		handler.Router.Put("/:notification_id", notification.updateNotificationToSent)
 		handler.Router.Put("/update-status/read", notification.UpdateNotiStatus)
+		handler.Router.Get("/deliveries", middleware.RequireScope(middleware.ScopeAdmin), notification.getDeliveries)
+		handler.Router.Get("/:notification_id/deliveries", notification.getNotificationDeliveries)
+		handler.Router.Put("/deliveries/:delivery_id/requeue", middleware.RequireScope(middleware.ScopeAdmin), notification.requeueDelivery)
 	})
 }
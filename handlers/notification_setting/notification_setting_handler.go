@@ -1,6 +1,7 @@
 package notification_setting
 
 import (
+	"dbms/preference"
 	"github.com/gofiber/fiber/v2"
 	"github.com/timewise-team/timewise-models/models"
 	"gorm.io/gorm"
@@ -31,7 +32,7 @@ func (h NotificationSettingHandler) GetNotificationSettingByUserId(ctx *fiber.Ct
 
 // CreateNotificationSetting godoc
 // @Summary Create notification setting
-// @Description Create notification setting
+// @Description extra_data holds the options the flags cannot express, as JSON: {"channels": {"email": {"comment": false}, "in_app": {...}}, "quiet_hours": {"start": "22:00", "end": "07:00"}, "digest": {"enabled": true, "hour": 8}, "targets": {"slack": {"webhook_url": "https://hooks.slack.com/..."}, "sms": {"phone": "+84..."}, "web_push": {"endpoint": "...", "keys": {"p256dh": "...", "auth": "..."}}}}. The web_push, slack and sms channels only deliver to users with a target for them; Slack webhooks must be on https://hooks.slack.com/ and push endpoints https URLs on public addresses. Quiet hours and the digest hour are in the user's timezone. Reminders follow notification_on_due_date, comments notification_on_comment, assignments notification_on_tag, schedule changes notification_on_schedule_change, and every email notification_on_email.
 // @Tags notification_setting
 // @Accept json
 // @Produce json
@@ -43,6 +44,9 @@ func (h NotificationSettingHandler) CreateNotificationSetting(ctx *fiber.Ctx) er
 	if err := ctx.BodyParser(&notificationSetting); err != nil {
 		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
 	}
+	if _, err := preference.ParseExtra(notificationSetting.ExtraData); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
 	if result := h.DB.Create(&notificationSetting); result.Error != nil {
 		return ctx.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
 	}
@@ -52,7 +56,7 @@ func (h NotificationSettingHandler) CreateNotificationSetting(ctx *fiber.Ctx) er
 
 // UpdateNotificationSetting godoc
 // @Summary Update notification setting
-// @Description Update notification setting
+// @Description extra_data holds the options the flags cannot express, as JSON: {"channels": {"email": {"comment": false}, "in_app": {...}}, "quiet_hours": {"start": "22:00", "end": "07:00"}, "digest": {"enabled": true, "hour": 8}, "targets": {"slack": {"webhook_url": "https://hooks.slack.com/..."}, "sms": {"phone": "+84..."}, "web_push": {"endpoint": "...", "keys": {"p256dh": "...", "auth": "..."}}}}. The web_push, slack and sms channels only deliver to users with a target for them; Slack webhooks must be on https://hooks.slack.com/ and push endpoints https URLs on public addresses. Quiet hours and the digest hour are in the user's timezone. Reminders follow notification_on_due_date, comments notification_on_comment, assignments notification_on_tag, schedule changes notification_on_schedule_change, and every email notification_on_email.
 // @Tags notification_setting
 // @Accept json
 // @Produce json
@@ -69,6 +73,9 @@ func (h NotificationSettingHandler) UpdateNotificationSetting(ctx *fiber.Ctx) er
 	if err := ctx.BodyParser(&notificationSetting); err != nil {
 		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
 	}
+	if _, err := preference.ParseExtra(notificationSetting.ExtraData); err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
 	if result := h.DB.Omit("deleted_at", "created_at").Save(&notificationSetting); result.Error != nil {
 		return ctx.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
 	}
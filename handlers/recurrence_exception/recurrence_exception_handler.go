@@ -1,6 +1,7 @@
 package recurrence_exception
 
 import (
+	"dbms/common"
 	"github.com/gofiber/fiber/v2"
 	"github.com/timewise-team/timewise-models/dtos/core_dtos"
 	"github.com/timewise-team/timewise-models/models"
@@ -11,19 +12,37 @@ type RecurrenceExceptionHandler struct {
 	DB *gorm.DB
 }
 
+// recurrenceExceptionFields are the recurrence exception columns the list
+// endpoint sorts by.
+var recurrenceExceptionFields = common.FilterFields{
+	"exception_date": {Column: "exception_date", Type: common.FieldTime},
+}
+
 // GetRecurrenceExceptions godoc
 // @Summary Get all recurrence exceptions
-// @Description Get all recurrence exceptions
+// @Description Get a page of the recurrence exceptions. The X-Total-Count header holds the number of recurrence exceptions and X-Next-Cursor the cursor of the next page, if any.
 // @Tags recurrence_exception
 // @Accept json
 // @Produce json
+// @Param limit query int false "Maximum number of recurrence exceptions (default 50, max 200); the whole list is returned when neither limit nor cursor is given"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Param sort query string false "Sort by id or exception_date; prefix with - for descending order (default id)"
 // @Success 200 {array} core_dtos.TwRecurrenceExceptionResponseDTO
+// @Header 200 {integer} X-Total-Count "Number of recurrence exceptions"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {string} string "Invalid sort or cursor"
 // @Router /dbms/v1/recurrence_exception [get]
 func (h *RecurrenceExceptionHandler) GetRecurrenceExceptions(c *fiber.Ctx) error {
+	page, err := common.ParsePage(c, recurrenceExceptionFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
 	var recurrenceExceptions []models.TwRecurrenceException
-	if result := h.DB.Find(&recurrenceExceptions); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	result, err := page.Find(h.DB, &recurrenceExceptions)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
+	result.Write(c)
 	var recurrenceExceptionDTOs []core_dtos.TwRecurrenceExceptionResponseDTO
 	for _, recurrenceException := range recurrenceExceptions {
 		recurrenceExceptionDTOs = append(recurrenceExceptionDTOs, core_dtos.TwRecurrenceExceptionResponseDTO{
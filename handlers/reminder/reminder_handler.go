@@ -1,6 +1,9 @@
 package reminder
 
 import (
+	"dbms/common"
+	"dbms/reminderlease"
+	"dbms/repository"
 	"github.com/gofiber/fiber/v2"
 	"github.com/timewise-team/timewise-models/models"
 	"gorm.io/gorm"
@@ -93,6 +96,7 @@ func (h ReminderHandler) UpdateReminder(ctx *fiber.Ctx) error {
 		First(&reminder); result.Error != nil {
 		return ctx.Status(fiber.StatusNotFound).SendString(result.Error.Error())
 	}
+	reminderTime := reminder.ReminderTime
 	if err := ctx.BodyParser(&reminder); err != nil {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"message": err.Error(),
@@ -105,6 +109,12 @@ func (h ReminderHandler) UpdateReminder(ctx *fiber.Ctx) error {
 	if result := h.DB.Omit("deleted_at", "created_at").Save(reminder); result.Error != nil {
 		return ctx.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
 	}
+	// A moved reminder is due again at its new time, even if it had expired or failed
+	if !reminder.ReminderTime.Equal(reminderTime) {
+		if err := reminderlease.Reschedule(h.DB, reminder.ID); err != nil {
+			return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+	}
 	return ctx.JSON(reminder)
 }
 
@@ -148,26 +158,36 @@ func (h ReminderHandler) DeleteReminder(ctx *fiber.Ctx) error {
 	})
 }
 
+// reminderFields are the reminder columns the list endpoint sorts by.
+var reminderFields = common.FilterFields{
+	"reminder_time": {Column: "reminder_time", Type: common.FieldTime},
+	"created_at":    {Column: "created_at", Type: common.FieldTime},
+}
+
 // getReminders godoc
 // @Summary Get all reminders
-// @Description Get all reminders
+// @Description Get a page of the reminders. The X-Total-Count header holds the number of reminders and X-Next-Cursor the cursor of the next page, if any.
 // @Tags reminder
 // @Accept json
 // @Produce json
+// @Param limit query int false "Maximum number of reminders (default 50, max 200); the whole list is returned when neither limit nor cursor is given"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Param sort query string false "Sort by id, reminder_time or created_at; prefix with - for descending order (default id)"
 // @Success 200 {array} models.TwReminder
+// @Header 200 {integer} X-Total-Count "Number of reminders"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {string} string "Invalid sort or cursor"
 // @Router /dbms/v1/reminder [get]
 func (h ReminderHandler) GetReminders(ctx *fiber.Ctx) error {
-	var reminders []models.TwReminder
-	if result := h.DB.
-		Where("deleted_at IS NULL").
-		Preload("WorkspaceUser").
-		Preload("WorkspaceUser.Workspace").
-		Preload("WorkspaceUser.UserEmail").
-		Preload("WorkspaceUser.UserEmail.User").
-		Preload("Schedule").
-		Find(&reminders); result.Error != nil {
-		return ctx.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	page, err := common.ParsePage(ctx, reminderFields)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	reminders, result, err := repository.FindReminders(h.DB, page)
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
+	result.Write(ctx)
 	return ctx.JSON(reminders)
 }
 
@@ -190,14 +210,8 @@ func (h ReminderHandler) CompleteReminder(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusNotFound).SendString(result.Error.Error())
 	}
 
-	// Chỉ cập nhật các thuộc tính cần thiết
-	updateFields := map[string]interface{}{
-		"updated_at": gorm.Expr("NOW()"),
-		"is_sent":    true,
-	}
-
-	if result := h.DB.Model(&reminder).Updates(updateFields); result.Error != nil {
-		return ctx.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	if err := repository.MarkReminderSent(h.DB, reminder.ID); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
 	return ctx.JSON(reminder)
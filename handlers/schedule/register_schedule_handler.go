@@ -2,6 +2,7 @@ package schedule
 
 import (
 	"dbms/common"
+	"dbms/middleware"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -14,12 +15,17 @@ func RegisterScheduleHandler(router fiber.Router, db *gorm.DB) {
 		handler.Router.Get("/", scheduleHandler.GetSchedules)
 		handler.Router.Get("/:schedule_id", scheduleHandler.GetScheduleById)
 		handler.Router.Get("/schedules/filter", scheduleHandler.FilterSchedules)
+		handler.Router.Get("/:schedule_id/occurrences", scheduleHandler.GetScheduleOccurrences)
 		//handler.Router.Get("/user/:user_id", scheduleHandler.GetSchedulesByUserId)
+		handler.Router.Get("/user/:user_id/agenda", scheduleHandler.GetUserAgenda)
 		handler.Router.Post("/", scheduleHandler.CreateSchedule)
+		handler.Router.Post("/from_template/:template_id", scheduleHandler.CreateScheduleFromTemplate)
+		handler.Router.Post("/:schedule_id/clone", middleware.RequireScope(middleware.ScopeAdmin), scheduleHandler.CloneSchedule)
 		handler.Router.Put("/:schedule_id/workspace_user/:workspace_user_id", scheduleHandler.UpdateSchedule)
 		handler.Router.Delete("/:schedule_id/workspace_user/:workspace_user_id", scheduleHandler.DeleteSchedule)
 		router.Get("/workspace/:workspace_id/board_column/:board_column_id", scheduleHandler.getSchedulesByBoardColumn)
 		router.Get("/workspace/:workspace_id/schedules", scheduleHandler.GetSchedulesByWorkspace)
+		router.Get("/workspace/:workspace_id/occurrences", scheduleHandler.GetWorkspaceOccurrences)
 		router.Put("/:schedule_id/transcript", scheduleHandler.UpdateTranscriptBySchedule)
 		router.Put("/position/:schedule_id/workspace_user/:workspace_user_id", scheduleHandler.UpdateSchedulePosition)
 		router.Get("/workspace/:workspace_id/board_column/:board_column_id/filter", scheduleHandler.getSchedulesByBoardColumnFilter)
@@ -0,0 +1,193 @@
+package schedule
+
+import (
+	"dbms/datetime"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"sort"
+	"time"
+)
+
+// defaultAgendaDays is the window of an agenda requested without from and to.
+const defaultAgendaDays = 7
+
+type AgendaResponse struct {
+	UserID int `json:"user_id"`
+	// Timezone is the IANA name the agenda is rendered in; UTC when the user
+	// has none or it is unknown.
+	Timezone string      `json:"timezone"`
+	From     time.Time   `json:"from"`
+	To       time.Time   `json:"to"`
+	Days     []AgendaDay `json:"days"`
+}
+
+// AgendaDay lists the occurrences on one day of the user's timezone. An
+// occurrence spanning several days is listed on each of them.
+type AgendaDay struct {
+	Date        string             `json:"date"`
+	Occurrences []AgendaOccurrence `json:"occurrences"`
+}
+
+type AgendaOccurrence struct {
+	ScheduleOccurrenceResponse
+	WorkspaceTitle string `json:"workspace_title"`
+}
+
+// GetUserAgenda godoc
+// @Summary Get user agenda
+// @Description Get what a user is doing within a time window, across every workspace they or their linked emails joined: the schedules they created or take part in, with recurrences expanded and exceptions applied. Times are rendered in the user's timezone and occurrences are grouped by day; all-day schedules keep their date. Without from and to, the window is the 7 days starting today.
+// @Tags schedule
+// @Accept json
+// @Produce json
+// @Param user_id path int true "User ID"
+// @Param from query string false "Window start (RFC 3339)"
+// @Param to query string false "Window end (RFC 3339)"
+// @Success 200 {object} AgendaResponse
+// @Failure 400 {string} string "Invalid time window"
+// @Failure 404 {string} string "User not found"
+// @Router /dbms/v1/schedule/user/{user_id}/agenda [get]
+func (h *ScheduleHandler) GetUserAgenda(c *fiber.Ctx) error {
+	var user models.TwUser
+	if err := h.DB.Where("id = ? AND deleted_at IS NULL", c.Params("user_id")).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).SendString("User not found")
+		}
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	loc := datetime.Location(user.Timezone)
+
+	var from, to time.Time
+	if c.Query("from") == "" && c.Query("to") == "" {
+		today := datetime.StartOfDay(time.Now(), loc)
+		from = today.UTC()
+		to = today.AddDate(0, 0, defaultAgendaDays).UTC()
+	} else {
+		var err error
+		if from, to, err = parseOccurrenceWindow(c); err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+		}
+	}
+
+	var workspaceUsers []models.TwWorkspaceUser
+	if err := h.DB.
+		Joins("JOIN tw_user_emails ON tw_user_emails.id = tw_workspace_users.user_email_id").
+		Joins("JOIN tw_workspaces ON tw_workspaces.id = tw_workspace_users.workspace_id").
+		Where("tw_user_emails.user_id = ? OR (tw_user_emails.is_linked_to = ? AND tw_user_emails.status = 'linked')", user.ID, user.ID).
+		Where("tw_user_emails.deleted_at IS NULL AND tw_workspaces.deleted_at IS NULL").
+		Where("tw_workspace_users.deleted_at IS NULL").
+		Where("tw_workspace_users.status = 'joined' AND tw_workspace_users.is_active = true").
+		Preload("Workspace").
+		Find(&workspaceUsers).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	response := AgendaResponse{
+		UserID:   user.ID,
+		Timezone: loc.String(),
+		From:     from.In(loc),
+		To:       to.In(loc),
+		Days:     []AgendaDay{},
+	}
+	if len(workspaceUsers) == 0 {
+		return c.JSON(response)
+	}
+	workspaceUserIds := make([]int, 0, len(workspaceUsers))
+	workspaceTitles := make(map[int]string)
+	for _, workspaceUser := range workspaceUsers {
+		workspaceUserIds = append(workspaceUserIds, workspaceUser.ID)
+		workspaceTitles[workspaceUser.WorkspaceId] = workspaceUser.Workspace.Title
+	}
+
+	var schedules []models.TwSchedule
+	if err := h.DB.
+		Where("id IN (?) OR created_by IN (?)", h.DB.Table("tw_schedule_participants").
+			Select("schedule_id").
+			Where("workspace_user_id IN (?)", workspaceUserIds).
+			Where("deleted_at IS NULL AND invitation_status != 'removed'"), workspaceUserIds).
+		Where("is_deleted = false AND deleted_at IS NULL").
+		Where("start_time IS NOT NULL AND start_time < ?", to).
+		Where("(recurrence_pattern IS NOT NULL AND recurrence_pattern != '') OR COALESCE(end_time, start_time) >= ?", from).
+		Find(&schedules).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	// Recurrences are expanded in the user's timezone, so that a weekly
+	// item keeps its weekday and wall clock on the days the agenda lists.
+	occurrences, err := h.expandSchedules(schedules, from, to, loc)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	for _, occurrence := range occurrences {
+		item := AgendaOccurrence{
+			ScheduleOccurrenceResponse: occurrence,
+			WorkspaceTitle:             workspaceTitles[occurrence.WorkspaceID],
+		}
+		dayFrom, dayTo, dayLoc := from, to, loc
+		if occurrence.AllDay {
+			// All-day schedules are stored at midnight UTC of their date,
+			// which other timezones would move to a neighbouring day, so
+			// they are listed against the dates of the window instead.
+			dayFrom, dayTo, dayLoc = datetime.Floating(from.In(loc)), datetime.FloatingEnd(to.In(loc)), time.UTC
+		} else {
+			item.StartTime = occurrence.StartTime.In(loc)
+			item.EndTime = occurrence.EndTime.In(loc)
+			item.OriginalStartTime = occurrence.OriginalStartTime.In(loc)
+		}
+		for _, day := range agendaDays(occurrence.StartTime, occurrence.EndTime, dayFrom, dayTo, dayLoc) {
+			response.Days = addToDay(response.Days, day, item)
+		}
+	}
+	sortAgenda(response.Days)
+	return c.JSON(response)
+}
+
+// agendaDays returns the dates, in loc, of the days [start, end) covers within
+// the window, none when it is outside the window. An occurrence without a
+// duration covers the day it starts on.
+func agendaDays(start, end, from, to time.Time, loc *time.Location) []string {
+	instant := !end.After(start)
+	if !start.Before(to) || (instant && start.Before(from)) || (!instant && !end.After(from)) {
+		return nil
+	}
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	day := datetime.StartOfDay(start, loc)
+	days := []string{day.Format(datetime.DateLayout)}
+	for day = day.AddDate(0, 0, 1); day.Before(end); day = day.AddDate(0, 0, 1) {
+		days = append(days, day.Format(datetime.DateLayout))
+	}
+	return days
+}
+
+func addToDay(days []AgendaDay, date string, item AgendaOccurrence) []AgendaDay {
+	for i := range days {
+		if days[i].Date == date {
+			days[i].Occurrences = append(days[i].Occurrences, item)
+			return days
+		}
+	}
+	return append(days, AgendaDay{Date: date, Occurrences: []AgendaOccurrence{item}})
+}
+
+// sortAgenda orders days by date and, within a day, lists all-day
+// occurrences first and the rest by start time.
+func sortAgenda(days []AgendaDay) {
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Date < days[j].Date
+	})
+	for _, day := range days {
+		sort.SliceStable(day.Occurrences, func(i, j int) bool {
+			a, b := day.Occurrences[i], day.Occurrences[j]
+			if a.AllDay != b.AllDay {
+				return a.AllDay
+			}
+			return a.StartTime.Before(b.StartTime)
+		})
+	}
+}
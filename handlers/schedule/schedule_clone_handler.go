@@ -0,0 +1,173 @@
+package schedule
+
+import (
+	"dbms/clone"
+	"dbms/lexorank"
+	"dbms/permission"
+	"dbms/realtime"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/timewise-team/timewise-models/dtos/core_dtos"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+type CloneRequest struct {
+	// BoardColumnID is the column the copy goes to.
+	BoardColumnID *int `json:"board_column_id"`
+	// WorkspaceID, when given without a board column, picks the column of the
+	// workspace named like the schedule's, or else its first column.
+	WorkspaceID     *int `json:"workspace_id"`
+	IncludeComments bool `json:"include_comments"`
+}
+
+type CloneResponse struct {
+	core_dtos.TwCreateShecduleResponse
+	SourceID int `json:"source_id"`
+}
+
+// cloneTarget returns the board column a copy of source goes to.
+func (h *ScheduleHandler) cloneTarget(source models.TwSchedule, request CloneRequest) (models.TwBoardColumn, error) {
+	var column models.TwBoardColumn
+	if request.BoardColumnID == nil && request.WorkspaceID == nil {
+		request.BoardColumnID = &source.BoardColumnId
+	}
+	if request.BoardColumnID != nil {
+		err := h.DB.Where("id = ? AND deleted_at IS NULL", *request.BoardColumnID).First(&column).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return column, fiber.NewError(fiber.StatusNotFound, "Board column not found")
+		}
+		if err == nil && request.WorkspaceID != nil && *request.WorkspaceID != column.WorkspaceId {
+			return column, fiber.NewError(fiber.StatusBadRequest, "board column is not in the workspace")
+		}
+		return column, err
+	}
+
+	var sourceColumn models.TwBoardColumn
+	if err := h.DB.Where("id = ?", source.BoardColumnId).First(&sourceColumn).Error; err != nil {
+		return column, err
+	}
+	err := h.DB.Where("workspace_id = ? AND name = ? AND deleted_at IS NULL", *request.WorkspaceID, sourceColumn.Name).
+		Order(lexorank.Order).
+		First(&column).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		err = h.DB.Where("workspace_id = ? AND deleted_at IS NULL", *request.WorkspaceID).
+			Order(lexorank.Order).
+			First(&column).Error
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return column, fiber.NewError(fiber.StatusNotFound, "Board column not found")
+	}
+	return column, err
+}
+
+// CloneSchedule godoc
+// @Summary Clone schedule
+// @Description Copy a schedule to the end of a board column, of its own or another workspace, with its participants, reminders, recurrence exceptions, document metadata and, with include_comments, comments. The acting workspace user, found through the user_id claim of its token and who must be allowed to create schedules where the copy goes, is its creator. In another workspace they must also be a member of the schedule's workspace, and participants and reminders are kept for the workspace users of the same email; documents and comments of others go to the acting user. Requires the dbms.admin scope.
+// @Tags schedule
+// @Accept json
+// @Produce json
+// @Param schedule_id path int true "Schedule ID"
+// @Param body body schedule.CloneRequest true "Where to copy the schedule"
+// @Success 201 {object} schedule.CloneResponse
+// @Failure 400 {object} fiber.Map "Board column not in the workspace"
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
+// @Failure 404 {object} fiber.Map "Schedule or board column not found"
+// @Router /dbms/v1/schedule/{schedule_id}/clone [post]
+func (h *ScheduleHandler) CloneSchedule(c *fiber.Ctx) error {
+	var request CloneRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	var source models.TwSchedule
+	if err := h.DB.Where("id = ? AND is_deleted = false AND deleted_at IS NULL", c.Params("schedule_id")).First(&source).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Schedule not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	boardColumn, err := h.cloneTarget(source, request)
+	if err != nil {
+		var fiberErr *fiber.Error
+		if errors.As(err, &fiberErr) {
+			return c.Status(fiberErr.Code).JSON(fiber.Map{
+				"error": fiberErr.Message,
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	actorId, err := permission.Actor(c, h.DB, boardColumn.WorkspaceId)
+	if err != nil {
+		return permission.Respond(c, err)
+	}
+	if _, err := permission.Authorize(h.DB, boardColumn.WorkspaceId, actorId, permission.ActionCreateSchedule); err != nil {
+		return permission.Respond(c, err)
+	}
+
+	members, err := clone.Members(h.DB, source.WorkspaceId, boardColumn.WorkspaceId)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	if source.WorkspaceId != boardColumn.WorkspaceId {
+		sourceActorId, err := permission.Actor(c, h.DB, source.WorkspaceId)
+		if err != nil {
+			return permission.Respond(c, err)
+		}
+		var joined int64
+		if err := h.DB.Model(&models.TwWorkspaceUser{}).
+			Where("workspace_id = ? AND deleted_at IS NULL AND status = 'joined' AND is_active = true", source.WorkspaceId).
+			Where("user_email_id = (?)", h.DB.Model(&models.TwWorkspaceUser{}).Select("user_email_id").Where("id = ?", sourceActorId)).
+			Count(&joined).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+		if joined == 0 {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "not a member of the schedule's workspace",
+			})
+		}
+	}
+
+	now := time.Now()
+	schedule := clone.Schedule(source, boardColumn.WorkspaceId, boardColumn.ID, actorId, now)
+	var rankKey string
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		var scheduleLog models.TwScheduleLog
+		var err error
+		if rankKey, scheduleLog, err = insertSchedule(tx, &schedule, actorId, fmt.Sprintf("Cloned from schedule %d", source.ID)); err != nil {
+			return err
+		}
+		if err := clone.Details(tx, source.ID, schedule.ID, members, actorId, request.IncludeComments); err != nil {
+			return err
+		}
+		return publishScheduleEvent(tx, realtime.ScheduleCreated, schedule, schedule.Position, []models.TwScheduleLog{scheduleLog})
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	if lexorank.NeedsRebalance(rankKey) {
+		lexorank.Schedules.RebalanceInBackground(h.DB, schedule.BoardColumnId)
+	}
+
+	response := CloneResponse{
+		TwCreateShecduleResponse: core_dtos.TwCreateShecduleResponse{
+			ID:            schedule.ID,
+			WorkspaceID:   schedule.WorkspaceId,
+			BoardColumnID: schedule.BoardColumnId,
+			Title:         schedule.Title,
+			Description:   schedule.Description,
+			Position:      schedule.Position,
+		},
+		SourceID: source.ID,
+	}
+	if schedule.StartTime != nil {
+		response.StartTime = *schedule.StartTime
+	}
+	if schedule.EndTime != nil {
+		response.EndTime = *schedule.EndTime
+	}
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
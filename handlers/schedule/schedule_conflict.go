@@ -0,0 +1,20 @@
+package schedule
+
+import (
+	"dbms/availability"
+	"github.com/timewise-team/timewise-models/dtos/core_dtos"
+)
+
+// CreateScheduleConflictsResponse is the created schedule together with the
+// creator's overlapping schedules, returned when conflicts=warn.
+type CreateScheduleConflictsResponse struct {
+	core_dtos.TwCreateShecduleResponse
+	Conflicts []availability.Conflict `json:"conflicts"`
+}
+
+// UpdateScheduleConflictsResponse is the updated schedule together with the
+// participants' overlapping schedules, returned when conflicts=warn.
+type UpdateScheduleConflictsResponse struct {
+	core_dtos.TwUpdateScheduleResponse
+	Conflicts []availability.Conflict `json:"conflicts"`
+}
@@ -0,0 +1,44 @@
+package schedule
+
+import (
+	"dbms/realtime"
+	"dbms/webhook"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+)
+
+// publishScheduleEvent records a schedule change on the workspace change feed
+// from inside the transaction that writes its tw_schedule_logs rows.
+func publishScheduleEvent(tx *gorm.DB, eventType string, schedule models.TwSchedule, position int, logs []models.TwScheduleLog) error {
+	data := realtime.ScheduleData{
+		ID:            schedule.ID,
+		BoardColumnID: schedule.BoardColumnId,
+		Title:         schedule.Title,
+		Position:      position,
+	}
+	for _, scheduleLog := range logs {
+		if scheduleLog.FieldChanged != "" {
+			data.ChangedFields = append(data.ChangedFields, scheduleLog.FieldChanged)
+		}
+	}
+	return realtime.Publish(tx, schedule.WorkspaceId, eventType, schedule.ID, data)
+}
+
+// enqueueStatusWebhook queues a schedule.status_changed webhook when logs
+// record a status change.
+func enqueueStatusWebhook(tx *gorm.DB, schedule models.TwSchedule, logs []models.TwScheduleLog) error {
+	for _, scheduleLog := range logs {
+		if scheduleLog.FieldChanged != "status" {
+			continue
+		}
+		return webhook.Enqueue(tx, schedule.WorkspaceId, webhook.EventScheduleStatusChanged, map[string]interface{}{
+			"schedule_id":       schedule.ID,
+			"board_column_id":   schedule.BoardColumnId,
+			"title":             schedule.Title,
+			"old_status":        scheduleLog.OldValue,
+			"new_status":        scheduleLog.NewValue,
+			"workspace_user_id": scheduleLog.WorkspaceUserId,
+		})
+	}
+	return nil
+}
@@ -1,13 +1,20 @@
 package schedule
 
 import (
+	"dbms/availability"
+	"dbms/common"
+	"dbms/datetime"
+	"dbms/lexorank"
+	"dbms/permission"
+	"dbms/preference"
+	"dbms/realtime"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"github.com/timewise-team/timewise-models/dtos/core_dtos"
 	"github.com/timewise-team/timewise-models/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"strconv"
 	"strings"
 	"time"
@@ -17,32 +24,44 @@ type ScheduleHandler struct {
 	DB *gorm.DB
 }
 
-func parseTime(timeStr string) (time.Time, error) {
-	// Sử dụng thư viện dateparse để phân tích chuỗi thời gian
-	layout := "2006-01-02 15:04:05.000"
-	parsedTime, err := time.ParseInLocation(layout, timeStr, time.UTC)
-	if err != nil {
-		return time.Time{}, err
-	}
-	return parsedTime.UTC(), nil
+// scheduleFields are the schedule columns list endpoints filter and sort by.
+// title and location search and start_time and end_time bound, as they did
+// before the field[op] syntax.
+var scheduleFields = common.FilterFields{
+	"id":              {Column: "tw_schedules.id", Type: common.FieldInt},
+	"workspace_id":    {Column: "tw_schedules.workspace_id", Type: common.FieldInt},
+	"board_column_id": {Column: "tw_schedules.board_column_id", Type: common.FieldInt},
+	"title":           {Column: "tw_schedules.title", Type: common.FieldString, Op: common.OpContains},
+	"location":        {Column: "tw_schedules.location", Type: common.FieldString, Op: common.OpContains},
+	"status":          {Column: "tw_schedules.status", Type: common.FieldString},
+	"priority":        {Column: "tw_schedules.priority", Type: common.FieldString},
+	"visibility":      {Column: "tw_schedules.visibility", Type: common.FieldString},
+	"all_day":         {Column: "tw_schedules.all_day", Type: common.FieldBool},
+	"is_deleted":      {Column: "tw_schedules.is_deleted", Type: common.FieldBool},
+	"created_by":      {Column: "tw_schedules.created_by", Type: common.FieldInt},
+	"start_time":      {Column: "tw_schedules.start_time", Type: common.FieldTime, Op: common.OpGte},
+	"end_time":        {Column: "tw_schedules.end_time", Type: common.FieldTime, Op: common.OpLte},
+	"created_at":      {Column: "tw_schedules.created_at", Type: common.FieldTime},
+	"updated_at":      {Column: "tw_schedules.updated_at", Type: common.FieldTime},
 }
 
 // FilterSchedules godoc
 // @Summary Filter schedule
-// @Description Filter schedules
+// @Description Filter schedules with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, e.g. status[in]=todo,done&start_time[gte]=2024-01-01T00:00:00Z&title[contains]=sync. The fields are id, workspace_id, board_column_id, title, location, status, priority, visibility, all_day, is_deleted, created_by, start_time, end_time, created_at and updated_at. Without an operator, title and location are contains, start_time is gte, end_time is lte and the others are eq, or in for a comma separated list.
 // @Tags schedule
 // @Accept json
 // @Produce json
-// @Param workspace_id query int false "Workspace ID"
+// @Param workspace_id query string false "Workspace ID, or a comma separated list of them"
 // @Param board_column_id query int false "Board Column ID"
 // @Param title query string false "Title of the schedule (searches with LIKE)"
-// @Param start_time query string false "Start time of the schedule (ISO8601 format, filter by schedules starting after this date)"
-// @Param end_time query string false "End time of the schedule (ISO8601 format, filter by schedules ending before this date)"
+// @Param start_time query string false "RFC 3339 time; schedules starting at or after it"
+// @Param end_time query string false "RFC 3339 time; schedules ending at or before it"
 // @Param location query string false "Location of the schedule (searches with LIKE)"
-// @Param created_by query int false "User ID of the creator"
+// @Param created_by query int false "Workspace user ID of the creator"
 // @Param status query string false "Status of the schedule"
 // @Param is_deleted query bool false "Filter by deleted schedules"
-// @Param assigned_to query int false "User ID assigned to the schedule"
+// @Param assigned_to query int false "Workspace user ID among the schedule's joined participants"
+// @Param sort query string false "Comma separated fields to sort by, each prefixed with - for descending order, e.g. -start_time,title"
 // @Success 200 {array} core_dtos.TwScheduleResponse "Filtered list of schedules"
 // @Failure 400 {object} fiber.Error "Invalid query parameters"
 // @Failure 500 {object} fiber.Error "Internal Server Error"
@@ -51,75 +70,33 @@ func (h *ScheduleHandler) FilterSchedules(c *fiber.Ctx) error {
 	var schedules []models.TwSchedule
 
 	query := h.DB.Table("tw_schedules").
+		Select("tw_schedules.*").
 		Joins("JOIN tw_workspaces ON tw_schedules.workspace_id = tw_workspaces.id AND tw_workspaces.deleted_at IS NULL").
 		Joins("JOIN tw_board_columns ON tw_schedules.board_column_id = tw_board_columns.id AND tw_board_columns.deleted_at IS NULL")
-	workspaceID := c.Query("workspace_id")
-	boardColumnID := c.Query("board_column_id")
-	title := c.Query("title")
-	startTime := c.Query("start_time")
-	endTime := c.Query("end_time")
-	location := c.Query("location")
-	createdBy := c.Query("created_by")
-	status := c.Query("status")
-	isDeleted := c.Query("is_deleted")
-	assignedTo := c.Query("assigned_to")
-
-	if workspaceID != "" {
-		workspaceIDSubStrings := strings.Split(workspaceID, ",")
-		query = query.Where("tw_schedules.workspace_id IN (?)", workspaceIDSubStrings)
-	}
-
-	if boardColumnID != "" {
-		query = query.Where("tw_schedules.board_column_id = ?", boardColumnID)
-	}
 
-	if title != "" {
-		query = query.Where("tw_schedules.title LIKE ?", "%"+title+"%")
+	filter, err := common.ParseFilter(c, scheduleFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
 	}
-
-	if startTime != "" {
-		parsedStartTime, err := parseTime(startTime)
-		if err != nil {
-			return err
-		}
-		query = query.Where("tw_schedules.start_time >= ?", parsedStartTime)
+	order, err := common.ParseSort(c, scheduleFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
 	}
+	query = order.Order(filter.Where(query))
 
-	if endTime != "" {
-		parsedEndTime, err := parseTime(endTime)
+	if assignedTo := c.Query("assigned_to"); assignedTo != "" {
+		workspaceUserId, err := strconv.Atoi(assignedTo)
 		if err != nil {
-			return err
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid value for assigned_to. Must be a workspace user ID")
 		}
-		query = query.Where("tw_schedules.end_time <= ?", parsedEndTime)
+		query = query.Where("EXISTS (?)", h.DB.Table("tw_schedule_participants").
+			Select("1").
+			Where("tw_schedule_participants.schedule_id = tw_schedules.id").
+			Where("tw_schedule_participants.workspace_user_id = ?", workspaceUserId).
+			Where("tw_schedule_participants.invitation_status = 'joined' AND tw_schedule_participants.deleted_at IS NULL"))
 	}
 
-	if location != "" {
-		query = query.Where("tw_schedules.location LIKE ?", "%"+location+"%")
-	}
-
-	if createdBy != "" {
-		query = query.Where("tw_schedules.created_by = ?", createdBy)
-	}
-
-	if status != "" {
-		query = query.Where("tw_schedules.status = ?", status)
-	}
-
-	if isDeleted != "" {
-		if isDeleted == "true" {
-			query = query.Where("tw_schedules.is_deleted = ?", 1)
-		} else if isDeleted == "false" {
-			query = query.Where("tw_schedules.is_deleted = ?", 0)
-		} else {
-			return c.Status(fiber.StatusBadRequest).SendString("Invalid value for is_deleted. Must be 'true' or 'false'")
-		}
-	}
-
-	if assignedTo != "" {
-		query = query.Where("tw_schedules.assigned_to @> ?", "{"+assignedTo+"}")
-	}
-
-	if result := query.Debug().Find(&schedules); result.Error != nil {
+	if result := query.Find(&schedules); result.Error != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
 	}
 
@@ -169,17 +146,33 @@ func (h *ScheduleHandler) FilterSchedules(c *fiber.Ctx) error {
 
 // GetSchedules godoc
 // @Summary Get all schedules
-// @Description Get all schedules
+// @Description Get a page of the schedules. The X-Total-Count header holds the number of schedules and X-Next-Cursor the cursor of the next page, if any. Filter with field=value or field[op]=value query parameters as in /schedule/schedules/filter.
 // @Tags schedule
 // @Accept json
 // @Produce json
+// @Param limit query int false "Maximum number of schedules (default 50, max 200); the whole list is returned when neither limit nor cursor is given"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Param sort query string false "Sort by id or one of the fields of /schedule/schedules/filter, prefixed with - for descending order (default id)"
 // @Success 200 {array} core_dtos.TwScheduleResponse
+// @Header 200 {integer} X-Total-Count "Number of schedules"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {string} string "Invalid sort or cursor"
 // @Router /dbms/v1/schedule [get]
 func (h *ScheduleHandler) GetSchedules(c *fiber.Ctx) error {
+	filter, err := common.ParseFilter(c, scheduleFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	page, err := common.ParsePage(c, scheduleFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
 	var schedules []models.TwSchedule
-	if result := h.DB.Find(&schedules); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	result, err := page.Find(filter.Where(h.DB), &schedules)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
+	result.Write(c)
 
 	var scheduleDTOs []core_dtos.TwScheduleResponse
 	for _, schedule := range schedules {
@@ -216,6 +209,7 @@ func (h *ScheduleHandler) GetSchedules(c *fiber.Ctx) error {
 // @Produce json
 // @Param schedule_id path int true "Schedule ID"
 // @Success 200 {object} core_dtos.TwScheduleResponse
+// @Header 200 {string} ETag "Version to send back in If-Match when updating"
 // @Router /dbms/v1/schedule/{schedule_id} [get]
 func (h *ScheduleHandler) GetScheduleById(c *fiber.Ctx) error {
 	var schedule models.TwSchedule
@@ -228,6 +222,20 @@ func (h *ScheduleHandler) GetScheduleById(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
+	if !schedule.IsDeleted {
+		position, err := lexorank.Schedules.Position(h.DB, schedule.BoardColumnId, schedule.ID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+		schedule.Position = position
+	}
+
+	version, err := currentVersion(h.DB, schedule.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	c.Set(fiber.HeaderETag, version.ETag())
+
 	var startTime, endTime, createdAt, updatedAt time.Time
 
 	if schedule.StartTime != nil {
@@ -272,12 +280,16 @@ func (h *ScheduleHandler) GetScheduleById(c *fiber.Ctx) error {
 
 // CreateSchedule godoc
 // @Summary Create a new schedule
-// @Description Create a new schedule
+// @Description Create a new schedule. start_time and end_time are RFC 3339 times with an offset, e.g. 2024-05-01T09:30:00+07:00; they default to now and an hour from now. The creator, workspace_user_id, must be one of the caller's workspace users, found through the user_id claim of its token, and be allowed to create schedules.
 // @Tags schedule
 // @Accept json
 // @Produce json
 // @Param schedule body core_dtos.TwCreateScheduleRequest true "Schedule"
+// @Param conflicts query string false "warn to also return the creator's overlapping schedules in any of their workspaces, reject to refuse with 409 when there are any"
 // @Success 201 {object} core_dtos.TwCreateShecduleResponse
+// @Failure 400 {string} string "Invalid start or end time"
+// @Failure 403 {object} fiber.Map "Permission denied"
+// @Failure 409 {object} availability.ConflictResponse "The creator has overlapping schedules"
 // @Router /dbms/v1/schedule [post]
 func (h *ScheduleHandler) CreateSchedule(c *fiber.Ctx) error {
 
@@ -285,132 +297,230 @@ func (h *ScheduleHandler) CreateSchedule(c *fiber.Ctx) error {
 	if err := c.BodyParser(&scheduleDTO); err != nil {
 		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
 	}
+	conflictMode, err := availability.ParseMode(c.Query("conflicts"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	startTime, err := parseScheduleTime(scheduleDTO.StartTime, false)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	endTime, err := parseScheduleTime(scheduleDTO.EndTime, false)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
 
-	var existingSchedules []models.TwSchedule
-	if err := h.DB.Where("board_column_id = ? and is_deleted = false", *scheduleDTO.BoardColumnID).Find(&existingSchedules).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	if scheduleDTO.WorkspaceID == nil || scheduleDTO.WorkspaceUserID == nil {
+		return c.Status(fiber.StatusBadRequest).SendString("workspace_id and workspace_user_id are required")
+	}
+	if err := permission.ActingAs(c, h.DB, *scheduleDTO.WorkspaceID, *scheduleDTO.WorkspaceUserID); err != nil {
+		return permission.Respond(c, err)
+	}
+	if _, err := permission.Authorize(h.DB, *scheduleDTO.WorkspaceID, *scheduleDTO.WorkspaceUserID, permission.ActionCreateSchedule); err != nil {
+		return permission.Respond(c, err)
 	}
 
 	now := time.Now()
-	endTime := now.Add(1 * time.Hour)
+	defaultEndTime := now.Add(1 * time.Hour)
 	schedule := models.TwSchedule{
 		WorkspaceId:   *scheduleDTO.WorkspaceID,
 		BoardColumnId: *scheduleDTO.BoardColumnID,
 		Title:         *scheduleDTO.Title,
 		Description:   *scheduleDTO.Description,
 		StartTime:     &now,
-		EndTime:       &endTime,
+		EndTime:       &defaultEndTime,
 		CreatedBy:     *scheduleDTO.WorkspaceUserID,
 		CreatedAt:     &now,
 		UpdatedAt:     &now,
-		Position:      len(existingSchedules) + 1,
 		Status:        "not yet",
 		Visibility:    "public",
 	}
 
-	if scheduleDTO.StartTime != nil {
-		isoTime := convertToISOFormat(*scheduleDTO.StartTime)
-		parsedTime := convertDateFormat(&isoTime)
-		if parsedTime != nil {
-			schedule.StartTime = parsedTime
-		}
+	if startTime != nil {
+		schedule.StartTime = startTime
+	}
+	if endTime != nil {
+		schedule.EndTime = endTime
+	}
 
+	var conflicts []availability.Conflict
+	if conflictMode != availability.ModeIgnore {
+		creatorIds, err := availability.WorkspaceUserIdsForPeople(h.DB, []int{schedule.CreatedBy})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+		conflicts, err = availability.Conflicts(h.DB, schedule, creatorIds, now)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+		if conflictMode == availability.ModeReject && len(conflicts) > 0 {
+			return availability.Respond(c, conflicts)
+		}
 	}
 
-	if scheduleDTO.EndTime != nil {
-		isoTime := convertToISOFormat(*scheduleDTO.EndTime)
-		parsedTime := convertDateFormat(&isoTime)
-		if parsedTime != nil {
-			schedule.EndTime = parsedTime
+	// The schedule, its log and its creator are written together, and the
+	// column lock keeps concurrent creates from taking the same rank key.
+	var rankKey string
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		var scheduleLog models.TwScheduleLog
+		var err error
+		if rankKey, scheduleLog, err = insertSchedule(tx, &schedule, *scheduleDTO.WorkspaceUserID, ""); err != nil {
+			return err
 		}
+		return publishScheduleEvent(tx, realtime.ScheduleCreated, schedule, schedule.Position, []models.TwScheduleLog{scheduleLog})
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).SendString("Board column not found")
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	if lexorank.NeedsRebalance(rankKey) {
+		lexorank.Schedules.RebalanceInBackground(h.DB, schedule.BoardColumnId)
 	}
 
-	if result := h.DB.Create(&schedule); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	response := core_dtos.TwCreateShecduleResponse{
+		ID:            schedule.ID,
+		WorkspaceID:   schedule.WorkspaceId,
+		BoardColumnID: schedule.BoardColumnId,
+		Title:         schedule.Title,
+		Description:   schedule.Description,
+		Position:      schedule.Position,
+		StartTime:     *schedule.StartTime,
+		EndTime:       *schedule.EndTime,
+	}
+	if conflictMode == availability.ModeWarn {
+		return c.Status(fiber.StatusCreated).JSON(CreateScheduleConflictsResponse{
+			TwCreateShecduleResponse: response,
+			Conflicts:                conflicts,
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+// insertSchedule adds schedule at the end of its board column, logs its
+// creation with description and joins creatorId to it as its creator. It
+// returns the rank key the schedule took and the log; publishing the change is
+// left to the caller, which may write more in the transaction first.
+func insertSchedule(tx *gorm.DB, schedule *models.TwSchedule, creatorId int, description string) (string, models.TwScheduleLog, error) {
+	var scheduleLog models.TwScheduleLog
+	if err := lexorank.Schedules.Lock(tx, schedule.BoardColumnId); err != nil {
+		return "", scheduleLog, err
+	}
+
+	var existingCount int64
+	if err := tx.Model(&models.TwSchedule{}).
+		Where("board_column_id = ? and is_deleted = false", schedule.BoardColumnId).
+		Count(&existingCount).Error; err != nil {
+		return "", scheduleLog, err
+	}
+	schedule.Position = int(existingCount) + 1
+
+	rankKey, err := lexorank.Schedules.KeyAt(tx, schedule.BoardColumnId, schedule.Position, 0)
+	if err != nil {
+		return "", scheduleLog, err
+	}
+
+	if err := tx.Create(schedule).Error; err != nil {
+		return "", scheduleLog, err
+	}
+	if err := lexorank.Schedules.Set(tx, schedule.ID, rankKey); err != nil {
+		return "", scheduleLog, err
 	}
 
-	newScheduleLog := models.TwScheduleLog{
+	scheduleLog = models.TwScheduleLog{
 		ScheduleId:      schedule.ID,
-		WorkspaceUserId: *scheduleDTO.WorkspaceUserID,
+		WorkspaceUserId: creatorId,
 		Action:          "create schedule",
+		Description:     description,
 	}
-
-	if result := h.DB.Create(&newScheduleLog); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	if err := tx.Create(&scheduleLog).Error; err != nil {
+		return "", scheduleLog, err
 	}
 
-	now = time.Now()
-	newScheduleParticipant := models.TwScheduleParticipant{
+	now := time.Now()
+	creator := models.TwScheduleParticipant{
 		CreatedAt:        now,
 		UpdatedAt:        now,
 		ScheduleId:       schedule.ID,
-		WorkspaceUserId:  *scheduleDTO.WorkspaceUserID,
+		WorkspaceUserId:  creatorId,
 		AssignAt:         &now,
-		AssignBy:         *scheduleDTO.WorkspaceUserID,
+		AssignBy:         creatorId,
 		Status:           "creator",
 		ResponseTime:     &now,
 		InvitationSentAt: &now,
 		InvitationStatus: "joined",
 	}
-
-	if result := h.DB.Create(&newScheduleParticipant); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	if err := tx.Create(&creator).Error; err != nil {
+		return "", scheduleLog, err
 	}
-
-	return c.Status(fiber.StatusCreated).JSON(core_dtos.TwCreateShecduleResponse{
-		ID:            schedule.ID,
-		WorkspaceID:   schedule.WorkspaceId,
-		BoardColumnID: schedule.BoardColumnId,
-		Title:         schedule.Title,
-		Description:   schedule.Description,
-		Position:      schedule.Position,
-		StartTime:     *schedule.StartTime,
-		EndTime:       *schedule.EndTime,
-	})
+	return rankKey, scheduleLog, nil
 }
 
-func convertToISOFormat(input string) string {
-	// Thay dấu cách bằng 'T' và bỏ phần '.000' ở cuối, sau đó thêm 'Z'
-	isoFormat := strings.Replace(input, " ", "T", 1)
-	isoFormat = strings.TrimSuffix(isoFormat, ".000") + "Z"
-	return isoFormat
+// parseScheduleTime reads the start or end time of a schedule: an RFC 3339
+// time, or for an all-day schedule a date, which is stored floating.
+func parseScheduleTime(value *string, allDay bool) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
+	parse := datetime.Parse
+	if allDay {
+		parse = datetime.ParseDate
+	}
+	parsed, err := parse(*value)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
 }
 
-func convertDateFormat(dateStr *string) *time.Time {
-	if dateStr == nil {
+// floatAllDayTimes moves the times of a schedule becoming all-day to the dates
+// they fall on for the editor, for the times the update does not replace.
+func floatAllDayTimes(tx *gorm.DB, schedule *models.TwSchedule, workspaceUserId int, start, end bool) error {
+	if !start && !end {
 		return nil
 	}
-
-	// Định dạng ngày giờ đầu vào, bao gồm múi giờ (UTC nếu không có múi giờ cụ thể)
-	const inputFormat = "2006-01-02T15:04:05Z07:00"
-
-	// Phân tích chuỗi ngày giờ theo múi giờ UTC
-	parsedTime, err := time.Parse(inputFormat, *dateStr)
+	preferences, err := preference.LoadForWorkspaceUser(tx, workspaceUserId)
 	if err != nil {
-		fmt.Println("Error parsing date:", err)
-		return nil
+		return err
 	}
-
-	// Trả về giá trị UTC để lưu trữ
-	utcTime := parsedTime.UTC()
-	return &utcTime
+	if start && schedule.StartTime != nil {
+		floating := datetime.Floating(schedule.StartTime.In(preferences.Location))
+		schedule.StartTime = &floating
+	}
+	if end && schedule.EndTime != nil {
+		floating := datetime.FloatingEnd(schedule.EndTime.In(preferences.Location))
+		schedule.EndTime = &floating
+	}
+	return nil
 }
 
 // UpdateSchedule godoc
 // @Summary Update an existing schedule
-// @Description Update an existing schedule
+// @Description Update an existing schedule. start_time and end_time are RFC 3339 times with an offset. All-day schedules take dates such as 2024-05-01 instead and are stored as floating dates, ending on the date after their last day; a schedule made all-day keeps the dates its times fall on for the editor. The editor, workspace_user_id in the path, must be one of the caller's workspace users, found through the user_id claim of its token.
 // @Tags schedule
 // @Accept json
 // @Produce json
 // @Param schedule_id path int true "Schedule ID"
 // @Param schedule body core_dtos.TwUpdateScheduleRequest true "Schedule"
+// @Param If-Match header string false "ETag from GetScheduleById; the update is refused if the schedule changed since"
+// @Param conflicts query string false "warn to also return the participants' overlapping schedules in any of their workspaces, reject to refuse with 409 when there are any"
 // @Success 200 {object} core_dtos.TwUpdateScheduleResponse
+// @Header 200 {string} ETag "New version of the schedule"
+// @Failure 400 {string} string "Invalid start or end time"
+// @Failure 403 {object} fiber.Map "Permission denied"
+// @Failure 409 {object} availability.ConflictResponse "Participants have overlapping schedules"
+// @Failure 412 {object} schedule.PreconditionFailedResponse "Schedule changed since the If-Match version"
 // @Router /dbms/v1/schedule/{schedule_id} [put]
 func (h *ScheduleHandler) UpdateSchedule(c *fiber.Ctx) error {
 	var scheduleDTO core_dtos.TwUpdateScheduleRequest
 	if err := c.BodyParser(&scheduleDTO); err != nil {
 		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
 	}
+	conflictMode, err := availability.ParseMode(c.Query("conflicts"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
 
 	var schedule models.TwSchedule
 
@@ -428,108 +538,178 @@ func (h *ScheduleHandler) UpdateSchedule(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
-	// Tạo danh sách các log khi trường được cập nhật
-	var logs []models.TwScheduleLog
-
-	// Hàm phụ: Kiểm tra và ghi log nếu có thay đổi
-	checkAndLog := func(field, oldValue, newValue string) {
-		if oldValue != newValue {
-			logs = append(logs, models.TwScheduleLog{
-				ScheduleId:      schedule.ID,
-				WorkspaceUserId: workspaceUserId,
-				Action:          "update schedule",
-				FieldChanged:    field,
-				OldValue:        oldValue,
-				NewValue:        newValue,
-			})
-		}
+	if err := permission.ActingAs(c, h.DB, schedule.WorkspaceId, workspaceUserId); err != nil {
+		return permission.Respond(c, err)
+	}
+	if _, err := permission.Authorize(h.DB, schedule.WorkspaceId, workspaceUserId, permission.ActionUpdateSchedule); err != nil {
+		return permission.Respond(c, err)
 	}
 
-	if scheduleDTO.Title != nil {
-		checkAndLog("title", schedule.Title, *scheduleDTO.Title)
-		schedule.Title = *scheduleDTO.Title
+	allDay := schedule.AllDay
+	if scheduleDTO.AllDay != nil {
+		allDay = *scheduleDTO.AllDay
 	}
-	if scheduleDTO.Description != nil {
-		checkAndLog("description", schedule.Description, *scheduleDTO.Description)
-		schedule.Description = *scheduleDTO.Description
+	startTime, err := parseScheduleTime(scheduleDTO.StartTime, allDay)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
 	}
-	if scheduleDTO.StartTime != nil {
-		oldStartTime := ""
-		if schedule.StartTime != nil {
-			oldStartTime = schedule.StartTime.String()
+	endTime, err := parseScheduleTime(scheduleDTO.EndTime, allDay)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	// Changes are computed against the schedule as locked, so that an If-Match
+	// check and the write cannot interleave with another editor.
+	var conflicts []availability.Conflict
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := lockForUpdate(tx, scheduleId, &schedule); err != nil {
+			return err
 		}
-		checkAndLog("start_time", oldStartTime, *scheduleDTO.StartTime)
-		parsedTime := convertDateFormat(scheduleDTO.StartTime)
-		if parsedTime != nil {
-			schedule.StartTime = parsedTime
+		if err := checkIfMatch(tx, c.Get(fiber.HeaderIfMatch), schedule.ID); err != nil {
+			return err
 		}
 
-	}
+		// Tạo danh sách các log khi trường được cập nhật
+		var logs []models.TwScheduleLog
+
+		// Hàm phụ: Kiểm tra và ghi log nếu có thay đổi
+		checkAndLog := func(field, oldValue, newValue string) {
+			if oldValue != newValue {
+				logs = append(logs, models.TwScheduleLog{
+					ScheduleId:      schedule.ID,
+					WorkspaceUserId: workspaceUserId,
+					Action:          "update schedule",
+					FieldChanged:    field,
+					OldValue:        oldValue,
+					NewValue:        newValue,
+				})
+			}
+		}
 
-	if scheduleDTO.EndTime != nil {
-		oldEndTime := ""
-		if schedule.EndTime != nil {
-			oldEndTime = schedule.EndTime.String()
+		if scheduleDTO.Title != nil {
+			checkAndLog("title", schedule.Title, *scheduleDTO.Title)
+			schedule.Title = *scheduleDTO.Title
 		}
-		checkAndLog("end_time", oldEndTime, *scheduleDTO.EndTime)
-		parsedTime := convertDateFormat(scheduleDTO.EndTime)
-		if parsedTime != nil {
-			schedule.EndTime = parsedTime
+		if scheduleDTO.Description != nil {
+			checkAndLog("description", schedule.Description, *scheduleDTO.Description)
+			schedule.Description = *scheduleDTO.Description
+		}
+		if startTime != nil {
+			oldStartTime := ""
+			if schedule.StartTime != nil {
+				oldStartTime = schedule.StartTime.String()
+			}
+			checkAndLog("start_time", oldStartTime, startTime.String())
+			schedule.StartTime = startTime
 		}
-	}
 
-	if scheduleDTO.Location != nil {
-		checkAndLog("location", schedule.Location, *scheduleDTO.Location)
-		schedule.Location = *scheduleDTO.Location
-	}
-	if scheduleDTO.Status != nil {
-		checkAndLog("status", schedule.Status, *scheduleDTO.Status)
-		schedule.Status = *scheduleDTO.Status
-	}
-	if scheduleDTO.AllDay != nil {
-		checkAndLog("all_day", strconv.FormatBool(schedule.AllDay), strconv.FormatBool(*scheduleDTO.AllDay))
-		schedule.AllDay = *scheduleDTO.AllDay
-	}
-	if scheduleDTO.Visibility != nil {
-		checkAndLog("visibility", schedule.Visibility, *scheduleDTO.Visibility)
-		schedule.Visibility = *scheduleDTO.Visibility
-	}
-	if scheduleDTO.ExtraData != nil {
-		checkAndLog("extra_data", schedule.ExtraData, *scheduleDTO.ExtraData)
-		schedule.ExtraData = *scheduleDTO.ExtraData
-	}
-	if scheduleDTO.RecurrencePattern != nil {
-		checkAndLog("recurrence_pattern", schedule.RecurrencePattern, *scheduleDTO.RecurrencePattern)
-		schedule.RecurrencePattern = *scheduleDTO.RecurrencePattern
-	}
-	if scheduleDTO.Priority != nil {
-		checkAndLog("priority", schedule.Priority, *scheduleDTO.Priority)
-		schedule.Priority = *scheduleDTO.Priority
-	}
-	if scheduleDTO.VideoTranscript != nil {
-		checkAndLog("video_transcript", schedule.VideoTranscript, *scheduleDTO.VideoTranscript)
-		schedule.VideoTranscript = *scheduleDTO.VideoTranscript
-	}
-	schedule.CreatedBy = workspaceUserId
+		if endTime != nil {
+			oldEndTime := ""
+			if schedule.EndTime != nil {
+				oldEndTime = schedule.EndTime.String()
+			}
+			checkAndLog("end_time", oldEndTime, endTime.String())
+			schedule.EndTime = endTime
+		}
 
-	// Update timestamp
-	now := time.Now()
-	schedule.UpdatedAt = &now
+		if scheduleDTO.Location != nil {
+			checkAndLog("location", schedule.Location, *scheduleDTO.Location)
+			schedule.Location = *scheduleDTO.Location
+		}
+		if scheduleDTO.Status != nil {
+			checkAndLog("status", schedule.Status, *scheduleDTO.Status)
+			schedule.Status = *scheduleDTO.Status
+		}
+		if scheduleDTO.AllDay != nil {
+			checkAndLog("all_day", strconv.FormatBool(schedule.AllDay), strconv.FormatBool(*scheduleDTO.AllDay))
+			if *scheduleDTO.AllDay && !schedule.AllDay {
+				if err := floatAllDayTimes(tx, &schedule, workspaceUserId, startTime == nil, endTime == nil); err != nil {
+					return err
+				}
+			}
+			schedule.AllDay = *scheduleDTO.AllDay
+		}
+		if schedule.AllDay && schedule.StartTime != nil && schedule.EndTime != nil && !schedule.EndTime.After(*schedule.StartTime) {
+			// An all-day schedule ends on the date after its last day.
+			nextDay := schedule.StartTime.AddDate(0, 0, 1)
+			schedule.EndTime = &nextDay
+		}
+		if scheduleDTO.Visibility != nil {
+			checkAndLog("visibility", schedule.Visibility, *scheduleDTO.Visibility)
+			schedule.Visibility = *scheduleDTO.Visibility
+		}
+		if scheduleDTO.ExtraData != nil {
+			checkAndLog("extra_data", schedule.ExtraData, *scheduleDTO.ExtraData)
+			schedule.ExtraData = *scheduleDTO.ExtraData
+		}
+		if scheduleDTO.RecurrencePattern != nil {
+			checkAndLog("recurrence_pattern", schedule.RecurrencePattern, *scheduleDTO.RecurrencePattern)
+			schedule.RecurrencePattern = *scheduleDTO.RecurrencePattern
+		}
+		if scheduleDTO.Priority != nil {
+			checkAndLog("priority", schedule.Priority, *scheduleDTO.Priority)
+			schedule.Priority = *scheduleDTO.Priority
+		}
+		if scheduleDTO.VideoTranscript != nil {
+			checkAndLog("video_transcript", schedule.VideoTranscript, *scheduleDTO.VideoTranscript)
+			schedule.VideoTranscript = *scheduleDTO.VideoTranscript
+		}
+		schedule.CreatedBy = workspaceUserId
 
-	// Lưu schedule đã cập nhật
-	if result := h.DB.Omit("deleted_at").Save(&schedule); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
-	}
+		// Update timestamp
+		now := time.Now()
+		schedule.UpdatedAt = &now
 
-	// Thêm các log vào cơ sở dữ liệu
-	if len(logs) > 0 {
-		if result := h.DB.Create(&logs); result.Error != nil {
-			return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+		// Participants are checked against the schedule as it will be saved.
+		if conflictMode != availability.ModeIgnore {
+			participantIds, err := availability.ParticipantIds(tx, schedule.ID)
+			if err != nil {
+				return err
+			}
+			if participantIds, err = availability.WorkspaceUserIdsForPeople(tx, participantIds); err != nil {
+				return err
+			}
+			if conflicts, err = availability.Conflicts(tx, schedule, participantIds, now); err != nil {
+				return err
+			}
+			if conflictMode == availability.ModeReject && len(conflicts) > 0 {
+				return &availability.ConflictError{Conflicts: conflicts}
+			}
 		}
+
+		// Lưu schedule đã cập nhật
+		if err := tx.Omit("deleted_at").Save(&schedule).Error; err != nil {
+			return err
+		}
+
+		// Thêm các log vào cơ sở dữ liệu
+		if len(logs) > 0 {
+			if err := tx.Create(&logs).Error; err != nil {
+				return err
+			}
+		}
+		if err := enqueueStatusWebhook(tx, schedule, logs); err != nil {
+			return err
+		}
+		return publishScheduleEvent(tx, realtime.ScheduleUpdated, schedule, 0, logs)
+	})
+	var stale *staleVersionError
+	if errors.As(err, &stale) {
+		return respondStaleVersion(c, stale)
+	}
+	var conflict *availability.ConflictError
+	if errors.As(err, &conflict) {
+		return availability.Respond(c, conflict.Conflicts)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	if version, err := currentVersion(h.DB, schedule.ID); err == nil {
+		c.Set(fiber.HeaderETag, version.ETag())
 	}
 
 	// Trả về kết quả cập nhật thành công
-	return c.JSON(core_dtos.TwUpdateScheduleResponse{
+	response := core_dtos.TwUpdateScheduleResponse{
 		ID:                schedule.ID,
 		WorkspaceID:       schedule.WorkspaceId,
 		BoardColumnID:     schedule.BoardColumnId,
@@ -550,7 +730,14 @@ func (h *ScheduleHandler) UpdateSchedule(c *fiber.Ctx) error {
 		Position:          schedule.Position,
 		Priority:          schedule.Priority,
 		VideoTranscript:   schedule.VideoTranscript,
-	})
+	}
+	if conflictMode == availability.ModeWarn {
+		return c.JSON(UpdateScheduleConflictsResponse{
+			TwUpdateScheduleResponse: response,
+			Conflicts:                conflicts,
+		})
+	}
+	return c.JSON(response)
 }
 
 func (h *ScheduleHandler) UpdateSchedulePosition(c *fiber.Ctx) error {
@@ -575,6 +762,17 @@ func (h *ScheduleHandler) UpdateSchedulePosition(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
+	if err := permission.ActingAs(c, h.DB, schedule.WorkspaceId, workspaceUserId); err != nil {
+		return permission.Respond(c, err)
+	}
+	if _, err := permission.Authorize(h.DB, schedule.WorkspaceId, workspaceUserId, permission.ActionMoveSchedule); err != nil {
+		return permission.Respond(c, err)
+	}
+
+	if scheduleDTO.BoardColumnID != nil && scheduleDTO.Position == nil {
+		return c.Status(fiber.StatusBadRequest).SendString("position is required")
+	}
+
 	var logs []models.TwScheduleLog
 
 	checkAndLog := func(field, oldValue, newValue string) {
@@ -590,93 +788,92 @@ func (h *ScheduleHandler) UpdateSchedulePosition(c *fiber.Ctx) error {
 		}
 	}
 
-	if scheduleDTO.BoardColumnID != nil {
-		if *scheduleDTO.BoardColumnID == schedule.BoardColumnId {
-			if *scheduleDTO.Position < schedule.Position {
-				var schedulesToUpdate []models.TwSchedule
-				if err := h.DB.Where("board_column_id = ? AND position < ? AND position >= ? AND is_deleted != 1", schedule.BoardColumnId, schedule.Position, scheduleDTO.Position).
-					Order("position ASC").Find(&schedulesToUpdate).Error; err != nil {
-					return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
-				}
+	// A move rewrites only the moved schedule: it gets a rank key between its
+	// new neighbours. Both columns stay locked so that concurrent drags on the
+	// board cannot pick the same key.
+	var rankKey string
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		lockedColumnId := schedule.BoardColumnId
+		if scheduleDTO.BoardColumnID != nil {
+			if err := lexorank.Schedules.Lock(tx, lockedColumnId, *scheduleDTO.BoardColumnID); err != nil {
+				return err
+			}
+		} else if err := lexorank.Schedules.Lock(tx, lockedColumnId); err != nil {
+			return err
+		}
 
-				for i := range schedulesToUpdate {
-					schedulesToUpdate[i].Position += 1
-					if err := h.DB.Omit("deleted_at,start_time,end_time").Save(&schedulesToUpdate[i]).Error; err != nil {
-						return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
-					}
-				}
-			} else if *scheduleDTO.Position > schedule.Position {
-				var schedulesToUpdate []models.TwSchedule
-				if err := h.DB.Where("board_column_id = ? AND position > ? AND position <= ? AND is_deleted != 1", schedule.BoardColumnId, schedule.Position, scheduleDTO.Position).
-					Order("position ASC").Find(&schedulesToUpdate).Error; err != nil {
-					return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
-				}
+		// Re-read the schedule now that nobody else can move it.
+		if err := lockForUpdate(tx, scheduleId, &schedule); err != nil {
+			return err
+		}
+		if schedule.BoardColumnId != lockedColumnId {
+			return errConcurrentMove
+		}
+		if err := checkIfMatch(tx, c.Get(fiber.HeaderIfMatch), schedule.ID); err != nil {
+			return err
+		}
 
-				for i := range schedulesToUpdate {
-					schedulesToUpdate[i].Position -= 1
-					if err := h.DB.Omit("deleted_at,start_time,end_time").Save(&schedulesToUpdate[i]).Error; err != nil {
-						return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
-					}
-				}
-			}
-			checkAndLog("position", strconv.Itoa(schedule.Position), strconv.Itoa(*scheduleDTO.Position))
-			schedule.Position = *scheduleDTO.Position
-		} else if *scheduleDTO.BoardColumnID != schedule.BoardColumnId {
-			var schedulesToUpdate []models.TwSchedule
-			if err := h.DB.Where("board_column_id = ? AND position > ? AND is_deleted != 1", schedule.BoardColumnId, schedule.Position).
-				Order("position ASC").Find(&schedulesToUpdate).Error; err != nil {
-				return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
-			}
+		now := time.Now()
+		schedule.UpdatedAt = &now
+		updates := map[string]interface{}{
+			"updated_at": now,
+		}
 
-			for i := range schedulesToUpdate {
-				schedulesToUpdate[i].Position -= 1
-				if err := h.DB.Omit("deleted_at,start_time,end_time").Save(&schedulesToUpdate[i]).Error; err != nil {
-					return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
-				}
-			}
-			var schedulesInBoardColumnToUpdate []models.TwSchedule
-			if err := h.DB.Where("board_column_id = ? AND position >= ? AND is_deleted != 1", scheduleDTO.BoardColumnID, scheduleDTO.Position).
-				Order("position ASC").Find(&schedulesInBoardColumnToUpdate).Error; err != nil {
-				return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
-			}
-			if len(schedulesInBoardColumnToUpdate) == 0 {
-				var maxPosition int
-				if err := h.DB.Model(&models.TwSchedule{}).
-					Where("board_column_id = ? AND is_deleted != 1", scheduleDTO.BoardColumnID).
-					Select("COALESCE(MAX(position), 0)").Scan(&maxPosition).Error; err != nil {
-					return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
-				}
-				checkAndLog("position", strconv.Itoa(schedule.Position), strconv.Itoa(*scheduleDTO.Position))
-				schedule.Position = maxPosition + 1
-			} else {
-				for i := range schedulesInBoardColumnToUpdate {
-					schedulesInBoardColumnToUpdate[i].Position += 1
-					if err := h.DB.Omit("deleted_at,start_time,end_time").Save(&schedulesInBoardColumnToUpdate[i]).Error; err != nil {
-						return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
-					}
-				}
-				checkAndLog("position", strconv.Itoa(schedule.Position), strconv.Itoa(*scheduleDTO.Position))
-				schedule.Position = *scheduleDTO.Position
+		if scheduleDTO.BoardColumnID != nil {
+			var err error
+			rankKey, err = lexorank.Schedules.KeyAt(tx, *scheduleDTO.BoardColumnID, *scheduleDTO.Position, schedule.ID)
+			if err != nil {
+				return err
 			}
+			checkAndLog("position", strconv.Itoa(schedule.Position), strconv.Itoa(*scheduleDTO.Position))
+			checkAndLog("board_column_id", strconv.Itoa(schedule.BoardColumnId), strconv.Itoa(*scheduleDTO.BoardColumnID))
+			schedule.BoardColumnId = *scheduleDTO.BoardColumnID
+			updates["board_column_id"] = schedule.BoardColumnId
+			updates["position"] = *scheduleDTO.Position
+			updates[lexorank.Column] = rankKey
 		}
-		checkAndLog("board_column_id", strconv.Itoa(schedule.BoardColumnId), strconv.Itoa(*scheduleDTO.BoardColumnID))
-		schedule.BoardColumnId = *scheduleDTO.BoardColumnID
-	}
 
-	// Update timestamp
-	now := time.Now()
-	schedule.UpdatedAt = &now
+		// Lưu schedule đã cập nhật
+		if err := tx.Model(&models.TwSchedule{}).Where("id = ?", schedule.ID).Updates(updates).Error; err != nil {
+			return err
+		}
 
-	// Lưu schedule đã cập nhật
-	if result := h.DB.Omit("deleted_at").Save(&schedule); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
-	}
+		// Thêm các log vào cơ sở dữ liệu
+		if len(logs) > 0 {
+			if err := tx.Create(&logs).Error; err != nil {
+				return err
+			}
+		}
 
-	// Thêm các log vào cơ sở dữ liệu
-	if len(logs) > 0 {
-		if result := h.DB.Create(&logs); result.Error != nil {
-			return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+		// Report where the schedule actually landed; positions past the end append.
+		position, err := lexorank.Schedules.Position(tx, schedule.BoardColumnId, schedule.ID)
+		if err != nil {
+			return err
+		}
+		schedule.Position = position
+		if scheduleDTO.BoardColumnID == nil {
+			return nil
 		}
+		return publishScheduleEvent(tx, realtime.ScheduleMoved, schedule, schedule.Position, logs)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).SendString("Board column not found")
+	}
+	if errors.Is(err, errConcurrentMove) {
+		return c.Status(fiber.StatusConflict).SendString(err.Error())
+	}
+	var stale *staleVersionError
+	if errors.As(err, &stale) {
+		return respondStaleVersion(c, stale)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	if lexorank.NeedsRebalance(rankKey) {
+		lexorank.Schedules.RebalanceInBackground(h.DB, schedule.BoardColumnId)
+	}
+	if version, err := currentVersion(h.DB, schedule.ID); err == nil {
+		c.Set(fiber.HeaderETag, version.ETag())
 	}
 
 	// Trả về kết quả cập nhật thành công
@@ -705,12 +902,13 @@ func (h *ScheduleHandler) UpdateSchedulePosition(c *fiber.Ctx) error {
 
 // DeleteSchedule godoc
 // @Summary Delete a schedule
-// @Description Delete a schedule
+// @Description Delete a schedule. The workspace_user_id in the path must be one of the caller's workspace users, found through the user_id claim of its token.
 // @Tags schedule
 // @Accept json
 // @Produce json
 // @Param schedule_id path int true "Schedule ID"
 // @Success 204 "No Content"
+// @Failure 403 {object} fiber.Map "Permission denied"
 // @Router /dbms/v1/schedule/{schedule_id} [delete]
 func (h *ScheduleHandler) DeleteSchedule(c *fiber.Ctx) error {
 	scheduleId := c.Params("schedule_id")
@@ -728,37 +926,52 @@ func (h *ScheduleHandler) DeleteSchedule(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
-	now := time.Now()
+	if err := permission.ActingAs(c, h.DB, schedule.WorkspaceId, workspaceUserId); err != nil {
+		return permission.Respond(c, err)
+	}
+	if _, err := permission.Authorize(h.DB, schedule.WorkspaceId, workspaceUserId, permission.ActionDeleteSchedule); err != nil {
+		return permission.Respond(c, err)
+	}
 
-	schedule.IsDeleted = true
-	schedule.UpdatedAt = &now
-	schedule.DeletedAt = &now
+	// Ranks of the remaining schedules are unaffected, so deleting only
+	// touches this row and its log.
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		// Re-read under a row lock so that two deletes cannot both pass the
+		// check and log or publish twice.
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", schedule.ID).
+			First(&schedule).Error; err != nil {
+			return err
+		}
+		if schedule.IsDeleted {
+			return gorm.ErrRecordNotFound
+		}
 
-	if result := h.DB.Omit("start_time,end_time").Save(&schedule); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
-	}
+		now := time.Now()
 
-	var schedulesToUpdate []models.TwSchedule
-	if err := h.DB.Where("board_column_id = ? AND position > ? AND is_deleted != 1", schedule.BoardColumnId, schedule.Position).
-		Order("position ASC").Find(&schedulesToUpdate).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
-	}
+		schedule.IsDeleted = true
+		schedule.UpdatedAt = &now
+		schedule.DeletedAt = &now
 
-	for i := range schedulesToUpdate {
-		schedulesToUpdate[i].Position -= 1
-		if err := h.DB.Omit("deleted_at,start_time,end_time").Save(&schedulesToUpdate[i]).Error; err != nil {
-			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		if err := tx.Omit("start_time,end_time").Save(&schedule).Error; err != nil {
+			return err
 		}
-	}
 
-	newScheduleLog := models.TwScheduleLog{
-		ScheduleId:      schedule.ID,
-		WorkspaceUserId: workspaceUserId,
-		Action:          "delete schedule",
+		newScheduleLog := models.TwScheduleLog{
+			ScheduleId:      schedule.ID,
+			WorkspaceUserId: workspaceUserId,
+			Action:          "delete schedule",
+		}
+		if err := tx.Create(&newScheduleLog).Error; err != nil {
+			return err
+		}
+		return publishScheduleEvent(tx, realtime.ScheduleDeleted, schedule, 0, nil)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).SendString("Schedule not found")
 	}
-
-	if result := h.DB.Create(&newScheduleLog); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
 	return c.SendStatus(fiber.StatusOK)
@@ -816,7 +1029,7 @@ func (h *ScheduleHandler) getSchedulesByBoardColumn(c *fiber.Ctx) error {
 	}
 	var schedules []models.TwSchedule
 	if result := h.DB.Where("board_column_id = ? and workspace_id = ? and is_deleted = false", boardColumnID, workspaceID).
-		Order("position").
+		Order(lexorank.Order).
 		Find(&schedules); result.Error != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": result.Error.Error(),
@@ -827,6 +1040,9 @@ func (h *ScheduleHandler) getSchedulesByBoardColumn(c *fiber.Ctx) error {
 			"message": "Failed to get schedules",
 		})
 	}
+	for i := range schedules {
+		schedules[i].Position = i + 1
+	}
 	return c.JSON(schedules)
 }
 
@@ -886,8 +1102,14 @@ func (h *ScheduleHandler) UpdateTranscriptBySchedule(ctx *fiber.Ctx) error {
 	schedule.UpdatedAt = &now
 
 	// Save the updated schedule back to the database
-	if result := h.DB.Save(&schedule); result.Error != nil {
-		return ctx.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&schedule).Error; err != nil {
+			return err
+		}
+		return publishScheduleEvent(tx, realtime.ScheduleUpdated, schedule, 0, []models.TwScheduleLog{{FieldChanged: "video_transcript"}})
+	})
+	if err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
 	// Return the updated schedule in the response
@@ -896,7 +1118,7 @@ func (h *ScheduleHandler) UpdateTranscriptBySchedule(ctx *fiber.Ctx) error {
 
 // GetSchedulesByBoardColumnFilter godoc
 // @Summary Get schedules by board column with filters
-// @Description Get schedules by board column with filters
+// @Description Get schedules by board column with filters. Besides the board shortcuts below, filter with field=value or field[op]=value query parameters as in /schedule/schedules/filter.
 // @Tags schedule
 // @Accept json
 // @Produce json
@@ -904,10 +1126,12 @@ func (h *ScheduleHandler) UpdateTranscriptBySchedule(ctx *fiber.Ctx) error {
 // @Param board_column_id path int true "Board Column ID"
 // @Param search query string false "Search by schedule title"
 // @Param member query string false "Filter by member emails"
-// @Param due query string false "Filter by due date (day, week, month)"
+// @Param X-Workspace-User-Id header int false "Workspace user whose timezone today, this week and overdue are computed in (default: UTC)"
+// @Param due query string false "Filter by start date: today (day), the 7 days (week) or the 30 days (month) from today"
 // @Param dueComplete query string false "Filter by due complete"
-// @Param overdue query string false "Filter by overdue"
+// @Param overdue query string false "Filter by overdue: started before today and not done"
 // @Param notDue query string false "Filter by not due"
+// @Param sort query string false "Comma separated fields to sort by, each prefixed with - for descending order (default: board order)"
 // @Success 200 {array} models.TwSchedule
 // @Failure 400 {object} fiber.Map
 // @Failure 500 {object} fiber.Map
@@ -926,6 +1150,18 @@ func (h *ScheduleHandler) getSchedulesByBoardColumnFilter(c *fiber.Ctx) error {
 	dueCompleteParam := c.Query("dueComplete")
 	overdueParam := c.Query("overdue")
 	notDueParam := c.Query("notDue")
+	filter, err := common.ParseFilter(c, scheduleFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	order, err := common.ParseSort(c, scheduleFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
 
 	var schedules []models.TwSchedule
 	query := h.DB.
@@ -934,27 +1170,32 @@ func (h *ScheduleHandler) getSchedulesByBoardColumnFilter(c *fiber.Ctx) error {
 		Joins("JOIN tw_schedule_participants ON tw_schedule_participants.schedule_id = tw_schedules.id").
 		Joins("JOIN tw_workspaces ON tw_workspaces.id = tw_schedules.workspace_id")
 
-	now := time.Now()
-	currentDate := now.Format("2006-01-02")
-	fmt.Println("Current date:", currentDate)
+	// Due dates are days of the requester's timezone.
+	loc, err := h.requesterLocation(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"message": err.Error(),
+		})
+	}
+	today := datetime.StartOfDay(time.Now(), loc)
 	// Apply filters
 	if search != "" {
 		query = query.Where("tw_schedules.title LIKE ?", "%"+search+"%")
 	}
 	if dueParam == "day" {
-		query = query.Where("DATE(tw_schedules.start_time) = ?", currentDate)
+		query = startsBetween(query, today, today.AddDate(0, 0, 1))
 	} else if dueParam == "week" {
-		weekEnd := now.AddDate(0, 0, 6).Format("2006-01-02")
-		query = query.Where("DATE(tw_schedules.start_time) >= ? AND DATE(tw_schedules.start_time) <= ?", currentDate, weekEnd)
+		query = startsBetween(query, today, today.AddDate(0, 0, 7))
 	} else if dueParam == "month" {
-		monthEnd := now.AddDate(0, 0, 29).Format("2006-01-02")
-		query = query.Where("DATE(tw_schedules.start_time) >= ? AND DATE(tw_schedules.start_time) <= ?", currentDate, monthEnd)
+		query = startsBetween(query, today, today.AddDate(0, 0, 30))
 	}
 	if dueCompleteParam == "true" {
 		query = query.Where("tw_schedules.status = 'done'")
 	}
 	if overdueParam == "true" {
-		query = query.Where("DATE(tw_schedules.start_time) < CURRENT_DATE AND tw_schedules.status != 'done'")
+		query = query.Where("(tw_schedules.all_day = false AND tw_schedules.start_time < ?) OR (tw_schedules.all_day = true AND tw_schedules.start_time < ?)",
+			today.UTC(), datetime.Floating(today)).
+			Where("tw_schedules.status != 'done'")
 	}
 	if notDueParam == "true" {
 		query = query.Where("tw_schedules.start_time IS NULL")
@@ -972,10 +1213,14 @@ func (h *ScheduleHandler) getSchedulesByBoardColumnFilter(c *fiber.Ctx) error {
 	}
 	query = query.
 		Where("tw_schedules.board_column_id = ? AND tw_schedules.workspace_id = ? AND tw_schedules.is_deleted = false AND tw_workspaces.deleted_at IS NULL", boardColumnID, workspaceID)
+	query = filter.Where(query)
+	if order.Empty() {
+		query = query.Order("tw_schedules.rank_key IS NULL, tw_schedules.rank_key, tw_schedules.id")
+	} else {
+		query = order.Order(query)
+	}
 
-	if result := query.
-		Order("tw_schedules.position").
-		Find(&schedules); result.Error != nil {
+	if result := query.Find(&schedules); result.Error != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"message": result.Error.Error(),
 		})
@@ -986,5 +1231,42 @@ func (h *ScheduleHandler) getSchedulesByBoardColumnFilter(c *fiber.Ctx) error {
 		})
 	}
 
+	// Positions are relative to the whole column, not to the filtered list.
+	if boardColumnIDInt, err := strconv.Atoi(boardColumnID); err == nil {
+		positions, err := lexorank.Schedules.Positions(h.DB, boardColumnIDInt)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"message": err.Error(),
+			})
+		}
+		for i := range schedules {
+			schedules[i].Position = positions[schedules[i].ID]
+		}
+	}
+
 	return c.JSON(schedules)
 }
+
+// requesterLocation returns the timezone of the workspace user in
+// HeaderWorkspaceUserID, or UTC when the request does not say who sends it.
+func (h *ScheduleHandler) requesterLocation(c *fiber.Ctx) (*time.Location, error) {
+	if c.Get(permission.HeaderWorkspaceUserID) == "" {
+		return time.UTC, nil
+	}
+	workspaceUserId, err := permission.ActorFromHeader(c)
+	if err != nil {
+		return nil, err
+	}
+	preferences, err := preference.LoadForWorkspaceUser(h.DB, workspaceUserId)
+	return preferences.Location, err
+}
+
+// startsBetween keeps the schedules starting on the days [from, to) of
+// from's timezone: timed schedules by their start time and all-day schedules
+// by their floating date.
+func startsBetween(query *gorm.DB, from, to time.Time) *gorm.DB {
+	return query.Where("(tw_schedules.all_day = false AND tw_schedules.start_time >= ? AND tw_schedules.start_time < ?) OR (tw_schedules.all_day = true AND tw_schedules.start_time >= ? AND tw_schedules.start_time < ?)",
+		from.UTC(), to.UTC(), datetime.Floating(from), datetime.Floating(to))
+}
+
+var errConcurrentMove = errors.New("schedule was moved by another request, reload and try again")
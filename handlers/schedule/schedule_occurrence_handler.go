@@ -0,0 +1,199 @@
+package schedule
+
+import (
+	"dbms/datetime"
+	"dbms/preference"
+	"dbms/recurrence"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxOccurrenceWindow bounds how far a single request may expand recurrences.
+const maxOccurrenceWindow = 366 * 24 * time.Hour
+
+type ScheduleOccurrenceResponse struct {
+	ScheduleID        int       `json:"schedule_id"`
+	WorkspaceID       int       `json:"workspace_id"`
+	BoardColumnID     int       `json:"board_column_id"`
+	Title             string    `json:"title"`
+	Location          string    `json:"location"`
+	Status            string    `json:"status"`
+	Priority          string    `json:"priority"`
+	AllDay            bool      `json:"all_day"`
+	StartTime         time.Time `json:"start_time"`
+	EndTime           time.Time `json:"end_time"`
+	OriginalStartTime time.Time `json:"original_start_time"`
+	IsRecurring       bool      `json:"is_recurring"`
+	IsMoved           bool      `json:"is_moved"`
+	ExceptionID       int       `json:"exception_id,omitempty"`
+}
+
+// GetScheduleOccurrences godoc
+// @Summary Get occurrences of a schedule
+// @Description Expand the schedule's recurrence pattern into concrete occurrences within a time window, applying recurrence exceptions. The pattern follows the wall clock of the creator's timezone, or of the timezone the schedule was imported with; all-day schedules keep their dates.
+// @Tags schedule
+// @Accept json
+// @Produce json
+// @Param schedule_id path int true "Schedule ID"
+// @Param from query string true "Window start (RFC 3339)"
+// @Param to query string true "Window end (RFC 3339)"
+// @Success 200 {array} ScheduleOccurrenceResponse
+// @Failure 400 {string} string "Invalid time window or recurrence pattern"
+// @Failure 404 {string} string "Schedule not found"
+// @Router /dbms/v1/schedule/{schedule_id}/occurrences [get]
+func (h *ScheduleHandler) GetScheduleOccurrences(c *fiber.Ctx) error {
+	from, to, err := parseOccurrenceWindow(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	var schedule models.TwSchedule
+	if err := h.DB.Where("id = ? AND is_deleted = false", c.Params("schedule_id")).First(&schedule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).SendString("Schedule not found")
+		}
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	exceptions, err := h.getRecurrenceExceptions([]int{schedule.ID})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	preferences, err := preference.LoadForWorkspaceUser(h.DB, schedule.CreatedBy)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	occurrences, err := recurrence.Expand(schedule, exceptions, from, to, preferences.Location)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid recurrence pattern: " + err.Error())
+	}
+
+	response := make([]ScheduleOccurrenceResponse, 0, len(occurrences))
+	for _, occurrence := range occurrences {
+		response = append(response, toOccurrenceResponse(schedule, occurrence))
+	}
+	return c.JSON(response)
+}
+
+// GetWorkspaceOccurrences godoc
+// @Summary Get schedule occurrences in a workspace
+// @Description Expand every schedule of the workspace into concrete occurrences within a time window, applying recurrence exceptions. Each pattern follows the wall clock of its creator's timezone, or of the timezone the schedule was imported with; all-day schedules keep their dates.
+// @Tags schedule
+// @Accept json
+// @Produce json
+// @Param workspace_id path int true "Workspace ID"
+// @Param from query string true "Window start (RFC 3339)"
+// @Param to query string true "Window end (RFC 3339)"
+// @Success 200 {array} ScheduleOccurrenceResponse
+// @Failure 400 {string} string "Invalid time window"
+// @Router /dbms/v1/schedule/workspace/{workspace_id}/occurrences [get]
+func (h *ScheduleHandler) GetWorkspaceOccurrences(c *fiber.Ctx) error {
+	from, to, err := parseOccurrenceWindow(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	var schedules []models.TwSchedule
+	if err := h.DB.
+		Where("workspace_id = ? AND is_deleted = false", c.Params("workspace_id")).
+		Where("start_time IS NOT NULL AND start_time < ?", to).
+		Where("(recurrence_pattern IS NOT NULL AND recurrence_pattern != '') OR COALESCE(end_time, start_time) >= ?", from).
+		Find(&schedules).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	response, err := h.expandSchedules(schedules, from, to, nil)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	return c.JSON(response)
+}
+
+// expandSchedules expands the given schedules into occurrences overlapping
+// [from, to), sorted by start time. Recurrences follow the wall clock of loc,
+// or of each schedule's creator when loc is nil. Schedules whose recurrence
+// pattern cannot be parsed are logged and skipped.
+func (h *ScheduleHandler) expandSchedules(schedules []models.TwSchedule, from, to time.Time, loc *time.Location) ([]ScheduleOccurrenceResponse, error) {
+	scheduleIds := make([]int, 0, len(schedules))
+	creatorIds := make([]int, 0, len(schedules))
+	for _, schedule := range schedules {
+		scheduleIds = append(scheduleIds, schedule.ID)
+		creatorIds = append(creatorIds, schedule.CreatedBy)
+	}
+	exceptions, err := h.getRecurrenceExceptions(scheduleIds)
+	if err != nil {
+		return nil, err
+	}
+	var locations map[int]*time.Location
+	if loc == nil {
+		if locations, err = preference.Locations(h.DB, creatorIds); err != nil {
+			return nil, err
+		}
+	}
+
+	response := make([]ScheduleOccurrenceResponse, 0)
+	for _, schedule := range schedules {
+		scheduleLoc := loc
+		if scheduleLoc == nil {
+			scheduleLoc = locations[schedule.CreatedBy]
+		}
+		occurrences, err := recurrence.Expand(schedule, exceptions, from, to, scheduleLoc)
+		if err != nil {
+			log.Printf("Skipping schedule %d with invalid recurrence pattern: %v", schedule.ID, err)
+			continue
+		}
+		for _, occurrence := range occurrences {
+			response = append(response, toOccurrenceResponse(schedule, occurrence))
+		}
+	}
+	sortOccurrences(response)
+	return response, nil
+}
+
+func (h *ScheduleHandler) getRecurrenceExceptions(scheduleIds []int) ([]models.TwRecurrenceException, error) {
+	var exceptions []models.TwRecurrenceException
+	if len(scheduleIds) == 0 {
+		return exceptions, nil
+	}
+	err := h.DB.
+		Where("schedule_id IN (?)", scheduleIds).
+		Where("deleted_at IS NULL").
+		Find(&exceptions).Error
+	return exceptions, err
+}
+
+func toOccurrenceResponse(schedule models.TwSchedule, occurrence recurrence.Occurrence) ScheduleOccurrenceResponse {
+	return ScheduleOccurrenceResponse{
+		ScheduleID:        schedule.ID,
+		WorkspaceID:       schedule.WorkspaceId,
+		BoardColumnID:     schedule.BoardColumnId,
+		Title:             schedule.Title,
+		Location:          schedule.Location,
+		Status:            schedule.Status,
+		Priority:          schedule.Priority,
+		AllDay:            schedule.AllDay,
+		StartTime:         occurrence.Start,
+		EndTime:           occurrence.End,
+		OriginalStartTime: occurrence.OriginalStart,
+		IsRecurring:       strings.TrimSpace(schedule.RecurrencePattern) != "",
+		IsMoved:           occurrence.IsMoved,
+		ExceptionID:       occurrence.ExceptionId,
+	}
+}
+
+func sortOccurrences(occurrences []ScheduleOccurrenceResponse) {
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].StartTime.Before(occurrences[j].StartTime)
+	})
+}
+
+func parseOccurrenceWindow(c *fiber.Ctx) (time.Time, time.Time, error) {
+	return datetime.ParseWindow(c.Query("from"), c.Query("to"), maxOccurrenceWindow)
+}
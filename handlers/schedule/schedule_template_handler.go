@@ -0,0 +1,289 @@
+package schedule
+
+import (
+	"dbms/datetime"
+	"dbms/lexorank"
+	"dbms/permission"
+	"dbms/preference"
+	"dbms/realtime"
+	"dbms/repository"
+	"dbms/scheduletemplate"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/timewise-team/timewise-models/dtos/core_dtos"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+type FromTemplateRequest struct {
+	BoardColumnID int `json:"board_column_id"`
+	// StartTime is an RFC 3339 time; the schedule starts now when it is omitted.
+	StartTime *string `json:"start_time"`
+	// AssigneeID is a workspace user who fills in {{assignee}} and is added
+	// along with the template's participants.
+	AssigneeID *int `json:"assignee_id"`
+	// Variables fill in the template's own placeholders, and may override
+	// date, time, assignee and workspace.
+	Variables map[string]string `json:"variables"`
+}
+
+type FromTemplateResponse struct {
+	core_dtos.TwCreateShecduleResponse
+	TemplateID int `json:"template_id"`
+	// Participants are the workspace users added besides the creator.
+	Participants []int                            `json:"participants"`
+	Reminders    []FromTemplateReminder           `json:"reminders"`
+	Checklist    []scheduletemplate.ChecklistItem `json:"checklist"`
+}
+
+type FromTemplateReminder struct {
+	ID           int       `json:"id"`
+	ReminderTime time.Time `json:"reminder_time"`
+	Type         string    `json:"type"`
+	Method       string    `json:"method"`
+}
+
+// CreateScheduleFromTemplate godoc
+// @Summary Create a schedule from a template
+// @Description Create a schedule in a board column from a schedule template of its workspace, like POST /schedule: the acting workspace user, found through the user_id claim of its token, is its creator and the creation is logged. The template's participants and the assignee are added and notified, its reminders are set relative to the start time, skipping those that would already be due, and its checklist goes into extra_data. {{date}} and {{time}} are the start in the creator's timezone, {{assignee}} the assignee's name and {{workspace}} the workspace title; other placeholders take the request's variables.
+// @Tags schedule
+// @Accept json
+// @Produce json
+// @Param template_id path int true "Template ID"
+// @Param body body schedule.FromTemplateRequest true "Schedule"
+// @Success 201 {object} schedule.FromTemplateResponse
+// @Failure 400 {object} fiber.Map "Invalid start time, assignee or missing variables"
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
+// @Failure 404 {object} fiber.Map "Template or board column not found"
+// @Router /dbms/v1/schedule/from_template/{template_id} [post]
+func (h *ScheduleHandler) CreateScheduleFromTemplate(c *fiber.Ctx) error {
+	var request FromTemplateRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	var template scheduletemplate.Template
+	if err := h.DB.Where("id = ? AND deleted_at IS NULL", c.Params("template_id")).First(&template).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Template not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	actorId, err := permission.Actor(c, h.DB, template.WorkspaceId)
+	if err != nil {
+		return permission.Respond(c, err)
+	}
+	if _, err := permission.Authorize(h.DB, template.WorkspaceId, actorId, permission.ActionCreateSchedule); err != nil {
+		return permission.Respond(c, err)
+	}
+
+	var boardColumn models.TwBoardColumn
+	if err := h.DB.Where("id = ? AND workspace_id = ? AND deleted_at IS NULL", request.BoardColumnID, template.WorkspaceId).
+		Preload("Workspace").
+		First(&boardColumn).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Board column not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	startTime := time.Now().UTC()
+	if request.StartTime != nil {
+		if startTime, err = datetime.Parse(*request.StartTime); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+	endTime := startTime.Add(time.Duration(template.DurationMinutes) * time.Minute)
+
+	preferences, err := preference.LoadForWorkspaceUser(h.DB, actorId)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	local := startTime.In(preferences.Location)
+	values := map[string]string{
+		scheduletemplate.VariableDate:      local.Format(datetime.DateLayout),
+		scheduletemplate.VariableTime:      local.Format("15:04"),
+		scheduletemplate.VariableWorkspace: boardColumn.Workspace.Title,
+	}
+
+	invited := make(map[int]bool)
+	var participantIds []int
+	invite := func(workspaceUserId int) {
+		if workspaceUserId != actorId && !invited[workspaceUserId] {
+			invited[workspaceUserId] = true
+			participantIds = append(participantIds, workspaceUserId)
+		}
+	}
+	if request.AssigneeID != nil {
+		var assignee models.TwWorkspaceUser
+		if err := h.DB.Where("id = ? AND workspace_id = ?", *request.AssigneeID, template.WorkspaceId).
+			Where("deleted_at IS NULL AND status = 'joined' AND is_active = true").
+			Preload("UserEmail.User").
+			First(&assignee).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "assignee is not a member of the workspace",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+		user := assignee.UserEmail.User
+		values[scheduletemplate.VariableAssignee] = strings.TrimSpace(user.FirstName + " " + user.LastName)
+		if values[scheduletemplate.VariableAssignee] == "" {
+			values[scheduletemplate.VariableAssignee] = assignee.UserEmail.Email
+		}
+		invite(assignee.ID)
+	}
+	for name, value := range request.Variables {
+		values[name] = value
+	}
+	rendered, err := template.Render(values)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	extraData, err := rendered.ExtraData()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	// Participants who left the workspace since the template was saved are
+	// skipped rather than failing every use of it.
+	if len(template.Participants) > 0 {
+		var members []int
+		if err := h.DB.Model(&models.TwWorkspaceUser{}).
+			Where("id IN (?) AND workspace_id = ?", template.Participants, template.WorkspaceId).
+			Where("deleted_at IS NULL AND status = 'joined' AND is_active = true").
+			Pluck("id", &members).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+		joined := make(map[int]bool, len(members))
+		for _, id := range members {
+			joined[id] = true
+		}
+		for _, id := range template.Participants {
+			if joined[id] {
+				invite(id)
+			}
+		}
+	}
+
+	now := time.Now()
+	schedule := models.TwSchedule{
+		WorkspaceId:   template.WorkspaceId,
+		BoardColumnId: boardColumn.ID,
+		Title:         rendered.Title,
+		Description:   rendered.Description,
+		Location:      rendered.Location,
+		StartTime:     &startTime,
+		EndTime:       &endTime,
+		CreatedBy:     actorId,
+		CreatedAt:     &now,
+		UpdatedAt:     &now,
+		Status:        "not yet",
+		Visibility:    template.Visibility,
+		Priority:      template.Priority,
+		ExtraData:     extraData,
+	}
+	if schedule.Visibility == "" {
+		schedule.Visibility = "public"
+	}
+
+	response := FromTemplateResponse{
+		TemplateID:   template.ID,
+		Participants: []int{},
+		Reminders:    []FromTemplateReminder{},
+		Checklist:    rendered.Checklist,
+	}
+	if response.Checklist == nil {
+		response.Checklist = []scheduletemplate.ChecklistItem{}
+	}
+	var rankKey string
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		var scheduleLog models.TwScheduleLog
+		var err error
+		if rankKey, scheduleLog, err = insertSchedule(tx, &schedule, actorId, fmt.Sprintf("Created from template %q", template.Name)); err != nil {
+			return err
+		}
+
+		for _, workspaceUserId := range participantIds {
+			participant := models.TwScheduleParticipant{
+				CreatedAt:        now,
+				UpdatedAt:        now,
+				ScheduleId:       schedule.ID,
+				WorkspaceUserId:  workspaceUserId,
+				AssignAt:         &now,
+				AssignBy:         actorId,
+				Status:           "participant",
+				InvitationSentAt: &now,
+				InvitationStatus: "joined",
+			}
+			if err := tx.Create(&participant).Error; err != nil {
+				return err
+			}
+			if err := realtime.PublishItem(tx, realtime.ParticipantCreated, realtime.ItemData{
+				ID:              participant.ID,
+				ScheduleID:      participant.ScheduleId,
+				WorkspaceUserID: participant.WorkspaceUserId,
+			}); err != nil {
+				return err
+			}
+			assigned := request.AssigneeID != nil && *request.AssigneeID == workspaceUserId
+			if err := repository.NotifyScheduleParticipant(tx, schedule, workspaceUserId, assigned); err != nil {
+				return err
+			}
+			response.Participants = append(response.Participants, workspaceUserId)
+		}
+
+		for _, templateReminder := range template.Reminders {
+			reminder := models.TwReminder{
+				ScheduleId:      schedule.ID,
+				ReminderTime:    startTime.Add(-time.Duration(templateReminder.MinutesBefore) * time.Minute),
+				Method:          templateReminder.Method,
+				Type:            templateReminder.Type,
+				WorkspaceUserID: actorId,
+			}
+			// A reminder that is already due would go out at once.
+			if reminder.ReminderTime.Before(now) {
+				continue
+			}
+			if err := tx.Create(&reminder).Error; err != nil {
+				return err
+			}
+			response.Reminders = append(response.Reminders, FromTemplateReminder{
+				ID:           reminder.ID,
+				ReminderTime: reminder.ReminderTime,
+				Type:         reminder.Type,
+				Method:       reminder.Method,
+			})
+		}
+		return publishScheduleEvent(tx, realtime.ScheduleCreated, schedule, schedule.Position, []models.TwScheduleLog{scheduleLog})
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	if lexorank.NeedsRebalance(rankKey) {
+		lexorank.Schedules.RebalanceInBackground(h.DB, schedule.BoardColumnId)
+	}
+
+	response.TwCreateShecduleResponse = core_dtos.TwCreateShecduleResponse{
+		ID:            schedule.ID,
+		WorkspaceID:   schedule.WorkspaceId,
+		BoardColumnID: schedule.BoardColumnId,
+		Title:         schedule.Title,
+		Description:   schedule.Description,
+		Position:      schedule.Position,
+		StartTime:     *schedule.StartTime,
+		EndTime:       *schedule.EndTime,
+	}
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
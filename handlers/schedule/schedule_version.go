@@ -0,0 +1,123 @@
+package schedule
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+// A schedule's version is the id of its latest tw_schedule_logs row together
+// with its updated_at. The log id lets a stale client be told what changed
+// since; updated_at catches writes that do not log anything.
+type scheduleVersion struct {
+	LogId     int
+	UpdatedAt int64
+}
+
+func (v scheduleVersion) ETag() string {
+	return fmt.Sprintf(`"%d-%d"`, v.LogId, v.UpdatedAt)
+}
+
+// parseETag reads a version back from an entity tag, accepting the weak form too.
+func parseETag(tag string) (scheduleVersion, bool) {
+	var version scheduleVersion
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+	if _, err := fmt.Sscanf(tag, `"%d-%d"`, &version.LogId, &version.UpdatedAt); err != nil {
+		return version, false
+	}
+	return version, true
+}
+
+// PreconditionFailedResponse is returned with 412 when If-Match names an old version.
+type PreconditionFailedResponse struct {
+	Message       string   `json:"message"`
+	ETag          string   `json:"etag"`
+	ChangedFields []string `json:"changed_fields"`
+}
+
+// staleVersionError carries the 412 response out of a transaction.
+type staleVersionError struct {
+	PreconditionFailedResponse
+}
+
+func (e *staleVersionError) Error() string {
+	return e.Message
+}
+
+func respondStaleVersion(c *fiber.Ctx, stale *staleVersionError) error {
+	c.Set(fiber.HeaderETag, stale.ETag)
+	return c.Status(fiber.StatusPreconditionFailed).JSON(stale.PreconditionFailedResponse)
+}
+
+// currentVersion reads the version of a schedule as stored.
+func currentVersion(db *gorm.DB, scheduleId int) (scheduleVersion, error) {
+	var version scheduleVersion
+	var schedule models.TwSchedule
+	if err := db.Select("id", "updated_at").Where("id = ?", scheduleId).First(&schedule).Error; err != nil {
+		return version, err
+	}
+	if schedule.UpdatedAt != nil {
+		version.UpdatedAt = schedule.UpdatedAt.UnixMilli()
+	}
+	err := db.Model(&models.TwScheduleLog{}).
+		Where("schedule_id = ?", scheduleId).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&version.LogId).Error
+	return version, err
+}
+
+// lockForUpdate re-reads a schedule with a row lock held until the end of tx.
+func lockForUpdate(tx *gorm.DB, scheduleId interface{}, schedule *models.TwSchedule) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", scheduleId).
+		First(schedule).Error
+}
+
+// checkIfMatch enforces an If-Match header against the locked schedule. A
+// missing header or "*" always matches. On a mismatch the returned
+// *staleVersionError lists the fields logged after the client's version.
+func checkIfMatch(tx *gorm.DB, ifMatch string, scheduleId int) error {
+	ifMatch = strings.TrimSpace(ifMatch)
+	if ifMatch == "" || ifMatch == "*" {
+		return nil
+	}
+	current, err := currentVersion(tx, scheduleId)
+	if err != nil {
+		return err
+	}
+
+	// The oldest version the client named decides what it has not seen.
+	seenLogId := -1
+	for _, tag := range strings.Split(ifMatch, ",") {
+		version, ok := parseETag(tag)
+		if !ok {
+			continue
+		}
+		if version == current {
+			return nil
+		}
+		if seenLogId < 0 || version.LogId < seenLogId {
+			seenLogId = version.LogId
+		}
+	}
+
+	changedFields := []string{}
+	if seenLogId >= 0 {
+		if err := tx.Model(&models.TwScheduleLog{}).
+			Where("schedule_id = ? AND id > ?", scheduleId, seenLogId).
+			Where("field_changed <> ''").
+			Group("field_changed").
+			Order("MIN(id)").
+			Pluck("field_changed", &changedFields).Error; err != nil {
+			return err
+		}
+	}
+	return &staleVersionError{PreconditionFailedResponse{
+		Message:       "schedule was changed since this version, reload and try again",
+		ETag:          current.ETag(),
+		ChangedFields: changedFields,
+	}}
+}
@@ -1,6 +1,7 @@
 package schedule_log
 
 import (
+	"dbms/common"
 	"errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/timewise-team/timewise-models/dtos/core_dtos/schedule_log_dtos"
@@ -9,19 +10,46 @@ import (
 	"log"
 )
 
+// scheduleLogFields are the schedule log columns list endpoints filter and
+// sort by.
+var scheduleLogFields = common.FilterFields{
+	"id":                {Column: "id", Type: common.FieldInt},
+	"schedule_id":       {Column: "schedule_id", Type: common.FieldInt},
+	"workspace_user_id": {Column: "workspace_user_id", Type: common.FieldInt},
+	"action":            {Column: "action", Type: common.FieldString},
+	"field_changed":     {Column: "field_changed", Type: common.FieldString},
+	"created_at":        {Column: "created_at", Type: common.FieldTime},
+}
+
 // getScheduleLogs godoc
 // @Summary Get all schedule logs
-// @Description Get all schedule logs
+// @Description Get a page of the schedule logs. The X-Total-Count header holds the number of schedule logs and X-Next-Cursor the cursor of the next page, if any. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, schedule_id, workspace_user_id, action, field_changed and created_at.
 // @Tags schedule_log
 // @Accept json
 // @Produce json
+// @Param limit query int false "Maximum number of schedule logs (default 50, max 200); the whole list is returned when neither limit nor cursor is given"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Param sort query string false "Sort by one of the filter fields, prefixed with - for descending order (default id)"
 // @Success 200 {array} models.TwScheduleLog
+// @Header 200 {integer} X-Total-Count "Number of schedule logs"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {string} string "Invalid sort or cursor"
 // @Router /dbms/v1/schedule_log [get]
 func (h *ScheduleLogHandler) getScheduleLogs(c *fiber.Ctx) error {
+	filter, err := common.ParseFilter(c, scheduleLogFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	page, err := common.ParsePage(c, scheduleLogFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
 	var scheduleLogs []models.TwScheduleLog
-	if result := h.DB.Find(&scheduleLogs); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	result, err := page.Find(filter.Where(h.DB), &scheduleLogs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
+	result.Write(c)
 
 	return c.JSON(scheduleLogs)
 }
@@ -116,6 +144,17 @@ func (h *ScheduleLogHandler) createScheduleLog(c *fiber.Ctx) error {
 	return c.JSON(scheduleLog)
 }
 
+// getScheduleLogsByScheduleID godoc
+// @Summary Get schedule logs by schedule
+// @Description Get the logs of a schedule with the members who made the changes. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, schedule_id, workspace_user_id, action, field_changed and created_at.
+// @Tags schedule_log
+// @Accept json
+// @Produce json
+// @Param scheduleId path int true "Schedule ID"
+// @Param sort query string false "Comma separated fields to sort by, each prefixed with - for descending order"
+// @Success 200 {array} schedule_log_dtos.TwScheduleLogResponse
+// @Failure 400 {object} fiber.Map "Invalid schedule ID, filter or sort"
+// @Router /dbms/v1/schedule_log/schedule/{scheduleId} [get]
 func (h *ScheduleLogHandler) getScheduleLogsByScheduleID(c *fiber.Ctx) error {
 	var scheduleLogs []schedule_log_dtos.TwScheduleLogResponse
 	scheduleId := c.Params("scheduleId")
@@ -125,9 +164,22 @@ func (h *ScheduleLogHandler) getScheduleLogsByScheduleID(c *fiber.Ctx) error {
 			"error": "Schedule ID không hợp lệ",
 		})
 	}
+	fields := scheduleLogFields.On("sl")
+	filter, err := common.ParseFilter(c, fields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	order, err := common.ParseSort(c, fields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
 	// Perform the SQL query with multiple joins
-	err := h.DB.Table("tw_schedule_logs AS sl").
+	err = order.Order(filter.Where(h.DB)).Table("tw_schedule_logs AS sl").
 		Select(`
             sl.id AS id,
             sl.created_at,
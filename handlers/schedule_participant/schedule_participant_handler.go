@@ -1,6 +1,10 @@
 package schedule_participant
 
 import (
+	"dbms/availability"
+	"dbms/common"
+	"dbms/realtime"
+	"dbms/repository"
 	"errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/timewise-team/timewise-models/dtos/core_dtos/schedule_participant_dtos"
@@ -8,24 +12,55 @@ import (
 	"gorm.io/gorm"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// participantFields are the participant columns list endpoints filter and
+// sort by.
+var participantFields = common.FilterFields{
+	"id":                 {Column: "id", Type: common.FieldInt},
+	"schedule_id":        {Column: "schedule_id", Type: common.FieldInt},
+	"workspace_user_id":  {Column: "workspace_user_id", Type: common.FieldInt},
+	"status":             {Column: "status", Type: common.FieldString},
+	"invitation_status":  {Column: "invitation_status", Type: common.FieldString},
+	"assign_by":          {Column: "assign_by", Type: common.FieldInt},
+	"assign_at":          {Column: "assign_at", Type: common.FieldTime},
+	"response_time":      {Column: "response_time", Type: common.FieldTime},
+	"invitation_sent_at": {Column: "invitation_sent_at", Type: common.FieldTime},
+	"created_at":         {Column: "created_at", Type: common.FieldTime},
+}
+
 // getScheduleParticipants godoc
 // @Summary Get all schedule participants
-// @Description Get all schedule participants
+// @Description Get a page of the schedule participants. The X-Total-Count header holds the number of schedule participants and X-Next-Cursor the cursor of the next page, if any. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, schedule_id, workspace_user_id, status, invitation_status, assign_by, assign_at, response_time, invitation_sent_at and created_at.
 // @Tags schedule_participant
 // @Accept json
 // @Produce json
+// @Param limit query int false "Maximum number of schedule participants (default 50, max 200); the whole list is returned when neither limit nor cursor is given"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Param sort query string false "Sort by one of the filter fields, prefixed with - for descending order (default id)"
 // @Success 200 {array} models.TwScheduleParticipant
+// @Header 200 {integer} X-Total-Count "Number of schedule participants"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {string} string "Invalid sort or cursor"
 // @Router /dbms/v1/schedule_participant [get]
 func (h *ScheduleParticipantHandler) getScheduleParticipants(c *fiber.Ctx) error {
+	filter, err := common.ParseFilter(c, participantFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	page, err := common.ParsePage(c, participantFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
 	var scheduleParticipants []models.TwScheduleParticipant
-	if result := h.DB.Find(&scheduleParticipants); result.Error != nil {
-		// handle error
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	result, err := page.Find(filter.Where(h.DB), &scheduleParticipants)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
+	result.Write(c)
 
 	return c.JSON(scheduleParticipants)
 }
@@ -103,8 +138,10 @@ func (h *ScheduleParticipantHandler) UpdateScheduleParticipant(c *fiber.Ctx) err
 	participant.UpdatedAt = now
 
 	// Lưu participant đã cập nhật
-	if result := h.DB.Omit("deleted_at").Save(&participant); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	if err := h.writeAndPublish(realtime.ParticipantUpdated, &participant, func(tx *gorm.DB) error {
+		return tx.Omit("deleted_at").Save(&participant).Error
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
 	// Trả về participant đã cập nhật
@@ -135,8 +172,10 @@ func (h *ScheduleParticipantHandler) deleteScheduleParticipant(c *fiber.Ctx) err
 	if result := h.DB.First(&scheduleParticipants, c.Params("id")); result.Error != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
 	}
-	if result := h.DB.Delete(&scheduleParticipants); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	if err := h.writeAndPublish(realtime.ParticipantDeleted, &scheduleParticipants, func(tx *gorm.DB) error {
+		return tx.Delete(&scheduleParticipants).Error
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 	return c.JSON(fiber.Map{
 		"status": "deleted",
@@ -157,8 +196,10 @@ func (h *ScheduleParticipantHandler) createScheduleParticipant(c *fiber.Ctx) err
 	if err := c.BodyParser(&scheduleParticipants); err != nil {
 		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
 	}
-	if result := h.DB.Create(&scheduleParticipants); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	if err := h.writeAndPublish(realtime.ParticipantCreated, &scheduleParticipants, func(tx *gorm.DB) error {
+		return tx.Create(&scheduleParticipants).Error
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 	createSchedule := schedule_participant_dtos.ScheduleParticipantResponse{
 		ID:               scheduleParticipants.ID,
@@ -310,81 +351,110 @@ func (h *ScheduleParticipantHandler) getScheduleParticipantsByScheduleId(c *fibe
 
 // getScheduleParticipantsByScheduleId godoc
 // @Summary Get schedule participants by schedule ID
-// @Description Get schedule participants by schedule ID
+// @Description Get the participants of a schedule who are still joined to its workspace. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, schedule_id, workspace_user_id, status, invitation_status, assign_by, assign_at, response_time, invitation_sent_at and created_at.
 // @Tags schedule_participant
 // @Accept json
 // @Produce json
 // @Param scheduleId path string true "Schedule ID"
 // @Param workspaceId path string true "Workspace ID"
+// @Param sort query string false "Comma separated fields to sort by, each prefixed with - for descending order"
 // @Success 200 {array} schedule_participant_dtos.ScheduleParticipantInfo
+// @Failure 400 {object} fiber.Map "Invalid schedule ID, filter or sort"
 // @Router /dbms/v1/schedule_participant/schedule/{scheduleId} [get]
 func (h *ScheduleParticipantHandler) getScheduleParticipantsBySchedule(c *fiber.Ctx) error {
-	var scheduleParticipants []schedule_participant_dtos.ScheduleParticipantInfo
-	scheduleId := c.Params("scheduleId")
-
-	if scheduleId == "" {
+	scheduleId, err := strconv.Atoi(c.Params("scheduleId"))
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Schedule ID không hợp lệ",
 		})
 	}
 
-	// Perform the SQL query with multiple joins
-	err := h.DB.Table("tw_schedule_participants AS sp").
-		Select(`
-            sp.id AS id,
-            sp.schedule_id,
-            sp.workspace_user_id,
-			sp.status,
-			sp.assign_at,
-			sp.assign_by,
-			sp.response_time,
-			sp.invitation_sent_at,
-			sp.invitation_status,
-			wu.role,
-			wu.status AS status_workspace_user,
-			wu.is_verified,
-			ue.id as user_id,
-			ue.email,
-			u.first_name,
-			u.last_name,
-			u.profile_picture
-            
-        `).
-		Joins("JOIN tw_workspace_users AS wu ON wu.id =sp.workspace_user_id").
-		Joins("JOIN tw_user_emails AS ue ON wu.user_email_id = ue.id").
-		Joins("JOIN tw_users AS u ON ue.user_id = u.id").
-		Where("sp.schedule_id = ?", scheduleId).
-		Where("sp.deleted_at IS NULL AND sp.invitation_status != 'removed'").
-		Where("wu.deleted_at IS NULL").
-		Where("ue.deleted_at IS NULL").
-		Where("u.deleted_at IS NULL").
-		Where("wu.is_active = true AND wu.is_verified = true AND wu.status = 'joined'").
-		Scan(&scheduleParticipants).Error
-
+	fields := participantFields.On("sp")
+	filter, err := common.ParseFilter(c, fields)
 	if err != nil {
-		log.Println("Error querying schedule participants:", err)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Không thể lấy danh sách participant",
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	order, err := common.ParseSort(c, fields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
 
+	scheduleParticipants, err := repository.FindScheduleParticipants(order.Order(filter.Where(h.DB)), scheduleId)
 	if err != nil {
-		log.Println(err)
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Không thể lấy workspace",
+		log.Println("Error querying schedule participants:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Không thể lấy danh sách participant",
 		})
 	}
 
 	return c.JSON(scheduleParticipants)
 }
 
+// InviteConflictsResponse is the invited participant together with their
+// overlapping schedules, returned when conflicts=warn.
+type InviteConflictsResponse struct {
+	models.TwScheduleParticipant
+	Conflicts []availability.Conflict `json:"conflicts"`
+}
+
+// inviteToSchedule godoc
+// @Summary Invite to schedule
+// @Description Add a workspace user to a schedule
+// @Tags schedule_participant
+// @Accept json
+// @Produce json
+// @Param participant body models.TwScheduleParticipant true "Participant"
+// @Param conflicts query string false "warn to also return the invited user's overlapping schedules in any of their workspaces, reject to refuse with 409 when there are any"
+// @Success 200 {object} models.TwScheduleParticipant
+// @Failure 404 {string} string "Schedule not found"
+// @Failure 409 {object} availability.ConflictResponse "The workspace user has overlapping schedules"
+// @Router /dbms/v1/schedule_participant/invite [post]
 func (h *ScheduleParticipantHandler) inviteToSchedule(c *fiber.Ctx) error {
 	var scheduleParticipants models.TwScheduleParticipant
 	if err := c.BodyParser(&scheduleParticipants); err != nil {
 		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
 	}
-	if result := h.DB.Create(&scheduleParticipants); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	conflictMode, err := availability.ParseMode(c.Query("conflicts"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+
+	var conflicts []availability.Conflict
+	if conflictMode != availability.ModeIgnore {
+		var schedule models.TwSchedule
+		if err := h.DB.Where("id = ? AND is_deleted = false", scheduleParticipants.ScheduleId).First(&schedule).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return c.Status(fiber.StatusNotFound).SendString("Schedule not found")
+			}
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+		inviteeIds, err := availability.WorkspaceUserIdsForPeople(h.DB, []int{scheduleParticipants.WorkspaceUserId})
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+		conflicts, err = availability.Conflicts(h.DB, schedule, inviteeIds, time.Now())
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+		if conflictMode == availability.ModeReject && len(conflicts) > 0 {
+			return availability.Respond(c, conflicts)
+		}
+	}
+
+	if err := h.writeAndPublish(realtime.ParticipantCreated, &scheduleParticipants, func(tx *gorm.DB) error {
+		return tx.Create(&scheduleParticipants).Error
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	if conflictMode == availability.ModeWarn {
+		return c.JSON(InviteConflictsResponse{
+			TwScheduleParticipant: scheduleParticipants,
+			Conflicts:             conflicts,
+		})
 	}
 	return c.JSON(scheduleParticipants)
 }
@@ -410,8 +480,10 @@ func (h *ScheduleParticipantHandler) RemoveParticipant(c *fiber.Ctx) error {
 	participant.UpdatedAt = now
 
 	// Lưu participant đã cập nhật
-	if result := h.DB.Omit("deleted_at").Save(&participant); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	if err := h.writeAndPublish(realtime.ParticipantDeleted, &participant, func(tx *gorm.DB) error {
+		return tx.Omit("deleted_at").Save(&participant).Error
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
 	return c.JSON(schedule_participant_dtos.ScheduleParticipantResponse{
@@ -447,8 +519,10 @@ func (h *ScheduleParticipantHandler) UnassignMember(c *fiber.Ctx) error {
 	participant.UpdatedAt = now
 
 	// Lưu participant đã cập nhật
-	if result := h.DB.Omit("deleted_at").Save(&participant); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	if err := h.writeAndPublish(realtime.ParticipantUpdated, &participant, func(tx *gorm.DB) error {
+		return tx.Omit("deleted_at").Save(&participant).Error
+	}); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
 	return c.JSON(schedule_participant_dtos.ScheduleParticipantResponse{
@@ -463,3 +537,18 @@ func (h *ScheduleParticipantHandler) UnassignMember(c *fiber.Ctx) error {
 		InvitationStatus: participant.InvitationStatus,
 	})
 }
+
+// writeAndPublish runs write and records the participant change on the
+// workspace change feed in the same transaction.
+func (h *ScheduleParticipantHandler) writeAndPublish(eventType string, participant *models.TwScheduleParticipant, write func(tx *gorm.DB) error) error {
+	return h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := write(tx); err != nil {
+			return err
+		}
+		return realtime.PublishItem(tx, eventType, realtime.ItemData{
+			ID:              participant.ID,
+			ScheduleID:      participant.ScheduleId,
+			WorkspaceUserID: participant.WorkspaceUserId,
+		})
+	})
+}
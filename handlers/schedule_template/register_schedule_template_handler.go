@@ -0,0 +1,20 @@
+package schedule_template
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func RegisterScheduleTemplateHandler(router fiber.Router, db *gorm.DB) {
+	scheduleTemplateHandler := ScheduleTemplateHandler{
+		Router: router,
+		DB:     db,
+	}
+
+	// Register all endpoints here
+	router.Get("/workspace/:workspace_id", scheduleTemplateHandler.getTemplatesByWorkspace)
+	router.Post("/workspace/:workspace_id", scheduleTemplateHandler.createTemplate)
+	router.Get("/:template_id", scheduleTemplateHandler.getTemplate)
+	router.Put("/:template_id", scheduleTemplateHandler.updateTemplate)
+	router.Delete("/:template_id", scheduleTemplateHandler.deleteTemplate)
+}
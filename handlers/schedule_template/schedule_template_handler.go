@@ -0,0 +1,277 @@
+package schedule_template
+
+import (
+	"dbms/permission"
+	"dbms/scheduletemplate"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"strconv"
+)
+
+type ScheduleTemplateHandler struct {
+	Router fiber.Router
+	DB     *gorm.DB
+}
+
+// TemplateRequest creates or changes a template. Title, description,
+// location and checklist texts may hold {{date}}, {{time}}, {{assignee}},
+// {{workspace}} and variables given when a schedule is created.
+type TemplateRequest struct {
+	Name            *string `json:"name"`
+	Title           *string `json:"title"`
+	Description     *string `json:"description"`
+	Location        *string `json:"location"`
+	DurationMinutes *int    `json:"duration_minutes"`
+	Priority        *string `json:"priority"`
+	Visibility      *string `json:"visibility"`
+	// Participants are workspace user IDs added besides the creator.
+	Participants *[]int                            `json:"participants"`
+	Reminders    *[]scheduletemplate.Reminder      `json:"reminders"`
+	Checklist    *[]scheduletemplate.ChecklistItem `json:"checklist"`
+}
+
+// apply copies the fields given in request onto template.
+func (request TemplateRequest) apply(template *scheduletemplate.Template) {
+	if request.Name != nil {
+		template.Name = *request.Name
+	}
+	if request.Title != nil {
+		template.Title = *request.Title
+	}
+	if request.Description != nil {
+		template.Description = *request.Description
+	}
+	if request.Location != nil {
+		template.Location = *request.Location
+	}
+	if request.DurationMinutes != nil {
+		template.DurationMinutes = *request.DurationMinutes
+	}
+	if request.Priority != nil {
+		template.Priority = *request.Priority
+	}
+	if request.Visibility != nil {
+		template.Visibility = *request.Visibility
+	}
+	if request.Participants != nil {
+		template.Participants = *request.Participants
+	}
+	if request.Reminders != nil {
+		template.Reminders = *request.Reminders
+	}
+	if request.Checklist != nil {
+		template.Checklist = *request.Checklist
+	}
+	// Lists are stored and returned as [] rather than null.
+	if template.Participants == nil {
+		template.Participants = []int{}
+	}
+	if template.Reminders == nil {
+		template.Reminders = []scheduletemplate.Reminder{}
+	}
+	if template.Checklist == nil {
+		template.Checklist = []scheduletemplate.ChecklistItem{}
+	}
+}
+
+// validate checks the template and that its participants belong to its
+// workspace.
+func (h *ScheduleTemplateHandler) validate(template scheduletemplate.Template) error {
+	if err := template.Validate(); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+	if len(template.Participants) == 0 {
+		return nil
+	}
+	var members []int
+	if err := h.DB.Model(&models.TwWorkspaceUser{}).
+		Where("id IN (?) AND workspace_id = ?", template.Participants, template.WorkspaceId).
+		Where("deleted_at IS NULL AND status = 'joined' AND is_active = true").
+		Pluck("id", &members).Error; err != nil {
+		return err
+	}
+	joined := make(map[int]bool, len(members))
+	for _, id := range members {
+		joined[id] = true
+	}
+	for _, id := range template.Participants {
+		if !joined[id] {
+			return fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("workspace user %d is not a member of the workspace", id))
+		}
+	}
+	return nil
+}
+
+// authorize returns the acting workspace user if they may perform action on
+// the templates of workspaceId.
+func (h *ScheduleTemplateHandler) authorize(c *fiber.Ctx, workspaceId int, action permission.Action) (int, error) {
+	actorId, err := permission.Actor(c, h.DB, workspaceId)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := permission.Authorize(h.DB, workspaceId, actorId, action); err != nil {
+		return 0, err
+	}
+	return actorId, nil
+}
+
+// findTemplate loads a live template and authorizes the actor on its workspace.
+func (h *ScheduleTemplateHandler) findTemplate(c *fiber.Ctx, template *scheduletemplate.Template, action permission.Action) error {
+	err := h.DB.Where("id = ?", c.Params("template_id")).
+		Where("deleted_at IS NULL").
+		First(template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "Template not found")
+	}
+	if err != nil {
+		return err
+	}
+	_, err = h.authorize(c, template.WorkspaceId, action)
+	return err
+}
+
+// getTemplatesByWorkspace godoc
+// @Summary Get schedule templates by workspace
+// @Description Get the schedule templates of a workspace, by name
+// @Tags schedule_template
+// @Accept json
+// @Produce json
+// @Param workspace_id path int true "Workspace ID"
+// @Success 200 {array} scheduletemplate.Template
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
+// @Router /dbms/v1/schedule_template/workspace/{workspace_id} [get]
+func (h *ScheduleTemplateHandler) getTemplatesByWorkspace(c *fiber.Ctx) error {
+	workspaceId, err := strconv.Atoi(c.Params("workspace_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid workspace_id")
+	}
+	if _, err := h.authorize(c, workspaceId, permission.ActionCreateSchedule); err != nil {
+		return permission.Respond(c, err)
+	}
+
+	templates := []scheduletemplate.Template{}
+	if err := h.DB.Where("workspace_id = ?", workspaceId).
+		Where("deleted_at IS NULL").
+		Order("name, id").
+		Find(&templates).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	return c.JSON(templates)
+}
+
+// getTemplate godoc
+// @Summary Get schedule template
+// @Description Get a schedule template
+// @Tags schedule_template
+// @Accept json
+// @Produce json
+// @Param template_id path int true "Template ID"
+// @Success 200 {object} scheduletemplate.Template
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
+// @Failure 404 {object} fiber.Map "Template not found"
+// @Router /dbms/v1/schedule_template/{template_id} [get]
+func (h *ScheduleTemplateHandler) getTemplate(c *fiber.Ctx) error {
+	var template scheduletemplate.Template
+	if err := h.findTemplate(c, &template, permission.ActionCreateSchedule); err != nil {
+		return permission.Respond(c, err)
+	}
+	return c.JSON(template)
+}
+
+// createTemplate godoc
+// @Summary Create schedule template
+// @Description Save a schedule template in a workspace. Schedules are created from it with POST /schedule/from_template/{template_id}.
+// @Tags schedule_template
+// @Accept json
+// @Produce json
+// @Param workspace_id path int true "Workspace ID"
+// @Param body body schedule_template.TemplateRequest true "Template"
+// @Success 201 {object} scheduletemplate.Template
+// @Failure 400 {object} fiber.Map "Invalid template"
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
+// @Router /dbms/v1/schedule_template/workspace/{workspace_id} [post]
+func (h *ScheduleTemplateHandler) createTemplate(c *fiber.Ctx) error {
+	workspaceId, err := strconv.Atoi(c.Params("workspace_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid workspace_id")
+	}
+	var request TemplateRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	actorId, err := h.authorize(c, workspaceId, permission.ActionManageTemplates)
+	if err != nil {
+		return permission.Respond(c, err)
+	}
+
+	template := scheduletemplate.Template{
+		WorkspaceId: workspaceId,
+		CreatedBy:   actorId,
+		Visibility:  "public",
+	}
+	request.apply(&template)
+	if err := h.validate(template); err != nil {
+		return permission.Respond(c, err)
+	}
+	if err := h.DB.Omit("deleted_at").Create(&template).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	return c.Status(fiber.StatusCreated).JSON(template)
+}
+
+// updateTemplate godoc
+// @Summary Update schedule template
+// @Description Change a schedule template. Omitted fields are left unchanged; schedules already created from it are not.
+// @Tags schedule_template
+// @Accept json
+// @Produce json
+// @Param template_id path int true "Template ID"
+// @Param body body schedule_template.TemplateRequest true "Template"
+// @Success 200 {object} scheduletemplate.Template
+// @Failure 400 {object} fiber.Map "Invalid template"
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
+// @Failure 404 {object} fiber.Map "Template not found"
+// @Router /dbms/v1/schedule_template/{template_id} [put]
+func (h *ScheduleTemplateHandler) updateTemplate(c *fiber.Ctx) error {
+	var request TemplateRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	var template scheduletemplate.Template
+	if err := h.findTemplate(c, &template, permission.ActionManageTemplates); err != nil {
+		return permission.Respond(c, err)
+	}
+
+	request.apply(&template)
+	if err := h.validate(template); err != nil {
+		return permission.Respond(c, err)
+	}
+	if err := h.DB.Save(&template).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	return c.JSON(template)
+}
+
+// deleteTemplate godoc
+// @Summary Delete schedule template
+// @Description Delete a schedule template. Schedules created from it are kept.
+// @Tags schedule_template
+// @Produce json
+// @Param template_id path int true "Template ID"
+// @Success 204 "No Content"
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
+// @Failure 404 {object} fiber.Map "Template not found"
+// @Router /dbms/v1/schedule_template/{template_id} [delete]
+func (h *ScheduleTemplateHandler) deleteTemplate(c *fiber.Ctx) error {
+	var template scheduletemplate.Template
+	if err := h.findTemplate(c, &template, permission.ActionManageTemplates); err != nil {
+		return permission.Respond(c, err)
+	}
+	if err := h.DB.Model(&template).Update("deleted_at", gorm.Expr("NOW()")).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,16 @@
+package search
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func RegisterSearchHandler(router fiber.Router, db *gorm.DB) {
+	searchHandler := SearchHandler{
+		Router: router,
+		DB:     db,
+	}
+
+	// Register all endpoints here
+	router.Get("/workspace/:workspace_id", searchHandler.searchWorkspace)
+}
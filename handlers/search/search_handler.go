@@ -0,0 +1,76 @@
+package search
+
+import (
+	"dbms/permission"
+	"dbms/search"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+)
+
+type SearchHandler struct {
+	Router fiber.Router
+	DB     *gorm.DB
+}
+
+// searchWorkspace godoc
+// @Summary Search workspace
+// @Description Search the schedules of a workspace by title, description and location, their comments, document file names and video transcripts. Every word must match, as a whole word or the start of one. Results are ranked best first and carry highlights: snippets of the matching fields, HTML-escaped, with the matched words wrapped in <mark></mark>. Guests only find the schedules they take part in. The acting workspace user is the caller's membership of the workspace, found through the user_id claim of its token.
+// @Tags search
+// @Accept json
+// @Produce json
+// @Param workspace_id path int true "Workspace ID"
+// @Param q query string true "Words to search for"
+// @Param types query string false "Comma separated result types to search: schedule, transcript, comment, document (default all)"
+// @Param limit query int false "Maximum number of results (default 20, max 100)"
+// @Success 200 {array} search.Result
+// @Failure 400 {object} fiber.Map "Invalid query or result type"
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
+// @Router /dbms/v1/search/workspace/{workspace_id} [get]
+func (h *SearchHandler) searchWorkspace(c *fiber.Ctx) error {
+	workspaceId, err := strconv.Atoi(c.Params("workspace_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid workspace_id")
+	}
+	actorId, err := permission.Actor(c, h.DB, workspaceId)
+	if err != nil {
+		return permission.Respond(c, err)
+	}
+	workspaceUser, err := permission.Authorize(h.DB, workspaceId, actorId, permission.ActionSearch)
+	if err != nil {
+		return permission.Respond(c, err)
+	}
+
+	query := search.Query{
+		WorkspaceId: workspaceId,
+		Text:        c.Query("q"),
+		Limit:       c.QueryInt("limit", search.DefaultLimit),
+	}
+	if types := c.Query("types"); types != "" {
+		for _, resultType := range strings.Split(types, ",") {
+			resultType = strings.TrimSpace(resultType)
+			if !search.ValidType(resultType) {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "types must be made of " + strings.Join(search.Types, ", "),
+				})
+			}
+			query.Types = append(query.Types, resultType)
+		}
+	}
+	if strings.EqualFold(workspaceUser.Role, permission.RoleGuest) {
+		query.ParticipantId = workspaceUser.ID
+	}
+
+	results, err := search.Search(h.DB, query)
+	if errors.Is(err, search.ErrEmptyQuery) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	return c.JSON(results)
+}
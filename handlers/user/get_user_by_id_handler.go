@@ -1,6 +1,7 @@
 package user
 
 import (
+	"dbms/common"
 	"errors"
 	"github.com/go-sql-driver/mysql"
 	"github.com/gofiber/fiber/v2"
@@ -11,21 +12,37 @@ import (
 	"time"
 )
 
+// userFields are the user columns the list endpoint sorts by.
+var userFields = common.FilterFields{
+	"created_at": {Column: "created_at", Type: common.FieldTime},
+}
+
 // GET /users
 // getUsers godoc
 // @Summary Get all users
-// @Description Get all users
+// @Description Get a page of the users. The X-Total-Count header holds the number of users and X-Next-Cursor the cursor of the next page, if any.
 // @Tags user
 // @Accept json
 // @Produce json
+// @Param limit query int false "Maximum number of users (default 50, max 200); the whole list is returned when neither limit nor cursor is given"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Param sort query string false "Sort by id or created_at; prefix with - for descending order (default id)"
 // @Success 200 {array} models.TwUser
+// @Header 200 {integer} X-Total-Count "Number of users"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {string} string "Invalid sort or cursor"
 // @Router /dbms/v1/user [get]
 func (h *UserHandler) getUsers(c *fiber.Ctx) error {
+	page, err := common.ParsePage(c, userFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
 	var users []models.TwUser
-	if result := h.DB.Find(&users); result.Error != nil {
-		// handle error
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	result, err := page.Find(h.DB, &users)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
+	result.Write(c)
 
 	return c.JSON(users)
 }
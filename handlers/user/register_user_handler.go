@@ -1,6 +1,7 @@
 package user
 
 import (
+	"dbms/middleware"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -22,6 +23,6 @@ func RegisterUserHandler(router fiber.Router, db *gorm.DB) {
 	router.Get("/:user_id", userHandler.getUserById)
 	router.Post("/", userHandler.createUser)
 	router.Put("/:user_id", userHandler.updateUser)
-	router.Delete("/:user_id", userHandler.deleteUser)
+	router.Delete("/:user_id", middleware.RequireScope(middleware.ScopeAdmin), userHandler.deleteUser)
 	router.Post("/get-create", userHandler.getOrCreateUser)
 }
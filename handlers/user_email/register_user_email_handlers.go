@@ -1,6 +1,7 @@
 package user_email
 
 import (
+	"dbms/middleware"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -26,7 +27,7 @@ func RegisterUserEmailHandler(router fiber.Router, db *gorm.DB) {
 	router.Delete("/", userEmailHandler.deleteUserEmail)
 	router.Get("/search/:query", userEmailHandler.searchUserEmail)
 	router.Get("/listApprove/:scheduleId", userEmailHandler.getEmailInProgress)
-	router.Get("/clear-expired", userEmailHandler.clearExpiredUserEmails)
+	router.Get("/clear-expired", middleware.RequireScope(middleware.ScopeWrite), userEmailHandler.clearExpiredUserEmails)
 	router.Get("/user_id/:user_id", userEmailHandler.getExactUserEmailByUserId)
-	router.Get("clear-rejected", userEmailHandler.clearStatusRejectedEmail)
+	router.Get("clear-rejected", middleware.RequireScope(middleware.ScopeWrite), userEmailHandler.clearStatusRejectedEmail)
 }
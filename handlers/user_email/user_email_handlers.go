@@ -1,6 +1,8 @@
 package user_email
 
 import (
+	"dbms/common"
+	"dbms/repository"
 	"errors"
 	"github.com/go-sql-driver/mysql"
 	"github.com/gofiber/fiber/v2"
@@ -13,27 +15,41 @@ import (
 	"time"
 )
 
+// userEmailFields are the user email columns the list endpoint sorts by.
+var userEmailFields = common.FilterFields{
+	"created_at": {Column: "created_at", Type: common.FieldTime},
+}
+
 // @Summary Get all user emails
-// @Description Get all user emails
+// @Description Get a page of the user emails. The X-Total-Count header holds the number of user emails and X-Next-Cursor the cursor of the next page, if any.
 // @Tags user_email
 // @Accept json
 // @Produce json
+// @Param user_id query int false "Only the emails of this user"
+// @Param limit query int false "Maximum number of user emails (default 50, max 200); the whole list is returned when neither limit nor cursor is given"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Param sort query string false "Sort by id or created_at; prefix with - for descending order (default id)"
 // @Success 200 {array} models.TwUserEmail
+// @Header 200 {integer} X-Total-Count "Number of user emails"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {string} string "Invalid sort or cursor"
 // @Router /dbms/v1/user_email [get]
 func (h *UserEmailHandler) getUserEmails(c *fiber.Ctx) error {
+	query := h.DB
 	// Get user_id from query param
-	userId := c.Query("user_id")
-	if userId != "" {
-		var userEmails []models.TwUserEmail
-		if result := h.DB.Where("user_id = ?", userId).Find(&userEmails); result.Error != nil {
-			return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
-		}
-		return c.JSON(userEmails)
+	if userId := c.Query("user_id"); userId != "" {
+		query = query.Where("user_id = ?", userId)
+	}
+	page, err := common.ParsePage(c, userEmailFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
 	}
 	var userEmails []models.TwUserEmail
-	if result := h.DB.Find(&userEmails); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	result, err := page.Find(query, &userEmails)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
+	result.Write(c)
 
 	return c.JSON(userEmails)
 }
@@ -105,7 +121,7 @@ func (h *UserEmailHandler) createUserEmail(ctx *fiber.Ctx) error {
 
 // updateUserIdInUserEmail godoc
 // @Summary Update user_id in tw_user_email by email
-// @Description Update user_id in tw_user_email by email
+// @Description Update user_id in tw_user_email by email. Setting status to pending emails the address a link to confirm it.
 // @Tags user_email
 // @Accept json
 // @Produce json
@@ -139,8 +155,18 @@ func (h *UserEmailHandler) updateStatusInUserEmail(c *fiber.Ctx) error {
 	}
 	userEmail.DeletedAt = nil
 
-	if result := h.DB.Save(&userEmail); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&userEmail).Error; err != nil {
+			return err
+		}
+		if status != "pending" {
+			return nil
+		}
+		// A pending email waits for its owner to confirm the link.
+		return repository.NotifyEmailLink(tx, userEmail)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
 	return c.JSON(userEmail)
@@ -148,7 +174,7 @@ func (h *UserEmailHandler) updateStatusInUserEmail(c *fiber.Ctx) error {
 
 // updateUserEmailStatus godoc
 // @Summary Update user email status
-// @Description Update user email status
+// @Description Update user email status. Setting status to pending emails the address a link to confirm linking it to the target user.
 // @Tags user_email
 // @Accept json
 // @Produce json
@@ -185,8 +211,18 @@ func (h *UserEmailHandler) updateUserEmailStatusAndIsLinkedTo(c *fiber.Ctx) erro
 	} else {
 		userEmail.ExpiresAt = nil
 	}
-	if result := h.DB.Save(&userEmail); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&userEmail).Error; err != nil {
+			return err
+		}
+		if status != "pending" {
+			return nil
+		}
+		// A pending email waits for its owner to confirm the link.
+		return repository.NotifyEmailLink(tx, userEmail)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
 	return c.JSON(userEmail)
@@ -380,14 +416,8 @@ func (h *UserEmailHandler) getEmailInProgress(c *fiber.Ctx) error {
 // @Success 200 {string} string
 // @Router /dbms/v1/user_email/clear-expired [get]
 func (h *UserEmailHandler) clearExpiredUserEmails(c *fiber.Ctx) error {
-	if result := h.DB.Model(&models.TwUserEmail{}).
-		Where("expires_at <= NOW()").
-		Updates(map[string]interface{}{
-			"status":       nil,
-			"is_linked_to": nil,
-			"expires_at":   nil,
-		}); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	if err := repository.ClearExpiredUserEmails(h.DB); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 	return c.Status(fiber.StatusOK).SendString("Expired user emails cleared successfully")
 }
@@ -1,9 +1,12 @@
 package feature
 
 import (
+	"dbms/config"
 	_ "dbms/docs"
 	"dbms/handlers/auth"
+	"dbms/handlers/availability"
 	"dbms/handlers/board_columns"
+	"dbms/handlers/calendar"
 	comments "dbms/handlers/comments"
 	"dbms/handlers/document"
 	"dbms/handlers/notification"
@@ -13,11 +16,16 @@ import (
 	"dbms/handlers/schedule"
 	"dbms/handlers/schedule_log"
 	"dbms/handlers/schedule_participant"
+	"dbms/handlers/schedule_template"
+	"dbms/handlers/search"
 	"dbms/handlers/user"
 	"dbms/handlers/user_email"
+	"dbms/handlers/webhook_subscription"
 	"dbms/handlers/workspace"
+	"dbms/handlers/workspace_event"
 	"dbms/handlers/workspace_log"
 	"dbms/handlers/workspace_user"
+	"dbms/middleware"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
 	"gorm.io/gorm"
@@ -25,10 +33,18 @@ import (
 
 // @host localhost:8080
 // @BasePath /dbms/v1
-func RegisterHandlerV1(db *gorm.DB) *fiber.App {
+func RegisterHandlerV1(db *gorm.DB, cfg *config.Config) *fiber.App {
 	router := fiber.New()
 	v1 := router.Group("/dbms/v1")
 	v1.Get("/swagger/*", swagger.HandlerDefault)
+	// Every route registered below requires a service token or a trusted client certificate.
+	v1.Use(middleware.NewAuth(middleware.AuthConfig{
+		JWTSecret:    cfg.AuthJWTSecret,
+		JWTIssuer:    cfg.AuthJWTIssuer,
+		JWTAudience:  cfg.AuthJWTAudience,
+		ClientScopes: cfg.AuthClientScopes,
+		Disabled:     cfg.AuthDisabled,
+	}))
 	user.RegisterUserHandler(v1.Group("/user"), db)
 	schedule_log.RegisterScheduleLogHandler(v1.Group("/schedule_log"), db)
 	schedule_participant.RegisterScheduleParticipantHandler(v1.Group("/schedule_participant"), db)
@@ -45,5 +61,11 @@ func RegisterHandlerV1(db *gorm.DB) *fiber.App {
 	notification.RegisterNotificationHandler(v1.Group("/notification"), db)
 	reminder.RegisterReminderHandler(v1.Group("/reminder"), db)
 	notification_setting.RegisterNotificationSettingHandler(v1.Group("/notification_setting"), db)
+	calendar.RegisterCalendarHandler(v1.Group("/calendar"), db)
+	workspace_event.RegisterWorkspaceEventHandler(v1.Group("/workspace_event"), db)
+	webhook_subscription.RegisterWebhookSubscriptionHandler(v1.Group("/webhook"), db)
+	search.RegisterSearchHandler(v1.Group("/search"), db)
+	availability.RegisterAvailabilityHandler(v1.Group("/availability"), db)
+	schedule_template.RegisterScheduleTemplateHandler(v1.Group("/schedule_template"), db)
 	return router
 }
@@ -0,0 +1,22 @@
+package webhook_subscription
+
+import (
+	"dbms/middleware"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func RegisterWebhookSubscriptionHandler(router fiber.Router, db *gorm.DB) {
+	webhookSubscriptionHandler := WebhookSubscriptionHandler{
+		Router: router,
+		DB:     db,
+	}
+
+	// Register all endpoints here
+	router.Post("/deliveries/dispatch", middleware.RequireScope(middleware.ScopeAdmin), webhookSubscriptionHandler.dispatchDeliveries)
+	router.Get("/workspace/:workspace_id", webhookSubscriptionHandler.getSubscriptionsByWorkspace)
+	router.Post("/workspace/:workspace_id", webhookSubscriptionHandler.createSubscription)
+	router.Put("/:webhook_id", webhookSubscriptionHandler.updateSubscription)
+	router.Delete("/:webhook_id", webhookSubscriptionHandler.deleteSubscription)
+	router.Get("/:webhook_id/deliveries", webhookSubscriptionHandler.getDeliveries)
+}
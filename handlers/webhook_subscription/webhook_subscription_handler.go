@@ -0,0 +1,325 @@
+package webhook_subscription
+
+import (
+	"dbms/egress"
+	"dbms/permission"
+	"dbms/webhook"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type WebhookSubscriptionHandler struct {
+	Router fiber.Router
+	DB     *gorm.DB
+}
+
+type SubscriptionRequest struct {
+	Url *string `json:"url"`
+	// EventTypes filters the events sent; empty means all of them.
+	EventTypes []string `json:"event_types"`
+	// Secret signs the deliveries; one is generated when it is left empty on create.
+	Secret   *string `json:"secret"`
+	IsActive *bool   `json:"is_active"`
+}
+
+type SubscriptionResponse struct {
+	ID          int       `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	WorkspaceId int       `json:"workspace_id"`
+	CreatedBy   int       `json:"created_by"`
+	Url         string    `json:"url"`
+	EventTypes  []string  `json:"event_types"`
+	IsActive    bool      `json:"is_active"`
+	// Secret is only returned when the subscription is created.
+	Secret string `json:"secret,omitempty"`
+}
+
+func toResponse(subscription webhook.Subscription) SubscriptionResponse {
+	eventTypes := []string{}
+	if subscription.EventTypes != "" {
+		eventTypes = strings.Split(subscription.EventTypes, ",")
+	}
+	return SubscriptionResponse{
+		ID:          subscription.ID,
+		CreatedAt:   subscription.CreatedAt,
+		UpdatedAt:   subscription.UpdatedAt,
+		WorkspaceId: subscription.WorkspaceId,
+		CreatedBy:   subscription.CreatedBy,
+		Url:         subscription.Url,
+		EventTypes:  eventTypes,
+		IsActive:    subscription.IsActive,
+	}
+}
+
+// validateUrl rejects URLs that are not http or https or whose host resolves
+// to a private, loopback or otherwise internal address.
+func validateUrl(rawUrl string) error {
+	if err := egress.CheckURL(rawUrl); err != nil {
+		return errors.New("url " + err.Error())
+	}
+	return nil
+}
+
+func joinEventTypes(eventTypes []string) (string, error) {
+	for _, eventType := range eventTypes {
+		if !webhook.ValidEventType(eventType) {
+			return "", errors.New("unknown event type " + eventType + ", expected one of " + strings.Join(webhook.EventTypes, ", "))
+		}
+	}
+	return strings.Join(eventTypes, ","), nil
+}
+
+// authorize returns the acting workspace user if they may manage the webhooks of workspaceId.
+func (h *WebhookSubscriptionHandler) authorize(c *fiber.Ctx, workspaceId int) (int, error) {
+	actorId, err := permission.Actor(c, h.DB, workspaceId)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := permission.Authorize(h.DB, workspaceId, actorId, permission.ActionManageWebhooks); err != nil {
+		return 0, err
+	}
+	return actorId, nil
+}
+
+// findSubscription loads a live subscription and authorizes the actor on its workspace.
+func (h *WebhookSubscriptionHandler) findSubscription(c *fiber.Ctx, subscription *webhook.Subscription) error {
+	err := h.DB.Where("id = ?", c.Params("webhook_id")).
+		Where("deleted_at IS NULL").
+		First(subscription).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, "Webhook not found")
+	}
+	if err != nil {
+		return err
+	}
+	_, err = h.authorize(c, subscription.WorkspaceId)
+	return err
+}
+
+// getSubscriptionsByWorkspace godoc
+// @Summary Get webhooks by workspace
+// @Description Get the webhook subscriptions of a workspace
+// @Tags webhook
+// @Accept json
+// @Produce json
+// @Param workspace_id path int true "Workspace ID"
+// @Success 200 {array} webhook_subscription.SubscriptionResponse
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
+// @Router /dbms/v1/webhook/workspace/{workspace_id} [get]
+func (h *WebhookSubscriptionHandler) getSubscriptionsByWorkspace(c *fiber.Ctx) error {
+	workspaceId, err := strconv.Atoi(c.Params("workspace_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid workspace_id")
+	}
+	if _, err := h.authorize(c, workspaceId); err != nil {
+		return permission.Respond(c, err)
+	}
+
+	var subscriptions []webhook.Subscription
+	if err := h.DB.Where("workspace_id = ?", workspaceId).
+		Where("deleted_at IS NULL").
+		Order("id").
+		Find(&subscriptions).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	responses := make([]SubscriptionResponse, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		responses = append(responses, toResponse(subscription))
+	}
+	return c.JSON(responses)
+}
+
+// createSubscription godoc
+// @Summary Create webhook
+// @Description Subscribe a URL to the events of a workspace. Its host must resolve to public addresses only. Deliveries are signed with the secret in the X-Timewise-Signature header as "t=<unix>,v1=<hex HMAC-SHA256 of t.body>". The secret is only returned here.
+// @Tags webhook
+// @Accept json
+// @Produce json
+// @Param workspace_id path int true "Workspace ID"
+// @Param body body webhook_subscription.SubscriptionRequest true "Webhook"
+// @Success 201 {object} webhook_subscription.SubscriptionResponse
+// @Failure 400 {object} fiber.Map "Invalid URL or event type"
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
+// @Router /dbms/v1/webhook/workspace/{workspace_id} [post]
+func (h *WebhookSubscriptionHandler) createSubscription(c *fiber.Ctx) error {
+	workspaceId, err := strconv.Atoi(c.Params("workspace_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid workspace_id")
+	}
+	var request SubscriptionRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	if request.Url == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "url is required",
+		})
+	}
+	if err := validateUrl(*request.Url); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	eventTypes, err := joinEventTypes(request.EventTypes)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	actorId, err := h.authorize(c, workspaceId)
+	if err != nil {
+		return permission.Respond(c, err)
+	}
+
+	subscription := webhook.Subscription{
+		WorkspaceId: workspaceId,
+		CreatedBy:   actorId,
+		Url:         *request.Url,
+		EventTypes:  eventTypes,
+		IsActive:    true,
+	}
+	if request.Secret != nil && *request.Secret != "" {
+		subscription.Secret = *request.Secret
+	} else if subscription.Secret, err = webhook.NewSecret(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	if err := h.DB.Omit("deleted_at").Create(&subscription).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	response := toResponse(subscription)
+	response.Secret = subscription.Secret
+	return c.Status(fiber.StatusCreated).JSON(response)
+}
+
+// updateSubscription godoc
+// @Summary Update webhook
+// @Description Change the URL, event types, secret or active flag of a webhook. Omitted fields are left unchanged.
+// @Tags webhook
+// @Accept json
+// @Produce json
+// @Param webhook_id path int true "Webhook ID"
+// @Param body body webhook_subscription.SubscriptionRequest true "Webhook"
+// @Success 200 {object} webhook_subscription.SubscriptionResponse
+// @Failure 400 {object} fiber.Map "Invalid URL or event type"
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
+// @Failure 404 {object} fiber.Map "Webhook not found"
+// @Router /dbms/v1/webhook/{webhook_id} [put]
+func (h *WebhookSubscriptionHandler) updateSubscription(c *fiber.Ctx) error {
+	var request SubscriptionRequest
+	if err := c.BodyParser(&request); err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	var subscription webhook.Subscription
+	if err := h.findSubscription(c, &subscription); err != nil {
+		return permission.Respond(c, err)
+	}
+
+	updates := map[string]interface{}{}
+	if request.Url != nil {
+		if err := validateUrl(*request.Url); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		updates["url"] = *request.Url
+	}
+	if request.EventTypes != nil {
+		eventTypes, err := joinEventTypes(request.EventTypes)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		updates["event_types"] = eventTypes
+	}
+	if request.Secret != nil && *request.Secret != "" {
+		updates["secret"] = *request.Secret
+	}
+	if request.IsActive != nil {
+		updates["is_active"] = *request.IsActive
+	}
+	if len(updates) > 0 {
+		if err := h.DB.Model(&subscription).Updates(updates).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+		if err := h.DB.First(&subscription, subscription.ID).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+		}
+	}
+	return c.JSON(toResponse(subscription))
+}
+
+// deleteSubscription godoc
+// @Summary Delete webhook
+// @Description Delete a webhook. Pending deliveries to it are marked failed by the worker.
+// @Tags webhook
+// @Produce json
+// @Param webhook_id path int true "Webhook ID"
+// @Success 204 "No Content"
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
+// @Failure 404 {object} fiber.Map "Webhook not found"
+// @Router /dbms/v1/webhook/{webhook_id} [delete]
+func (h *WebhookSubscriptionHandler) deleteSubscription(c *fiber.Ctx) error {
+	var subscription webhook.Subscription
+	if err := h.findSubscription(c, &subscription); err != nil {
+		return permission.Respond(c, err)
+	}
+	if err := h.DB.Model(&subscription).Update("deleted_at", gorm.Expr("NOW()")).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// getDeliveries godoc
+// @Summary Get webhook deliveries
+// @Description Get the delivery log of a webhook, newest first, with the payload, attempts and the subscriber's last response
+// @Tags webhook
+// @Produce json
+// @Param webhook_id path int true "Webhook ID"
+// @Param status query string false "Filter by status (pending, succeeded, failed)"
+// @Param limit query int false "Maximum number of deliveries (default 50, max 200); the whole list is returned when neither limit nor cursor is given"
+// @Success 200 {array} webhook.Delivery
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
+// @Failure 404 {object} fiber.Map "Webhook not found"
+// @Router /dbms/v1/webhook/{webhook_id}/deliveries [get]
+func (h *WebhookSubscriptionHandler) getDeliveries(c *fiber.Ctx) error {
+	var subscription webhook.Subscription
+	if err := h.findSubscription(c, &subscription); err != nil {
+		return permission.Respond(c, err)
+	}
+	limit := c.QueryInt("limit", 50)
+	if limit <= 0 || limit > 200 {
+		limit = 200
+	}
+
+	query := h.DB.Where("subscription_id = ?", subscription.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var deliveries []webhook.Delivery
+	if err := query.Order("id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	return c.JSON(deliveries)
+}
+
+// dispatchDeliveries godoc
+// @Summary Dispatch webhook deliveries
+// @Description Send the webhook deliveries that are due, retrying failures with exponential backoff. The cron worker runs the same dispatch against the database; this route requires the dbms.admin scope.
+// @Tags webhook
+// @Produce json
+// @Success 200 {object} webhook.DispatchResult
+// @Router /dbms/v1/webhook/deliveries/dispatch [post]
+func (h *WebhookSubscriptionHandler) dispatchDeliveries(c *fiber.Ctx) error {
+	result, err := webhook.Dispatch(h.DB, webhook.DefaultClient)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	return c.JSON(result)
+}
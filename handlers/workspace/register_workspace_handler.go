@@ -1,6 +1,7 @@
 package workspace
 
 import (
+	"dbms/middleware"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -14,7 +15,7 @@ func RegisterWorkspaceHandler(router fiber.Router, db *gorm.DB) {
 	// Register all endpoints here
 	router.Get("/", workspaceHandler.getWorkspaces)
 	router.Get("/:workspace_id", workspaceHandler.getWorkspaceById)
-	router.Delete("/:workspace_id", workspaceHandler.removeWorkspaceById)
+	router.Delete("/:workspace_id", middleware.RequireScope(middleware.ScopeAdmin), workspaceHandler.removeWorkspaceById)
 	router.Post("/", workspaceHandler.createWorkspace)
 	router.Put("/:workspace_id", workspaceHandler.updateWorkspace)
 	router.Get("/user/:user_id", workspaceHandler.getWorkspacesByUserId)
@@ -22,5 +23,7 @@ func RegisterWorkspaceHandler(router fiber.Router, db *gorm.DB) {
 	router.Get("/is_active/:is_active", workspaceHandler.getWorkspacesByIsActive)
 	router.Get("/email/:email", workspaceHandler.getWorkspacesByEmail)
 	router.Get("/filter/workspace", workspaceHandler.filterWorkspaces)
+	router.Post("/:workspace_id/clone", middleware.RequireScope(middleware.ScopeAdmin), workspaceHandler.cloneWorkspace)
+	router.Get("/clone/:job_id", workspaceHandler.getCloneJob)
 
 }
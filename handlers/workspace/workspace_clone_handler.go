@@ -0,0 +1,100 @@
+package workspace
+
+import (
+	"dbms/clone"
+	"dbms/permission"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"strconv"
+)
+
+// cloneWorkspace godoc
+// @Summary Clone workspace
+// @Description Start copying a workspace into a new one: its joined members with their roles mapped by role_map, its board columns in order and its schedules in their positions, with their participants, reminders, recurrence exceptions, document metadata and, with include_comments, comments. The acting workspace user, found through the user_id claim of its token, becomes an owner of the clone. The copy runs in the background; follow it with GET /workspace/clone/{job_id}. The clone is logged in both workspaces. Requires the dbms.admin scope.
+// @Tags workspace
+// @Accept json
+// @Produce json
+// @Param workspace_id path int true "Workspace ID"
+// @Param body body clone.Options true "Clone options"
+// @Success 202 {object} clone.Job
+// @Failure 400 {object} fiber.Map "Invalid role_map"
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
+// @Failure 404 {object} fiber.Map "Workspace not found"
+// @Router /dbms/v1/workspace/{workspace_id}/clone [post]
+func (handler *WorkspaceHandler) cloneWorkspace(c *fiber.Ctx) error {
+	workspaceId, err := strconv.Atoi(c.Params("workspace_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid workspace_id")
+	}
+	var options clone.Options
+	if err := c.BodyParser(&options); err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	if err := options.Validate(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	var workspace models.TwWorkspace
+	if err := handler.DB.Where("id = ? AND deleted_at IS NULL", workspaceId).First(&workspace).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Workspace not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	actorId, err := permission.Actor(c, handler.DB, workspaceId)
+	if err != nil {
+		return permission.Respond(c, err)
+	}
+	if _, err := permission.Authorize(handler.DB, workspaceId, actorId, permission.ActionCloneWorkspace); err != nil {
+		return permission.Respond(c, err)
+	}
+
+	job := clone.Job{
+		SourceWorkspaceId: workspaceId,
+		CreatedBy:         actorId,
+		Status:            clone.StatusPending,
+	}
+	if err := handler.DB.Create(&job).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	running := job
+	go clone.Run(handler.DB, &running, options)
+
+	return c.Status(fiber.StatusAccepted).JSON(job)
+}
+
+// getCloneJob godoc
+// @Summary Get workspace clone progress
+// @Description Get a workspace clone: its status (pending, running, succeeded or failed), how many of the total members, board columns and schedules are copied, the new workspace and, if it failed, the error. A failed clone is deleted with its members, schedules and reminders. A clone that makes no progress for 15 minutes, e.g. because the server stopped, fails.
+// @Tags workspace
+// @Accept json
+// @Produce json
+// @Param job_id path int true "Clone job ID"
+// @Success 200 {object} clone.Job
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
+// @Failure 404 {object} fiber.Map "Clone job not found"
+// @Router /dbms/v1/workspace/clone/{job_id} [get]
+func (handler *WorkspaceHandler) getCloneJob(c *fiber.Ctx) error {
+	var job clone.Job
+	if err := handler.DB.Where("id = ?", c.Params("job_id")).First(&job).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Clone job not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	actorId, err := permission.Actor(c, handler.DB, job.SourceWorkspaceId)
+	if err != nil {
+		return permission.Respond(c, err)
+	}
+	if _, err := permission.Authorize(handler.DB, job.SourceWorkspaceId, actorId, permission.ActionCloneWorkspace); err != nil {
+		return permission.Respond(c, err)
+	}
+	return c.JSON(job)
+}
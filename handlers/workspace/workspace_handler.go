@@ -1,6 +1,7 @@
 package workspace
 
 import (
+	"dbms/common"
 	"errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/timewise-team/timewise-models/models"
@@ -8,6 +9,7 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"strings"
 )
 
 type WorkspaceHandler struct {
@@ -15,19 +17,53 @@ type WorkspaceHandler struct {
 	DB     *gorm.DB
 }
 
+// workspaceFields are the workspace columns list endpoints filter and sort by.
+var workspaceFields = common.FilterFields{
+	"id":         {Column: "tw_workspaces.id", Type: common.FieldInt},
+	"title":      {Column: "tw_workspaces.title", Type: common.FieldString},
+	"key":        {Column: "tw_workspaces.key", Type: common.FieldString},
+	"type":       {Column: "tw_workspaces.type", Type: common.FieldString},
+	"is_deleted": {Column: "tw_workspaces.is_deleted", Type: common.FieldBool},
+	"created_at": {Column: "tw_workspaces.created_at", Type: common.FieldTime},
+	"updated_at": {Column: "tw_workspaces.updated_at", Type: common.FieldTime},
+}
+
+// memberWorkspaceFields add the membership of the user whose workspaces are
+// listed.
+var memberWorkspaceFields = workspaceFields.With(common.FilterFields{
+	"role":  {Column: "tw_workspace_users.role", Type: common.FieldString},
+	"email": {Column: "tw_user_emails.email", Type: common.FieldString},
+})
+
 // GetWorkspaces godoc
 // @Summary Get all workspaces
-// @Description Get all workspaces
+// @Description Get a page of the workspaces. The X-Total-Count header holds the number of workspaces and X-Next-Cursor the cursor of the next page, if any. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, title, key, type, is_deleted, created_at and updated_at.
 // @Tags workspace
 // @Accept json
 // @Produce json
+// @Param limit query int false "Maximum number of workspaces (default 50, max 200); the whole list is returned when neither limit nor cursor is given"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Param sort query string false "Sort by one of the filter fields, prefixed with - for descending order (default id)"
 // @Success 200 {array} models.TwWorkspace
+// @Header 200 {integer} X-Total-Count "Number of workspaces"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {string} string "Invalid sort or cursor"
 // @Router /dbms/v1/workspace [get]
 func (handler *WorkspaceHandler) getWorkspaces(c *fiber.Ctx) error {
+	filter, err := common.ParseFilter(c, workspaceFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	page, err := common.ParsePage(c, workspaceFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
 	var workspaces []models.TwWorkspace
-	if result := handler.DB.Find(&workspaces); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	result, err := page.Find(filter.Where(handler.DB), &workspaces)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
+	result.Write(c)
 
 	return c.JSON(workspaces)
 }
@@ -265,17 +301,17 @@ func (handler *WorkspaceHandler) getWorkspacesByEmail(c *fiber.Ctx) error {
 
 // filterWorkspaces godoc
 // @Summary Filter workspaces
-// @Description Filter workspaces
+// @Description Filter the workspaces a user is a member of with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, e.g. role[in]=owner,admin&title[contains]=team. The fields are id, title, key, type, is_deleted, created_at, updated_at, and role and email of the user's membership.
 // @Tags workspace
 // @Accept json
 // @Produce json
-// @Param email query string false "Email"
-// @Param role query string false "Role"
-// @Param search query string false "Search"
-// @Param sortBy query string false "Sort by"
-// @Param order query string false "Order"
+// @Param search query string false "Search the workspace titles"
+// @Param sort query string false "Comma separated fields to sort by, each prefixed with - for descending order, e.g. -created_at,title"
+// @Param sortBy query string false "Field to sort by when sort is not given, as in earlier versions"
+// @Param order query string false "asc or desc, the order of sortBy (default asc)"
 // @Param userid query string true "User ID"
 // @Success 200 {object} []models.TwWorkspace
+// @Failure 400 {object} fiber.Map "Invalid user ID, filter or sort"
 // @Router /dbms/v1/workspace/filter/workspace [get]
 func (handler *WorkspaceHandler) filterWorkspaces(c *fiber.Ctx) error {
 	var workspaces []models.TwWorkspace
@@ -283,6 +319,14 @@ func (handler *WorkspaceHandler) filterWorkspaces(c *fiber.Ctx) error {
 	if c.Query("userid") == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid user ID"})
 	}
+	filter, err := common.ParseFilter(c, memberWorkspaceFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	order, err := common.SortBy(legacySort(c), memberWorkspaceFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
 	query = query.Joins("JOIN tw_workspace_users ON tw_workspaces.id = tw_workspace_users.workspace_id").
 		Joins("JOIN tw_user_emails ON tw_workspace_users.user_email_id = tw_user_emails.id").
 		Joins("JOIN tw_users ON tw_user_emails.user_id = tw_users.id").
@@ -295,29 +339,13 @@ func (handler *WorkspaceHandler) filterWorkspaces(c *fiber.Ctx) error {
 		Where("tw_workspace_users.role != 'Guest'").
 		Where("tw_workspace_users.deleted_at IS NULL").
 		Where("tw_user_emails.user_id = ? or (tw_user_emails.is_linked_to = ? and tw_user_emails.status = 'linked') ", c.Query("userid"), c.Query("userid"))
-	// Filter by email
-	if email := c.Query("email"); email != "" {
-		query = query.
-			Where("tw_user_emails.email = ?", email)
-	}
-
-	// Filter by role
-	if role := c.Query("role"); role != "" {
-		query = query.
-			Where("tw_workspace_users.role = ?", role)
-	}
+	query = order.Order(filter.Where(query))
 
 	// Search by keyword
 	if search := c.Query("search"); search != "" {
 		query = query.Where("tw_workspaces.title LIKE ? ", "%"+search+"%")
 	}
 
-	// Sort by field
-	if sortBy := c.Query("sortBy"); sortBy != "" {
-		order := c.Query("order", "asc")
-		query = query.Order(sortBy + " " + order)
-	}
-
 	// Execute the query
 	if err := query.Find(&workspaces).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
@@ -325,3 +353,18 @@ func (handler *WorkspaceHandler) filterWorkspaces(c *fiber.Ctx) error {
 
 	return c.JSON(workspaces)
 }
+
+// legacySort returns the sort parameter, or the order the sortBy and order
+// parameters of earlier clients ask for.
+func legacySort(c *fiber.Ctx) string {
+	sortBy := c.Query("sortBy")
+	if c.Query("sort") != "" || sortBy == "" {
+		return c.Query("sort")
+	}
+	// sortBy used to name columns, sometimes with their table.
+	sortBy = sortBy[strings.LastIndex(sortBy, ".")+1:]
+	if strings.EqualFold(c.Query("order"), "desc") {
+		return "-" + sortBy
+	}
+	return sortBy
+}
@@ -0,0 +1,17 @@
+package workspace_event
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func RegisterWorkspaceEventHandler(router fiber.Router, db *gorm.DB) {
+	workspaceEventHandler := WorkspaceEventHandler{
+		Router: router,
+		DB:     db,
+	}
+
+	// Register all endpoints here
+	router.Get("/workspace/:workspace_id", workspaceEventHandler.getEvents)
+	router.Get("/workspace/:workspace_id/stream", workspaceEventHandler.streamEvents)
+}
@@ -0,0 +1,158 @@
+package workspace_event
+
+import (
+	"bufio"
+	"dbms/realtime"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultLimit = 100
+	maxLimit     = 500
+	// pollInterval bounds how late a stream sees events committed by another
+	// instance, or committed after their wake-up was sent.
+	pollInterval      = 2 * time.Second
+	heartbeatInterval = 15 * time.Second
+)
+
+type WorkspaceEventHandler struct {
+	Router fiber.Router
+	DB     *gorm.DB
+}
+
+type EventsResponse struct {
+	Events []realtime.Event `json:"events"`
+	// Cursor is the value to resume from: the last event returned, or the
+	// requested cursor when there is nothing new.
+	Cursor int `json:"cursor"`
+}
+
+// getEvents godoc
+// @Summary Get workspace events
+// @Description Get the changes on a workspace board after a cursor, for clients that poll or catch up before streaming
+// @Tags workspace_event
+// @Accept json
+// @Produce json
+// @Param workspace_id path int true "Workspace ID"
+// @Param cursor query int false "Return events after this cursor (default 0)"
+// @Param limit query int false "Maximum number of events (default 100, max 500)"
+// @Success 200 {object} workspace_event.EventsResponse
+// @Router /dbms/v1/workspace_event/workspace/{workspace_id} [get]
+func (h *WorkspaceEventHandler) getEvents(c *fiber.Ctx) error {
+	workspaceId, err := strconv.Atoi(c.Params("workspace_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid workspace_id")
+	}
+	cursor := c.QueryInt("cursor", 0)
+	limit := c.QueryInt("limit", defaultLimit)
+	if limit <= 0 || limit > maxLimit {
+		limit = maxLimit
+	}
+
+	events, err := realtime.Since(h.DB, workspaceId, cursor, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+	if len(events) > 0 {
+		cursor = events[len(events)-1].Seq
+	}
+	return c.JSON(EventsResponse{Events: events, Cursor: cursor})
+}
+
+// streamEvents godoc
+// @Summary Stream workspace events
+// @Description Stream the changes on a workspace board as server-sent events. Each event carries its cursor as the SSE id and its type (e.g. schedule.moved) as the SSE event name. A reconnecting client resumes after the Last-Event-ID header or the cursor query; without either, the stream starts with the next change.
+// @Tags workspace_event
+// @Produce text/event-stream
+// @Param workspace_id path int true "Workspace ID"
+// @Param cursor query int false "Resume after this cursor"
+// @Param Last-Event-ID header int false "Resume after this cursor; takes precedence over the cursor query"
+// @Success 200 {string} string "Event stream"
+// @Router /dbms/v1/workspace_event/workspace/{workspace_id}/stream [get]
+func (h *WorkspaceEventHandler) streamEvents(c *fiber.Ctx) error {
+	workspaceId, err := strconv.Atoi(c.Params("workspace_id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString("Invalid workspace_id")
+	}
+
+	resume := c.Get("Last-Event-ID")
+	if resume == "" {
+		resume = c.Query("cursor")
+	}
+	var cursor int
+	if resume != "" {
+		if cursor, err = strconv.Atoi(resume); err != nil {
+			return c.Status(fiber.StatusBadRequest).SendString("Invalid cursor")
+		}
+	} else if cursor, err = realtime.Latest(h.DB, workspaceId); err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	db := h.DB
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		wake, unsubscribe := realtime.Subscribe(workspaceId)
+		defer unsubscribe()
+		poll := time.NewTicker(pollInterval)
+		defer poll.Stop()
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			events, err := realtime.Since(db, workspaceId, cursor, defaultLimit)
+			if err != nil {
+				log.Printf("Could not read events of workspace %d: %v", workspaceId, err)
+				return
+			}
+			for _, event := range events {
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+				cursor = event.Seq
+			}
+			if len(events) > 0 {
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+			if len(events) == defaultLimit {
+				// More are waiting; keep draining before sleeping.
+				continue
+			}
+
+			select {
+			case <-wake:
+			case <-poll.C:
+			case <-heartbeat.C:
+				// Comments keep proxies from closing an idle stream and
+				// reveal a client that has gone away.
+				if _, err := w.WriteString(": ping\n\n"); err != nil {
+					return
+				}
+				if err := w.Flush(); err != nil {
+					return
+				}
+			}
+		}
+	})
+	return nil
+}
+
+func writeEvent(w *bufio.Writer, event realtime.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Seq, event.Type, data)
+	return err
+}
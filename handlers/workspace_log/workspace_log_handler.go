@@ -1,6 +1,7 @@
 package workspace_log
 
 import (
+	"dbms/common"
 	"errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/timewise-team/timewise-models/models"
@@ -12,6 +13,17 @@ type WorkspaceLog struct {
 	DB     *gorm.DB
 }
 
+// workspaceLogFields are the workspace log columns list endpoints filter and
+// sort by.
+var workspaceLogFields = common.FilterFields{
+	"id":                {Column: "id", Type: common.FieldInt},
+	"workspace_id":      {Column: "workspace_id", Type: common.FieldInt},
+	"workspace_user_id": {Column: "workspace_user_id", Type: common.FieldInt},
+	"action":            {Column: "action", Type: common.FieldString},
+	"field_changed":     {Column: "field_changed", Type: common.FieldString},
+	"created_at":        {Column: "created_at", Type: common.FieldTime},
+}
+
 // createWorkspaceLog godoc
 // @Summary Create workspace log
 // @Description Create workspace log
@@ -33,17 +45,33 @@ func (h *WorkspaceLog) createWorkspaceLog(c *fiber.Ctx) error {
 }
 
 // @Summary Get all workspace logs
-// @Description Get all workspace logs
+// @Description Get a page of the workspace logs. The X-Total-Count header holds the number of workspace logs and X-Next-Cursor the cursor of the next page, if any. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, workspace_id, workspace_user_id, action, field_changed and created_at.
 // @Tags workspace_log
 // @Accept json
 // @Produce json
+// @Param limit query int false "Maximum number of workspace logs (default 50, max 200); the whole list is returned when neither limit nor cursor is given"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Param sort query string false "Sort by one of the filter fields, prefixed with - for descending order (default id)"
 // @Success 200 {array} models.TwWorkspaceLog
+// @Header 200 {integer} X-Total-Count "Number of workspace logs"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {string} string "Invalid sort or cursor"
 // @Router /dbms/v1/workspace_log [get]
 func (h *WorkspaceLog) getWorkspaceLog(c *fiber.Ctx) error {
+	filter, err := common.ParseFilter(c, workspaceLogFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	page, err := common.ParsePage(c, workspaceLogFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
 	var workspaceLogs []models.TwWorkspaceLog
-	if result := h.DB.Find(&workspaceLogs); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	result, err := page.Find(filter.Where(h.DB), &workspaceLogs)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
+	result.Write(c)
 
 	return c.JSON(workspaceLogs)
 }
@@ -87,10 +115,29 @@ func (h *WorkspaceLog) removeWorkspaceLogById(c *fiber.Ctx) error {
 
 }
 
+// getWorkspaceLogsByWorkspaceId godoc
+// @Summary Get workspace logs by workspace
+// @Description Get the logs of a workspace. Filter with field=value or field[op]=value query parameters, where op is eq, ne, in, lt, lte, gt, gte or contains, on id, workspace_id, workspace_user_id, action, field_changed and created_at.
+// @Tags workspace_log
+// @Accept json
+// @Produce json
+// @Param workspace_id path int true "Workspace ID"
+// @Param sort query string false "Comma separated fields to sort by, each prefixed with - for descending order"
+// @Success 200 {array} models.TwWorkspaceLog
+// @Failure 400 {string} string "Invalid filter or sort"
+// @Router /dbms/v1/workspace_log/workspace/{workspace_id} [get]
 func (h *WorkspaceLog) getWorkspaceLogsByWorkspaceId(ctx *fiber.Ctx) error {
 	workspaceId := ctx.Params("workspace_id")
+	filter, err := common.ParseFilter(ctx, workspaceLogFields)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
+	order, err := common.ParseSort(ctx, workspaceLogFields)
+	if err != nil {
+		return ctx.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
 	var workspaceLogs []models.TwWorkspaceLog
-	if result := h.DB.Where("workspace_id = ? and deleted_at IS NULL", workspaceId).Find(&workspaceLogs); result.Error != nil {
+	if result := order.Order(filter.Where(h.DB)).Where("workspace_id = ? and deleted_at IS NULL", workspaceId).Find(&workspaceLogs); result.Error != nil {
 		return ctx.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
 	}
 	return ctx.JSON(workspaceLogs)
@@ -1,6 +1,7 @@
 package workspace_user
 
 import (
+	"dbms/middleware"
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
 )
@@ -14,9 +15,9 @@ func RegisterWorkspaceUserHandler(router fiber.Router, db *gorm.DB) {
 	// Register all endpoints here
 	router.Get("/", workspaceUserHandler.getWorkspaceUsers)
 	router.Get("/:workspace_user_id", workspaceUserHandler.getWorkspaceUserById)
-	router.Delete("/:workspace_user_id", workspaceUserHandler.removeWorkspaceUserById)
+	router.Delete("/:workspace_user_id", middleware.RequireScope(middleware.ScopeAdmin), workspaceUserHandler.removeWorkspaceUserById)
 	router.Post("/", workspaceUserHandler.createWorkspaceUser)
-	router.Put("/:workspace_user_id", workspaceUserHandler.updateWorkspaceUser)
+	router.Put("/:workspace_user_id", middleware.RequireScope(middleware.ScopeAdmin), workspaceUserHandler.updateWorkspaceUser)
 	router.Get("/workspace/:workspace_id", workspaceUserHandler.getWorkspaceUsersByWorkspaceId)
 	router.Get("/manage/workspace/:workspace_id", workspaceUserHandler.getWorkspaceUsersByWorkspaceIdForManage)
 	router.Get("/user/:user_id", workspaceUserHandler.getWorkspaceUsersByUserId)
@@ -25,8 +26,8 @@ func RegisterWorkspaceUserHandler(router fiber.Router, db *gorm.DB) {
 	router.Get("/is_active/:is_active", workspaceUserHandler.getWorkspaceUsersByIsActive)
 	router.Get("/email/:email/workspace/:workspace_id", workspaceUserHandler.getWorkspaceUserByEmailAndWorkspace)
 	router.Get("/invitation/workspace/:workspace_id", workspaceUserHandler.GetWorkspaceUserInvitationList)
-	router.Delete("/:workspace_user_id/workspace/:workspace_id/", workspaceUserHandler.DeleteWorkspaceUser)
-	router.Put("/role/workspace/:workspace_id", workspaceUserHandler.UpdateRole)
+	router.Delete("/:workspace_user_id/workspace/:workspace_id/", middleware.RequireScope(middleware.ScopeAdmin), workspaceUserHandler.DeleteWorkspaceUser)
+	router.Put("/role/workspace/:workspace_id", middleware.RequireScope(middleware.ScopeAdmin), workspaceUserHandler.UpdateRole)
 	router.Put("/verify-invitation/workspace/:workspace_id/email/:email", workspaceUserHandler.VerifyMemberInvitationRequest)
 	router.Put("/disprove-invitation/workspace/:workspace_id/email/:email", workspaceUserHandler.DisproveMemberInvitationRequest)
 	router.Put("/update-status/:workspace_user_id", workspaceUserHandler.UpdateWorkspaceUserStatus)
@@ -2,14 +2,21 @@ package workspace_user
 
 //workspace_user_handler.go
 import (
+	"dbms/common"
+	"dbms/permission"
+	"dbms/repository"
+	"dbms/webhook"
 	"errors"
+	"fmt"
 	"github.com/gofiber/fiber/v2"
 	workspaceUserDtos "github.com/timewise-team/timewise-models/dtos/core_dtos/workspace_user_dtos"
 	"github.com/timewise-team/timewise-models/models"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type WorkspaceUserHandler struct {
@@ -17,11 +24,37 @@ type WorkspaceUserHandler struct {
 	DB     *gorm.DB
 }
 
+// workspaceUserFields are the workspace user columns the list endpoint sorts
+// by.
+var workspaceUserFields = common.FilterFields{
+	"created_at": {Column: "created_at", Type: common.FieldTime},
+}
+
+// getWorkspaceUsers godoc
+// @Summary Get all workspace users
+// @Description Get a page of the workspace users. The X-Total-Count header holds the number of workspace users and X-Next-Cursor the cursor of the next page, if any.
+// @Tags workspace_user
+// @Accept json
+// @Produce json
+// @Param limit query int false "Maximum number of workspace users (default 50, max 200); the whole list is returned when neither limit nor cursor is given"
+// @Param cursor query string false "X-Next-Cursor of the previous page"
+// @Param sort query string false "Sort by id or created_at; prefix with - for descending order (default id)"
+// @Success 200 {array} models.TwWorkspaceUser
+// @Header 200 {integer} X-Total-Count "Number of workspace users"
+// @Header 200 {string} X-Next-Cursor "Cursor of the next page"
+// @Failure 400 {string} string "Invalid sort or cursor"
+// @Router /dbms/v1/workspace_user [get]
 func (h *WorkspaceUserHandler) getWorkspaceUsers(c *fiber.Ctx) error {
+	page, err := common.ParsePage(c, workspaceUserFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
+	}
 	var workspaceUsers []models.TwWorkspaceUser
-	if result := h.DB.Find(&workspaceUsers); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	result, err := page.Find(h.DB, &workspaceUsers)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
+	result.Write(c)
 
 	return c.JSON(workspaceUsers)
 }
@@ -52,8 +85,36 @@ func (h *WorkspaceUserHandler) createWorkspaceUser(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).SendString(err.Error())
 	}
 
-	if result := h.DB.Create(workspaceUser); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	// Anyone added without having joined yet is invited by the caller, whom
+	// the invitation email names.
+	inviterId := 0
+	if workspaceUser.Status != "joined" {
+		var err error
+		if inviterId, err = permission.Actor(c, h.DB, workspaceUser.WorkspaceId); err != nil {
+			return permission.Respond(c, err)
+		}
+	}
+
+	err := h.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(workspaceUser).Error; err != nil {
+			return err
+		}
+		if workspaceUser.Status == "joined" {
+			return nil
+		}
+		// Anyone added without having joined yet is an invitation.
+		if err := webhook.Enqueue(tx, workspaceUser.WorkspaceId, webhook.EventMemberInvited, map[string]interface{}{
+			"workspace_user_id": workspaceUser.ID,
+			"user_email_id":     workspaceUser.UserEmailId,
+			"role":              workspaceUser.Role,
+			"status":            workspaceUser.Status,
+		}); err != nil {
+			return err
+		}
+		return repository.NotifyInvitation(tx, *workspaceUser, inviterId)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
 	return c.JSON(workspaceUser)
@@ -309,13 +370,15 @@ func (h *WorkspaceUserHandler) GetWorkspaceUserInvitationList(c *fiber.Ctx) erro
 
 // deleteWorkspaceUser godoc
 // @Summary Delete workspace user
-// @Description Delete workspace user
+// @Description Remove a workspace user. The acting workspace user is the caller's membership of the workspace, found through the user_id claim of its token. Anyone may leave; removing someone else needs a role above theirs. The last owner of a workspace cannot be removed. Requires the dbms.admin scope.
 // @Tags workspace_user
 // @Accept json
 // @Produce json
 // @Param workspace_id path string true "Workspace ID"
 // @Param workspace_user_id path string true "Workspace User ID"
 // @Success 200 {object} fiber.Map
+// @Failure 403 {object} fiber.Map "Permission denied or the token names no user"
+// @Failure 409 {object} fiber.Map "The last owner cannot be removed"
 // @Router /dbms/v1/workspace_user/{workspace_user_id}/workspace/{workspace_id} [delete]
 func (h *WorkspaceUserHandler) DeleteWorkspaceUser(c *fiber.Ctx) error {
 	workspaceId := c.Params("workspace_id")
@@ -330,15 +393,66 @@ func (h *WorkspaceUserHandler) DeleteWorkspaceUser(c *fiber.Ctx) error {
 			"error": "Workspace User is required",
 		})
 	}
+	workspaceIdInt, err := strconv.Atoi(workspaceId)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid workspace ID",
+		})
+	}
+	actorId, err := permission.Actor(c, h.DB, workspaceIdInt)
+	if err != nil {
+		return permission.Respond(c, err)
+	}
 	var workspaceUser models.TwWorkspaceUser
-	err := h.DB.Where("id = ? and workspace_id = ?", workspaceUserId, workspaceId).First(&workspaceUser).Error
+	err = h.DB.Where("id = ? and workspace_id = ?", workspaceUserId, workspaceId).First(&workspaceUser).Error
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
 	}
 
-	if result := h.DB.Model(&workspaceUser).
-		Update("deleted_at", gorm.Expr("NOW()")); result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).SendString(result.Error.Error())
+	// Anyone may leave a workspace; removing someone else needs the permission
+	// and a higher role than theirs.
+	if actorId != workspaceUser.ID {
+		actor, err := permission.Authorize(h.DB, workspaceIdInt, actorId, permission.ActionRemoveMember)
+		if err != nil {
+			return permission.Respond(c, err)
+		}
+		if !permission.Outranks(actor.Role, workspaceUser.Role) {
+			return permission.Respond(c, permission.Deny(h.DB, &permission.DeniedError{
+				WorkspaceId:     workspaceIdInt,
+				WorkspaceUserId: actorId,
+				Role:            actor.Role,
+				Action:          permission.ActionRemoveMember,
+				Reason:          fmt.Sprintf("role %q cannot remove a workspace user with role %q", actor.Role, workspaceUser.Role),
+			}))
+		}
+	}
+
+	err = h.DB.Transaction(func(tx *gorm.DB) error {
+		// A workspace keeps at least one owner. The owners are locked so that
+		// two owners removing each other cannot both succeed.
+		if strings.EqualFold(workspaceUser.Role, permission.RoleOwner) {
+			var owners []int
+			if err := tx.Model(&models.TwWorkspaceUser{}).
+				Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("workspace_id = ? AND role = ?", workspaceIdInt, permission.RoleOwner).
+				Where("deleted_at IS NULL AND status = 'joined' AND is_active = true").
+				Pluck("id", &owners).Error; err != nil {
+				return err
+			}
+			remaining := 0
+			for _, id := range owners {
+				if id != workspaceUser.ID {
+					remaining++
+				}
+			}
+			if remaining == 0 {
+				return fiber.NewError(fiber.StatusConflict, "the last owner of a workspace cannot be removed")
+			}
+		}
+		return tx.Model(&workspaceUser).Update("deleted_at", gorm.Expr("NOW()")).Error
+	})
+	if err != nil {
+		return permission.Respond(c, err)
 	}
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "User deleted successfully",
@@ -347,7 +461,7 @@ func (h *WorkspaceUserHandler) DeleteWorkspaceUser(c *fiber.Ctx) error {
 
 // UpdateRole godoc
 // @Summary Update role of workspace user
-// @Description Update role of workspace user
+// @Description Update role of workspace user. Requires the dbms.admin scope.
 // @Tags workspace_user
 // @Accept json
 // @Produce json
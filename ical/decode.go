@@ -0,0 +1,356 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Property is a single unfolded content line such as
+// "DTSTART;TZID=Europe/Paris:20240101T090000".
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Decode parses an RFC 5545 document and returns its VEVENTs. Components
+// nested in an event (VALARM) and other top-level components (VTIMEZONE,
+// VTODO, ...) are skipped. Times with a TZID are resolved through the Go
+// timezone database, by Windows name or by the X-LIC-LOCATION of the
+// matching VTIMEZONE; floating times are read as UTC. An event with a TZID
+// none of these resolve is returned with Err set rather than failing the
+// whole calendar.
+func Decode(r io.Reader) (Calendar, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return Calendar{}, err
+	}
+	aliases := timezoneAliases(lines)
+
+	var calendar Calendar
+	var event *Event
+	var hasEnd bool
+	var duration time.Duration
+	inCalendar := false
+	nested := 0
+	for number, line := range lines {
+		property, err := parseProperty(line)
+		if err != nil {
+			return calendar, fmt.Errorf("line %d: %w", number+1, err)
+		}
+		value := strings.ToUpper(property.Value)
+		switch {
+		case property.Name == "BEGIN" && value == "VCALENDAR":
+			inCalendar = true
+			continue
+		case property.Name == "END" && value == "VCALENDAR":
+			inCalendar = false
+			continue
+		case !inCalendar:
+			continue
+		case property.Name == "BEGIN" && value == "VEVENT" && event == nil && nested == 0:
+			event = &Event{}
+			hasEnd = false
+			duration = 0
+			continue
+		case property.Name == "END" && value == "VEVENT" && event != nil && nested == 0:
+			if event.Err == nil && event.Start.IsZero() {
+				return calendar, fmt.Errorf("line %d: event %q has no DTSTART", number+1, event.UID)
+			}
+			if !hasEnd && duration > 0 {
+				event.End = event.Start.Add(duration)
+			}
+			calendar.Events = append(calendar.Events, *event)
+			event = nil
+			continue
+		case property.Name == "BEGIN":
+			nested++
+			continue
+		case property.Name == "END":
+			if nested > 0 {
+				nested--
+			}
+			continue
+		case nested > 0:
+			continue
+		}
+
+		if event == nil {
+			if property.Name == "X-WR-CALNAME" {
+				calendar.Name = UnescapeText(property.Value)
+			}
+			continue
+		}
+
+		switch property.Name {
+		case "UID":
+			event.UID = property.Value
+		case "SUMMARY":
+			event.Summary = UnescapeText(property.Value)
+		case "DESCRIPTION":
+			event.Description = UnescapeText(property.Value)
+		case "LOCATION":
+			event.Location = UnescapeText(property.Value)
+		case "CLASS":
+			event.Class = strings.ToUpper(property.Value)
+		case "PRIORITY":
+			event.Priority, _ = strconv.Atoi(property.Value)
+		case "RRULE":
+			event.RRule = property.Value
+		case "DTSTART":
+			start, allDay, err := property.time(aliases)
+			if err != nil {
+				if skip(event, number, err) {
+					continue
+				}
+				return calendar, fmt.Errorf("line %d: %w", number+1, err)
+			}
+			event.Start = start
+			event.AllDay = allDay
+		case "DTEND":
+			end, _, err := property.time(aliases)
+			if err != nil {
+				if skip(event, number, err) {
+					continue
+				}
+				return calendar, fmt.Errorf("line %d: %w", number+1, err)
+			}
+			event.End = end
+			hasEnd = true
+		case "DURATION":
+			duration, err = ParseDuration(property.Value)
+			if err != nil {
+				return calendar, fmt.Errorf("line %d: %w", number+1, err)
+			}
+		case "RECURRENCE-ID":
+			recurrenceID, _, err := property.time(aliases)
+			if err != nil {
+				if skip(event, number, err) {
+					continue
+				}
+				return calendar, fmt.Errorf("line %d: %w", number+1, err)
+			}
+			event.RecurrenceID = &recurrenceID
+		case "EXDATE":
+			for _, value := range strings.Split(property.Value, ",") {
+				exDate, _, err := Property{Name: property.Name, Params: property.Params, Value: value}.time(aliases)
+				if err != nil {
+					if skip(event, number, err) {
+						break
+					}
+					return calendar, fmt.Errorf("line %d: %w", number+1, err)
+				}
+				event.ExDates = append(event.ExDates, exDate)
+			}
+		case "CREATED":
+			event.Created, _, _ = property.Time()
+		case "LAST-MODIFIED":
+			event.LastModified, _, _ = property.Time()
+		}
+	}
+	if event != nil {
+		return calendar, errors.New("unterminated VEVENT")
+	}
+	return calendar, nil
+}
+
+// skip marks event as unreadable when err is an unknown TZID, so that
+// Decode goes on with the next event; other errors fail the calendar.
+func skip(event *Event, number int, err error) bool {
+	if !errors.Is(err, ErrUnknownTZID) {
+		return false
+	}
+	if event.Err == nil {
+		event.Err = fmt.Errorf("line %d: %w", number+1, err)
+	}
+	return true
+}
+
+// Time parses a DATE or DATE-TIME property value. The second result reports
+// whether the value is a DATE (an all-day value).
+func (p Property) Time() (time.Time, bool, error) {
+	return p.time(nil)
+}
+
+func (p Property) time(aliases map[string]string) (time.Time, bool, error) {
+	value := strings.TrimSpace(p.Value)
+	if strings.EqualFold(p.Params["VALUE"], "DATE") || len(value) == len(dateLayout) {
+		t, err := time.ParseInLocation(dateLayout, value, time.UTC)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid %s %q", p.Name, p.Value)
+		}
+		return t, true, nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(dateTimeLayout, value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid %s %q", p.Name, p.Value)
+		}
+		return t, false, nil
+	}
+	loc := time.UTC
+	if tzid := p.Params["TZID"]; tzid != "" {
+		var err error
+		if loc, err = loadTZID(tzid, aliases); err != nil {
+			return time.Time{}, false, err
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid %s %q", p.Name, p.Value)
+	}
+	return t, false, nil
+}
+
+// UnescapeText reverses EscapeText.
+func UnescapeText(value string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range value {
+		if !escaped {
+			if r == '\\' {
+				escaped = true
+			} else {
+				b.WriteRune(r)
+			}
+			continue
+		}
+		escaped = false
+		switch r {
+		case 'n', 'N':
+			b.WriteRune('\n')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ParseDuration parses an RFC 5545 duration such as "-PT15M", "P1DT2H" or "P2W".
+func ParseDuration(value string) (time.Duration, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	sign := time.Duration(1)
+	switch {
+	case strings.HasPrefix(s, "-"):
+		sign = -1
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) < 3 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	s = s[1:]
+
+	var total time.Duration
+	inTime := false
+	number := ""
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			number += string(r)
+			continue
+		case r == 'T':
+			if inTime || number != "" {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			inTime = true
+			continue
+		}
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		number = ""
+		var unit time.Duration
+		switch {
+		case r == 'W' && !inTime:
+			unit = 7 * 24 * time.Hour
+		case r == 'D' && !inTime:
+			unit = 24 * time.Hour
+		case r == 'H' && inTime:
+			unit = time.Hour
+		case r == 'M' && inTime:
+			unit = time.Minute
+		case r == 'S' && inTime:
+			unit = time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		total += time.Duration(n) * unit
+	}
+	if number != "" {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return sign * total, nil
+}
+
+func unfold(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var lines []string
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+func parseProperty(line string) (Property, error) {
+	// The value starts at the first colon outside a quoted parameter value.
+	colon := -1
+	quoted := false
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return Property{}, fmt.Errorf("invalid content line %q", line)
+	}
+
+	parts := splitParams(line[:colon])
+	property := Property{
+		Name:   strings.ToUpper(parts[0]),
+		Params: make(map[string]string),
+		Value:  line[colon+1:],
+	}
+	for _, param := range parts[1:] {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		property.Params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+	}
+	return property, nil
+}
+
+func splitParams(s string) []string {
+	var parts []string
+	quoted := false
+	last := 0
+	for i, r := range s {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ';' && !quoted {
+			parts = append(parts, s[last:i])
+			last = i + 1
+		}
+	}
+	return append(parts, s[last:])
+}
@@ -0,0 +1,203 @@
+package ical
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func calendarWith(lines ...string) string {
+	return strings.Join(append(append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...), "END:VCALENDAR"), "\r\n")
+}
+
+func TestDecodeResolvesTZID(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		want    time.Time
+		wantLoc string
+	}{
+		{
+			name:    "IANA name",
+			lines:   []string{"DTSTART;TZID=Europe/Berlin:20260330T090000"},
+			want:    time.Date(2026, 3, 30, 7, 0, 0, 0, time.UTC),
+			wantLoc: "Europe/Berlin",
+		},
+		{
+			name:    "IANA name with a leading slash",
+			lines:   []string{"DTSTART;TZID=/Asia/Ho_Chi_Minh:20260330T090000"},
+			want:    time.Date(2026, 3, 30, 2, 0, 0, 0, time.UTC),
+			wantLoc: "Asia/Ho_Chi_Minh",
+		},
+		{
+			name:    "Windows name",
+			lines:   []string{`DTSTART;TZID="W. Europe Standard Time":20260330T090000`},
+			want:    time.Date(2026, 3, 30, 7, 0, 0, 0, time.UTC),
+			wantLoc: "Europe/Berlin",
+		},
+		{
+			name: "VTIMEZONE with X-LIC-LOCATION",
+			lines: []string{
+				"BEGIN:VTIMEZONE", "TZID:Custom/Berlin", "X-LIC-LOCATION:Europe/Berlin",
+				"BEGIN:STANDARD", "TZOFFSETFROM:+0200", "TZOFFSETTO:+0100", "END:STANDARD",
+				"END:VTIMEZONE",
+				"DTSTART;TZID=Custom/Berlin:20260330T090000",
+			},
+			want:    time.Date(2026, 3, 30, 7, 0, 0, 0, time.UTC),
+			wantLoc: "Europe/Berlin",
+		},
+		{
+			name:    "UTC",
+			lines:   []string{"DTSTART:20260330T090000Z"},
+			want:    time.Date(2026, 3, 30, 9, 0, 0, 0, time.UTC),
+			wantLoc: "UTC",
+		},
+		{
+			name:    "floating time is read as UTC",
+			lines:   []string{"DTSTART:20260330T090000"},
+			want:    time.Date(2026, 3, 30, 9, 0, 0, 0, time.UTC),
+			wantLoc: "UTC",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := tt.lines[:len(tt.lines)-1]
+			dtstart := tt.lines[len(tt.lines)-1]
+			document := calendarWith(append(lines, "BEGIN:VEVENT", "UID:1", dtstart, "END:VEVENT")...)
+			calendar, err := Decode(strings.NewReader(document))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(calendar.Events) != 1 {
+				t.Fatalf("decoded %d events, want 1", len(calendar.Events))
+			}
+			event := calendar.Events[0]
+			if event.Err != nil {
+				t.Fatalf("Err = %v", event.Err)
+			}
+			if !event.Start.Equal(tt.want) {
+				t.Errorf("Start = %s, want %s", event.Start.UTC(), tt.want)
+			}
+			if got := event.Start.Location().String(); got != tt.wantLoc {
+				t.Errorf("Start is in %s, want %s", got, tt.wantLoc)
+			}
+		})
+	}
+}
+
+func TestDecodeSkipsEventsWithUnknownTZID(t *testing.T) {
+	document := calendarWith(
+		"BEGIN:VEVENT", "UID:unknown", "DTSTART;TZID=Mars/Olympus_Mons:20260330T090000", "SUMMARY:Lost", "END:VEVENT",
+		"BEGIN:VEVENT", "UID:known", "DTSTART;TZID=Europe/Berlin:20260330T090000", "END:VEVENT",
+	)
+	calendar, err := Decode(strings.NewReader(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calendar.Events) != 2 {
+		t.Fatalf("decoded %d events, want 2", len(calendar.Events))
+	}
+	if !errors.Is(calendar.Events[0].Err, ErrUnknownTZID) {
+		t.Errorf("Err = %v, want ErrUnknownTZID", calendar.Events[0].Err)
+	}
+	if calendar.Events[0].Summary != "Lost" {
+		t.Errorf("Summary = %q, want the rest of the event read", calendar.Events[0].Summary)
+	}
+	if calendar.Events[1].Err != nil {
+		t.Errorf("Err = %v on the second event", calendar.Events[1].Err)
+	}
+}
+
+func TestDecodeEvent(t *testing.T) {
+	document := calendarWith(
+		"X-WR-CALNAME:Team\\, Berlin",
+		"BEGIN:VEVENT",
+		"UID:42",
+		"DTSTART;VALUE=DATE:20260330",
+		"DURATION:P1D",
+		"SUMMARY:Off\\nsite",
+		"DESCRIPTION:A long description that is folded onto",
+		"  the next line",
+		"RRULE:FREQ=WEEKLY;BYDAY=MO",
+		"EXDATE;VALUE=DATE:20260406,20260413",
+		"BEGIN:VALARM", "TRIGGER:-PT15M", "DESCRIPTION:Nested", "END:VALARM",
+		"END:VEVENT",
+	)
+	calendar, err := Decode(strings.NewReader(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calendar.Name != "Team, Berlin" {
+		t.Errorf("Name = %q", calendar.Name)
+	}
+	if len(calendar.Events) != 1 {
+		t.Fatalf("decoded %d events, want 1", len(calendar.Events))
+	}
+	event := calendar.Events[0]
+	if !event.AllDay || !event.Start.Equal(time.Date(2026, 3, 30, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Start = %s, AllDay = %v", event.Start, event.AllDay)
+	}
+	if !event.End.Equal(time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("End = %s, want DTSTART plus DURATION", event.End)
+	}
+	if event.Summary != "Off\nsite" {
+		t.Errorf("Summary = %q", event.Summary)
+	}
+	if event.Description != "A long description that is folded onto the next line" {
+		t.Errorf("Description = %q", event.Description)
+	}
+	if event.RRule != "FREQ=WEEKLY;BYDAY=MO" {
+		t.Errorf("RRule = %q", event.RRule)
+	}
+	if len(event.ExDates) != 2 {
+		t.Errorf("ExDates = %v, want 2 dates", event.ExDates)
+	}
+}
+
+func TestDecodeRejectsMalformedCalendars(t *testing.T) {
+	tests := map[string]string{
+		"event without DTSTART": calendarWith("BEGIN:VEVENT", "UID:1", "END:VEVENT"),
+		"unterminated event":    calendarWith("BEGIN:VEVENT", "UID:1", "DTSTART:20260330T090000Z"),
+		"invalid DTSTART":       calendarWith("BEGIN:VEVENT", "UID:1", "DTSTART:tomorrow", "END:VEVENT"),
+		"invalid DURATION":      calendarWith("BEGIN:VEVENT", "UID:1", "DTSTART:20260330T090000Z", "DURATION:1h", "END:VEVENT"),
+		"line without a colon":  calendarWith("BEGIN:VEVENT", "UID"),
+	}
+	for name, document := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Decode(strings.NewReader(document)); err == nil {
+				t.Error("Decode succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{value: "PT15M", want: 15 * time.Minute},
+		{value: "-PT15M", want: -15 * time.Minute},
+		{value: "+P1DT2H", want: 26 * time.Hour},
+		{value: "P2W", want: 14 * 24 * time.Hour},
+		{value: "PT1H30M10S", want: time.Hour + 30*time.Minute + 10*time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseDuration(tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDuration(%q) = %s, want %s", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	for _, value := range []string{"", "P", "15M", "PT", "P1H", "PT1D", "PT15"} {
+		if _, err := ParseDuration(value); err == nil {
+			t.Errorf("ParseDuration(%q) succeeded, want an error", value)
+		}
+	}
+}
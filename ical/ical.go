@@ -0,0 +1,222 @@
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+	prodID         = "-//Timewise//Timewise DMS//EN"
+)
+
+type Calendar struct {
+	Name   string
+	Events []Event
+}
+
+type Attendee struct {
+	Email    string
+	Name     string
+	PartStat string
+}
+
+// Alarm triggers either relative to the event start (Offset, negative for
+// before) or at an absolute time (At) when At is set.
+type Alarm struct {
+	Offset      time.Duration
+	At          *time.Time
+	Description string
+}
+
+type Event struct {
+	UID          string
+	RecurrenceID *time.Time
+	Start        time.Time
+	End          time.Time
+	AllDay       bool
+	Summary      string
+	Description  string
+	Location     string
+	Class        string
+	Priority     int
+	RRule        string
+	ExDates      []time.Time
+	Organizer    *Attendee
+	Attendees    []Attendee
+	Alarms       []Alarm
+	Created      time.Time
+	LastModified time.Time
+	// Err is set on an event Decode could not read, such as one in a timezone
+	// it does not know, so that the caller can report it and go on.
+	Err error
+}
+
+// Encode renders the calendar as an RFC 5545 document with CRLF line endings
+// and folded content lines.
+func (c Calendar) Encode() string {
+	var b builder
+	b.line("BEGIN:VCALENDAR")
+	b.line("VERSION:2.0")
+	b.line("PRODID:" + prodID)
+	b.line("CALSCALE:GREGORIAN")
+	b.line("METHOD:PUBLISH")
+	if c.Name != "" {
+		b.line("X-WR-CALNAME:" + EscapeText(c.Name))
+	}
+	now := time.Now().UTC()
+	for _, event := range c.Events {
+		event.encode(&b, now)
+	}
+	b.line("END:VCALENDAR")
+	return b.String()
+}
+
+func (e Event) encode(b *builder, now time.Time) {
+	b.line("BEGIN:VEVENT")
+	b.line("UID:" + e.UID)
+	b.line("DTSTAMP:" + now.Format(dateTimeLayout))
+	if e.RecurrenceID != nil {
+		b.line(e.timeProperty("RECURRENCE-ID", *e.RecurrenceID))
+	}
+	b.line(e.timeProperty("DTSTART", e.Start))
+	if !e.End.IsZero() && e.End.After(e.Start) {
+		b.line(e.timeProperty("DTEND", e.End))
+	} else if e.AllDay {
+		b.line(e.timeProperty("DTEND", e.Start.AddDate(0, 0, 1)))
+	}
+	b.line("SUMMARY:" + EscapeText(e.Summary))
+	if e.Description != "" {
+		b.line("DESCRIPTION:" + EscapeText(e.Description))
+	}
+	if e.Location != "" {
+		b.line("LOCATION:" + EscapeText(e.Location))
+	}
+	if e.Class != "" {
+		b.line("CLASS:" + e.Class)
+	}
+	if e.Priority > 0 {
+		b.line(fmt.Sprintf("PRIORITY:%d", e.Priority))
+	}
+	if e.RRule != "" {
+		b.line("RRULE:" + e.RRule)
+	}
+	for _, exDate := range e.ExDates {
+		b.line(e.timeProperty("EXDATE", exDate))
+	}
+	if e.Organizer != nil {
+		b.line("ORGANIZER" + nameParam(e.Organizer.Name) + ":mailto:" + e.Organizer.Email)
+	}
+	for _, attendee := range e.Attendees {
+		partStat := attendee.PartStat
+		if partStat == "" {
+			partStat = "NEEDS-ACTION"
+		}
+		b.line("ATTENDEE" + nameParam(attendee.Name) + ";PARTSTAT=" + partStat + ":mailto:" + attendee.Email)
+	}
+	if !e.Created.IsZero() {
+		b.line("CREATED:" + e.Created.UTC().Format(dateTimeLayout))
+	}
+	if !e.LastModified.IsZero() {
+		b.line("LAST-MODIFIED:" + e.LastModified.UTC().Format(dateTimeLayout))
+	}
+	for _, alarm := range e.Alarms {
+		b.line("BEGIN:VALARM")
+		b.line("ACTION:DISPLAY")
+		if alarm.At != nil {
+			b.line("TRIGGER;VALUE=DATE-TIME:" + alarm.At.UTC().Format(dateTimeLayout))
+		} else {
+			b.line("TRIGGER:" + FormatDuration(alarm.Offset))
+		}
+		description := alarm.Description
+		if description == "" {
+			description = e.Summary
+		}
+		b.line("DESCRIPTION:" + EscapeText(description))
+		b.line("END:VALARM")
+	}
+	b.line("END:VEVENT")
+}
+
+func (e Event) timeProperty(name string, t time.Time) string {
+	if e.AllDay {
+		return name + ";VALUE=DATE:" + t.Format(dateLayout)
+	}
+	return name + ":" + t.UTC().Format(dateTimeLayout)
+}
+
+func nameParam(name string) string {
+	if name == "" {
+		return ""
+	}
+	return `;CN="` + strings.NewReplacer(`"`, "'", "\r", "", "\n", " ").Replace(name) + `"`
+}
+
+// EscapeText escapes a TEXT property value.
+func EscapeText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(value)
+}
+
+// FormatDuration renders d as an RFC 5545 duration such as "-PT15M" or "P1D".
+func FormatDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign = "-"
+		d = -d
+	}
+	seconds := int64(d / time.Second)
+	days := seconds / 86400
+	seconds %= 86400
+	hours := seconds / 3600
+	seconds %= 3600
+	minutes := seconds / 60
+	seconds %= 60
+
+	result := sign + "P"
+	if days > 0 {
+		result += fmt.Sprintf("%dD", days)
+	}
+	if hours > 0 || minutes > 0 || seconds > 0 || days == 0 {
+		result += "T"
+		if hours > 0 {
+			result += fmt.Sprintf("%dH", hours)
+		}
+		if minutes > 0 {
+			result += fmt.Sprintf("%dM", minutes)
+		}
+		if seconds > 0 || (hours == 0 && minutes == 0) {
+			result += fmt.Sprintf("%dS", seconds)
+		}
+	}
+	return result
+}
+
+type builder struct {
+	strings.Builder
+}
+
+// line writes a content line, folding it at 75 octets without splitting
+// multi-byte characters.
+func (b *builder) line(content string) {
+	const limit = 75
+	width := 0
+	for _, r := range content {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+}
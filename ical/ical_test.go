@@ -0,0 +1,123 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("timezone Europe/Berlin is not available: %v", err)
+	}
+	at := time.Date(2026, 3, 30, 8, 45, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		event   Event
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "times are written in UTC",
+			event: Event{UID: "1", Summary: "Standup",
+				Start: time.Date(2026, 3, 30, 9, 0, 0, 0, berlin), End: time.Date(2026, 3, 30, 9, 15, 0, 0, berlin)},
+			want: []string{"DTSTART:20260330T070000Z", "DTEND:20260330T071500Z", "SUMMARY:Standup"},
+		},
+		{
+			name:    "an end before the start is left out",
+			event:   Event{UID: "1", Start: at, End: at.Add(-time.Hour)},
+			notWant: []string{"DTEND"},
+		},
+		{
+			name:  "all-day events are dates ending the next day",
+			event: Event{UID: "1", AllDay: true, Start: time.Date(2026, 3, 30, 0, 0, 0, 0, time.UTC)},
+			want:  []string{"DTSTART;VALUE=DATE:20260330", "DTEND;VALUE=DATE:20260331"},
+		},
+		{
+			name: "recurrences keep their rule and exceptions",
+			event: Event{UID: "1", Start: at, RRule: "FREQ=WEEKLY;BYDAY=MO",
+				ExDates: []time.Time{at.AddDate(0, 0, 7)}, RecurrenceID: &at},
+			want: []string{"RRULE:FREQ=WEEKLY;BYDAY=MO", "EXDATE:20260406T084500Z", "RECURRENCE-ID:20260330T084500Z"},
+		},
+		{
+			name:  "text is escaped",
+			event: Event{UID: "1", Start: at, Summary: "Plan; review, ship\nnow", Location: `C:\room`},
+			want:  []string{`SUMMARY:Plan\; review\, ship\nnow`, `LOCATION:C:\\room`},
+		},
+		{
+			name: "attendees and alarms",
+			event: Event{UID: "1", Start: at, Summary: "Standup",
+				Organizer: &Attendee{Email: "an@example.com", Name: `An "Boss"`},
+				Attendees: []Attendee{{Email: "binh@example.com"}, {Email: "chi@example.com", PartStat: "ACCEPTED"}},
+				Alarms:    []Alarm{{Offset: -15 * time.Minute}, {At: &at, Description: "Now"}}},
+			want: []string{
+				`ORGANIZER;CN="An 'Boss'":mailto:an@example.com`,
+				"ATTENDEE;PARTSTAT=NEEDS-ACTION:mailto:binh@example.com",
+				"ATTENDEE;PARTSTAT=ACCEPTED:mailto:chi@example.com",
+				"TRIGGER:-PT15M\r\nDESCRIPTION:Standup",
+				"TRIGGER;VALUE=DATE-TIME:20260330T084500Z\r\nDESCRIPTION:Now",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document := Calendar{Name: "Team", Events: []Event{tt.event}}.Encode()
+			if !strings.HasPrefix(document, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n") || !strings.HasSuffix(document, "END:VCALENDAR\r\n") {
+				t.Errorf("document is not a CRLF VCALENDAR:\n%s", document)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(document, want+"\r\n") {
+					t.Errorf("document does not contain %q:\n%s", want, document)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(document, notWant) {
+					t.Errorf("document contains %q:\n%s", notWant, document)
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeFoldsLongLines(t *testing.T) {
+	summary := strings.Repeat("Lịch họp ", 20)
+	document := Calendar{Events: []Event{{UID: "1", Start: time.Now(), Summary: summary}}}.Encode()
+	for _, line := range strings.Split(strings.TrimSuffix(document, "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line is %d octets long: %q", len(line), line)
+		}
+	}
+
+	calendar, err := Decode(strings.NewReader(document))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calendar.Events) != 1 || calendar.Events[0].Summary != summary {
+		t.Errorf("folded summary did not round-trip: %+v", calendar.Events)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{d: 0, want: "PT0S"},
+		{d: -15 * time.Minute, want: "-PT15M"},
+		{d: 24 * time.Hour, want: "P1D"},
+		{d: 26*time.Hour + 30*time.Second, want: "P1DT2H30S"},
+		{d: time.Hour + 5*time.Minute, want: "PT1H5M"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := FormatDuration(tt.d); got != tt.want {
+				t.Errorf("FormatDuration(%s) = %q, want %q", tt.d, got, tt.want)
+			}
+			if back, err := ParseDuration(tt.want); err != nil || back != tt.d {
+				t.Errorf("ParseDuration(%q) = %s, %v", tt.want, back, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package lexorank
+
+import (
+	"errors"
+	"strings"
+)
+
+// Keys are strings over the base-36 alphabet below and sort with plain byte
+// comparison. Generated keys never end in '0', so there is always room for
+// another key between any two of them.
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+// MaxLength is the key length past which a group should be rebalanced.
+const MaxLength = 16
+
+var (
+	ErrInvalidKey = errors.New("invalid rank key")
+	ErrOrder      = errors.New("rank keys are not in order")
+)
+
+// Between returns a key that sorts strictly after prev and before next. An
+// empty prev means the start of the list and an empty next its end.
+func Between(prev string, next string) (string, error) {
+	if !valid(prev) || !valid(next) {
+		return "", ErrInvalidKey
+	}
+	if next != "" && prev >= next {
+		return "", ErrOrder
+	}
+	if next == "" {
+		return after(prev), nil
+	}
+	if prev == "" {
+		return before(next), nil
+	}
+
+	var key strings.Builder
+	bounded := true
+	for i := 0; ; i++ {
+		lo := 0
+		if i < len(prev) {
+			lo = strings.IndexByte(digits, prev[i])
+		}
+		hi := base
+		if bounded {
+			hi = strings.IndexByte(digits, next[i])
+		}
+
+		if hi-lo > 1 {
+			key.WriteByte(digits[(lo+hi)/2])
+			return key.String(), nil
+		}
+		key.WriteByte(digits[lo])
+		if hi-lo == 1 {
+			// The key is already below next; only prev constrains the rest.
+			bounded = false
+		}
+	}
+}
+
+// Sequence returns n ascending keys between prev and next, bisecting so that
+// bulk inserts stay short.
+func Sequence(prev string, next string, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	mid, err := Between(prev, next)
+	if err != nil {
+		return nil, err
+	}
+	left, err := Sequence(prev, mid, (n-1)/2)
+	if err != nil {
+		return nil, err
+	}
+	right, err := Sequence(mid, next, n-1-(n-1)/2)
+	if err != nil {
+		return nil, err
+	}
+	keys := append(left, mid)
+	return append(keys, right...), nil
+}
+
+// after returns a short key above prev by bumping its first digit that has room.
+func after(prev string) string {
+	for i := 0; i < len(prev); i++ {
+		if d := strings.IndexByte(digits, prev[i]); d < base-1 {
+			return prev[:i] + string(digits[d+1])
+		}
+	}
+	return prev + string(digits[base/2])
+}
+
+// before returns a short key below next by lowering its first digit that has
+// room without leaving a trailing '0'.
+func before(next string) string {
+	for i := 0; i < len(next); i++ {
+		if d := strings.IndexByte(digits, next[i]); d > 1 {
+			return next[:i] + string(digits[d-1])
+		}
+	}
+	return next[:len(next)-1] + "0" + string(digits[base/2])
+}
+
+// Spread returns n ascending keys of equal length spaced evenly across the key
+// space, for backfills and rebalancing.
+func Spread(n int) []string {
+	if n <= 0 {
+		return nil
+	}
+	length := 1
+	for capacity := base; capacity < n+1; capacity *= base {
+		length++
+	}
+	space := 1
+	for i := 0; i < length; i++ {
+		space *= base
+	}
+
+	keys := make([]string, n)
+	step := space / (n + 1)
+	for i := range keys {
+		value := step * (i + 1)
+		key := make([]byte, length)
+		for j := length - 1; j >= 0; j-- {
+			key[j] = digits[value%base]
+			value /= base
+		}
+		keys[i] = strings.TrimRight(string(key), "0")
+	}
+	return keys
+}
+
+// NeedsRebalance reports whether key has grown long enough that its group
+// should be respread.
+func NeedsRebalance(key string) bool {
+	return len(key) > MaxLength
+}
+
+func valid(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(digits, key[i]) < 0 {
+			return false
+		}
+	}
+	return !strings.HasSuffix(key, "0")
+}
@@ -0,0 +1,219 @@
+package lexorank
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	tests := []struct {
+		prev string
+		next string
+		want string
+	}{
+		{prev: "", next: "", want: "i"},
+		{prev: "i", next: "", want: "j"},
+		{prev: "zz", next: "", want: "zzi"},
+		{prev: "", next: "i", want: "h"},
+		{prev: "", next: "1", want: "0i"},
+		{prev: "", next: "01", want: "00i"},
+		{prev: "a", next: "c", want: "b"},
+		{prev: "a", next: "b", want: "ai"},
+		{prev: "az", next: "b", want: "azi"},
+		{prev: "a", next: "a1", want: "a0i"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prev+"_"+tt.next, func(t *testing.T) {
+			got, err := Between(tt.prev, tt.next)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Between(%q, %q) = %q, want %q", tt.prev, tt.next, got, tt.want)
+			}
+			assertBetween(t, tt.prev, got, tt.next)
+		})
+	}
+}
+
+func TestBetweenRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		prev string
+		next string
+		want error
+	}{
+		{name: "uppercase", prev: "A", want: ErrInvalidKey},
+		{name: "trailing zero", next: "a0", want: ErrInvalidKey},
+		{name: "outside the alphabet", prev: "a-b", want: ErrInvalidKey},
+		{name: "equal keys", prev: "b", next: "b", want: ErrOrder},
+		{name: "reversed keys", prev: "c", next: "b", want: ErrOrder},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Between(tt.prev, tt.next); !errors.Is(err, tt.want) {
+				t.Errorf("Between(%q, %q) error = %v, want %v", tt.prev, tt.next, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBetweenRepeatedly(t *testing.T) {
+	tests := []struct {
+		name      string
+		insert    func(keys []string) (string, string, int)
+		maxLength int
+		rebalance bool
+	}{
+		{
+			name:      "append",
+			insert:    func(keys []string) (string, string, int) { return keys[len(keys)-1], "", len(keys) },
+			maxLength: MaxLength,
+		},
+		{
+			name:      "prepend",
+			insert:    func(keys []string) (string, string, int) { return "", keys[0], 0 },
+			maxLength: MaxLength,
+		},
+		{
+			name: "insert after the first",
+			// Keys squeezed into the same gap grow until the group is respread.
+			insert:    func(keys []string) (string, string, int) { return keys[0], keys[1], 1 },
+			rebalance: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := []string{"h", "i"}
+			for n := 0; n < 200; n++ {
+				prev, next, at := tt.insert(keys)
+				key, err := Between(prev, next)
+				if err != nil {
+					t.Fatalf("insert %d: %v", n, err)
+				}
+				keys = append(keys[:at], append([]string{key}, keys[at:]...)...)
+			}
+			if !sort.StringsAreSorted(keys) {
+				t.Fatalf("keys are not sorted: %v", keys)
+			}
+			needsRebalance := false
+			for i, key := range keys {
+				needsRebalance = needsRebalance || NeedsRebalance(key)
+				if !valid(key) {
+					t.Errorf("key %q is not valid", key)
+				}
+				if i > 0 && keys[i-1] == key {
+					t.Errorf("key %q is duplicated", key)
+				}
+				if tt.maxLength > 0 && len(key) > tt.maxLength {
+					t.Errorf("key %q is longer than %d", key, tt.maxLength)
+				}
+			}
+			if needsRebalance != tt.rebalance {
+				t.Errorf("NeedsRebalance of some key = %v, want %v", needsRebalance, tt.rebalance)
+			}
+		})
+	}
+}
+
+func TestSequence(t *testing.T) {
+	tests := []struct {
+		prev string
+		next string
+		n    int
+	}{
+		{n: 0},
+		{n: 1},
+		{n: 100},
+		{prev: "a", next: "b", n: 10},
+		{prev: "zz", n: 50},
+		{next: "01", n: 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prev+"_"+tt.next, func(t *testing.T) {
+			keys, err := Sequence(tt.prev, tt.next, tt.n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(keys) != tt.n {
+				t.Fatalf("got %d keys, want %d", len(keys), tt.n)
+			}
+			prev := tt.prev
+			for _, key := range keys {
+				assertBetween(t, prev, key, tt.next)
+				prev = key
+			}
+		})
+	}
+}
+
+func TestSpread(t *testing.T) {
+	tests := []struct {
+		n         int
+		maxLength int
+	}{
+		{n: 0},
+		{n: 1, maxLength: 1},
+		{n: 35, maxLength: 1},
+		{n: 36, maxLength: 2},
+		{n: 1000, maxLength: 2},
+		{n: 1295, maxLength: 2},
+		{n: 1296, maxLength: 3},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.n), func(t *testing.T) {
+			keys := Spread(tt.n)
+			if len(keys) != tt.n {
+				t.Fatalf("got %d keys, want %d", len(keys), tt.n)
+			}
+			prev := ""
+			for _, key := range keys {
+				assertBetween(t, prev, key, "")
+				if len(key) > tt.maxLength {
+					t.Errorf("key %q is longer than %d", key, tt.maxLength)
+				}
+				prev = key
+			}
+			if tt.n > 0 {
+				// Rebalanced groups leave room at both ends.
+				if _, err := Between("", keys[0]); err != nil {
+					t.Errorf("no room before %q: %v", keys[0], err)
+				}
+				if after := after(keys[len(keys)-1]); NeedsRebalance(after) {
+					t.Errorf("appending after %q needs a rebalance", keys[len(keys)-1])
+				}
+			}
+		})
+	}
+}
+
+func TestNeedsRebalance(t *testing.T) {
+	tests := map[string]bool{
+		"":                                 false,
+		"i":                                false,
+		strings.Repeat("a", MaxLength):     false,
+		strings.Repeat("a", MaxLength+1):   true,
+		strings.Repeat("a", 2*MaxLength+1): true,
+	}
+	for key, want := range tests {
+		if got := NeedsRebalance(key); got != want {
+			t.Errorf("NeedsRebalance(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func assertBetween(t *testing.T, prev, key, next string) {
+	t.Helper()
+	if !valid(key) || key == "" {
+		t.Errorf("key %q is not valid", key)
+	}
+	if prev != "" && key <= prev {
+		t.Errorf("key %q does not sort after %q", key, prev)
+	}
+	if next != "" && key >= next {
+		t.Errorf("key %q does not sort before %q", key, next)
+	}
+}
@@ -0,0 +1,276 @@
+package lexorank
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"log"
+	"sort"
+)
+
+// Column holds the rank key on every ranked table.
+const Column = "rank_key"
+
+// Order sorts ranked rows. Rows without a key, which Migrate converts but
+// other writers may still leave behind, go last rather than first as MySQL
+// would put NULLs.
+const Order = "rank_key IS NULL, rank_key, id"
+
+// reverseOrder sorts rows without a key first and the rest by descending key.
+const reverseOrder = "rank_key IS NULL DESC, rank_key DESC, id DESC"
+
+// Group describes a table whose rows are ordered by rank key within a parent row.
+type Group struct {
+	Table       string
+	Parent      string
+	ParentTable string
+	// Live selects the rows that take part in the ordering.
+	Live string
+}
+
+var (
+	Schedules = Group{
+		Table:       "tw_schedules",
+		Parent:      "board_column_id",
+		ParentTable: "tw_board_columns",
+		Live:        "is_deleted = false",
+	}
+	BoardColumns = Group{
+		Table:       "tw_board_columns",
+		Parent:      "workspace_id",
+		ParentTable: "tw_workspaces",
+		Live:        "deleted_at IS NULL",
+	}
+)
+
+// Lock takes row locks on the parent rows for the rest of the transaction, so
+// that keys computed from neighbours cannot collide. Parents are locked in id
+// order to avoid deadlocks between two moves across the same pair.
+func (g Group) Lock(tx *gorm.DB, parentIds ...int) error {
+	sort.Ints(parentIds)
+	for i, parentId := range parentIds {
+		if i > 0 && parentId == parentIds[i-1] {
+			continue
+		}
+		var ids []int
+		if err := tx.Table(g.ParentTable).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", parentId).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+	}
+	return nil
+}
+
+// KeyAt returns the key that places a row at the 1-based position among the
+// live rows of parentId, leaving excludeId (the row being moved) out of the
+// count. Positions past the end append to the group.
+func (g Group) KeyAt(tx *gorm.DB, parentId int, position int, excludeId int) (string, error) {
+	key, err := g.keyAt(tx, parentId, position, excludeId)
+	if errors.Is(err, ErrOrder) || errors.Is(err, ErrInvalidKey) {
+		// Duplicate or missing keys leave no gap to insert into; respread and retry.
+		if err := g.Rebalance(tx, parentId); err != nil {
+			return "", err
+		}
+		key, err = g.keyAt(tx, parentId, position, excludeId)
+	}
+	return key, err
+}
+
+func (g Group) keyAt(tx *gorm.DB, parentId int, position int, excludeId int) (string, error) {
+	query := func() *gorm.DB {
+		return tx.Table(g.Table).
+			Where(g.Parent+" = ? AND id != ?", parentId, excludeId).
+			Where(g.Live)
+	}
+
+	// Rows without a key sort last; meeting one as a neighbour means the
+	// group needs a rebalance before a key can be placed.
+	var neighbours []string
+	if position <= 1 {
+		if err := query().Order(Order).Limit(1).
+			Pluck("COALESCE(rank_key, '')", &neighbours).Error; err != nil {
+			return "", err
+		}
+		next := ""
+		if len(neighbours) > 0 {
+			if next = neighbours[0]; next == "" {
+				return "", ErrInvalidKey
+			}
+		}
+		return Between("", next)
+	}
+
+	if err := query().Order(Order).Offset(position-2).Limit(2).
+		Pluck("COALESCE(rank_key, '')", &neighbours).Error; err != nil {
+		return "", err
+	}
+	for _, neighbour := range neighbours {
+		if neighbour == "" {
+			return "", ErrInvalidKey
+		}
+	}
+	switch len(neighbours) {
+	case 2:
+		return Between(neighbours[0], neighbours[1])
+	case 1:
+		return Between(neighbours[0], "")
+	}
+
+	// Past the end: append after the last row, if any.
+	if err := query().Order(reverseOrder).Limit(1).
+		Pluck("COALESCE(rank_key, '')", &neighbours).Error; err != nil {
+		return "", err
+	}
+	if len(neighbours) == 0 {
+		return Between("", "")
+	}
+	if neighbours[0] == "" {
+		return "", ErrInvalidKey
+	}
+	return Between(neighbours[0], "")
+}
+
+// AppendKeys returns n ascending keys that place new rows after the last live
+// row of parentId.
+func (g Group) AppendKeys(tx *gorm.DB, parentId int, n int) ([]string, error) {
+	var last []string
+	if err := tx.Table(g.Table).
+		Where(g.Parent+" = ?", parentId).
+		Where(g.Live).
+		Order(reverseOrder).
+		Limit(1).
+		Pluck("COALESCE(rank_key, '')", &last).Error; err != nil {
+		return nil, err
+	}
+	prev := ""
+	var err error
+	if len(last) > 0 {
+		if prev = last[0]; prev == "" {
+			// A row without a key would sort after the new ones.
+			err = ErrInvalidKey
+		}
+	}
+	var keys []string
+	if err == nil {
+		keys, err = Sequence(prev, "", n)
+	}
+	if errors.Is(err, ErrInvalidKey) {
+		if err := g.Rebalance(tx, parentId); err != nil {
+			return nil, err
+		}
+		return g.AppendKeys(tx, parentId, n)
+	}
+	return keys, err
+}
+
+// Set stores key on a single row.
+func (g Group) Set(tx *gorm.DB, id int, key string) error {
+	return tx.Table(g.Table).Where("id = ?", id).Update(Column, key).Error
+}
+
+// Positions maps the id of every live row of parentId to its 1-based position.
+func (g Group) Positions(tx *gorm.DB, parentId int) (map[int]int, error) {
+	var ids []int
+	if err := tx.Table(g.Table).
+		Where(g.Parent+" = ?", parentId).
+		Where(g.Live).
+		Order(Order).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	positions := make(map[int]int, len(ids))
+	for i, id := range ids {
+		positions[id] = i + 1
+	}
+	return positions, nil
+}
+
+// Position returns the 1-based position of a single row among the live rows of its parent.
+func (g Group) Position(tx *gorm.DB, parentId int, id int) (int, error) {
+	var before int64
+	err := tx.Table(g.Table+" AS other").
+		Joins("JOIN "+g.Table+" AS self ON self.id = ?", id).
+		Where("other."+g.Parent+" = ? AND other.id != self.id", parentId).
+		Where("other." + g.Live).
+		Where("((other.rank_key IS NULL) < (self.rank_key IS NULL) OR " +
+			"((other.rank_key IS NULL) = (self.rank_key IS NULL) AND " +
+			"(COALESCE(other.rank_key, '') < COALESCE(self.rank_key, '') OR " +
+			"(COALESCE(other.rank_key, '') = COALESCE(self.rank_key, '') AND other.id < self.id))))").
+		Count(&before).Error
+	return int(before) + 1, err
+}
+
+// Rebalance respreads the keys of every row of parentId evenly, keeping their
+// order. Rows without a key go last, in their legacy position order. Callers
+// should hold the parent lock.
+func (g Group) Rebalance(tx *gorm.DB, parentId int) error {
+	var ids []int
+	if err := tx.Table(g.Table).
+		Where(g.Parent+" = ?", parentId).
+		Order("rank_key IS NULL, rank_key, position, id").
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	for i, key := range Spread(len(ids)) {
+		if err := g.Set(tx, ids[i], key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RebalanceInBackground respreads parentId in its own transaction once the
+// caller's request is done. Failures are only logged: long keys still sort
+// correctly, they just waste space.
+func (g Group) RebalanceInBackground(db *gorm.DB, parentId int) {
+	go func() {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := g.Lock(tx, parentId); err != nil {
+				return err
+			}
+			return g.Rebalance(tx, parentId)
+		})
+		if err != nil {
+			log.Printf("Could not rebalance %s %d: %v", g.Table, parentId, err)
+		}
+	}()
+}
+
+// Migrate adds the rank key column to every ranked table and converts the
+// integer positions of rows that have no key yet.
+func Migrate(db *gorm.DB) error {
+	for _, g := range []Group{Schedules, BoardColumns} {
+		if !db.Migrator().HasColumn(g.Table, Column) {
+			if err := db.Exec("ALTER TABLE " + g.Table +
+				" ADD COLUMN rank_key VARCHAR(64) CHARACTER SET ascii COLLATE ascii_bin NULL," +
+				" ADD INDEX idx_" + g.Table + "_rank_key (" + g.Parent + ", rank_key)").Error; err != nil {
+				return err
+			}
+		}
+
+		var parentIds []int
+		if err := db.Table(g.Table).
+			Where("rank_key IS NULL").
+			Distinct(g.Parent).
+			Pluck(g.Parent, &parentIds).Error; err != nil {
+			return err
+		}
+		for _, parentId := range parentIds {
+			err := db.Transaction(func(tx *gorm.DB) error {
+				if err := g.Lock(tx, parentId); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+				return g.Rebalance(tx, parentId)
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,286 @@
+package lexorank
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// scriptedDriver answers the statements of one test in order, so that the
+// queries a Group runs can be checked without a MySQL server.
+type scriptedDriver struct {
+	mu      sync.Mutex
+	scripts map[string]*script
+}
+
+var scripts = &scriptedDriver{scripts: make(map[string]*script)}
+
+func init() {
+	sql.Register("lexorank_scripted", scripts)
+}
+
+// step is an expected statement: its SQL must contain match and its last
+// arguments be args. A query returns rows of a single column.
+type step struct {
+	match string
+	args  []driver.Value
+	rows  []driver.Value
+}
+
+type script struct {
+	t     *testing.T
+	steps []step
+	execs [][]driver.Value
+}
+
+func openScript(t *testing.T, steps ...step) (*gorm.DB, *script) {
+	t.Helper()
+	s := &script{t: t, steps: steps}
+	scripts.mu.Lock()
+	scripts.scripts[t.Name()] = s
+	scripts.mu.Unlock()
+	t.Cleanup(func() {
+		scripts.mu.Lock()
+		delete(scripts.scripts, t.Name())
+		scripts.mu.Unlock()
+		if len(s.steps) > 0 {
+			t.Errorf("%d statements were not run, next %q", len(s.steps), s.steps[0].match)
+		}
+	})
+
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DriverName:                "lexorank_scripted",
+		DSN:                       t.Name(),
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{SkipDefaultTransaction: true, Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, s
+}
+
+func (s *script) next(query string, args []driver.NamedValue) (step, error) {
+	if len(s.steps) == 0 {
+		s.t.Errorf("unexpected statement %q", query)
+		return step{}, errors.New("unexpected statement")
+	}
+	current := s.steps[0]
+	s.steps = s.steps[1:]
+	if !strings.Contains(query, current.match) {
+		s.t.Errorf("statement %q does not contain %q", query, current.match)
+		return step{}, errors.New("unexpected statement")
+	}
+	if len(current.args) > len(args) {
+		s.t.Errorf("statement %q has %d arguments, want at least %d", query, len(args), len(current.args))
+		return step{}, errors.New("unexpected arguments")
+	}
+	for i, want := range current.args {
+		if got := args[len(args)-len(current.args)+i].Value; got != want {
+			s.t.Errorf("statement %q has argument %v, want %v", query, got, want)
+		}
+	}
+	return current, nil
+}
+
+func (d *scriptedDriver) Open(name string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	s, ok := d.scripts[name]
+	if !ok {
+		return nil, fmt.Errorf("no script for %s", name)
+	}
+	return &scriptedConn{script: s}, nil
+}
+
+type scriptedConn struct {
+	script *script
+}
+
+func (c *scriptedConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not scripted")
+}
+
+func (c *scriptedConn) Close() error { return nil }
+
+func (c *scriptedConn) Begin() (driver.Tx, error) { return c, nil }
+
+func (c *scriptedConn) Commit() error { return nil }
+
+func (c *scriptedConn) Rollback() error { return nil }
+
+func (c *scriptedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	current, err := c.script.next(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &scriptedRows{values: current.rows}, nil
+}
+
+func (c *scriptedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if _, err := c.script.next(query, args); err != nil {
+		return nil, err
+	}
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	c.script.execs = append(c.script.execs, values)
+	return driver.RowsAffected(1), nil
+}
+
+type scriptedRows struct {
+	values []driver.Value
+}
+
+func (r *scriptedRows) Columns() []string { return []string{"value"} }
+
+func (r *scriptedRows) Close() error { return nil }
+
+func (r *scriptedRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0] = r.values[0]
+	r.values = r.values[1:]
+	return nil
+}
+
+func TestRebalance(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  []driver.Value
+	}{
+		{name: "empty group"},
+		{name: "rows keep their order, keyless ones last", ids: []driver.Value{int64(7), int64(3), int64(9)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps := []step{{match: "ORDER BY rank_key IS NULL, rank_key, position, id", rows: tt.ids}}
+			for range tt.ids {
+				steps = append(steps, step{match: "UPDATE `tw_schedules` SET `rank_key`=?"})
+			}
+			db, s := openScript(t, steps...)
+
+			if err := Schedules.Rebalance(db, 1); err != nil {
+				t.Fatal(err)
+			}
+			keys := Spread(len(tt.ids))
+			for i, exec := range s.execs {
+				if exec[0] != keys[i] || exec[1] != tt.ids[i] {
+					t.Errorf("update %d = %v, want key %q on id %v", i, exec, keys[i], tt.ids[i])
+				}
+			}
+		})
+	}
+}
+
+func TestKeyAt(t *testing.T) {
+	spread := Spread(2)
+	tests := []struct {
+		name     string
+		position int
+		steps    []step
+		want     func(key string) bool
+		updates  int
+	}{
+		{
+			name:     "first in an empty group",
+			position: 1,
+			steps:    []step{{match: "ORDER BY rank_key IS NULL, rank_key, id LIMIT ?", args: []driver.Value{int64(1)}}},
+			want:     func(key string) bool { return key == "i" },
+		},
+		{
+			name:     "between two neighbours",
+			position: 2,
+			steps:    []step{{match: "ORDER BY rank_key IS NULL, rank_key, id LIMIT ?", args: []driver.Value{int64(2)}, rows: []driver.Value{"a", "c"}}},
+			want:     func(key string) bool { return key == "b" },
+		},
+		{
+			name:     "after the last neighbour",
+			position: 2,
+			steps:    []step{{match: "LIMIT ?", args: []driver.Value{int64(2)}, rows: []driver.Value{"a"}}},
+			want:     func(key string) bool { return key == "b" },
+		},
+		{
+			name:     "past the end",
+			position: 9,
+			steps: []step{
+				{match: "LIMIT ? OFFSET ?", args: []driver.Value{int64(2), int64(7)}},
+				{match: "ORDER BY rank_key IS NULL DESC, rank_key DESC, id DESC LIMIT ?", rows: []driver.Value{"x"}},
+			},
+			want: func(key string) bool { return key == "y" },
+		},
+		{
+			name:     "a keyless neighbour respreads the group first",
+			position: 1,
+			steps: []step{
+				{match: "LIMIT ?", args: []driver.Value{int64(1)}, rows: []driver.Value{""}},
+				{match: "ORDER BY rank_key IS NULL, rank_key, position, id", rows: []driver.Value{int64(4), int64(5)}},
+				{match: "UPDATE"},
+				{match: "UPDATE"},
+				{match: "LIMIT ?", args: []driver.Value{int64(1)}, rows: []driver.Value{spread[0]}},
+			},
+			want:    func(key string) bool { return key != "" && key < spread[0] },
+			updates: 2,
+		},
+		{
+			name:     "duplicate neighbours respread the group first",
+			position: 2,
+			steps: []step{
+				{match: "LIMIT ?", args: []driver.Value{int64(2)}, rows: []driver.Value{"b", "b"}},
+				{match: "ORDER BY rank_key IS NULL, rank_key, position, id", rows: []driver.Value{int64(4), int64(5)}},
+				{match: "UPDATE"},
+				{match: "UPDATE"},
+				{match: "LIMIT ?", args: []driver.Value{int64(2)}, rows: []driver.Value{spread[0], spread[1]}},
+			},
+			want:    func(key string) bool { return key > spread[0] && key < spread[1] },
+			updates: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, s := openScript(t, tt.steps...)
+			key, err := Schedules.KeyAt(db, 1, tt.position, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tt.want(key) {
+				t.Errorf("KeyAt = %q", key)
+			}
+			if len(s.execs) != tt.updates {
+				t.Errorf("ran %d updates, want %d", len(s.execs), tt.updates)
+			}
+		})
+	}
+}
+
+func TestAppendKeysAfterKeylessRow(t *testing.T) {
+	db, s := openScript(t,
+		step{match: "ORDER BY rank_key IS NULL DESC, rank_key DESC, id DESC LIMIT ?", rows: []driver.Value{""}},
+		step{match: "ORDER BY rank_key IS NULL, rank_key, position, id", rows: []driver.Value{int64(4)}},
+		step{match: "UPDATE"},
+		step{match: "ORDER BY rank_key IS NULL DESC, rank_key DESC, id DESC LIMIT ?", rows: []driver.Value{Spread(1)[0]}},
+	)
+	keys, err := Schedules.AppendKeys(db, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 3 || len(s.execs) != 1 {
+		t.Fatalf("AppendKeys = %v after %d updates", keys, len(s.execs))
+	}
+	prev := Spread(1)[0]
+	for _, key := range keys {
+		assertBetween(t, prev, key, "")
+		prev = key
+	}
+}
@@ -8,7 +8,12 @@ import (
 // @title timewise-dbms
 // @version 1.0
 // @description Timewise database management system
+// @description Every /dbms/v1 route requires either a verified TLS client certificate or an HS256 service token with the dbms.read, dbms.write or dbms.admin scope.
+// @securityDefinitions.apikey BearerAuth
 // @in header
+// @name Authorization
+// @description Service token sent as "Bearer <JWT>".
+// @security BearerAuth
 func main() {
 	server.RegisterServer()
 }
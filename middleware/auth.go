@@ -0,0 +1,236 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	ScopeRead  = "dbms.read"
+	ScopeWrite = "dbms.write"
+	ScopeAdmin = "dbms.admin"
+
+	principalKey = "principal"
+	clockSkew    = 30 * time.Second
+)
+
+// scopeRank orders scopes so that a stronger scope grants the weaker ones.
+var scopeRank = map[string]int{
+	ScopeRead:  1,
+	ScopeWrite: 2,
+	ScopeAdmin: 3,
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string
+	Scopes  []string
+	// Method is "jwt", "mtls" or "disabled".
+	Method string
+	// UserID is the end user the caller acts for, from the user_id claim of
+	// its token; 0 for calls made by a service on its own behalf.
+	UserID int
+}
+
+// HasScope reports whether the principal holds scope or a stronger one.
+func (p Principal) HasScope(scope string) bool {
+	required, ok := scopeRank[scope]
+	for _, granted := range p.Scopes {
+		if granted == scope {
+			return true
+		}
+		if ok && scopeRank[granted] >= required {
+			return true
+		}
+	}
+	return false
+}
+
+type AuthConfig struct {
+	// JWTSecret verifies HS256 signed service tokens.
+	JWTSecret string
+	// JWTIssuer and JWTAudience are checked against the iss and aud claims when set.
+	JWTIssuer   string
+	JWTAudience string
+	// ClientScopes maps the common name of a verified TLS client certificate
+	// to the scopes granted to it.
+	ClientScopes map[string][]string
+	// Disabled lets every request through as an admin. Only for local development.
+	Disabled bool
+}
+
+type claims struct {
+	Issuer    string          `json:"iss"`
+	Subject   string          `json:"sub"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt int64           `json:"exp"`
+	NotBefore int64           `json:"nbf"`
+	Scope     string          `json:"scope"`
+	Scopes    []string        `json:"scopes"`
+	UserID    int             `json:"user_id"`
+}
+
+// NewAuth returns a middleware that authenticates every request with either a
+// verified TLS client certificate or an "Authorization: Bearer <JWT>" header,
+// and requires ScopeRead for safe methods and ScopeWrite for everything else.
+// Routes needing more can add RequireScope.
+func NewAuth(cfg AuthConfig) fiber.Handler {
+	if cfg.Disabled {
+		log.Println("WARNING: authentication is disabled, every request is treated as admin")
+	} else if cfg.JWTSecret == "" && len(cfg.ClientScopes) == 0 {
+		log.Println("WARNING: no JWT secret or TLS clients configured, every request will be rejected")
+	}
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Disabled {
+			c.Locals(principalKey, Principal{Subject: "anonymous", Scopes: []string{ScopeAdmin}, Method: "disabled"})
+			return c.Next()
+		}
+
+		principal, err := authenticate(c, cfg)
+		if err != nil {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="dbms"`)
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		}
+		c.Locals(principalKey, principal)
+
+		required := ScopeWrite
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+			required = ScopeRead
+		}
+		if !principal.HasScope(required) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "missing scope " + required})
+		}
+		return c.Next()
+	}
+}
+
+// RequireScope rejects requests whose principal lacks scope. It must run after NewAuth.
+func RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal, ok := GetPrincipal(c)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthenticated"})
+		}
+		if !principal.HasScope(scope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "missing scope " + scope})
+		}
+		return c.Next()
+	}
+}
+
+// GetPrincipal returns the principal stored by NewAuth.
+func GetPrincipal(c *fiber.Ctx) (Principal, bool) {
+	principal, ok := c.Locals(principalKey).(Principal)
+	return principal, ok
+}
+
+func authenticate(c *fiber.Ctx, cfg AuthConfig) (Principal, error) {
+	if state := c.Context().TLSConnectionState(); state != nil && len(state.VerifiedChains) > 0 {
+		commonName := state.VerifiedChains[0][0].Subject.CommonName
+		if scopes, ok := cfg.ClientScopes[commonName]; ok {
+			return Principal{Subject: commonName, Scopes: scopes, Method: "mtls"}, nil
+		}
+	}
+
+	header := c.Get(fiber.HeaderAuthorization)
+	if header == "" {
+		return Principal{}, errors.New("missing credentials")
+	}
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found {
+		return Principal{}, errors.New("authorization header must use the Bearer scheme")
+	}
+	if cfg.JWTSecret == "" {
+		return Principal{}, errors.New("bearer tokens are not accepted")
+	}
+	tokenClaims, err := verifyJWT(strings.TrimSpace(token), []byte(cfg.JWTSecret), time.Now())
+	if err != nil {
+		return Principal{}, err
+	}
+	if cfg.JWTIssuer != "" && tokenClaims.Issuer != cfg.JWTIssuer {
+		return Principal{}, errors.New("invalid token issuer")
+	}
+	if cfg.JWTAudience != "" && !hasAudience(tokenClaims.Audience, cfg.JWTAudience) {
+		return Principal{}, errors.New("invalid token audience")
+	}
+
+	scopes := tokenClaims.Scopes
+	if tokenClaims.Scope != "" {
+		scopes = append(scopes, strings.Fields(tokenClaims.Scope)...)
+	}
+	return Principal{Subject: tokenClaims.Subject, Scopes: scopes, Method: "jwt", UserID: tokenClaims.UserID}, nil
+}
+
+// verifyJWT checks an HS256 signed compact JWT and its time claims.
+func verifyJWT(token string, secret []byte, now time.Time) (claims, error) {
+	var tokenClaims claims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return tokenClaims, errors.New("malformed token")
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return tokenClaims, errors.New("malformed token header")
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Alg != "HS256" {
+		return tokenClaims, errors.New("unsupported token algorithm")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return tokenClaims, errors.New("malformed token signature")
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return tokenClaims, errors.New("invalid token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return tokenClaims, errors.New("malformed token payload")
+	}
+	if err := json.Unmarshal(payload, &tokenClaims); err != nil {
+		return tokenClaims, errors.New("malformed token payload")
+	}
+	if tokenClaims.ExpiresAt == 0 {
+		return tokenClaims, errors.New("token has no expiry")
+	}
+	if now.Add(-clockSkew).Unix() >= tokenClaims.ExpiresAt {
+		return tokenClaims, errors.New("token has expired")
+	}
+	if tokenClaims.NotBefore != 0 && now.Add(clockSkew).Unix() < tokenClaims.NotBefore {
+		return tokenClaims, errors.New("token is not valid yet")
+	}
+	return tokenClaims, nil
+}
+
+// hasAudience accepts the aud claim as either a string or an array of strings.
+func hasAudience(raw json.RawMessage, audience string) bool {
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return single == audience
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err == nil {
+		for _, a := range many {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
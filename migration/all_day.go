@@ -0,0 +1,51 @@
+package main
+
+import (
+	"dbms/datetime"
+	"dbms/preference"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+// floatAllDaySchedules moves all-day schedules stored as instants, before
+// all-day times became floating dates, to the dates they fell on in their
+// creator's timezone. Floating dates are at midnight UTC, so times already
+// there are left alone and the migration can run again.
+func floatAllDaySchedules(db *gorm.DB) error {
+	var schedules []models.TwSchedule
+	return db.Select("id, created_by, start_time, end_time").
+		Where("all_day = true").
+		FindInBatches(&schedules, 500, func(tx *gorm.DB, batch int) error {
+			creatorIds := make([]int, 0, len(schedules))
+			for _, schedule := range schedules {
+				creatorIds = append(creatorIds, schedule.CreatedBy)
+			}
+			locations, err := preference.Locations(db, creatorIds)
+			if err != nil {
+				return err
+			}
+
+			for _, schedule := range schedules {
+				loc := locations[schedule.CreatedBy]
+				updates := map[string]interface{}{}
+				if schedule.StartTime != nil && !isFloating(*schedule.StartTime) {
+					updates["start_time"] = datetime.Floating(schedule.StartTime.In(loc))
+				}
+				if schedule.EndTime != nil && !isFloating(*schedule.EndTime) {
+					updates["end_time"] = datetime.FloatingEnd(schedule.EndTime.In(loc))
+				}
+				if len(updates) == 0 {
+					continue
+				}
+				if err := db.Model(&models.TwSchedule{}).Where("id = ?", schedule.ID).UpdateColumns(updates).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
+func isFloating(t time.Time) bool {
+	return t.UTC().Equal(datetime.Floating(t.UTC()))
+}
@@ -1,8 +1,16 @@
 package main
 
 import (
+	"dbms/clone"
 	"dbms/config"
 	"dbms/database"
+	"dbms/lexorank"
+	"dbms/outbox"
+	"dbms/realtime"
+	"dbms/reminderlease"
+	"dbms/scheduletemplate"
+	"dbms/search"
+	"dbms/webhook"
 	"github.com/spf13/viper"
 	"github.com/timewise-team/timewise-models/models"
 	"log"
@@ -52,11 +60,41 @@ func main() {
 		//&models.TwNotificationSettings{},
 		//&models.TwNotifications{},
 		//&models.TwDocument{},
+		&realtime.Event{},
+		&webhook.Subscription{},
+		&webhook.Delivery{},
+		&outbox.Delivery{},
+		&reminderlease.Recipient{},
+		&scheduletemplate.Template{},
+		&clone.Job{},
 	)
 	if err != nil {
 		log.Fatalf("Could not migrate schema: %v", err)
 		return
-	} else {
-		log.Println("Migration success")
 	}
+
+	// Add rank keys and convert existing integer positions
+	if err := lexorank.Migrate(db); err != nil {
+		log.Fatalf("Could not migrate rank keys: %v", err)
+		return
+	}
+
+	// Add the lease columns that cron workers claim reminders with
+	if err := reminderlease.Migrate(db); err != nil {
+		log.Fatalf("Could not migrate reminders: %v", err)
+		return
+	}
+
+	// Add the FULLTEXT indexes workspace search runs on
+	if err := search.Migrate(db); err != nil {
+		log.Fatalf("Could not migrate search indexes: %v", err)
+		return
+	}
+
+	// Store existing all-day schedules as floating dates
+	if err := floatAllDaySchedules(db); err != nil {
+		log.Fatalf("Could not migrate all-day schedules: %v", err)
+		return
+	}
+	log.Println("Migration success")
 }
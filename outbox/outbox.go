@@ -0,0 +1,342 @@
+// Package outbox delivers notifications outside the app. Each notification
+// gets a delivery row per channel that records its attempts, when it is next
+// tried and why it last failed. A delivery that keeps failing is dead-lettered
+// instead of being retried forever; an admin can requeue it.
+package outbox
+
+import (
+	"errors"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+const ChannelEmail = "email"
+
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	StatusDead    = "dead"
+	// StatusSuppressed is a delivery the recipient turned off.
+	StatusSuppressed = "suppressed"
+	// StatusHeld is a delivery waiting for the recipient's digest.
+	StatusHeld = "held"
+)
+
+const (
+	// MaxAttempts is the number of tries before a delivery is dead-lettered.
+	MaxAttempts = 6
+	// Attempt n+1 waits retryBase * 2^(n-1) after attempt n, capped at
+	// retryMax: 1m, 2m, 4m, 8m, 16m.
+	retryBase = time.Minute
+	retryMax  = time.Hour
+	// claimTimeout is how long a claimed delivery is hidden from other workers.
+	claimTimeout = 5 * time.Minute
+	batchSize    = 100
+)
+
+// Delivery is the outbox entry of one notification on one channel.
+type Delivery struct {
+	ID             int        `json:"id" gorm:"primary_key"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	NotificationId int        `json:"notification_id" gorm:"uniqueIndex:idx_tw_notification_deliveries_channel,priority:1"`
+	Channel        string     `json:"channel" gorm:"size:32;uniqueIndex:idx_tw_notification_deliveries_channel,priority:2"`
+	Status         string     `json:"status" gorm:"size:16;index:idx_tw_notification_deliveries_due,priority:1"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at" gorm:"index:idx_tw_notification_deliveries_due,priority:2"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	LastError      string     `json:"last_error" gorm:"type:text"`
+	SentAt         *time.Time `json:"sent_at"`
+	// Notification is only loaded for the admin listing.
+	Notification *models.TwNotifications `json:"notification,omitempty" gorm:"foreignKey:NotificationId;-:migration"`
+}
+
+func (Delivery) TableName() string {
+	return "tw_notification_deliveries"
+}
+
+// Backoff returns the wait after the given number of failed attempts.
+func Backoff(attempts int) time.Duration {
+	wait := retryBase
+	for i := 1; i < attempts && wait < retryMax; i++ {
+		wait *= 2
+	}
+	if wait > retryMax {
+		wait = retryMax
+	}
+	return wait
+}
+
+// Collect queues a delivery on each of channels for every unsent
+// notification that has no deliveries yet, due when the notification is.
+// Notifications are written by the handlers and by other services, so they
+// are picked up here rather than where they are created. Deliveries on
+// channels the recipient does not use are suppressed when dispatched, which
+// leaves a status for every channel on every notification.
+func Collect(db *gorm.DB, channels []string) (int64, error) {
+	if len(channels) == 0 {
+		return 0, nil
+	}
+	names := strings.TrimSuffix(strings.Repeat("SELECT ? AS channel UNION ALL ", len(channels)), " UNION ALL ")
+	args := []interface{}{StatusPending}
+	for _, channel := range channels {
+		args = append(args, channel)
+	}
+	result := db.Exec(`INSERT IGNORE INTO tw_notification_deliveries
+		(created_at, updated_at, notification_id, channel, status, attempts, next_attempt_at)
+		SELECT NOW(), NOW(), n.id, c.channel, ?, 0, COALESCE(n.notified_at, NOW())
+		FROM tw_notifications AS n
+		CROSS JOIN (`+names+`) AS c
+		WHERE n.is_sent = false AND n.deleted_at IS NULL
+		AND NOT EXISTS (SELECT 1 FROM tw_notification_deliveries AS d WHERE d.notification_id = n.id)`,
+		args...)
+	return result.RowsAffected, result.Error
+}
+
+// SendFunc delivers a notification on a channel. Besides failing, it can
+// return ErrPermanent, ErrSuppressed, ErrHeld or a DeferError to say why it
+// did not send.
+type SendFunc func(notification models.TwNotifications) error
+
+var (
+	// ErrPermanent fails a delivery without retrying it, e.g. when the
+	// recipient's address is gone.
+	ErrPermanent = errors.New("permanent failure")
+	// ErrSuppressed drops a delivery the recipient does not want.
+	ErrSuppressed = errors.New("the recipient turned this notification off")
+	// ErrHeld keeps a delivery for SendHeld to batch with others.
+	ErrHeld = errors.New("held for the digest")
+)
+
+// DeferError puts a delivery off until Until without counting the attempt,
+// e.g. during the recipient's quiet hours.
+type DeferError struct {
+	Until time.Time
+}
+
+func (e DeferError) Error() string {
+	return "deferred until " + e.Until.Format(time.RFC3339)
+}
+
+// DispatchResult counts what one Dispatch run did.
+type DispatchResult struct {
+	Sent     int `json:"sent"`
+	Retrying int `json:"retrying"`
+	Dead     int `json:"dead"`
+}
+
+// Dispatch sends the deliveries of channel that are due, up to one batch.
+// Each delivery is claimed before it is sent, so several workers can run at
+// once without sending it twice.
+func Dispatch(db *gorm.DB, channel string, send SendFunc) (DispatchResult, error) {
+	var result DispatchResult
+	now := time.Now()
+
+	var due []Delivery
+	if err := db.Where("channel = ? AND status = ? AND next_attempt_at <= ?", channel, StatusPending, now).
+		Order("next_attempt_at").
+		Limit(batchSize).
+		Find(&due).Error; err != nil {
+		return result, err
+	}
+
+	for _, delivery := range due {
+		claimed, err := claim(db, &delivery, now)
+		if err != nil {
+			return result, err
+		}
+		if !claimed {
+			continue
+		}
+
+		switch status, err := attempt(db, &delivery, send); {
+		case err != nil:
+			return result, err
+		case status == StatusSent:
+			result.Sent++
+		case status == StatusDead:
+			result.Dead++
+		default:
+			result.Retrying++
+		}
+	}
+	return result, nil
+}
+
+// claim counts the attempt and pushes next_attempt_at past claimTimeout,
+// unless another worker got there first.
+func claim(db *gorm.DB, delivery *Delivery, now time.Time) (bool, error) {
+	claimed := db.Model(&Delivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", delivery.ID, StatusPending, delivery.Attempts).
+		Updates(map[string]interface{}{
+			"attempts":        delivery.Attempts + 1,
+			"next_attempt_at": now.Add(claimTimeout),
+			"last_attempt_at": now,
+		})
+	if claimed.Error != nil {
+		return false, claimed.Error
+	}
+	delivery.Attempts++
+	return claimed.RowsAffected == 1, nil
+}
+
+// attempt sends a claimed delivery and records the outcome.
+func attempt(db *gorm.DB, delivery *Delivery, send SendFunc) (string, error) {
+	var notification models.TwNotifications
+	err := db.Where("id = ?", delivery.NotificationId).
+		Preload("UserEmail").
+		Preload("UserEmail.User").
+		First(&notification).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+
+	var sendErr error
+	switch {
+	case err != nil || notification.DeletedAt != nil:
+		sendErr = errors.New("notification was deleted")
+	case delivery.Channel == ChannelEmail && notification.IsSent:
+		// Already marked sent through the API.
+	default:
+		sendErr = send(notification)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"last_error": ""}
+	status := StatusPending
+	var deferred DeferError
+	switch {
+	case sendErr == nil:
+		status = StatusSent
+		updates["sent_at"] = now
+	case errors.Is(sendErr, ErrSuppressed):
+		status = StatusSuppressed
+	case errors.Is(sendErr, ErrHeld):
+		// Held and deferred deliveries were not tried, so the claim's
+		// attempt is given back.
+		status = StatusHeld
+		updates["attempts"] = delivery.Attempts - 1
+		updates["next_attempt_at"] = now
+	case errors.As(sendErr, &deferred):
+		updates["attempts"] = delivery.Attempts - 1
+		updates["next_attempt_at"] = deferred.Until
+	case err != nil || notification.DeletedAt != nil || errors.Is(sendErr, ErrPermanent) || delivery.Attempts >= MaxAttempts:
+		status = StatusDead
+	default:
+		updates["next_attempt_at"] = now.Add(Backoff(delivery.Attempts))
+	}
+	updates["status"] = status
+	if sendErr != nil {
+		updates["last_error"] = sendErr.Error()
+	}
+
+	return status, db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Delivery{}).Where("id = ?", delivery.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		if status == StatusSent && delivery.Channel == ChannelEmail && !notification.IsSent {
+			return tx.Model(&models.TwNotifications{}).Where("id = ?", notification.ID).Update("is_sent", true).Error
+		}
+		return nil
+	})
+}
+
+// Requeue makes a dead delivery due now with a fresh attempt count. It
+// returns gorm.ErrRecordNotFound when there is no dead delivery with that id.
+func Requeue(db *gorm.DB, deliveryId int) error {
+	result := db.Model(&Delivery{}).
+		Where("id = ? AND status = ?", deliveryId, StatusDead).
+		Updates(map[string]interface{}{
+			"status":          StatusPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Held returns the deliveries of channel held for digests that are due,
+// oldest first, with their notification and its recipient. Their
+// last_attempt_at is when they were held; a digest that failed to send puts
+// their next_attempt_at off.
+func Held(db *gorm.DB, channel string) ([]Delivery, error) {
+	var deliveries []Delivery
+	err := db.Where("channel = ? AND status = ?", channel, StatusHeld).
+		Where("next_attempt_at IS NULL OR next_attempt_at <= ?", time.Now()).
+		Preload("Notification").
+		Preload("Notification.UserEmail").
+		Preload("Notification.UserEmail.User").
+		Order("id").
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// SendHeld sends held deliveries together with one call to send. They are
+// claimed first, so that only one worker sends them; it returns false when
+// another worker got there first. When send fails, each delivery counts the
+// attempt and is held again after a backoff, or dead-lettered after
+// MaxAttempts like the deliveries Dispatch sends.
+func SendHeld(db *gorm.DB, deliveryIds []int, send func() error) (bool, error) {
+	now := time.Now()
+	err := db.Transaction(func(tx *gorm.DB) error {
+		claimed := tx.Model(&Delivery{}).
+			Where("id IN ? AND status = ?", deliveryIds, StatusHeld).
+			Updates(map[string]interface{}{"status": StatusSent, "sent_at": now, "last_error": ""})
+		if claimed.Error != nil {
+			return claimed.Error
+		}
+		if claimed.RowsAffected != int64(len(deliveryIds)) {
+			return errNotClaimed
+		}
+		return nil
+	})
+	if errors.Is(err, errNotClaimed) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if sendErr := send(); sendErr != nil {
+		return false, errors.Join(sendErr, releaseHeld(db, deliveryIds, sendErr, now))
+	}
+	return true, db.Model(&models.TwNotifications{}).
+		Where("id IN (?)", db.Model(&Delivery{}).Select("notification_id").Where("id IN ?", deliveryIds)).
+		Update("is_sent", true).Error
+}
+
+var errNotClaimed = errors.New("deliveries were claimed by another worker")
+
+// releaseHeld records a failed digest on each of its deliveries. Their
+// last_attempt_at is left as when they were held, which decides the digest
+// they go in.
+func releaseHeld(db *gorm.DB, deliveryIds []int, cause error, now time.Time) error {
+	var deliveries []Delivery
+	if err := db.Where("id IN ?", deliveryIds).Find(&deliveries).Error; err != nil {
+		return err
+	}
+	for _, delivery := range deliveries {
+		attempts := delivery.Attempts + 1
+		updates := map[string]interface{}{
+			"status":          StatusHeld,
+			"attempts":        attempts,
+			"next_attempt_at": now.Add(Backoff(attempts)),
+			"sent_at":         nil,
+			"last_error":      cause.Error(),
+		}
+		if attempts >= MaxAttempts {
+			updates["status"] = StatusDead
+		}
+		if err := db.Model(&Delivery{}).Where("id = ?", delivery.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+package permission
+
+import (
+	"dbms/middleware"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// HeaderWorkspaceUserID carries the acting workspace user on routes whose
+// path parameters only identify the target of the change.
+const HeaderWorkspaceUserID = "X-Workspace-User-Id"
+
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+	RoleGuest  = "guest"
+)
+
+type Action string
+
+const (
+	ActionCreateSchedule    Action = "schedule.create"
+	ActionUpdateSchedule    Action = "schedule.update"
+	ActionMoveSchedule      Action = "schedule.move"
+	ActionDeleteSchedule    Action = "schedule.delete"
+	ActionDeleteBoardColumn Action = "board_column.delete"
+	ActionRemoveMember      Action = "workspace_user.remove"
+	ActionManageWebhooks    Action = "webhook.manage"
+	ActionManageTemplates   Action = "schedule_template.manage"
+	ActionSearch            Action = "workspace.search"
+	ActionCloneWorkspace    Action = "workspace.clone"
+)
+
+// policy lists the actions each workspace role may perform.
+var policy = map[string]map[Action]bool{
+	RoleOwner: {
+		ActionCreateSchedule:    true,
+		ActionUpdateSchedule:    true,
+		ActionMoveSchedule:      true,
+		ActionDeleteSchedule:    true,
+		ActionDeleteBoardColumn: true,
+		ActionRemoveMember:      true,
+		ActionManageWebhooks:    true,
+		ActionManageTemplates:   true,
+		ActionSearch:            true,
+		ActionCloneWorkspace:    true,
+	},
+	RoleAdmin: {
+		ActionCreateSchedule:    true,
+		ActionUpdateSchedule:    true,
+		ActionMoveSchedule:      true,
+		ActionDeleteSchedule:    true,
+		ActionDeleteBoardColumn: true,
+		ActionRemoveMember:      true,
+		ActionManageWebhooks:    true,
+		ActionManageTemplates:   true,
+		ActionSearch:            true,
+		ActionCloneWorkspace:    true,
+	},
+	RoleMember: {
+		ActionCreateSchedule:  true,
+		ActionUpdateSchedule:  true,
+		ActionMoveSchedule:    true,
+		ActionDeleteSchedule:  true,
+		ActionManageTemplates: true,
+		ActionSearch:          true,
+	},
+	RoleGuest: {
+		ActionSearch: true,
+	},
+}
+
+// roleRank orders roles so that a member can only be removed by someone ranked above them.
+var roleRank = map[string]int{
+	RoleGuest:  1,
+	RoleMember: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+// Allowed reports whether role may perform action. Roles are compared case-insensitively.
+func Allowed(role string, action Action) bool {
+	return policy[strings.ToLower(role)][action]
+}
+
+// Outranks reports whether role is strictly above target.
+func Outranks(role string, target string) bool {
+	return roleRank[strings.ToLower(role)] > roleRank[strings.ToLower(target)]
+}
+
+// DeniedError is returned by Authorize when the policy rejects an action.
+type DeniedError struct {
+	WorkspaceId     int
+	WorkspaceUserId int
+	Role            string
+	Action          Action
+	Reason          string
+}
+
+func (e *DeniedError) Error() string {
+	return e.Reason
+}
+
+// Authorize loads the acting workspace user, who must be an active, joined
+// member of workspaceId, and checks that their role allows action. Denials are
+// recorded in tw_workspace_logs and returned as *DeniedError.
+func Authorize(db *gorm.DB, workspaceId int, workspaceUserId int, action Action) (models.TwWorkspaceUser, error) {
+	var workspaceUser models.TwWorkspaceUser
+	err := db.Where("id = ? AND workspace_id = ?", workspaceUserId, workspaceId).
+		Where("deleted_at IS NULL").
+		Where("status = 'joined' AND is_active = true").
+		First(&workspaceUser).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return workspaceUser, Deny(db, &DeniedError{
+			WorkspaceId:     workspaceId,
+			WorkspaceUserId: workspaceUserId,
+			Action:          action,
+			Reason:          "workspace user is not an active member of this workspace",
+		})
+	}
+	if err != nil {
+		return workspaceUser, err
+	}
+
+	if !Allowed(workspaceUser.Role, action) {
+		return workspaceUser, Deny(db, &DeniedError{
+			WorkspaceId:     workspaceId,
+			WorkspaceUserId: workspaceUserId,
+			Role:            workspaceUser.Role,
+			Action:          action,
+			Reason:          fmt.Sprintf("role %q is not allowed to perform %s", workspaceUser.Role, action),
+		})
+	}
+	return workspaceUser, nil
+}
+
+// Deny records a denied action in tw_workspace_logs and returns it, so that
+// handlers with extra rules beyond the policy can reject in the same way.
+func Deny(db *gorm.DB, denied *DeniedError) error {
+	workspaceLog := models.TwWorkspaceLog{
+		WorkspaceId:     denied.WorkspaceId,
+		WorkspaceUserId: denied.WorkspaceUserId,
+		Action:          "permission denied",
+		FieldChanged:    string(denied.Action),
+		OldValue:        denied.Role,
+		Description:     denied.Reason,
+	}
+	if err := db.Omit("deleted_at").Create(&workspaceLog).Error; err != nil {
+		log.Printf("Could not record permission denial: %v", err)
+	}
+	return denied
+}
+
+// Respond writes the response for an error returned by Authorize or Actor:
+// 403 for denials, the status of a *fiber.Error, and 500 for anything else.
+func Respond(c *fiber.Ctx, err error) error {
+	var denied *DeniedError
+	if errors.As(err, &denied) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error":  denied.Reason,
+			"action": denied.Action,
+		})
+	}
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(fiber.Map{
+			"error": fiberErr.Message,
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).SendString(err.Error())
+}
+
+// Actor returns the workspace user of workspaceId the authenticated caller
+// acts as: the one joined through any email of the user named by the user_id
+// claim of its token. With authentication disabled for local development,
+// there is no such claim and HeaderWorkspaceUserID is read instead.
+func Actor(c *fiber.Ctx, db *gorm.DB, workspaceId int) (int, error) {
+	userId, err := caller(c)
+	if err != nil {
+		return 0, err
+	}
+	if userId == 0 {
+		actorId, err := ActorFromHeader(c)
+		if err != nil {
+			return 0, fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		return actorId, nil
+	}
+
+	workspaceUserIds, err := memberships(db, userId, workspaceId)
+	if err != nil {
+		return 0, err
+	}
+	if len(workspaceUserIds) == 0 {
+		return 0, Deny(db, &DeniedError{
+			WorkspaceId: workspaceId,
+			Reason:      fmt.Sprintf("user %d is not a member of this workspace", userId),
+		})
+	}
+	return workspaceUserIds[0], nil
+}
+
+// ActingAs checks that workspaceUserId, which routes like
+// /schedule/:schedule_id/workspace_user/:workspace_user_id name in their path,
+// is one of the caller's workspace users in workspaceId, so that a caller
+// cannot act with the role of another member. With authentication disabled
+// the path is trusted.
+func ActingAs(c *fiber.Ctx, db *gorm.DB, workspaceId int, workspaceUserId int) error {
+	userId, err := caller(c)
+	if err != nil || userId == 0 {
+		return err
+	}
+	workspaceUserIds, err := memberships(db, userId, workspaceId)
+	if err != nil {
+		return err
+	}
+	for _, id := range workspaceUserIds {
+		if id == workspaceUserId {
+			return nil
+		}
+	}
+	return Deny(db, &DeniedError{
+		WorkspaceId:     workspaceId,
+		WorkspaceUserId: workspaceUserId,
+		Reason:          fmt.Sprintf("workspace user %d does not belong to user %d", workspaceUserId, userId),
+	})
+}
+
+// caller returns the user named by the token of the request, or zero when
+// authentication is disabled and the caller is whoever the request says.
+func caller(c *fiber.Ctx) (int, error) {
+	principal, ok := middleware.GetPrincipal(c)
+	if !ok {
+		return 0, fiber.NewError(fiber.StatusUnauthorized, "unauthenticated")
+	}
+	if principal.UserID == 0 && principal.Method != "disabled" {
+		return 0, fiber.NewError(fiber.StatusForbidden, "the token does not name the user acting")
+	}
+	return principal.UserID, nil
+}
+
+// memberships returns the workspace users of workspaceId joined through any
+// email of userId, oldest first.
+func memberships(db *gorm.DB, userId int, workspaceId int) ([]int, error) {
+	var workspaceUserIds []int
+	err := db.Model(&models.TwWorkspaceUser{}).
+		Joins("JOIN tw_user_emails ON tw_user_emails.id = tw_workspace_users.user_email_id").
+		Where("tw_user_emails.user_id = ? OR (tw_user_emails.is_linked_to = ? AND tw_user_emails.status = 'linked')", userId, userId).
+		Where("tw_user_emails.deleted_at IS NULL").
+		Where("tw_workspace_users.workspace_id = ? AND tw_workspace_users.deleted_at IS NULL", workspaceId).
+		Order("tw_workspace_users.id").
+		Pluck("tw_workspace_users.id", &workspaceUserIds).Error
+	return workspaceUserIds, err
+}
+
+// ActorFromHeader reads the acting workspace user from HeaderWorkspaceUserID.
+func ActorFromHeader(c *fiber.Ctx) (int, error) {
+	value := c.Get(HeaderWorkspaceUserID)
+	if value == "" {
+		return 0, errors.New(HeaderWorkspaceUserID + " header is required")
+	}
+	workspaceUserId, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.New("invalid " + HeaderWorkspaceUserID + " header")
+	}
+	return workspaceUserId, nil
+}
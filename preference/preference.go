@@ -0,0 +1,308 @@
+// Package preference decides whether, when and how a user is notified, from
+// their tw_notification_settings row. The row's flags turn notification types
+// and email on or off; its extra_data holds the options they cannot express,
+// as an Extra JSON document.
+package preference
+
+import (
+	"dbms/channel"
+	"dbms/datetime"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	ChannelEmail   = channel.Email
+	ChannelInApp   = "in_app"
+	ChannelWebPush = channel.WebPush
+	ChannelSlack   = channel.Slack
+	ChannelSMS     = channel.SMS
+)
+
+var channels = map[string]bool{
+	ChannelEmail:   true,
+	ChannelInApp:   true,
+	ChannelWebPush: true,
+	ChannelSlack:   true,
+	ChannelSMS:     true,
+}
+
+// Notification types with a setting of their own. Other types are always on
+// unless a channel override turns them off.
+const (
+	TypeReminder       = "reminder"
+	TypeInvitation     = "invitation"
+	TypeComment        = "comment"
+	TypeAssignment     = "assignment"
+	TypeScheduleChange = "schedule_change"
+)
+
+// lowPriority types go into the daily digest for users who want one.
+var lowPriority = map[string]bool{
+	TypeComment:        true,
+	TypeScheduleChange: true,
+}
+
+// Extra is the JSON kept in the extra_data of notification settings.
+type Extra struct {
+	// Channels turns types off on one channel only, e.g.
+	// {"email": {"comment": false}}. Channels are "email", "in_app",
+	// "web_push", "slack" and "sms".
+	Channels map[string]map[string]bool `json:"channels,omitempty"`
+	// Targets turns on the channels that need an address: a push
+	// subscription for "web_push", {"webhook_url"} for "slack" and
+	// {"phone"} for "sms".
+	Targets map[string]json.RawMessage `json:"targets,omitempty"`
+	// QuietHours holds back emails between Start and End, given as "15:04"
+	// in the user's timezone. End may be before Start to span midnight.
+	QuietHours *QuietHours `json:"quiet_hours,omitempty"`
+	// Digest batches low-priority emails into one a day, sent at Hour
+	// (0-23) in the user's timezone.
+	Digest *Digest `json:"digest,omitempty"`
+}
+
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+type Digest struct {
+	Enabled bool `json:"enabled"`
+	Hour    int  `json:"hour"`
+}
+
+// ParseExtra reads and validates extra_data; an empty one has no options.
+func ParseExtra(extraData string) (Extra, error) {
+	var extra Extra
+	if extraData == "" {
+		return extra, nil
+	}
+	if err := json.Unmarshal([]byte(extraData), &extra); err != nil {
+		return extra, fmt.Errorf("extra_data must be a JSON object: %w", err)
+	}
+	for name := range extra.Channels {
+		if !channels[name] {
+			return extra, fmt.Errorf("unknown channel %q", name)
+		}
+	}
+	for name, target := range extra.Targets {
+		if err := channel.ValidateTarget(name, target); err != nil {
+			return extra, err
+		}
+	}
+	if q := extra.QuietHours; q != nil {
+		if _, err := parseClock(q.Start); err != nil {
+			return extra, err
+		}
+		if _, err := parseClock(q.End); err != nil {
+			return extra, err
+		}
+	}
+	if d := extra.Digest; d != nil && (d.Hour < 0 || d.Hour > 23) {
+		return extra, errors.New("digest hour must be between 0 and 23")
+	}
+	return extra, nil
+}
+
+// Preferences are the notification settings of one user.
+type Preferences struct {
+	// settings is nil for users who never saved any; they get everything.
+	settings *models.TwNotificationSettings
+	extra    Extra
+	// Location is the user's timezone, UTC when unknown.
+	Location *time.Location
+}
+
+// Load returns the preferences of userId.
+func Load(db *gorm.DB, userId int) (Preferences, error) {
+	preferences := Preferences{Location: time.UTC}
+
+	var user models.TwUser
+	if err := db.Select("id", "timezone").Where("id = ?", userId).First(&user).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return preferences, err
+	}
+	preferences.Location = datetime.Location(user.Timezone)
+
+	var settings models.TwNotificationSettings
+	err := db.Where("user_id = ? AND deleted_at IS NULL", userId).First(&settings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return preferences, nil
+	}
+	if err != nil {
+		return preferences, err
+	}
+	preferences.settings = &settings
+	// Settings saved before extra_data held options keep working without them.
+	preferences.extra, _ = ParseExtra(settings.ExtraData)
+	return preferences, nil
+}
+
+// LoadForUserEmail returns the preferences of the user who owns userEmailId.
+func LoadForUserEmail(db *gorm.DB, userEmailId int) (Preferences, error) {
+	var userIds []int
+	if err := db.Model(&models.TwUserEmail{}).Where("id = ?", userEmailId).Pluck("user_id", &userIds).Error; err != nil {
+		return Preferences{Location: time.UTC}, err
+	}
+	if len(userIds) == 0 {
+		return Preferences{Location: time.UTC}, nil
+	}
+	return Load(db, userIds[0])
+}
+
+// LoadForWorkspaceUser returns the preferences of the user behind
+// workspaceUserId.
+func LoadForWorkspaceUser(db *gorm.DB, workspaceUserId int) (Preferences, error) {
+	var userEmailIds []int
+	if err := db.Model(&models.TwWorkspaceUser{}).Where("id = ?", workspaceUserId).Pluck("user_email_id", &userEmailIds).Error; err != nil {
+		return Preferences{Location: time.UTC}, err
+	}
+	if len(userEmailIds) == 0 {
+		return Preferences{Location: time.UTC}, nil
+	}
+	return LoadForUserEmail(db, userEmailIds[0])
+}
+
+// Locations returns the timezone of the user behind each of workspaceUserIds,
+// UTC when unknown.
+func Locations(db *gorm.DB, workspaceUserIds []int) (map[int]*time.Location, error) {
+	locations := make(map[int]*time.Location, len(workspaceUserIds))
+	if len(workspaceUserIds) == 0 {
+		return locations, nil
+	}
+	var rows []struct {
+		ID       int
+		Timezone string
+	}
+	if err := db.Table("tw_workspace_users").
+		Select("tw_workspace_users.id, tw_users.timezone").
+		Joins("JOIN tw_user_emails ON tw_user_emails.id = tw_workspace_users.user_email_id").
+		Joins("JOIN tw_users ON tw_users.id = tw_user_emails.user_id").
+		Where("tw_workspace_users.id IN (?)", workspaceUserIds).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		locations[row.ID] = datetime.Location(row.Timezone)
+	}
+	for _, id := range workspaceUserIds {
+		if locations[id] == nil {
+			locations[id] = time.UTC
+		}
+	}
+	return locations, nil
+}
+
+// Allows reports whether notifications of notificationType go out on
+// channelName. Channels that need a target are off until one is saved.
+func (p Preferences) Allows(channelName string, notificationType string) bool {
+	switch channelName {
+	case ChannelWebPush, ChannelSlack, ChannelSMS:
+		if p.Target(channelName) == nil {
+			return false
+		}
+	}
+	if s := p.settings; s != nil {
+		if channelName == ChannelEmail && !s.NotificationOnEmail {
+			return false
+		}
+		switch notificationType {
+		case TypeReminder:
+			if !s.NotificationOnDueDate {
+				return false
+			}
+		case TypeComment:
+			if !s.NotificationOnComment {
+				return false
+			}
+		case TypeAssignment:
+			if !s.NotificationOnTag {
+				return false
+			}
+		case TypeScheduleChange:
+			if !s.NotificationOnScheduleChange {
+				return false
+			}
+		}
+	}
+	if allowed, ok := p.extra.Channels[channelName][notificationType]; ok {
+		return allowed
+	}
+	return true
+}
+
+// Target returns the user's address on channelName, or nil.
+func (p Preferences) Target(channelName string) json.RawMessage {
+	return p.extra.Targets[channelName]
+}
+
+// Wants reports whether notifications of notificationType go out on any channel.
+func (p Preferences) Wants(notificationType string) bool {
+	for name := range channels {
+		if p.Allows(name, notificationType) {
+			return true
+		}
+	}
+	return false
+}
+
+// QuietUntil returns when the quiet hours around now end, if now is in them.
+func (p Preferences) QuietUntil(now time.Time) (time.Time, bool) {
+	q := p.extra.QuietHours
+	if q == nil {
+		return time.Time{}, false
+	}
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return time.Time{}, false
+	}
+	end, err := parseClock(q.End)
+	if err != nil || start == end {
+		return time.Time{}, false
+	}
+
+	local := now.In(p.Location)
+	midnight := datetime.StartOfDay(now, p.Location)
+	clock := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute
+	switch {
+	case start < end && clock >= start && clock < end:
+		return datetime.AtClock(midnight, end), true
+	case start > end && clock >= start:
+		return datetime.AtClock(midnight.AddDate(0, 0, 1), end), true
+	case start > end && clock < end:
+		return datetime.AtClock(midnight, end), true
+	}
+	return time.Time{}, false
+}
+
+// Digests reports whether emails of notificationType wait for the digest.
+func (p Preferences) Digests(notificationType string) bool {
+	return p.extra.Digest != nil && p.extra.Digest.Enabled && lowPriority[notificationType]
+}
+
+// DigestSlot returns the latest digest time at or before now. Notifications
+// held before it belong in the digest sent at it.
+func (p Preferences) DigestSlot(now time.Time) time.Time {
+	hour := 0
+	if p.extra.Digest != nil {
+		hour = p.extra.Digest.Hour
+	}
+	local := now.In(p.Location)
+	slot := time.Date(local.Year(), local.Month(), local.Day(), hour, 0, 0, 0, p.Location)
+	if slot.After(local) {
+		slot = slot.AddDate(0, 0, -1)
+	}
+	return slot
+}
+
+// parseClock reads the "15:04" clock times of quiet hours.
+func parseClock(value string) (time.Duration, error) {
+	clock, err := datetime.ParseClock(value)
+	if err != nil {
+		return 0, fmt.Errorf("quiet hours must be given as HH:MM, got %q", value)
+	}
+	return clock, nil
+}
@@ -0,0 +1,146 @@
+package realtime
+
+import (
+	"encoding/json"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+const (
+	ScheduleCreated = "schedule.created"
+	ScheduleUpdated = "schedule.updated"
+	ScheduleMoved   = "schedule.moved"
+	ScheduleDeleted = "schedule.deleted"
+
+	ColumnCreated = "column.created"
+	ColumnUpdated = "column.updated"
+	ColumnMoved   = "column.moved"
+	ColumnDeleted = "column.deleted"
+
+	CommentCreated = "comment.created"
+	CommentUpdated = "comment.updated"
+	CommentDeleted = "comment.deleted"
+
+	DocumentCreated = "document.created"
+	DocumentDeleted = "document.deleted"
+
+	ParticipantCreated = "participant.created"
+	ParticipantUpdated = "participant.updated"
+	ParticipantDeleted = "participant.deleted"
+)
+
+// ScheduleData is the data of schedule.* events.
+type ScheduleData struct {
+	ID            int      `json:"id"`
+	BoardColumnID int      `json:"board_column_id"`
+	Title         string   `json:"title"`
+	Position      int      `json:"position,omitempty"`
+	ChangedFields []string `json:"changed_fields,omitempty"`
+}
+
+// ColumnData is the data of column.* events.
+type ColumnData struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Position int    `json:"position,omitempty"`
+}
+
+// ItemData is the data of comment.*, document.* and participant.* events:
+// the item and the schedule it belongs to.
+type ItemData struct {
+	ID              int `json:"id"`
+	ScheduleID      int `json:"schedule_id"`
+	WorkspaceUserID int `json:"workspace_user_id"`
+}
+
+// Event is one change on a workspace board. Seq, sent to clients as the
+// cursor, numbers the events of a workspace without gaps and in commit order,
+// so a client that reconnects with the last cursor it saw receives exactly
+// what it missed.
+type Event struct {
+	ID          int             `json:"-" gorm:"primary_key"`
+	CreatedAt   time.Time       `json:"created_at"`
+	WorkspaceId int             `json:"workspace_id" gorm:"uniqueIndex:idx_tw_workspace_events_cursor"`
+	Seq         int             `json:"cursor" gorm:"uniqueIndex:idx_tw_workspace_events_cursor"`
+	Type        string          `json:"type" gorm:"size:64"`
+	EntityId    int             `json:"entity_id"`
+	Data        json.RawMessage `json:"data" gorm:"type:json" swaggertype:"object"`
+}
+
+func (Event) TableName() string {
+	return "tw_workspace_events"
+}
+
+// Publish records an event for workspaceId. Pass the transaction that makes
+// the change, so the event commits with it. The workspace row stays locked
+// until then, which keeps cursors gapless and in commit order.
+func Publish(db *gorm.DB, workspaceId int, eventType string, entityId int, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	err = db.Transaction(func(tx *gorm.DB) error {
+		var ids []int
+		if err := tx.Table("tw_workspaces").
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", workspaceId).
+			Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		last, err := Latest(tx, workspaceId)
+		if err != nil {
+			return err
+		}
+		return tx.Create(&Event{
+			WorkspaceId: workspaceId,
+			Seq:         last + 1,
+			Type:        eventType,
+			EntityId:    entityId,
+			Data:        payload,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	hub.notify(workspaceId)
+	return nil
+}
+
+// PublishItem records an event about something attached to a schedule, such
+// as a comment, in the schedule's workspace.
+func PublishItem(db *gorm.DB, eventType string, item ItemData) error {
+	var workspaceIds []int
+	if err := db.Table("tw_schedules").Where("id = ?", item.ScheduleID).Pluck("workspace_id", &workspaceIds).Error; err != nil {
+		return err
+	}
+	if len(workspaceIds) == 0 {
+		return errors.New("schedule not found")
+	}
+	return Publish(db, workspaceIds[0], eventType, item.ID, item)
+}
+
+// Since returns up to limit events of workspaceId after cursor, oldest first.
+func Since(db *gorm.DB, workspaceId int, cursor int, limit int) ([]Event, error) {
+	var events []Event
+	err := db.Where("workspace_id = ? AND seq > ?", workspaceId, cursor).
+		Order("seq").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// Latest returns the cursor of the newest event of workspaceId, or 0.
+func Latest(db *gorm.DB, workspaceId int) (int, error) {
+	var last int
+	err := db.Model(&Event{}).
+		Where("workspace_id = ?", workspaceId).
+		Select("COALESCE(MAX(seq), 0)").
+		Scan(&last).Error
+	return last, err
+}
@@ -0,0 +1,46 @@
+package realtime
+
+import "sync"
+
+// broker wakes the streams of a workspace when an event is published in this
+// process. A wake-up is only a hint: it can arrive before the publishing
+// transaction commits, and other instances never see it, so streams also poll.
+type broker struct {
+	mu   sync.Mutex
+	subs map[int]map[chan struct{}]struct{}
+}
+
+var hub = &broker{subs: map[int]map[chan struct{}]struct{}{}}
+
+// Subscribe returns a channel that receives a value after events are
+// published for workspaceId, and a function that releases it.
+func Subscribe(workspaceId int) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	hub.mu.Lock()
+	if hub.subs[workspaceId] == nil {
+		hub.subs[workspaceId] = map[chan struct{}]struct{}{}
+	}
+	hub.subs[workspaceId][ch] = struct{}{}
+	hub.mu.Unlock()
+
+	return ch, func() {
+		hub.mu.Lock()
+		delete(hub.subs[workspaceId], ch)
+		if len(hub.subs[workspaceId]) == 0 {
+			delete(hub.subs, workspaceId)
+		}
+		hub.mu.Unlock()
+	}
+}
+
+func (b *broker) notify(workspaceId int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs[workspaceId] {
+		select {
+		case ch <- struct{}{}:
+		default:
+			// A wake-up is already pending.
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package recurrence
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/timewise-team/timewise-models/models"
+)
+
+// maxPeriods guards against runaway expansion of unbounded rules.
+const maxPeriods = 100000
+
+// Occurrence is a single concrete instance of a schedule.
+type Occurrence struct {
+	ScheduleId    int
+	Start         time.Time
+	End           time.Time
+	OriginalStart time.Time
+	IsMoved       bool
+	ExceptionId   int
+}
+
+// Between returns the start of every occurrence of the rule anchored at dtstart
+// that falls within [from, to). Occurrences keep the wall clock and weekday of
+// dtstart in dtstart's location, so dtstart must be in the timezone the rule
+// is meant for: in UTC, a weekly 09:00 meeting in Berlin moves an hour with
+// daylight saving, and one at 01:00 in Ho Chi Minh City falls on the day
+// before.
+func (r *Rule) Between(dtstart, from, to time.Time) []time.Time {
+	var result []time.Time
+	r.each(dtstart, from, func(t time.Time) bool {
+		if !t.Before(to) {
+			return false
+		}
+		if !t.Before(from) {
+			result = append(result, t)
+		}
+		return true
+	})
+	return result
+}
+
+// OccurrenceOn returns the instance of the rule anchored at dtstart that falls
+// on the calendar day of day, evaluated in dtstart's location.
+func (r *Rule) OccurrenceOn(dtstart, day time.Time) (time.Time, bool) {
+	day = day.In(dtstart.Location())
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, dtstart.Location())
+	occurrences := r.Between(dtstart, dayStart, dayStart.AddDate(0, 0, 1))
+	if len(occurrences) == 0 {
+		return time.Time{}, false
+	}
+	return occurrences[0], true
+}
+
+// Expand returns the occurrences of schedule overlapping [from, to), with the
+// schedule's recurrence exceptions applied. Cancelled instances are dropped and
+// moved instances are reported at their new time. A schedule without a
+// recurrence pattern yields at most one occurrence. The pattern recurs in the
+// timezone Location picks for schedule, with loc as its fallback.
+func Expand(schedule models.TwSchedule, exceptions []models.TwRecurrenceException, from, to time.Time, loc *time.Location) ([]Occurrence, error) {
+	if schedule.StartTime == nil {
+		return nil, nil
+	}
+	start := *schedule.StartTime
+	var duration time.Duration
+	if schedule.EndTime != nil && schedule.EndTime.After(start) {
+		duration = schedule.EndTime.Sub(start)
+	}
+
+	if strings.TrimSpace(schedule.RecurrencePattern) == "" {
+		if overlaps(start, start.Add(duration), from, to) {
+			return []Occurrence{{
+				ScheduleId:    schedule.ID,
+				Start:         start,
+				End:           start.Add(duration),
+				OriginalStart: start,
+			}}, nil
+		}
+		return nil, nil
+	}
+
+	rule, err := Parse(schedule.RecurrencePattern)
+	if err != nil {
+		return nil, err
+	}
+
+	loc = Location(schedule, loc)
+	start = start.In(loc)
+	exceptionsByDate := make(map[string]models.TwRecurrenceException)
+	for _, exception := range exceptions {
+		if exception.ScheduleId != schedule.ID {
+			continue
+		}
+		exceptionsByDate[dateKey(exception.ExceptionDate.In(loc))] = exception
+	}
+
+	var occurrences []Occurrence
+	for _, occurrenceStart := range rule.Between(start, from.Add(-duration), to) {
+		if exception, ok := exceptionsByDate[dateKey(occurrenceStart)]; ok {
+			if exception.IsCancelled || !exception.NewStartTime.IsZero() {
+				// Moved instances are added below at their new time.
+				continue
+			}
+		}
+		if overlaps(occurrenceStart, occurrenceStart.Add(duration), from, to) {
+			occurrences = append(occurrences, Occurrence{
+				ScheduleId:    schedule.ID,
+				Start:         occurrenceStart,
+				End:           occurrenceStart.Add(duration),
+				OriginalStart: occurrenceStart,
+			})
+		}
+	}
+
+	for _, exception := range exceptionsByDate {
+		if exception.IsCancelled || exception.NewStartTime.IsZero() {
+			continue
+		}
+		newStart := exception.NewStartTime.In(loc)
+		newEnd := newStart.Add(duration)
+		if !exception.NewEndTime.IsZero() && exception.NewEndTime.After(exception.NewStartTime) {
+			newEnd = exception.NewEndTime.In(loc)
+		}
+		if !overlaps(newStart, newEnd, from, to) {
+			continue
+		}
+		originalStart, ok := rule.OccurrenceOn(start, exception.ExceptionDate)
+		if !ok {
+			// The exception does not refer to an instance of the rule.
+			continue
+		}
+		occurrences = append(occurrences, Occurrence{
+			ScheduleId:    schedule.ID,
+			Start:         newStart,
+			End:           newEnd,
+			OriginalStart: originalStart,
+			IsMoved:       true,
+			ExceptionId:   exception.ID,
+		})
+	}
+
+	// Occurrences are reported in UTC, as schedules are stored.
+	for i := range occurrences {
+		occurrences[i].Start = occurrences[i].Start.UTC()
+		occurrences[i].End = occurrences[i].End.UTC()
+		occurrences[i].OriginalStart = occurrences[i].OriginalStart.UTC()
+	}
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].Start.Before(occurrences[j].Start)
+	})
+	return occurrences, nil
+}
+
+// Location returns the timezone the wall clock of schedule recurs in.
+// All-day schedules hold floating dates and recur in UTC. Schedules imported
+// with a timezone keep it, named by "timezone" in their extra_data. Others
+// recur in fallback: the timezone of their owner, or of whoever views them.
+func Location(schedule models.TwSchedule, fallback *time.Location) *time.Location {
+	if schedule.AllDay {
+		return time.UTC
+	}
+	if schedule.ExtraData != "" {
+		var extraData struct {
+			Timezone string `json:"timezone"`
+		}
+		if err := json.Unmarshal([]byte(schedule.ExtraData), &extraData); err == nil && extraData.Timezone != "" {
+			if loc, err := time.LoadLocation(extraData.Timezone); err == nil {
+				return loc
+			}
+		}
+	}
+	if fallback == nil {
+		return time.UTC
+	}
+	return fallback
+}
+
+func overlaps(start, end, from, to time.Time) bool {
+	if !start.Before(to) {
+		return false
+	}
+	if end.After(start) {
+		return end.After(from)
+	}
+	return !start.Before(from)
+}
+
+func dateKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func (r *Rule) each(dtstart, from time.Time, fn func(time.Time) bool) {
+	first := 0
+	if r.Count == 0 {
+		// Without COUNT nothing before the window needs to be enumerated.
+		first = r.skipPeriods(dtstart, from)
+	}
+	until := r.until(dtstart)
+	count := 0
+	for period := first; period < first+maxPeriods; period++ {
+		for _, candidate := range r.candidates(dtstart, period) {
+			if candidate.Before(dtstart) {
+				continue
+			}
+			if until != nil && candidate.After(*until) {
+				return
+			}
+			if !fn(candidate) {
+				return
+			}
+			count++
+			if r.Count > 0 && count >= r.Count {
+				return
+			}
+		}
+	}
+}
+
+// skipPeriods returns a period index safely before the one containing from.
+func (r *Rule) skipPeriods(dtstart, from time.Time) int {
+	if !from.After(dtstart) {
+		return 0
+	}
+	var periods int
+	switch r.Freq {
+	case Daily:
+		periods = int(from.Sub(dtstart).Hours() / 24)
+	case Weekly:
+		periods = int(from.Sub(dtstart).Hours() / (24 * 7))
+	case Monthly:
+		periods = (from.Year()-dtstart.Year())*12 + int(from.Month()) - int(dtstart.Month())
+	case Yearly:
+		periods = from.Year() - dtstart.Year()
+	}
+	skip := periods/r.Interval - 1
+	if skip < 0 {
+		return 0
+	}
+	return skip
+}
+
+// candidates returns the sorted instances generated by the given period,
+// where period 0 is the one containing dtstart.
+func (r *Rule) candidates(dtstart time.Time, period int) []time.Time {
+	loc := dtstart.Location()
+	hour, minute, second := dtstart.Clock()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, minute, second, dtstart.Nanosecond(), loc)
+	}
+	offset := period * r.Interval
+
+	var result []time.Time
+	switch r.Freq {
+	case Daily:
+		t := at(dtstart.Year(), dtstart.Month(), dtstart.Day()+offset)
+		if r.matchesByDay(t) && r.matchesByMonthDay(t) {
+			result = append(result, t)
+		}
+	case Weekly:
+		// Weeks start on Monday.
+		monday := dtstart.Day() - (int(dtstart.Weekday())+6)%7 + offset*7
+		for i := 0; i < 7; i++ {
+			t := at(dtstart.Year(), dtstart.Month(), monday+i)
+			if len(r.ByDay) == 0 {
+				if t.Weekday() != dtstart.Weekday() {
+					continue
+				}
+			} else if !r.matchesByDay(t) {
+				continue
+			}
+			if r.matchesByMonthDay(t) {
+				result = append(result, t)
+			}
+		}
+	case Monthly:
+		first := time.Date(dtstart.Year(), dtstart.Month()+time.Month(offset), 1, 0, 0, 0, 0, loc)
+		for _, day := range r.daysInSpan(first, daysIn(first.Year(), first.Month()), dtstart) {
+			result = append(result, at(first.Year(), first.Month(), day))
+		}
+	case Yearly:
+		year := dtstart.Year() + offset
+		if len(r.ByDay) == 0 {
+			// Without BYDAY the month is taken from DTSTART.
+			first := time.Date(year, dtstart.Month(), 1, 0, 0, 0, 0, loc)
+			for _, day := range r.daysInSpan(first, daysIn(year, dtstart.Month()), dtstart) {
+				result = append(result, at(year, dtstart.Month(), day))
+			}
+		} else {
+			first := time.Date(year, time.January, 1, 0, 0, 0, 0, loc)
+			days := time.Date(year, time.December, 31, 0, 0, 0, 0, loc).YearDay()
+			for _, day := range r.daysInSpan(first, days, dtstart) {
+				result = append(result, at(year, time.January, day))
+			}
+		}
+	}
+	return result
+}
+
+// daysInSpan returns the 1-based day offsets in the span of the given length
+// starting at first that match BYDAY and BYMONTHDAY. With neither set, the day
+// of month of dtstart is used.
+func (r *Rule) daysInSpan(first time.Time, length int, dtstart time.Time) []int {
+	var days []int
+	switch {
+	case len(r.ByDay) > 0:
+		selected := make(map[int]bool)
+		for _, weekdayNum := range r.ByDay {
+			var matching []int
+			for i := 0; i < length; i++ {
+				if first.AddDate(0, 0, i).Weekday() == weekdayNum.Weekday {
+					matching = append(matching, i+1)
+				}
+			}
+			switch {
+			case weekdayNum.N == 0:
+				for _, day := range matching {
+					selected[day] = true
+				}
+			case weekdayNum.N > 0 && weekdayNum.N <= len(matching):
+				selected[matching[weekdayNum.N-1]] = true
+			case weekdayNum.N < 0 && -weekdayNum.N <= len(matching):
+				selected[matching[len(matching)+weekdayNum.N]] = true
+			}
+		}
+		for day := range selected {
+			if r.matchesByMonthDay(first.AddDate(0, 0, day-1)) {
+				days = append(days, day)
+			}
+		}
+	case len(r.ByMonthDay) > 0:
+		for i := 1; i <= length; i++ {
+			if r.matchesByMonthDay(first.AddDate(0, 0, i-1)) {
+				days = append(days, i)
+			}
+		}
+	default:
+		if dtstart.Day() <= length {
+			days = append(days, dtstart.Day())
+		}
+	}
+	sort.Ints(days)
+	return days
+}
+
+func (r *Rule) matchesByDay(t time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, weekdayNum := range r.ByDay {
+		if weekdayNum.Weekday == t.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) matchesByMonthDay(t time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	last := daysIn(t.Year(), t.Month())
+	for _, monthDay := range r.ByMonthDay {
+		if monthDay > 0 && t.Day() == monthDay {
+			return true
+		}
+		if monthDay < 0 && t.Day() == last+monthDay+1 {
+			return true
+		}
+	}
+	return false
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
@@ -0,0 +1,97 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+
+	"github.com/timewise-team/timewise-models/models"
+)
+
+func TestExpand(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	saigon := mustLoadLocation(t, "Asia/Ho_Chi_Minh")
+	at := func(year int, month time.Month, day, hour, minute int) *time.Time {
+		t := time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+		return &t
+	}
+	from := time.Date(2026, 3, 23, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 4, 6, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		schedule   models.TwSchedule
+		exceptions []models.TwRecurrenceException
+		loc        *time.Location
+		want       []time.Time
+		wantMoved  []bool
+	}{
+		{
+			name:     "without a pattern",
+			schedule: models.TwSchedule{ID: 1, StartTime: at(2026, 3, 24, 8, 0), EndTime: at(2026, 3, 24, 9, 0)},
+			loc:      berlin,
+			want:     []time.Time{*at(2026, 3, 24, 8, 0)},
+		},
+		{
+			name:     "recurs in the owner's timezone",
+			schedule: models.TwSchedule{ID: 1, StartTime: at(2026, 3, 16, 8, 0), RecurrencePattern: "FREQ=WEEKLY"},
+			loc:      berlin,
+			want:     []time.Time{*at(2026, 3, 23, 8, 0), *at(2026, 3, 30, 7, 0)},
+		},
+		{
+			name:     "recurs in UTC without an owner timezone",
+			schedule: models.TwSchedule{ID: 1, StartTime: at(2026, 3, 16, 8, 0), RecurrencePattern: "FREQ=WEEKLY"},
+			want:     []time.Time{*at(2026, 3, 23, 8, 0), *at(2026, 3, 30, 8, 0)},
+		},
+		{
+			name: "an imported timezone wins over the owner's",
+			schedule: models.TwSchedule{ID: 1, StartTime: at(2026, 3, 16, 8, 0), RecurrencePattern: "FREQ=WEEKLY",
+				ExtraData: `{"timezone": "Europe/Berlin"}`},
+			loc:  saigon,
+			want: []time.Time{*at(2026, 3, 23, 8, 0), *at(2026, 3, 30, 7, 0)},
+		},
+		{
+			name: "all-day schedules recur on floating dates",
+			schedule: models.TwSchedule{ID: 1, StartTime: at(2026, 3, 16, 0, 0), EndTime: at(2026, 3, 17, 0, 0),
+				AllDay: true, RecurrencePattern: "FREQ=WEEKLY"},
+			loc:  saigon,
+			want: []time.Time{*at(2026, 3, 23, 0, 0), *at(2026, 3, 30, 0, 0)},
+		},
+		{
+			name:     "an occurrence overlapping the start of the window is kept",
+			schedule: models.TwSchedule{ID: 1, StartTime: at(2026, 3, 15, 23, 0), EndTime: at(2026, 3, 16, 1, 0), RecurrencePattern: "FREQ=WEEKLY"},
+			want:     []time.Time{*at(2026, 3, 22, 23, 0), *at(2026, 3, 29, 23, 0), *at(2026, 4, 5, 23, 0)},
+		},
+		{
+			name:     "exceptions are matched on the date in the rule's timezone",
+			schedule: models.TwSchedule{ID: 1, StartTime: at(2026, 3, 15, 23, 30), EndTime: at(2026, 3, 16, 0, 30), RecurrencePattern: "FREQ=WEEKLY"},
+			exceptions: []models.TwRecurrenceException{
+				{ID: 10, ScheduleId: 1, ExceptionDate: *at(2026, 3, 23, 0, 0), IsCancelled: true},
+				{ID: 11, ScheduleId: 1, ExceptionDate: *at(2026, 3, 30, 0, 0), NewStartTime: *at(2026, 4, 1, 2, 0)},
+				{ID: 12, ScheduleId: 2, ExceptionDate: *at(2026, 3, 30, 0, 0), IsCancelled: true},
+			},
+			loc:       saigon,
+			want:      []time.Time{*at(2026, 4, 1, 2, 0), *at(2026, 4, 5, 23, 30)},
+			wantMoved: []bool{true, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			occurrences, err := Expand(tt.schedule, tt.exceptions, from, to, tt.loc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			starts := make([]time.Time, 0, len(occurrences))
+			for i, occurrence := range occurrences {
+				starts = append(starts, occurrence.Start)
+				if occurrence.Start.Location() != time.UTC {
+					t.Errorf("occurrence %d is in %s, want UTC", i, occurrence.Start.Location())
+				}
+				moved := i < len(tt.wantMoved) && tt.wantMoved[i]
+				if occurrence.IsMoved != moved {
+					t.Errorf("occurrence %d IsMoved = %v, want %v", i, occurrence.IsMoved, moved)
+				}
+			}
+			assertTimes(t, starts, tt.want)
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package recurrence
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// WeekdayNum is a BYDAY entry such as "MO", "2TU" or "-1FR".
+// N is zero when the entry applies to every matching weekday of the period.
+type WeekdayNum struct {
+	Weekday time.Weekday
+	N       int
+}
+
+// Rule is the subset of an RFC 5545 RRULE supported by the DMS.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      *time.Time
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+
+	// floating is set when UNTIL has no "Z" suffix. Until then holds the wall
+	// clock in UTC, and the rule ends at that wall clock in DTSTART's timezone.
+	floating bool
+}
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// Parse parses a recurrence pattern stored on TwSchedule.RecurrencePattern.
+// The pattern may be a bare rule ("FREQ=WEEKLY;BYDAY=MO") or an "RRULE:" line,
+// optionally surrounded by other iCalendar lines which are ignored.
+func Parse(pattern string) (*Rule, error) {
+	line := ""
+	for _, l := range strings.FieldsFunc(pattern, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		l = strings.TrimSpace(l)
+		if strings.HasPrefix(strings.ToUpper(l), "RRULE:") {
+			line = l[len("RRULE:"):]
+			break
+		}
+		if line == "" && strings.Contains(strings.ToUpper(l), "FREQ=") {
+			line = l
+		}
+	}
+	if line == "" {
+		return nil, errors.New("recurrence pattern has no FREQ")
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(line, ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid rule part %q", part)
+		}
+		key, value := strings.ToUpper(strings.TrimSpace(kv[0])), strings.TrimSpace(kv[1])
+		switch key {
+		case "FREQ":
+			switch Frequency(strings.ToUpper(value)) {
+			case Daily, Weekly, Monthly, Yearly:
+				rule.Freq = Frequency(strings.ToUpper(value))
+			default:
+				return nil, fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL %q", value)
+			}
+			rule.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("invalid COUNT %q", value)
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, floating, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			rule.Until = &until
+			rule.floating = floating
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekdayNum, err := parseWeekdayNum(day)
+				if err != nil {
+					return nil, err
+				}
+				rule.ByDay = append(rule.ByDay, weekdayNum)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				monthDay, err := strconv.Atoi(strings.TrimSpace(day))
+				if err != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY %q", day)
+				}
+				rule.ByMonthDay = append(rule.ByMonthDay, monthDay)
+			}
+		case "WKST":
+			// Weeks always start on Monday, which is the RFC 5545 default.
+		default:
+			return nil, fmt.Errorf("unsupported rule part %q", key)
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, errors.New("recurrence pattern has no FREQ")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return nil, errors.New("COUNT and UNTIL must not both be set")
+	}
+	return rule, nil
+}
+
+// String renders the rule back into RRULE value syntax (without the "RRULE:" prefix).
+func (r *Rule) String() string {
+	parts := []string{"FREQ=" + string(r.Freq)}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if r.Until != nil && r.floating {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102T150405"))
+	} else if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.UTC().Format("20060102T150405Z"))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, 0, len(r.ByDay))
+		for _, day := range r.ByDay {
+			days = append(days, day.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, 0, len(r.ByMonthDay))
+		for _, day := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	return strings.Join(parts, ";")
+}
+
+func (w WeekdayNum) String() string {
+	for code, weekday := range weekdays {
+		if weekday == w.Weekday {
+			if w.N != 0 {
+				return strconv.Itoa(w.N) + code
+			}
+			return code
+		}
+	}
+	return ""
+}
+
+func parseWeekdayNum(value string) (WeekdayNum, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) < 2 {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+	weekday, ok := weekdays[value[len(value)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+	n := 0
+	if prefix := value[:len(value)-2]; prefix != "" {
+		var err error
+		n, err = strconv.Atoi(prefix)
+		if err != nil || n == 0 || n < -53 || n > 53 {
+			return WeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+		}
+	}
+	return WeekdayNum{Weekday: weekday, N: n}, nil
+}
+
+// parseUntil parses an UNTIL value. A value without a "Z" suffix is a floating
+// local time and is reported as such, with its wall clock held in UTC.
+func parseUntil(value string) (time.Time, bool, error) {
+	layouts := []string{"20060102T150405Z", "20060102T150405", "20060102"}
+	for _, layout := range layouts {
+		if until, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			if layout == "20060102" {
+				// A date-only UNTIL includes the whole day.
+				until = until.Add(24*time.Hour - time.Second)
+			}
+			return until, layout != "20060102T150405Z", nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+// until returns the last instant the rule anchored at dtstart may recur at.
+// A floating UNTIL is read in dtstart's location, which is the timezone the
+// rule recurs in.
+func (r *Rule) until(dtstart time.Time) *time.Time {
+	if r.Until == nil || !r.floating {
+		return r.Until
+	}
+	u := *r.Until
+	until := time.Date(u.Year(), u.Month(), u.Day(), u.Hour(), u.Minute(), u.Second(), u.Nanosecond(), dtstart.Location())
+	return &until
+}
@@ -0,0 +1,209 @@
+package recurrence
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRoundTrip(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "FREQ=WEEKLY;BYDAY=MO,WE", want: "FREQ=WEEKLY;BYDAY=MO,WE"},
+		{pattern: "RRULE:FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR", want: "FREQ=MONTHLY;INTERVAL=2;BYDAY=-1FR"},
+		{pattern: "DTSTART:20260105T090000\nRRULE:FREQ=DAILY;COUNT=3", want: "FREQ=DAILY;COUNT=3"},
+		{pattern: "FREQ=DAILY;UNTIL=20260110T090000Z", want: "FREQ=DAILY;UNTIL=20260110T090000Z"},
+		{pattern: "FREQ=DAILY;UNTIL=20260110T090000", want: "FREQ=DAILY;UNTIL=20260110T090000"},
+		{pattern: "FREQ=YEARLY;BYMONTHDAY=1,-1;WKST=MO", want: "FREQ=YEARLY;BYMONTHDAY=1,-1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			rule, err := Parse(tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rule.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidRules(t *testing.T) {
+	tests := []string{
+		"",
+		"BYDAY=MO",
+		"FREQ=HOURLY",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=2;UNTIL=20260110",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=DAILY;UNTIL=tomorrow",
+		"FREQ=DAILY;BYSETPOS=1",
+	}
+	for _, pattern := range tests {
+		t.Run(pattern, func(t *testing.T) {
+			if _, err := Parse(pattern); err == nil {
+				t.Errorf("Parse(%q) succeeded, want an error", pattern)
+			}
+		})
+	}
+}
+
+func TestBetween(t *testing.T) {
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+	saigon := mustLoadLocation(t, "Asia/Ho_Chi_Minh")
+
+	tests := []struct {
+		name    string
+		pattern string
+		dtstart time.Time
+		from    time.Time
+		to      time.Time
+		want    []time.Time
+	}{
+		{
+			name:    "weekly keeps the wall clock across daylight saving",
+			pattern: "FREQ=WEEKLY",
+			dtstart: time.Date(2026, 3, 23, 9, 0, 0, 0, berlin),
+			from:    time.Date(2026, 3, 23, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 3, 23, 8, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 30, 7, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "BYDAY is matched in the rule's timezone, not in UTC",
+			pattern: "FREQ=WEEKLY;BYDAY=MO",
+			dtstart: time.Date(2026, 1, 5, 1, 0, 0, 0, saigon),
+			from:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2026, 1, 14, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 1, 4, 18, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 11, 18, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "daily with a UTC offset",
+			pattern: "FREQ=DAILY;INTERVAL=2",
+			dtstart: time.Date(2026, 1, 1, 23, 30, 0, 0, time.FixedZone("", -5*60*60)),
+			from:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2026, 1, 6, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 1, 2, 4, 30, 0, 0, time.UTC),
+				time.Date(2026, 1, 4, 4, 30, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "COUNT counts occurrences before the window",
+			pattern: "FREQ=DAILY;COUNT=3",
+			dtstart: time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC),
+			from:    time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 3, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "UTC UNTIL is an instant",
+			pattern: "FREQ=DAILY;UNTIL=20260103T080000Z",
+			dtstart: time.Date(2026, 1, 1, 9, 0, 0, 0, berlin),
+			from:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 3, 8, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "floating UNTIL is read in the rule's timezone",
+			pattern: "FREQ=DAILY;UNTIL=20260103T083000",
+			dtstart: time.Date(2026, 1, 1, 9, 0, 0, 0, berlin),
+			from:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 2, 8, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "date-only UNTIL includes the whole day",
+			pattern: "FREQ=DAILY;UNTIL=20260102",
+			dtstart: time.Date(2026, 1, 1, 23, 0, 0, 0, berlin),
+			from:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 1, 1, 22, 0, 0, 0, time.UTC),
+				time.Date(2026, 1, 2, 22, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "monthly on the last Friday",
+			pattern: "FREQ=MONTHLY;BYDAY=-1FR",
+			dtstart: time.Date(2026, 1, 30, 15, 0, 0, 0, time.UTC),
+			from:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 1, 30, 15, 0, 0, 0, time.UTC),
+				time.Date(2026, 2, 27, 15, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 27, 15, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "monthly on the 31st skips shorter months",
+			pattern: "FREQ=MONTHLY",
+			dtstart: time.Date(2026, 1, 31, 9, 0, 0, 0, time.UTC),
+			from:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2026, 1, 31, 9, 0, 0, 0, time.UTC),
+				time.Date(2026, 3, 31, 9, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "yearly keeps DTSTART's month",
+			pattern: "FREQ=YEARLY",
+			dtstart: time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC),
+			from:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2029, 1, 1, 0, 0, 0, 0, time.UTC),
+			want: []time.Time{
+				time.Date(2024, 2, 29, 9, 0, 0, 0, time.UTC),
+				time.Date(2028, 2, 29, 9, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertTimes(t, rule.Between(tt.dtstart, tt.from, tt.to), tt.want)
+		})
+	}
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone %s is not available: %v", name, err)
+	}
+	return loc
+}
+
+func assertTimes(t *testing.T, got, want []time.Time) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d times %v, want %d %v", len(got), got, len(want), want)
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("time %d = %s, want %s", i, got[i].UTC(), want[i])
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Package reminderlease hands due reminders to cron workers. A worker claims a
+// batch by writing a lease on the rows, so replicas never pick the same
+// reminder while it is being sent, and a reminder whose worker died is picked
+// up again once its lease runs out.
+package reminderlease
+
+import (
+	"errors"
+	"fmt"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusSent    = "sent"
+	StatusExpired = "expired"
+	StatusFailed  = "failed"
+)
+
+// ErrLeaseLost is returned when a reminder's lease ran out and another worker
+// claimed it before the outcome was recorded.
+var ErrLeaseLost = errors.New("reminder lease was lost")
+
+// Policy controls how due reminders are handed out.
+type Policy struct {
+	// CatchUp is how late a reminder may still be sent, e.g. after the
+	// workers were down. Older ones are marked expired. Zero sends every
+	// overdue reminder however late.
+	CatchUp time.Duration
+	// Lease is how long a claimed reminder is hidden from other workers. It
+	// must be longer than sending one reminder takes.
+	Lease time.Duration
+	// MaxAttempts is the number of tries before a reminder is marked failed.
+	MaxAttempts int
+	// RetryDelay is the wait after a failed attempt.
+	RetryDelay time.Duration
+	// BatchSize is the most reminders one claim takes; zero means 100.
+	BatchSize int
+}
+
+const defaultBatchSize = 100
+
+// due matches every overdue, unsent, non-deleted reminder that nobody holds.
+const due = "deleted_at IS NULL AND is_sent = false AND dispatch_status IS NULL" +
+	" AND reminder_time <= ? AND (claimed_until IS NULL OR claimed_until <= ?)"
+
+// claims numbers the claims of this process, which keeps their leases apart.
+var claims atomic.Int64
+
+// WorkerId names this run of the process in the leases it takes.
+func WorkerId() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "worker"
+	}
+	return fmt.Sprintf("%s:%d:%d", host, os.Getpid(), time.Now().Unix())
+}
+
+// Expire marks the due reminders that are later than policy.CatchUp as
+// expired, so they are never sent.
+func Expire(db *gorm.DB, policy Policy, now time.Time) (int64, error) {
+	if policy.CatchUp <= 0 {
+		return 0, nil
+	}
+	result := db.Exec("UPDATE tw_reminders SET dispatch_status = ?, claimed_by = NULL, claimed_until = NULL"+
+		" WHERE "+due+" AND reminder_time < ?",
+		StatusExpired, now, now, now.Add(-policy.CatchUp))
+	return result.RowsAffected, result.Error
+}
+
+// Claim leases up to a batch of due reminders, oldest first, and
+// returns them with what is needed to send them. The returned lease
+// identifies this claim to Complete and Release.
+func Claim(db *gorm.DB, worker string, policy Policy, now time.Time) ([]models.TwReminder, string, error) {
+	lease := fmt.Sprintf("%s/%d", worker, claims.Add(1))
+	batchSize := policy.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	if err := db.Exec("UPDATE tw_reminders SET claimed_by = ?, claimed_until = ?, attempts = attempts + 1"+
+		" WHERE "+due+" ORDER BY reminder_time LIMIT ?",
+		lease, now.Add(policy.Lease), now, now, batchSize).Error; err != nil {
+		return nil, "", err
+	}
+
+	var reminders []models.TwReminder
+	err := db.
+		Where("claimed_by = ?", lease).
+		Preload("WorkspaceUser").
+		Preload("WorkspaceUser.Workspace").
+		Preload("WorkspaceUser.UserEmail").
+		Preload("WorkspaceUser.UserEmail.User").
+		Preload("Schedule").
+		Order("reminder_time").
+		Find(&reminders).Error
+	return reminders, lease, err
+}
+
+// Complete records that a claimed reminder was sent. Run it in the
+// transaction that stores whatever else the send produced, so that it is
+// only kept if the lease was still held.
+func Complete(tx *gorm.DB, reminderId int, lease string) error {
+	result := tx.Exec("UPDATE tw_reminders SET is_sent = true, dispatch_status = ?, sent_at = ?, last_error = NULL,"+
+		" claimed_by = NULL, claimed_until = NULL, updated_at = NOW() WHERE id = ? AND claimed_by = ?",
+		StatusSent, time.Now(), reminderId, lease)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// Release gives a claimed reminder back after a failed attempt. It is tried
+// again after policy.RetryDelay, or marked failed after policy.MaxAttempts.
+func Release(db *gorm.DB, reminderId int, lease string, policy Policy, cause error) error {
+	return db.Exec("UPDATE tw_reminders SET last_error = ?, claimed_by = NULL, claimed_until = ?,"+
+		" dispatch_status = CASE WHEN attempts >= ? THEN ? ELSE NULL END WHERE id = ? AND claimed_by = ?",
+		cause.Error(), time.Now().Add(policy.RetryDelay), policy.MaxAttempts, StatusFailed, reminderId, lease).Error
+}
+
+// Recipient records that a reminder was emailed to one recipient, so that a
+// retry after a failed attempt only sends to the recipients still missing it.
+type Recipient struct {
+	ID          int       `json:"id" gorm:"primary_key"`
+	ReminderId  int       `json:"reminder_id" gorm:"uniqueIndex:idx_tw_reminder_recipients_user_email,priority:1"`
+	UserEmailId int       `json:"user_email_id" gorm:"uniqueIndex:idx_tw_reminder_recipients_user_email,priority:2"`
+	SentAt      time.Time `json:"sent_at"`
+}
+
+func (Recipient) TableName() string {
+	return "tw_reminder_recipients"
+}
+
+// Sent returns the user emails a reminder was already emailed to.
+func Sent(db *gorm.DB, reminderId int) (map[int]bool, error) {
+	var userEmailIds []int
+	if err := db.Model(&Recipient{}).
+		Where("reminder_id = ?", reminderId).
+		Pluck("user_email_id", &userEmailIds).Error; err != nil {
+		return nil, err
+	}
+	sent := make(map[int]bool, len(userEmailIds))
+	for _, userEmailId := range userEmailIds {
+		sent[userEmailId] = true
+	}
+	return sent, nil
+}
+
+// RecordSent records that a reminder was emailed to userEmailId. Record each
+// recipient as soon as their email is out, before trying the next one.
+func RecordSent(db *gorm.DB, reminderId int, userEmailId int) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&Recipient{
+		ReminderId:  reminderId,
+		UserEmailId: userEmailId,
+		SentAt:      time.Now(),
+	}).Error
+}
+
+// Migrate adds the dispatch columns to tw_reminders.
+func Migrate(db *gorm.DB) error {
+	if db.Migrator().HasColumn("tw_reminders", "claimed_by") {
+		return nil
+	}
+	return db.Exec("ALTER TABLE tw_reminders" +
+		" ADD COLUMN dispatch_status VARCHAR(16) NULL," +
+		" ADD COLUMN claimed_by VARCHAR(128) NULL," +
+		" ADD COLUMN claimed_until DATETIME(3) NULL," +
+		" ADD COLUMN attempts INT NOT NULL DEFAULT 0," +
+		" ADD COLUMN last_error TEXT NULL," +
+		" ADD COLUMN sent_at DATETIME(3) NULL," +
+		" ADD INDEX idx_tw_reminders_due (is_sent, dispatch_status, reminder_time)," +
+		" ADD INDEX idx_tw_reminders_claimed_by (claimed_by)").Error
+}
+
+// Reschedule makes a reminder due again from its new time, forgetting any
+// earlier outcome, including who it was sent to, and dropping a lease in
+// flight.
+func Reschedule(db *gorm.DB, reminderId int) error {
+	if err := db.Where("reminder_id = ?", reminderId).Delete(&Recipient{}).Error; err != nil {
+		return err
+	}
+	return db.Exec("UPDATE tw_reminders SET dispatch_status = NULL, attempts = 0, last_error = NULL,"+
+		" claimed_by = NULL, claimed_until = NULL WHERE id = ?", reminderId).Error
+}
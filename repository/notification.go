@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"dbms/common"
+	"dbms/email"
+	"dbms/preference"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+	"net/url"
+	"strings"
+)
+
+// CreateNotification stores a notification to be sent by the worker, unless
+// the recipient turned its type off on every channel. A skipped notification
+// is left with a zero ID.
+func CreateNotification(db *gorm.DB, notification *models.TwNotifications) error {
+	preferences, err := preference.LoadForUserEmail(db, notification.UserEmailId)
+	if err != nil {
+		return err
+	}
+	if !preferences.Wants(notification.Type) {
+		return nil
+	}
+	return db.Create(notification).Error
+}
+
+// FindUnsentNotifications returns a page of the notifications that have not
+// been sent, with the email they go to and its user.
+func FindUnsentNotifications(db *gorm.DB, page common.Page) ([]models.TwNotifications, common.PageResult, error) {
+	var notifications []models.TwNotifications
+	result, err := page.Find(db.Where("is_sent = ?", false), &notifications, "UserEmail", "UserEmail.User")
+	return notifications, result, err
+}
+
+// MarkNotificationSent flags a notification as sent.
+func MarkNotificationSent(db *gorm.DB, notificationId int) error {
+	return db.Model(&models.TwNotifications{}).
+		Where("id = ?", notificationId).
+		Update("is_sent", true).Error
+}
+
+// NotifyInvitation stores the invitation notification of a workspace user
+// who has not joined yet. It is emailed with the invitation template, from
+// the email.InvitationData in its extra_data. inviterId, the workspace user
+// who sent the invitation, may be 0 when it is not known.
+func NotifyInvitation(db *gorm.DB, workspaceUser models.TwWorkspaceUser, inviterId int) error {
+	var workspace models.TwWorkspace
+	if err := db.Where("id = ?", workspaceUser.WorkspaceId).First(&workspace).Error; err != nil {
+		return err
+	}
+	data := email.InvitationData{
+		WorkspaceTitle: workspace.Title,
+		Role:           workspaceUser.Role,
+		Link:           fmt.Sprintf("/workspace/%d/invitation", workspace.ID),
+	}
+	if inviterId != 0 {
+		var inviter models.TwUser
+		err := db.Joins("JOIN tw_user_emails ON tw_user_emails.user_id = tw_users.id").
+			Joins("JOIN tw_workspace_users ON tw_workspace_users.user_email_id = tw_user_emails.id").
+			Where("tw_workspace_users.id = ?", inviterId).
+			First(&inviter).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		data.InviterName = strings.TrimSpace(inviter.FirstName + " " + inviter.LastName)
+	}
+	extraData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return CreateNotification(db, &models.TwNotifications{
+		UserEmailId:     workspaceUser.UserEmailId,
+		Type:            preference.TypeInvitation,
+		Title:           "Workspace invitation",
+		Message:         fmt.Sprintf("You are invited to join the workspace %s", workspace.Title),
+		Link:            data.Link,
+		RelatedItemId:   workspace.ID,
+		RelatedItemType: "workspace",
+		ExtraData:       string(extraData),
+	})
+}
+
+// NotifyEmailLink stores the notification that asks the owner of userEmail to
+// confirm linking it to the user named by its is_linked_to. It is emailed
+// with the email link template, from the email.EmailLinkData in its
+// extra_data.
+func NotifyEmailLink(db *gorm.DB, userEmail models.TwUserEmail) error {
+	data := email.EmailLinkData{
+		Email:     userEmail.Email,
+		Link:      "/link-email?email=" + url.QueryEscape(userEmail.Email),
+		ExpiresAt: userEmail.ExpiresAt,
+	}
+	if userEmail.IsLinkedTo != nil {
+		var user models.TwUser
+		err := db.Where("id = ?", *userEmail.IsLinkedTo).First(&user).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		data.UserName = strings.TrimSpace(user.FirstName + " " + user.LastName)
+	}
+	extraData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return CreateNotification(db, &models.TwNotifications{
+		UserEmailId:     userEmail.ID,
+		Type:            email.TemplateEmailLink,
+		Title:           "Confirm your email",
+		Message:         fmt.Sprintf("Confirm linking %s to a Timewise account", userEmail.Email),
+		Link:            data.Link,
+		RelatedItemId:   userEmail.ID,
+		RelatedItemType: "user_email",
+		ExtraData:       string(extraData),
+	})
+}
+
+// NotifyScheduleParticipant stores the notification of a workspace user who
+// was added to a schedule without being asked, such as a participant of a
+// schedule template. assigned tells them the schedule is assigned to them.
+// Both follow the user's assignment setting.
+func NotifyScheduleParticipant(db *gorm.DB, schedule models.TwSchedule, workspaceUserId int, assigned bool) error {
+	var workspaceUser models.TwWorkspaceUser
+	if err := db.Where("id = ?", workspaceUserId).First(&workspaceUser).Error; err != nil {
+		return err
+	}
+	title, message := "Added to a schedule", fmt.Sprintf("You were added to the schedule %s", schedule.Title)
+	if assigned {
+		title, message = "Schedule assigned", fmt.Sprintf("The schedule %s was assigned to you", schedule.Title)
+	}
+	return CreateNotification(db, &models.TwNotifications{
+		UserEmailId:     workspaceUser.UserEmailId,
+		Type:            preference.TypeAssignment,
+		Title:           title,
+		Message:         message,
+		RelatedItemId:   schedule.ID,
+		RelatedItemType: "schedule",
+	})
+}
@@ -0,0 +1,33 @@
+// Package repository holds the queries shared by the HTTP handlers and the
+// cron worker, so that both read and write the tables the same way.
+package repository
+
+import (
+	"dbms/common"
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+)
+
+// FindReminders returns a page of the reminders that have not been deleted,
+// with the workspace user, workspace, email, user and schedule they belong to.
+func FindReminders(db *gorm.DB, page common.Page) ([]models.TwReminder, common.PageResult, error) {
+	var reminders []models.TwReminder
+	result, err := page.Find(db.Where("deleted_at IS NULL"), &reminders,
+		"WorkspaceUser",
+		"WorkspaceUser.Workspace",
+		"WorkspaceUser.UserEmail",
+		"WorkspaceUser.UserEmail.User",
+		"Schedule",
+	)
+	return reminders, result, err
+}
+
+// MarkReminderSent flags a reminder as sent.
+func MarkReminderSent(db *gorm.DB, reminderId int) error {
+	return db.Model(&models.TwReminder{}).
+		Where("id = ?", reminderId).
+		Updates(map[string]interface{}{
+			"updated_at": gorm.Expr("NOW()"),
+			"is_sent":    true,
+		}).Error
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"github.com/timewise-team/timewise-models/dtos/core_dtos/schedule_participant_dtos"
+	"gorm.io/gorm"
+)
+
+// FindScheduleParticipants returns the participants of a schedule who are
+// still joined to its workspace, with their email and profile.
+func FindScheduleParticipants(db *gorm.DB, scheduleId int) ([]schedule_participant_dtos.ScheduleParticipantInfo, error) {
+	var participants []schedule_participant_dtos.ScheduleParticipantInfo
+	err := db.Table("tw_schedule_participants AS sp").
+		Select(`
+			sp.id AS id,
+			sp.schedule_id,
+			sp.workspace_user_id,
+			sp.status,
+			sp.assign_at,
+			sp.assign_by,
+			sp.response_time,
+			sp.invitation_sent_at,
+			sp.invitation_status,
+			wu.role,
+			wu.status AS status_workspace_user,
+			wu.is_verified,
+			ue.id as user_id,
+			ue.email,
+			u.first_name,
+			u.last_name,
+			u.profile_picture
+		`).
+		Joins("JOIN tw_workspace_users AS wu ON wu.id = sp.workspace_user_id").
+		Joins("JOIN tw_user_emails AS ue ON wu.user_email_id = ue.id").
+		Joins("JOIN tw_users AS u ON ue.user_id = u.id").
+		Where("sp.schedule_id = ?", scheduleId).
+		Where("sp.deleted_at IS NULL AND sp.invitation_status != 'removed'").
+		Where("wu.deleted_at IS NULL").
+		Where("ue.deleted_at IS NULL").
+		Where("u.deleted_at IS NULL").
+		Where("wu.is_active = true AND wu.is_verified = true AND wu.status = 'joined'").
+		Scan(&participants).Error
+	return participants, err
+}
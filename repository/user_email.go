@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"github.com/timewise-team/timewise-models/models"
+	"gorm.io/gorm"
+)
+
+// ClearExpiredUserEmails releases the emails whose link request has expired.
+func ClearExpiredUserEmails(db *gorm.DB) error {
+	return db.Model(&models.TwUserEmail{}).
+		Where("expires_at <= NOW()").
+		Updates(map[string]interface{}{
+			"status":       nil,
+			"is_linked_to": nil,
+			"expires_at":   nil,
+		}).Error
+}
+
+// FindLocales returns the locale of the user behind each of userEmailIds,
+// keyed by user email id. Users without a locale are left out.
+func FindLocales(db *gorm.DB, userEmailIds []int) (map[int]string, error) {
+	locales := make(map[int]string)
+	if len(userEmailIds) == 0 {
+		return locales, nil
+	}
+	var rows []struct {
+		UserEmailId int
+		Locale      string
+	}
+	if err := db.Table("tw_user_emails AS ue").
+		Select("ue.id AS user_email_id, u.locale").
+		Joins("JOIN tw_users AS u ON ue.user_id = u.id").
+		Where("ue.id IN ?", userEmailIds).
+		Where("u.locale <> ''").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		locales[row.UserEmailId] = row.Locale
+	}
+	return locales, nil
+}
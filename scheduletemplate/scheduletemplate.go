@@ -0,0 +1,165 @@
+// Package scheduletemplate keeps the schedule templates of a workspace: the
+// cards a team creates over and over, with their default participants,
+// reminders and checklist. Texts may hold {{variable}} placeholders that are
+// filled in when a schedule is created from the template.
+package scheduletemplate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MaxDuration is the longest schedule a template may create.
+const MaxDuration = 31 * 24 * 60
+
+// Variables filled in from the schedule being created. Others are given by
+// the request.
+const (
+	// VariableDate is the start date in the creator's timezone, 2006-01-02.
+	VariableDate = "date"
+	// VariableTime is the start time in the creator's timezone, 15:04.
+	VariableTime = "time"
+	// VariableAssignee is the full name of the assignee, if there is one.
+	VariableAssignee = "assignee"
+	// VariableWorkspace is the title of the workspace.
+	VariableWorkspace = "workspace"
+)
+
+var placeholder = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// Template is a schedule template. Participants, Reminders and Checklist are
+// stored as JSON.
+type Template struct {
+	ID          int        `json:"id" gorm:"primary_key"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at" gorm:"default:null"`
+	WorkspaceId int        `json:"workspace_id" gorm:"index"`
+	CreatedBy   int        `json:"created_by"`
+	// Name tells templates apart; Title is the title of the schedules.
+	Name        string `json:"name" gorm:"size:255"`
+	Title       string `json:"title" gorm:"size:255"`
+	Description string `json:"description" gorm:"type:text"`
+	Location    string `json:"location" gorm:"size:255"`
+	// DurationMinutes is the time from the start to the end of a schedule.
+	DurationMinutes int    `json:"duration_minutes"`
+	Priority        string `json:"priority" gorm:"size:32"`
+	Visibility      string `json:"visibility" gorm:"size:32"`
+	// Participants are the workspace users added besides the creator.
+	Participants []int           `json:"participants" gorm:"serializer:json;type:text"`
+	Reminders    []Reminder      `json:"reminders" gorm:"serializer:json;type:text"`
+	Checklist    []ChecklistItem `json:"checklist" gorm:"serializer:json;type:text"`
+}
+
+func (Template) TableName() string {
+	return "tw_schedule_templates"
+}
+
+// Reminder is a reminder set on every schedule created from a template.
+type Reminder struct {
+	// MinutesBefore is how long before the start the reminder goes off.
+	MinutesBefore int `json:"minutes_before"`
+	// Type is "only me" for the creator alone, or anything else for every
+	// participant, as on reminders.
+	Type   string `json:"type"`
+	Method string `json:"method"`
+}
+
+// ChecklistItem is an entry of the checklist schedules are created with, kept
+// in their extra_data as {"checklist": [...]}.
+type ChecklistItem struct {
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+// Validate reports whether schedules can be created from the template.
+func (t Template) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return errors.New("name is required")
+	}
+	if strings.TrimSpace(t.Title) == "" {
+		return errors.New("title is required")
+	}
+	if t.DurationMinutes <= 0 || t.DurationMinutes > MaxDuration {
+		return fmt.Errorf("duration_minutes must be between 1 and %d", MaxDuration)
+	}
+	for _, reminder := range t.Reminders {
+		if reminder.MinutesBefore < 0 {
+			return errors.New("reminders must not go off after the start")
+		}
+	}
+	for _, item := range t.Checklist {
+		if strings.TrimSpace(item.Text) == "" {
+			return errors.New("checklist items need a text")
+		}
+	}
+	return nil
+}
+
+// Render fills in the {{variable}} placeholders of text. Placeholders without
+// a value are reported rather than left in the schedule.
+func Render(text string, values map[string]string) (string, error) {
+	var missing []string
+	rendered := placeholder.ReplaceAllStringFunc(text, func(match string) string {
+		name := placeholder.FindStringSubmatch(match)[1]
+		value, ok := values[name]
+		if !ok {
+			missing = append(missing, name)
+			return match
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return "", fmt.Errorf("no value for template variables %s", strings.Join(missing, ", "))
+	}
+	return rendered, nil
+}
+
+// Schedule is what a template renders to.
+type Schedule struct {
+	Title       string
+	Description string
+	Location    string
+	Checklist   []ChecklistItem
+}
+
+// Render fills in the texts of the template with values.
+func (t Template) Render(values map[string]string) (Schedule, error) {
+	var schedule Schedule
+	var err error
+	if schedule.Title, err = Render(t.Title, values); err != nil {
+		return schedule, err
+	}
+	if schedule.Description, err = Render(t.Description, values); err != nil {
+		return schedule, err
+	}
+	if schedule.Location, err = Render(t.Location, values); err != nil {
+		return schedule, err
+	}
+	for _, item := range t.Checklist {
+		text, err := Render(item.Text, values)
+		if err != nil {
+			return schedule, err
+		}
+		schedule.Checklist = append(schedule.Checklist, ChecklistItem{Text: text, Done: item.Done})
+	}
+	return schedule, nil
+}
+
+// ExtraData returns the extra_data holding a schedule's checklist, or "" for
+// none.
+func (s Schedule) ExtraData() (string, error) {
+	if len(s.Checklist) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(struct {
+		Checklist []ChecklistItem `json:"checklist"`
+	}{s.Checklist})
+	return string(data), err
+}
@@ -0,0 +1,148 @@
+package search
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"html"
+	"strings"
+	"unicode"
+)
+
+const (
+	// snippetLength is the number of characters a highlight keeps of a long
+	// field, starting a little before the first match.
+	snippetLength = 160
+	snippetLead   = 40
+
+	markOpen  = "<mark>"
+	markClose = "</mark>"
+)
+
+// Highlight is a field of a result with the words that matched the search
+// wrapped in <mark></mark>. The rest of the snippet is HTML-escaped, so it
+// can be rendered as HTML as is.
+type Highlight struct {
+	Field   string `json:"field"`
+	Snippet string `json:"snippet"`
+}
+
+type span struct {
+	start, end int
+}
+
+// Highlighted returns the part of text around its first match of terms, with
+// every matched word marked, and whether anything matched. Like the search, a
+// term matches the words it is a prefix of, ignoring case and accents.
+func Highlighted(text string, terms []string) (string, bool) {
+	runes := []rune(text)
+	folded := make([]rune, len(runes))
+	for i, r := range runes {
+		folded[i] = fold(r)
+	}
+	matches := findMatches(folded, terms)
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	start, end := 0, len(runes)
+	if len(runes) > snippetLength {
+		start = matches[0].start - snippetLead
+		if start < 0 {
+			start = 0
+		}
+		end = start + snippetLength
+		if end > len(runes) {
+			end = len(runes)
+			start = end - snippetLength
+		}
+		// Start and end on word boundaries when there is one nearby.
+		for i := start; i > 0 && i > start-10; i-- {
+			if !isWordRune(runes[i-1]) {
+				start = i
+				break
+			}
+		}
+		for i := end; i < len(runes) && i < end+10; i++ {
+			if !isWordRune(runes[i]) {
+				end = i
+				break
+			}
+		}
+	}
+
+	var snippet strings.Builder
+	if start > 0 {
+		snippet.WriteString("…")
+	}
+	at := start
+	for _, m := range matches {
+		if m.start < at || m.end > end {
+			continue
+		}
+		snippet.WriteString(html.EscapeString(string(runes[at:m.start])))
+		snippet.WriteString(markOpen)
+		snippet.WriteString(html.EscapeString(string(runes[m.start:m.end])))
+		snippet.WriteString(markClose)
+		at = m.end
+	}
+	snippet.WriteString(html.EscapeString(string(runes[at:end])))
+	if end < len(runes) {
+		snippet.WriteString("…")
+	}
+	return snippet.String(), true
+}
+
+// findMatches returns the words of folded that start with one of terms, in
+// order.
+func findMatches(folded []rune, terms []string) []span {
+	termRunes := make([][]rune, len(terms))
+	for i, term := range terms {
+		for _, r := range term {
+			termRunes[i] = append(termRunes[i], fold(r))
+		}
+	}
+
+	var matches []span
+	for i := 0; i < len(folded); i++ {
+		if !isWordRune(folded[i]) || (i > 0 && isWordRune(folded[i-1])) {
+			continue
+		}
+		for _, term := range termRunes {
+			if !hasPrefix(folded[i:], term) {
+				continue
+			}
+			end := i + len(term)
+			for end < len(folded) && isWordRune(folded[end]) {
+				end++
+			}
+			matches = append(matches, span{start: i, end: end})
+			i = end - 1
+			break
+		}
+	}
+	return matches
+}
+
+func hasPrefix(runes []rune, prefix []rune) bool {
+	if len(prefix) == 0 || len(runes) < len(prefix) {
+		return false
+	}
+	for i, r := range prefix {
+		if runes[i] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// fold maps a character to its lowercase base letter, e.g. Ệ to e, keeping
+// one character for one so that matches can be located in the original text.
+func fold(r rune) rune {
+	r = unicode.ToLower(r)
+	if r < unicode.MaxASCII {
+		return r
+	}
+	for _, base := range norm.NFD.String(string(r)) {
+		return base
+	}
+	return r
+}
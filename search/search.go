@@ -0,0 +1,260 @@
+package search
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Result types, one per FULLTEXT index searched.
+const (
+	TypeSchedule   = "schedule"
+	TypeTranscript = "transcript"
+	TypeComment    = "comment"
+	TypeDocument   = "document"
+)
+
+var Types = []string{TypeSchedule, TypeTranscript, TypeComment, TypeDocument}
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+	// maxTerms bounds the boolean query built from a search.
+	maxTerms = 10
+)
+
+// ErrEmptyQuery is returned for a query without any word to search for.
+var ErrEmptyQuery = errors.New("query has no words to search for")
+
+// index is a FULLTEXT index. MATCH must name exactly the columns of an index.
+type index struct {
+	table   string
+	name    string
+	columns []string
+}
+
+var indexes = []index{
+	{table: "tw_schedules", name: "ft_tw_schedules_text", columns: []string{"title", "description", "location"}},
+	{table: "tw_schedules", name: "ft_tw_schedules_transcript", columns: []string{"video_transcript"}},
+	{table: "tw_comments", name: "ft_tw_comments_content", columns: []string{"content"}},
+	{table: "tw_documents", name: "ft_tw_documents_file_name", columns: []string{"file_name"}},
+}
+
+// Migrate adds the FULLTEXT indexes searches run on. Building an index over
+// existing rows can take a while on large tables; it is only done once.
+func Migrate(db *gorm.DB) error {
+	for _, idx := range indexes {
+		if db.Migrator().HasIndex(idx.table, idx.name) {
+			continue
+		}
+		err := db.Exec("ALTER TABLE " + idx.table +
+			" ADD FULLTEXT INDEX " + idx.name + " (" + strings.Join(idx.columns, ", ") + ")").Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Query is a search in one workspace.
+type Query struct {
+	WorkspaceId int
+	Text        string
+	// Types limits the search to some result types; all of them when empty.
+	Types []string
+	Limit int
+	// ParticipantId restricts results to the schedules the workspace user
+	// takes part in, for guests who do not see the whole workspace. Zero
+	// searches every schedule.
+	ParticipantId int
+}
+
+// Result is a schedule, comment, document or transcript matching a search.
+type Result struct {
+	Type string `json:"type"`
+	// ID is the id of the schedule, comment or document; transcripts use
+	// their schedule's id.
+	ID            int     `json:"id"`
+	ScheduleId    int     `json:"schedule_id"`
+	ScheduleTitle string  `json:"schedule_title"`
+	Score         float64 `json:"score"`
+	// Highlights are the matching fields, with the matched words marked.
+	Highlights []Highlight `json:"highlights"`
+}
+
+// source describes how one result type is searched: its FULLTEXT columns,
+// and up to three text fields selected as f1, f2 and f3 for highlights.
+type source struct {
+	kind string
+	from string
+	// key is the id column of the result.
+	key     string
+	match   string
+	fields  []string
+	selects string
+	where   string
+}
+
+var sources = map[string]source{
+	TypeSchedule: {
+		kind:    TypeSchedule,
+		from:    "tw_schedules AS s",
+		key:     "s.id",
+		match:   "s.title, s.description, s.location",
+		fields:  []string{"title", "description", "location"},
+		selects: "s.id, s.id AS schedule_id, s.title AS schedule_title, s.title AS f1, s.description AS f2, s.location AS f3",
+	},
+	TypeTranscript: {
+		kind:    TypeTranscript,
+		from:    "tw_schedules AS s",
+		key:     "s.id",
+		match:   "s.video_transcript",
+		fields:  []string{"video_transcript"},
+		selects: "s.id, s.id AS schedule_id, s.title AS schedule_title, s.video_transcript AS f1",
+	},
+	TypeComment: {
+		kind:    TypeComment,
+		from:    "tw_comments AS c JOIN tw_schedules AS s ON s.id = c.schedule_id",
+		key:     "c.id",
+		match:   "c.content",
+		fields:  []string{"content"},
+		selects: "c.id, s.id AS schedule_id, s.title AS schedule_title, c.content AS f1",
+		where:   "c.deleted_at IS NULL AND c.is_deleted = false",
+	},
+	TypeDocument: {
+		kind:    TypeDocument,
+		from:    "tw_documents AS d JOIN tw_schedules AS s ON s.id = d.schedule_id",
+		key:     "d.id",
+		match:   "d.file_name",
+		fields:  []string{"file_name"},
+		selects: "d.id, s.id AS schedule_id, s.title AS schedule_title, d.file_name AS f1",
+		where:   "d.deleted_at IS NULL AND d.is_deleted = false",
+	},
+}
+
+type row struct {
+	ID            int
+	ScheduleId    int
+	ScheduleTitle string
+	Score         float64
+	F1, F2, F3    *string
+}
+
+// ValidType reports whether resultType is a type that can be searched.
+func ValidType(resultType string) bool {
+	_, ok := sources[resultType]
+	return ok
+}
+
+// Search runs query against the FULLTEXT indexes of its types and returns
+// the best results first. Every word must match, and words match as prefixes
+// so that a partly typed word finds results.
+//
+// Scores come from different indexes, so they order results of one type
+// exactly but results of different types only roughly.
+func Search(db *gorm.DB, query Query) ([]Result, error) {
+	terms := Terms(query.Text)
+	if len(terms) == 0 {
+		return nil, ErrEmptyQuery
+	}
+	against := booleanQuery(terms)
+	if query.Limit <= 0 || query.Limit > MaxLimit {
+		query.Limit = DefaultLimit
+	}
+	types := query.Types
+	if len(types) == 0 {
+		types = Types
+	}
+
+	results := []Result{}
+	for _, resultType := range types {
+		src, ok := sources[resultType]
+		if !ok {
+			continue
+		}
+		match := "MATCH (" + src.match + ") AGAINST (? IN BOOLEAN MODE)"
+		find := db.Table(src.from).
+			Select(src.selects+", "+match+" AS score", against).
+			Where(match, against).
+			Where("s.workspace_id = ?", query.WorkspaceId).
+			Where("s.deleted_at IS NULL AND s.is_deleted = false")
+		if src.where != "" {
+			find = find.Where(src.where)
+		}
+		if query.ParticipantId != 0 {
+			find = find.Where("s.id IN (?)", db.Table("tw_schedule_participants").
+				Select("schedule_id").
+				Where("workspace_user_id = ?", query.ParticipantId).
+				Where("deleted_at IS NULL AND invitation_status != 'removed'"))
+		}
+		var rows []row
+		if err := find.Order("score DESC").Order(src.key).Limit(query.Limit).Scan(&rows).Error; err != nil {
+			return nil, err
+		}
+
+		for _, r := range rows {
+			result := Result{
+				Type:          src.kind,
+				ID:            r.ID,
+				ScheduleId:    r.ScheduleId,
+				ScheduleTitle: r.ScheduleTitle,
+				Score:         r.Score,
+				Highlights:    []Highlight{},
+			}
+			for i, text := range []*string{r.F1, r.F2, r.F3}[:len(src.fields)] {
+				if text == nil {
+					continue
+				}
+				if snippet, ok := Highlighted(*text, terms); ok {
+					result.Highlights = append(result.Highlights, Highlight{Field: src.fields[i], Snippet: snippet})
+				}
+			}
+			results = append(results, result)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+	return results, nil
+}
+
+// Terms splits text into the lowercased words to search for. Characters
+// that are neither letters nor digits, including the boolean mode operators,
+// separate words.
+func Terms(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !isWordRune(r)
+	})
+	terms := make([]string, 0, len(words))
+	seen := make(map[string]bool, len(words))
+	for _, word := range words {
+		if seen[word] {
+			continue
+		}
+		seen[word] = true
+		terms = append(terms, word)
+		if len(terms) == maxTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// booleanQuery requires every term, as a prefix: +sync* +weekly*.
+func booleanQuery(terms []string) string {
+	required := make([]string, len(terms))
+	for i, term := range terms {
+		required[i] = "+" + term + "*"
+	}
+	return strings.Join(required, " ")
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
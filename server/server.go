@@ -1,10 +1,16 @@
 package server
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"dbms/clone"
 	"dbms/config"
 	"dbms/database"
 	h "dbms/handlers"
+	"errors"
 	"log"
+	"os"
+	"time"
 )
 
 func RegisterServer() {
@@ -20,11 +26,59 @@ func RegisterServer() {
 		log.Fatalf("Could not initialize database: %v", err)
 	}
 
+	// Fail the workspace clones an earlier run of the server left unfinished
+	if err := clone.FailStale(db, time.Now()); err != nil {
+		log.Printf("Could not fail stale workspace clones: %v", err)
+	}
+
 	// Initialize router
-	r := h.RegisterHandlerV1(db)
+	r := h.RegisterHandlerV1(db, cfg)
 	// Start server
+	if cfg.TLSCertFile != "" && cfg.TLSKeyFile != "" {
+		tlsConfig, err := loadTLSConfig(cfg)
+		if err != nil {
+			log.Fatalf("Could not load TLS config: %v", err)
+		}
+		ln, err := tls.Listen("tcp", ":"+cfg.ServerPort, tlsConfig)
+		if err != nil {
+			log.Fatalf("Could not start server: %v", err)
+		}
+		log.Printf("Server is running with TLS on port %s", cfg.ServerPort)
+		if err := r.Listener(ln); err != nil {
+			log.Fatalf("Could not start server: %v", err)
+		}
+		return
+	}
+
 	log.Printf("Server is running on port %s", cfg.ServerPort)
 	if err := r.Listen(":" + cfg.ServerPort); err != nil {
 		log.Fatalf("Could not start server: %v", err)
 	}
 }
+
+// loadTLSConfig verifies client certificates against the client CA when one is
+// configured. Certificates are optional so that callers can still
+// authenticate with a bearer token instead.
+func loadTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if cfg.TLSClientCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("no certificates found in client CA file")
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,198 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"dbms/egress"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// MaxAttempts is the number of tries before a delivery is marked failed.
+	MaxAttempts = 8
+	// Attempt n+1 waits retryBase * 2^(n-1) after attempt n, capped at retryMax:
+	// 1m, 2m, 4m, ... for about two hours in total.
+	retryBase = time.Minute
+	retryMax  = 2 * time.Hour
+	// claimTimeout is how long a claimed delivery is hidden from other workers.
+	// A worker that dies mid-send leaves it to be retried after this.
+	claimTimeout = 5 * time.Minute
+	batchSize    = 100
+	// Only this much of a subscriber's response is kept for debugging.
+	maxResponseBody = 2048
+)
+
+// DefaultClient gives up on slow subscribers so one cannot stall a batch,
+// and only connects to public addresses, even if a subscriber's host is
+// re-pointed after it was checked.
+var DefaultClient = egress.Client(10 * time.Second)
+
+const (
+	HeaderSignature = "X-Timewise-Signature"
+	HeaderEvent     = "X-Timewise-Event"
+	HeaderDelivery  = "X-Timewise-Delivery"
+)
+
+// Sign returns the signature header value for a body sent at timestamp:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>" keyed with secret>".
+// Receivers should recompute it and reject stale timestamps.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(t + "."))
+	mac.Write(body)
+	return "t=" + t + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns the wait after the given number of failed attempts.
+func Backoff(attempts int) time.Duration {
+	wait := retryBase
+	for i := 1; i < attempts && wait < retryMax; i++ {
+		wait *= 2
+	}
+	if wait > retryMax {
+		wait = retryMax
+	}
+	return wait
+}
+
+// DispatchResult counts what one Dispatch run did.
+type DispatchResult struct {
+	Succeeded int `json:"succeeded"`
+	Retrying  int `json:"retrying"`
+	Failed    int `json:"failed"`
+}
+
+// Dispatch sends the deliveries that are due, up to one batch. Each delivery
+// is claimed before it is sent, so several workers can run at once without
+// sending it twice.
+func Dispatch(db *gorm.DB, client *http.Client) (DispatchResult, error) {
+	var result DispatchResult
+	now := time.Now()
+
+	var due []Delivery
+	if err := db.Where("status = ? AND next_attempt_at <= ?", StatusPending, now).
+		Order("next_attempt_at").
+		Limit(batchSize).
+		Find(&due).Error; err != nil {
+		return result, err
+	}
+
+	for _, delivery := range due {
+		claimed, err := claim(db, &delivery, now)
+		if err != nil {
+			return result, err
+		}
+		if !claimed {
+			continue
+		}
+
+		switch status, err := attempt(db, client, &delivery); {
+		case err != nil:
+			return result, err
+		case status == StatusSucceeded:
+			result.Succeeded++
+		case status == StatusFailed:
+			result.Failed++
+		default:
+			result.Retrying++
+		}
+	}
+	return result, nil
+}
+
+// claim counts the attempt and pushes next_attempt_at past claimTimeout,
+// unless another worker got there first.
+func claim(db *gorm.DB, delivery *Delivery, now time.Time) (bool, error) {
+	hiddenUntil := now.Add(claimTimeout)
+	claimed := db.Model(&Delivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", delivery.ID, StatusPending, delivery.Attempts).
+		Updates(map[string]interface{}{
+			"attempts":        delivery.Attempts + 1,
+			"next_attempt_at": hiddenUntil,
+			"last_attempt_at": now,
+		})
+	if claimed.Error != nil {
+		return false, claimed.Error
+	}
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	return claimed.RowsAffected == 1, nil
+}
+
+// attempt posts a claimed delivery and records the outcome.
+func attempt(db *gorm.DB, client *http.Client, delivery *Delivery) (string, error) {
+	var subscription Subscription
+	err := db.Where("id = ?", delivery.SubscriptionId).First(&subscription).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", err
+	}
+
+	updates := map[string]interface{}{}
+	switch {
+	case err != nil || subscription.DeletedAt != nil:
+		updates["error"] = "subscription was deleted"
+		updates["status"] = StatusFailed
+	case !subscription.IsActive:
+		updates["error"] = "subscription is disabled"
+		updates["status"] = StatusFailed
+	default:
+		responseStatus, responseBody, sendErr := send(client, subscription, delivery)
+		updates["response_status"] = responseStatus
+		updates["response_body"] = responseBody
+		updates["error"] = ""
+		if sendErr != nil {
+			updates["error"] = sendErr.Error()
+		}
+		switch {
+		case sendErr == nil && responseStatus >= 200 && responseStatus < 300:
+			updates["status"] = StatusSucceeded
+		case delivery.Attempts >= MaxAttempts:
+			updates["status"] = StatusFailed
+		default:
+			updates["next_attempt_at"] = time.Now().Add(Backoff(delivery.Attempts))
+		}
+	}
+
+	status := StatusPending
+	if s, ok := updates["status"].(string); ok {
+		status = s
+	}
+	return status, db.Model(&Delivery{}).Where("id = ?", delivery.ID).Updates(updates).Error
+}
+
+func send(client *http.Client, subscription Subscription, delivery *Delivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequest(http.MethodPost, subscription.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Timewise-Webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(delivery.ID))
+	req.Header.Set(HeaderSignature, Sign(subscription.Secret, time.Now(), body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		return resp.StatusCode, "", err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(responseBody), fmt.Errorf("subscriber responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(responseBody), nil
+}
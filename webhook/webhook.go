@@ -0,0 +1,170 @@
+package webhook
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+// Event types a subscription can filter on.
+const (
+	EventScheduleStatusChanged = "schedule.status_changed"
+	EventCommentCreated        = "comment.created"
+	EventDocumentUploaded      = "document.uploaded"
+	EventMemberInvited         = "member.invited"
+)
+
+var EventTypes = []string{
+	EventScheduleStatusChanged,
+	EventCommentCreated,
+	EventDocumentUploaded,
+	EventMemberInvited,
+}
+
+const (
+	StatusPending   = "pending"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Subscription sends the events of a workspace to an external URL. An empty
+// event type list subscribes to every event.
+type Subscription struct {
+	ID          int        `json:"id" gorm:"primary_key"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at" gorm:"default:null"`
+	WorkspaceId int        `json:"workspace_id" gorm:"index"`
+	CreatedBy   int        `json:"created_by"`
+	Url         string     `json:"url" gorm:"size:2048"`
+	Secret      string     `json:"-" gorm:"size:128"`
+	// EventTypes is a comma separated list.
+	EventTypes string `json:"event_types" gorm:"size:255"`
+	IsActive   bool   `json:"is_active" gorm:"default:true"`
+}
+
+func (Subscription) TableName() string {
+	return "tw_webhook_subscriptions"
+}
+
+// Accepts reports whether the subscription wants events of eventType.
+func (s Subscription) Accepts(eventType string) bool {
+	if s.EventTypes == "" {
+		return true
+	}
+	for _, accepted := range strings.Split(s.EventTypes, ",") {
+		if accepted == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// Delivery is one event sent, or still to be sent, to one subscription.
+// Payload holds the exact body that is signed and posted on every attempt.
+type Delivery struct {
+	ID             int        `json:"id" gorm:"primary_key"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	SubscriptionId int        `json:"subscription_id" gorm:"index"`
+	EventType      string     `json:"event_type" gorm:"size:64"`
+	Payload        string     `json:"payload" gorm:"type:text"`
+	Status         string     `json:"status" gorm:"size:16;index:idx_tw_webhook_deliveries_due,priority:1"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at" gorm:"index:idx_tw_webhook_deliveries_due,priority:2"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	ResponseStatus int        `json:"response_status"`
+	ResponseBody   string     `json:"response_body" gorm:"type:text"`
+	Error          string     `json:"error" gorm:"type:text"`
+}
+
+func (Delivery) TableName() string {
+	return "tw_webhook_deliveries"
+}
+
+// Envelope is the JSON body posted to subscribers.
+type Envelope struct {
+	Type        string      `json:"type"`
+	WorkspaceId int         `json:"workspace_id"`
+	CreatedAt   time.Time   `json:"created_at"`
+	Data        interface{} `json:"data"`
+}
+
+// ValidEventType reports whether eventType is one subscriptions can filter on.
+func ValidEventType(eventType string) bool {
+	for _, known := range EventTypes {
+		if known == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+// Enqueue queues a delivery of the event to every active subscription of
+// workspaceId that accepts it. Pass the transaction that makes the change so
+// that deliveries are only queued for committed changes; the worker sends them.
+func Enqueue(db *gorm.DB, workspaceId int, eventType string, data interface{}) error {
+	var subscriptions []Subscription
+	if err := db.Where("workspace_id = ? AND is_active = true", workspaceId).
+		Where("deleted_at IS NULL").
+		Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var payload []byte
+	var deliveries []Delivery
+	for _, subscription := range subscriptions {
+		if !subscription.Accepts(eventType) {
+			continue
+		}
+		if payload == nil {
+			var err error
+			payload, err = json.Marshal(Envelope{
+				Type:        eventType,
+				WorkspaceId: workspaceId,
+				CreatedAt:   now.UTC(),
+				Data:        data,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		deliveries = append(deliveries, Delivery{
+			SubscriptionId: subscription.ID,
+			EventType:      eventType,
+			Payload:        string(payload),
+			Status:         StatusPending,
+			NextAttemptAt:  &now,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return db.Create(&deliveries).Error
+}
+
+// EnqueueForSchedule queues an event about something attached to a schedule,
+// such as a comment, to the subscriptions of the schedule's workspace.
+func EnqueueForSchedule(db *gorm.DB, scheduleId int, eventType string, data interface{}) error {
+	var workspaceIds []int
+	if err := db.Table("tw_schedules").Where("id = ?", scheduleId).Pluck("workspace_id", &workspaceIds).Error; err != nil {
+		return err
+	}
+	if len(workspaceIds) == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return Enqueue(db, workspaceIds[0], eventType, data)
+}